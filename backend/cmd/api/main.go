@@ -1,18 +1,15 @@
+// Command api serves the Gophiway API, as `gophiway serve` does
 package main
 
 import (
 	"log"
+	"log/slog"
 	"os"
-	"os/signal"
-	"syscall"
 
-	"github.com/Shihasz/gophiway/internal/api"
 	"github.com/Shihasz/gophiway/internal/config"
 	"github.com/Shihasz/gophiway/internal/database"
-	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
-	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/Shihasz/gophiway/internal/logging"
+	"github.com/Shihasz/gophiway/internal/server"
 	"github.com/joho/godotenv"
 )
 
@@ -23,79 +20,24 @@ func main() {
 	}
 
 	// Load configuration
-	cfg := config.Load()
-
-	// Initialize database
-	db, err := database.Connect(cfg)
+	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-	}
-
-	// Run migrations
-	if err := database.Migrate(db); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+		log.Fatal(err)
 	}
 
-	// Create Fiber app
-	app := fiber.New(fiber.Config{
-		AppName:      cfg.AppName,
-		ErrorHandler: customErrorHandler,
-	})
-
-	// Middleware
-	app.Use(recover.New())
-	app.Use(logger.New(logger.Config{
-		Format: "[${time}] ${status} - ${method} ${path} ${latency}\n",
-	}))
-	app.Use(cors.New(cors.Config{
-		AllowOrigins:     cfg.CORSAllowedOrigins,
-		AllowMethods:     cfg.CORSAllowedMethods,
-		AllowHeaders:     cfg.CORSAllowedHeaders,
-		AllowCredentials: true,
-	}))
-
-	// Health check
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":  "ok",
-			"service": cfg.AppName,
-			"version": cfg.APIVersion,
-		})
-	})
-
-	// Setup API routes
-	api.SetupRoutes(app, db, cfg)
+	// Initialize logger
+	logger := logging.New(cfg)
+	slog.SetDefault(logger)
 
-	// Graceful shutdown
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-
-	go func() {
-		<-c
-		log.Println("Gracefully shutting down...")
-		_ = app.Shutdown()
-	}()
-
-	// Start server
-	addr := ":" + cfg.Port
-	log.Printf("🚀 Gophiway API starting on %s", addr)
-	if err := app.Listen(addr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	// Initialize database
+	db, err := database.Connect(cfg, logger)
+	if err != nil {
+		logger.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
 	}
-}
-
-func customErrorHandler(c *fiber.Ctx, err error) error {
-	code := fiber.StatusInternalServerError
 
-	if e, ok := err.(*fiber.Error); ok {
-		code = e.Code
+	if err := server.Run(cfg, db, logger); err != nil {
+		logger.Error("Failed to start server", "error", err)
+		os.Exit(1)
 	}
-
-	return c.Status(code).JSON(fiber.Map{
-		"success": false,
-		"error": fiber.Map{
-			"code":    code,
-			"message": err.Error(),
-		},
-	})
 }
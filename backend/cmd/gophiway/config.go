@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Shihasz/gophiway/internal/config"
+)
+
+const configUsage = `Usage: gophiway config print
+
+  print   List every setting with its value and source, secrets redacted,
+          then fail with the validation errors, if any`
+
+// configCommand shows the configuration the other commands run with. An
+// invalid configuration is printed too, followed by what is wrong with it.
+func configCommand(cfg *config.Config, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("config", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, configUsage) }
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 || flags.Arg(0) != "print" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+	for _, setting := range cfg.Settings() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Key, setting.Value, setting.Source)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return cfg.Validate()
+}
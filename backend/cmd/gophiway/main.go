@@ -0,0 +1,100 @@
+// Command gophiway serves the API and administers its database
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/database"
+	"github.com/Shihasz/gophiway/internal/logging"
+	"github.com/Shihasz/gophiway/internal/server"
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: gophiway <command> [flags]
+
+Commands:
+  serve            Start the API server
+  migrate          Apply, roll back or list database migrations
+  create-admin     Create an admin user
+  reset-password   Set a new password for a user
+  set-role         Change the role of a user
+  seed             Fill the database with demo data
+  config print     Show the configuration with secrets redacted
+
+Run "gophiway <command> -h" for the flags of a command.`
+
+// command runs a subcommand with the arguments after its name
+type command func(cfg *config.Config, logger *slog.Logger, args []string) error
+
+var commands = map[string]command{
+	"serve":          serve,
+	"migrate":        migrate,
+	"create-admin":   createAdmin,
+	"reset-password": resetPassword,
+	"set-role":       setRole,
+	"seed":           seedDemoData,
+	"config":         configCommand,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	// Load configuration
+	cfg, err := config.Read()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Initialize logger
+	logger := logging.New(cfg)
+	slog.SetDefault(logger)
+
+	// Only the server and migrations refuse an invalid configuration, config
+	// print lists the errors with the settings so they can be fixed
+	if err := cfg.Validate(); err != nil {
+		switch os.Args[1] {
+		case "serve", "migrate":
+			logger.Error("Configuration is invalid", "error", err)
+			os.Exit(1)
+		case "config":
+		default:
+			logger.Warn("Configuration is invalid", "error", err)
+		}
+	}
+
+	if err := run(cfg, logger, os.Args[2:]); err != nil {
+		logger.Error("Command failed", "command", os.Args[1], "error", err)
+		os.Exit(1)
+	}
+}
+
+// serve starts the API server
+func serve(cfg *config.Config, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	_ = flags.Parse(args)
+
+	db, err := database.Connect(cfg, logger)
+	if err != nil {
+		return err
+	}
+	return server.Run(cfg, db, logger)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/database"
+)
+
+const migrateUsage = `Usage: gophiway migrate <up|down|status> [steps]
+
+  up [n]     Apply all pending migrations, or the next n
+  down [n]   Roll back the last applied migration, or the last n
+  status     List migrations and whether they are applied`
+
+// migrate applies, rolls back or lists the database migrations
+func migrate(cfg *config.Config, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, migrateUsage) }
+	_ = flags.Parse(args)
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	action := flags.Arg(0)
+
+	steps := 0
+	if action == "down" {
+		steps = 1
+	}
+	if flags.NArg() == 2 {
+		n, err := strconv.Atoi(flags.Arg(1))
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number of steps: %s", flags.Arg(1))
+		}
+		steps = n
+	}
+
+	db, err := database.Connect(cfg, logger)
+	if err != nil {
+		return err
+	}
+	migrator, err := database.NewMigrator(db, logger)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "up":
+		applied, err := migrator.Up(steps)
+		for _, migration := range applied {
+			logger.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			logger.Info("Database is up to date")
+			return nil
+		}
+		logger.Info("Migrations applied", "applied", len(applied))
+
+	case "down":
+		reverted, err := migrator.Down(steps)
+		for _, migration := range reverted {
+			logger.Info("Rolled back migration", "version", migration.Version, "name", migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		logger.Info("Migrations rolled back", "reverted", len(reverted))
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, status := range statuses {
+			state, appliedAt := "pending", ""
+			if status.Applied {
+				state = "applied"
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			switch {
+			case status.Modified:
+				state = "modified"
+			case status.Missing:
+				state = "missing"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+	return nil
+}
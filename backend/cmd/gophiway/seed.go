@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/database"
+	"github.com/Shihasz/gophiway/internal/seed"
+	"github.com/Shihasz/gophiway/internal/service"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// seedDemoData fills the database with demo data
+func seedDemoData(cfg *config.Config, logger *slog.Logger, args []string) error {
+	names := make([]string, 0, len(seed.Scales))
+	for name := range seed.Scales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	scaleName := flags.String("scale", "small", "amount of demo data: "+strings.Join(names, ", "))
+	randomSeed := flags.Int64("seed", 1, "random seed, the same seed creates the same data")
+	_ = flags.Parse(args)
+
+	scale, ok := seed.Scales[*scaleName]
+	if !ok {
+		return fmt.Errorf("unknown scale %q, use one of %s", *scaleName, strings.Join(names, ", "))
+	}
+
+	if cfg.AppEnv == "production" {
+		return fmt.Errorf("refusing to seed demo data in production")
+	}
+
+	// Demo accounts need no strong hashing, and no demo email is sent
+	seedCfg := *cfg
+	seedCfg.BcryptCost = bcrypt.MinCost
+	seedCfg.SMTPUser = ""
+
+	db, err := database.Connect(&seedCfg, logger)
+	if err != nil {
+		return err
+	}
+	services, err := service.NewServices(db, &seedCfg, logger, nil)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Seeding demo data", "scale", *scaleName)
+	result, err := seed.New(db, services, &seedCfg, logger, *randomSeed).Run(context.Background(), scale)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Seeded demo data",
+		"categories", result.Categories,
+		"products", result.Products,
+		"customers", result.Customers,
+		"orders", result.Orders)
+	logger.Info("Customers sign in as customerNNNN@example.com", "password", seed.DemoPassword)
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/database"
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/Shihasz/gophiway/internal/validation"
+)
+
+// createAdmin creates an admin user, the only way to get the first one
+func createAdmin(cfg *config.Config, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	email := flags.String("email", "", "email address (required)")
+	firstName := flags.String("first-name", "Admin", "first name")
+	lastName := flags.String("last-name", "User", "last name")
+	password := flags.String("password", "", "password, read from standard input when empty")
+	_ = flags.Parse(args)
+
+	if *password == "" {
+		var err error
+		if *password, err = readPassword(); err != nil {
+			return err
+		}
+	}
+
+	req := &service.CreateUserRequest{
+		Email:     *email,
+		Password:  *password,
+		FirstName: *firstName,
+		LastName:  *lastName,
+		Role:      models.RoleAdmin,
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	authService, err := newAuthService(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	user, err := authService.CreateUser(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Created admin", "email", user.Email, "user_id", user.ID)
+	return nil
+}
+
+// resetPassword sets a new password for a user
+func resetPassword(cfg *config.Config, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	email := flags.String("email", "", "email address of the user (required)")
+	password := flags.String("password", "", "new password, read from standard input when empty")
+	_ = flags.Parse(args)
+
+	if *password == "" {
+		var err error
+		if *password, err = readPassword(); err != nil {
+			return err
+		}
+	}
+
+	req := &service.ResetPasswordRequest{Email: *email, Password: *password}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	authService, err := newAuthService(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	user, err := authService.ResetPassword(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Reset password", "email", user.Email)
+	return nil
+}
+
+// setRole changes the role of a user
+func setRole(cfg *config.Config, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("set-role", flag.ExitOnError)
+	email := flags.String("email", "", "email address of the user (required)")
+	role := flags.String("role", "", "new role: customer or admin (required)")
+	_ = flags.Parse(args)
+
+	req := &service.SetRoleRequest{Email: *email, Role: *role}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	authService, err := newAuthService(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	user, err := authService.SetRole(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Changed role; tokens issued before keep the old role until they expire", "email", user.Email, "role", user.Role)
+	return nil
+}
+
+// newAuthService connects to the database for the user commands
+func newAuthService(cfg *config.Config, logger *slog.Logger) (*service.AuthService, error) {
+	db, err := database.Connect(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	return service.NewAuthService(repository.NewUserRepository(db), cfg, nil), nil
+}
+
+// readPassword reads a password from the first line of standard input, so
+// it stays out of the shell history
+func readPassword() (string, error) {
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("no password given")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// validateRequest reports the failed validation rules of a request
+func validateRequest(req interface{}) error {
+	err := validation.ValidateStruct(req)
+	if err == nil {
+		return nil
+	}
+
+	var fields []string
+	for _, fieldErr := range validation.FormatValidationErrors(err) {
+		fields = append(fields, fieldErr.Field+": "+fieldErr.Message)
+	}
+	if len(fields) == 0 {
+		return err
+	}
+	return errors.New(strings.Join(fields, ", "))
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/Shihasz/gophiway/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AddressHandler struct {
+	addressService *service.AddressService
+}
+
+func NewAddressHandler(addressService *service.AddressService) *AddressHandler {
+	return &AddressHandler{
+		addressService: addressService,
+	}
+}
+
+// ListAddresses handles listing the user's addresses
+func (h *AddressHandler) ListAddresses(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	addresses, err := h.addressService.ListAddresses(c.UserContext(), userID)
+	if err != nil {
+		return sendAddressError(c, err, "Failed to list addresses")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    addresses,
+	})
+}
+
+// GetAddress handles getting one of the user's addresses
+func (h *AddressHandler) GetAddress(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid address ID")
+	}
+
+	address, err := h.addressService.GetAddress(c.UserContext(), userID, id)
+	if err != nil {
+		return sendAddressError(c, err, "Failed to get address")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    address,
+	})
+}
+
+// CreateAddress handles adding an address to the user's address book
+func (h *AddressHandler) CreateAddress(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	var req service.AddressRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	address, err := h.addressService.CreateAddress(c.UserContext(), userID, &req)
+	if err != nil {
+		return sendAddressError(c, err, "Failed to create address")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    address,
+		"message": "Address created successfully",
+	})
+}
+
+// UpdateAddress handles replacing one of the user's addresses
+func (h *AddressHandler) UpdateAddress(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid address ID")
+	}
+
+	var req service.AddressRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	address, err := h.addressService.UpdateAddress(c.UserContext(), userID, id, &req)
+	if err != nil {
+		return sendAddressError(c, err, "Failed to update address")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    address,
+		"message": "Address updated successfully",
+	})
+}
+
+// SetDefaultAddress handles making an address the default of its type
+func (h *AddressHandler) SetDefaultAddress(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid address ID")
+	}
+
+	address, err := h.addressService.SetDefaultAddress(c.UserContext(), userID, id)
+	if err != nil {
+		return sendAddressError(c, err, "Failed to set default address")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    address,
+		"message": "Default address updated successfully",
+	})
+}
+
+// DeleteAddress handles removing an address from the user's address book
+func (h *AddressHandler) DeleteAddress(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid address ID")
+	}
+
+	if err := h.addressService.DeleteAddress(c.UserContext(), userID, id); err != nil {
+		return sendAddressError(c, err, "Failed to delete address")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Address deleted successfully",
+	})
+}
+
+// sendAddressError maps address service errors to responses
+func sendAddressError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrAddressNotFound):
+		return middleware.SendError(c, fiber.StatusNotFound, "ADDRESS_NOT_FOUND", "Address not found")
+	case errors.Is(err, service.ErrInvalidAddress):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "INVALID_ADDRESS", err.Error())
+	}
+
+	return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", fallback)
+}
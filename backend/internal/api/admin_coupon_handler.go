@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/Shihasz/gophiway/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminCouponHandler struct {
+	couponService *service.CouponService
+}
+
+func NewAdminCouponHandler(couponService *service.CouponService) *AdminCouponHandler {
+	return &AdminCouponHandler{
+		couponService: couponService,
+	}
+}
+
+// ListCoupons handles listing all coupons
+func (h *AdminCouponHandler) ListCoupons(c *fiber.Ctx) error {
+	coupons, err := h.couponService.ListCoupons(c.UserContext())
+	if err != nil {
+		return sendAdminCouponError(c, err, "Failed to list coupons")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    coupons,
+	})
+}
+
+// GetCoupon handles getting a coupon
+func (h *AdminCouponHandler) GetCoupon(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid coupon ID")
+	}
+
+	coupon, err := h.couponService.GetCoupon(c.UserContext(), id)
+	if err != nil {
+		return sendAdminCouponError(c, err, "Failed to get coupon")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    coupon,
+	})
+}
+
+// CreateCoupon handles creating a coupon
+func (h *AdminCouponHandler) CreateCoupon(c *fiber.Ctx) error {
+	var req service.CouponRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	coupon, err := h.couponService.CreateCoupon(c.UserContext(), &req)
+	if err != nil {
+		return sendAdminCouponError(c, err, "Failed to create coupon")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    coupon,
+		"message": "Coupon created successfully",
+	})
+}
+
+// UpdateCoupon handles replacing a coupon's settings
+func (h *AdminCouponHandler) UpdateCoupon(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid coupon ID")
+	}
+
+	var req service.CouponRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	coupon, err := h.couponService.UpdateCoupon(c.UserContext(), id, &req)
+	if err != nil {
+		return sendAdminCouponError(c, err, "Failed to update coupon")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    coupon,
+		"message": "Coupon updated successfully",
+	})
+}
+
+// DeleteCoupon handles deleting a coupon
+func (h *AdminCouponHandler) DeleteCoupon(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid coupon ID")
+	}
+
+	if err := h.couponService.DeleteCoupon(c.UserContext(), id); err != nil {
+		return sendAdminCouponError(c, err, "Failed to delete coupon")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Coupon deleted successfully",
+	})
+}
+
+// sendAdminCouponError maps coupon management errors to responses
+func sendAdminCouponError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrCouponNotFound):
+		return middleware.SendError(c, fiber.StatusNotFound, "COUPON_NOT_FOUND", "Coupon not found")
+	case errors.Is(err, service.ErrCouponCodeTaken):
+		return middleware.SendError(c, fiber.StatusConflict, "COUPON_CODE_TAKEN", "A coupon with this code already exists")
+	case errors.Is(err, service.ErrInvalidCoupon):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "INVALID_COUPON", err.Error())
+	}
+
+	return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", fallback)
+}
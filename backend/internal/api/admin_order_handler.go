@@ -0,0 +1,219 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/Shihasz/gophiway/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminOrderHandler struct {
+	orderService       *service.OrderService
+	fulfillmentService *service.FulfillmentService
+}
+
+func NewAdminOrderHandler(orderService *service.OrderService, fulfillmentService *service.FulfillmentService) *AdminOrderHandler {
+	return &AdminOrderHandler{
+		orderService:       orderService,
+		fulfillmentService: fulfillmentService,
+	}
+}
+
+// SearchOrders handles searching orders
+func (h *AdminOrderHandler) SearchOrders(c *fiber.Ctx) error {
+	var req service.SearchOrdersRequest
+
+	// Parse query parameters
+	if err := c.QueryParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid query parameters")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	resp, err := h.orderService.SearchOrders(c.UserContext(), &req)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to search orders")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    resp,
+	})
+}
+
+// GetOrder handles getting an order
+func (h *AdminOrderHandler) GetOrder(c *fiber.Ctx) error {
+	orderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid order ID")
+	}
+
+	order, err := h.orderService.GetOrder(c.UserContext(), orderID)
+	if err != nil {
+		if errors.Is(err, service.ErrOrderNotFound) {
+			return middleware.SendError(c, fiber.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+		}
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get order")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    order,
+	})
+}
+
+// UpdateStatus handles moving an order to a new status
+func (h *AdminOrderHandler) UpdateStatus(c *fiber.Ctx) error {
+	orderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid order ID")
+	}
+
+	var req service.UpdateOrderStatusRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	order, err := h.orderService.TransitionStatus(c.UserContext(), orderID, models.OrderStatus(req.Status), actorFromContext(c), req.Reason)
+	if err != nil {
+		return sendTransitionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    order,
+		"message": "Order status updated successfully",
+	})
+}
+
+// GetStatusHistory handles getting the status history of an order
+func (h *AdminOrderHandler) GetStatusHistory(c *fiber.Ctx) error {
+	orderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid order ID")
+	}
+
+	history, err := h.orderService.GetStatusHistory(c.UserContext(), orderID)
+	if err != nil {
+		if errors.Is(err, service.ErrOrderNotFound) {
+			return middleware.SendError(c, fiber.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+		}
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get order history")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    history,
+	})
+}
+
+// CreateFulfillment handles shipping some or all of an order's items
+func (h *AdminOrderHandler) CreateFulfillment(c *fiber.Ctx) error {
+	orderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid order ID")
+	}
+
+	var req service.CreateFulfillmentRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	fulfillment, err := h.fulfillmentService.CreateFulfillment(c.UserContext(), orderID, &req, actorFromContext(c))
+	if err != nil {
+		if errors.Is(err, service.ErrOrderNotFulfillable) {
+			return middleware.SendError(c, fiber.StatusConflict, "ORDER_NOT_FULFILLABLE", "Order cannot be fulfilled in its current status")
+		}
+		if errors.Is(err, service.ErrInvalidFulfillmentItems) {
+			return middleware.SendError(c, fiber.StatusUnprocessableEntity, "INVALID_FULFILLMENT_ITEMS", err.Error())
+		}
+		return sendTransitionError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    fulfillment,
+		"message": "Fulfillment created successfully",
+	})
+}
+
+// ListFulfillments handles listing the fulfillments of an order
+func (h *AdminOrderHandler) ListFulfillments(c *fiber.Ctx) error {
+	orderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid order ID")
+	}
+
+	fulfillments, err := h.fulfillmentService.ListFulfillments(c.UserContext(), orderID)
+	if err != nil {
+		if errors.Is(err, service.ErrOrderNotFound) {
+			return middleware.SendError(c, fiber.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+		}
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list fulfillments")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    fulfillments,
+	})
+}
+
+// MarkFulfillmentDelivered handles marking a fulfillment as delivered
+func (h *AdminOrderHandler) MarkFulfillmentDelivered(c *fiber.Ctx) error {
+	fulfillmentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid fulfillment ID")
+	}
+
+	fulfillment, err := h.fulfillmentService.MarkDelivered(c.UserContext(), fulfillmentID, actorFromContext(c))
+	if err != nil {
+		if errors.Is(err, service.ErrFulfillmentNotFound) {
+			return middleware.SendError(c, fiber.StatusNotFound, "FULFILLMENT_NOT_FOUND", "Fulfillment not found")
+		}
+		if errors.Is(err, service.ErrFulfillmentAlreadyDelivered) {
+			return middleware.SendError(c, fiber.StatusConflict, "ALREADY_DELIVERED", "Fulfillment already delivered")
+		}
+		return sendTransitionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    fulfillment,
+		"message": "Fulfillment marked as delivered",
+	})
+}
+
+// sendTransitionError maps order status transition errors to responses
+func sendTransitionError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound):
+		return middleware.SendError(c, fiber.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+	case errors.Is(err, service.ErrInvalidTransition):
+		return middleware.SendError(c, fiber.StatusConflict, "INVALID_TRANSITION", err.Error())
+	case errors.Is(err, service.ErrTransitionNotPermitted):
+		return middleware.SendError(c, fiber.StatusForbidden, "TRANSITION_NOT_PERMITTED", err.Error())
+	}
+
+	return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update order status")
+}
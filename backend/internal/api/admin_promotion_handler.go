@@ -0,0 +1,138 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/Shihasz/gophiway/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminPromotionHandler struct {
+	promotionService *service.PromotionService
+}
+
+func NewAdminPromotionHandler(promotionService *service.PromotionService) *AdminPromotionHandler {
+	return &AdminPromotionHandler{
+		promotionService: promotionService,
+	}
+}
+
+// ListPromotions handles listing all promotions
+func (h *AdminPromotionHandler) ListPromotions(c *fiber.Ctx) error {
+	promotions, err := h.promotionService.ListPromotions(c.UserContext())
+	if err != nil {
+		return sendPromotionError(c, err, "Failed to list promotions")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    promotions,
+	})
+}
+
+// GetPromotion handles getting a promotion
+func (h *AdminPromotionHandler) GetPromotion(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid promotion ID")
+	}
+
+	promotion, err := h.promotionService.GetPromotion(c.UserContext(), id)
+	if err != nil {
+		return sendPromotionError(c, err, "Failed to get promotion")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    promotion,
+	})
+}
+
+// CreatePromotion handles creating a promotion
+func (h *AdminPromotionHandler) CreatePromotion(c *fiber.Ctx) error {
+	var req service.PromotionRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	promotion, err := h.promotionService.CreatePromotion(c.UserContext(), &req)
+	if err != nil {
+		return sendPromotionError(c, err, "Failed to create promotion")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    promotion,
+		"message": "Promotion created successfully",
+	})
+}
+
+// UpdatePromotion handles replacing a promotion's settings
+func (h *AdminPromotionHandler) UpdatePromotion(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid promotion ID")
+	}
+
+	var req service.PromotionRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	promotion, err := h.promotionService.UpdatePromotion(c.UserContext(), id, &req)
+	if err != nil {
+		return sendPromotionError(c, err, "Failed to update promotion")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    promotion,
+		"message": "Promotion updated successfully",
+	})
+}
+
+// DeletePromotion handles deleting a promotion
+func (h *AdminPromotionHandler) DeletePromotion(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid promotion ID")
+	}
+
+	if err := h.promotionService.DeletePromotion(c.UserContext(), id); err != nil {
+		return sendPromotionError(c, err, "Failed to delete promotion")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Promotion deleted successfully",
+	})
+}
+
+// sendPromotionError maps promotion management errors to responses
+func sendPromotionError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrPromotionNotFound):
+		return middleware.SendError(c, fiber.StatusNotFound, "PROMOTION_NOT_FOUND", "Promotion not found")
+	case errors.Is(err, service.ErrInvalidPromotion):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "INVALID_PROMOTION", err.Error())
+	}
+
+	return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", fallback)
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/Shihasz/gophiway/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminShippingHandler struct {
+	shippingService *service.ShippingService
+}
+
+func NewAdminShippingHandler(shippingService *service.ShippingService) *AdminShippingHandler {
+	return &AdminShippingHandler{
+		shippingService: shippingService,
+	}
+}
+
+// ListZones handles listing the shipping zones with their methods
+func (h *AdminShippingHandler) ListZones(c *fiber.Ctx) error {
+	zones, err := h.shippingService.ListZones(c.UserContext())
+	if err != nil {
+		return sendShippingError(c, err, "Failed to list shipping zones")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    zones,
+	})
+}
+
+// CreateZone handles creating a shipping zone
+func (h *AdminShippingHandler) CreateZone(c *fiber.Ctx) error {
+	var req service.ShippingZoneRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	zone, err := h.shippingService.CreateZone(c.UserContext(), &req)
+	if err != nil {
+		return sendShippingError(c, err, "Failed to create shipping zone")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    zone,
+		"message": "Shipping zone created successfully",
+	})
+}
+
+// UpdateZone handles renaming a zone and replacing its regions
+func (h *AdminShippingHandler) UpdateZone(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid shipping zone ID")
+	}
+
+	var req service.ShippingZoneRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	zone, err := h.shippingService.UpdateZone(c.UserContext(), id, &req)
+	if err != nil {
+		return sendShippingError(c, err, "Failed to update shipping zone")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    zone,
+		"message": "Shipping zone updated successfully",
+	})
+}
+
+// DeleteZone handles deleting a zone and its methods
+func (h *AdminShippingHandler) DeleteZone(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid shipping zone ID")
+	}
+
+	if err := h.shippingService.DeleteZone(c.UserContext(), id); err != nil {
+		return sendShippingError(c, err, "Failed to delete shipping zone")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Shipping zone deleted successfully",
+	})
+}
+
+// CreateMethod handles adding a shipping method to a zone
+func (h *AdminShippingHandler) CreateMethod(c *fiber.Ctx) error {
+	zoneID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid shipping zone ID")
+	}
+
+	var req service.ShippingMethodRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	method, err := h.shippingService.CreateMethod(c.UserContext(), zoneID, &req)
+	if err != nil {
+		return sendShippingError(c, err, "Failed to create shipping method")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    method,
+		"message": "Shipping method created successfully",
+	})
+}
+
+// UpdateMethod handles replacing a shipping method
+func (h *AdminShippingHandler) UpdateMethod(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid shipping method ID")
+	}
+
+	var req service.ShippingMethodRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	method, err := h.shippingService.UpdateMethod(c.UserContext(), id, &req)
+	if err != nil {
+		return sendShippingError(c, err, "Failed to update shipping method")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    method,
+		"message": "Shipping method updated successfully",
+	})
+}
+
+// DeleteMethod handles deleting a shipping method
+func (h *AdminShippingHandler) DeleteMethod(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid shipping method ID")
+	}
+
+	if err := h.shippingService.DeleteMethod(c.UserContext(), id); err != nil {
+		return sendShippingError(c, err, "Failed to delete shipping method")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Shipping method deleted successfully",
+	})
+}
+
+// sendShippingError maps shipping service errors to responses
+func sendShippingError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrShippingZoneNotFound):
+		return middleware.SendError(c, fiber.StatusNotFound, "SHIPPING_ZONE_NOT_FOUND", "Shipping zone not found")
+	case errors.Is(err, service.ErrShippingMethodNotFound):
+		return middleware.SendError(c, fiber.StatusNotFound, "SHIPPING_METHOD_NOT_FOUND", "Shipping method not found")
+	case errors.Is(err, service.ErrInvalidShippingMethod):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "INVALID_SHIPPING_METHOD", err.Error())
+	}
+
+	return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", fallback)
+}
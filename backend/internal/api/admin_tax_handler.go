@@ -0,0 +1,114 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/Shihasz/gophiway/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminTaxHandler struct {
+	taxService *service.TaxService
+}
+
+func NewAdminTaxHandler(taxService *service.TaxService) *AdminTaxHandler {
+	return &AdminTaxHandler{
+		taxService: taxService,
+	}
+}
+
+// ListRates handles listing the tax rate table
+func (h *AdminTaxHandler) ListRates(c *fiber.Ctx) error {
+	rates, err := h.taxService.ListRates(c.UserContext())
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list tax rates")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    rates,
+	})
+}
+
+// CreateRate handles adding a tax rate
+func (h *AdminTaxHandler) CreateRate(c *fiber.Ctx) error {
+	var req service.TaxRateRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	rate, err := h.taxService.CreateRate(c.UserContext(), &req)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create tax rate")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    rate,
+		"message": "Tax rate created successfully",
+	})
+}
+
+// UpdateRate handles replacing a tax rate
+func (h *AdminTaxHandler) UpdateRate(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid tax rate ID")
+	}
+
+	var req service.TaxRateRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	rate, err := h.taxService.UpdateRate(c.UserContext(), id, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrTaxRateNotFound) {
+			return middleware.SendError(c, fiber.StatusNotFound, "TAX_RATE_NOT_FOUND", "Tax rate not found")
+		}
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update tax rate")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    rate,
+		"message": "Tax rate updated successfully",
+	})
+}
+
+// DeleteRate handles deleting a tax rate
+func (h *AdminTaxHandler) DeleteRate(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid tax rate ID")
+	}
+
+	if err := h.taxService.DeleteRate(c.UserContext(), id); err != nil {
+		if errors.Is(err, service.ErrTaxRateNotFound) {
+			return middleware.SendError(c, fiber.StatusNotFound, "TAX_RATE_NOT_FOUND", "Tax rate not found")
+		}
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete tax rate")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Tax rate deleted successfully",
+	})
+}
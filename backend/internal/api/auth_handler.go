@@ -25,13 +25,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 
 	// Parse request body
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_REQUEST",
-				"message": "Invalid request body",
-			},
-		})
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
 	}
 
 	// Validate request
@@ -40,24 +34,12 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	}
 
 	// Register user
-	resp, err := h.authService.Register(&req)
+	resp, err := h.authService.Register(c.UserContext(), &req)
 	if err != nil {
 		if errors.Is(err, service.ErrEmailAlreadyExists) {
-			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-				"success": false,
-				"error": fiber.Map{
-					"code":    "EMAIL_EXISTS",
-					"message": "Email already exists",
-				},
-			})
+			return middleware.SendError(c, fiber.StatusConflict, "EMAIL_EXISTS", "Email already exists")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INTERNAL_ERROR",
-				"message": "Failed to register user",
-			},
-		})
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to register user")
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -73,13 +55,7 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 
 	// Parse request body
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_REQUEST",
-				"message": "Invalid request body",
-			},
-		})
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
 	}
 
 	// Validate request
@@ -88,24 +64,12 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	}
 
 	// Login user
-	resp, err := h.authService.Login(&req)
+	resp, err := h.authService.Login(c.UserContext(), &req)
 	if err != nil {
 		if errors.Is(err, service.ErrInvalidCredentials) {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"error": fiber.Map{
-					"code":    "INVALID_CREDENTIALS",
-					"message": "Invalid email or password",
-				},
-			})
+			return middleware.SendError(c, fiber.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid email or password")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INTERNAL_ERROR",
-				"message": "Failed to login",
-			},
-		})
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to login")
 	}
 
 	return c.JSON(fiber.Map{
@@ -123,13 +87,7 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 
 	// Parse request body
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_REQUEST",
-				"message": "Invalid request body",
-			},
-		})
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
 	}
 
 	// Validate request
@@ -138,15 +96,9 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 	}
 
 	// Refresh token
-	resp, err := h.authService.RefreshToken(req.RefreshToken)
+	resp, err := h.authService.RefreshToken(c.UserContext(), req.RefreshToken)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_TOKEN",
-				"message": "Invalid or expired refresh token",
-			},
-		})
+		return middleware.SendError(c, fiber.StatusUnauthorized, "INVALID_TOKEN", "Invalid or expired refresh token")
 	}
 
 	return c.JSON(fiber.Map{
@@ -161,34 +113,16 @@ func (h *AuthHandler) GetMe(c *fiber.Ctx) error {
 	// Get user ID from context
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "UNAUTHORIZED",
-				"message": "User not authenticated",
-			},
-		})
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
 	}
 
 	// Get user
-	user, err := h.authService.GetCurrentUser(userID)
+	user, err := h.authService.GetCurrentUser(c.UserContext(), userID)
 	if err != nil {
 		if errors.Is(err, service.ErrUserNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"success": false,
-				"error": fiber.Map{
-					"code":    "USER_NOT_FOUND",
-					"message": "User not found",
-				},
-			})
+			return middleware.SendError(c, fiber.StatusNotFound, "USER_NOT_FOUND", "User not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INTERNAL_ERROR",
-				"message": "Failed to get user",
-			},
-		})
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get user")
 	}
 
 	return c.JSON(fiber.Map{
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/pricing"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/Shihasz/gophiway/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CartHandler struct {
+	cartService *service.CartService
+}
+
+func NewCartHandler(cartService *service.CartService) *CartHandler {
+	return &CartHandler{
+		cartService: cartService,
+	}
+}
+
+// GetCart handles getting the user's priced cart
+func (h *CartHandler) GetCart(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	cart, err := h.cartService.GetCart(c.UserContext(), userID)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get cart")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    cart,
+	})
+}
+
+// ApplyCoupon handles applying a discount code to the cart
+func (h *CartHandler) ApplyCoupon(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	var req service.ApplyCouponRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	cart, err := h.cartService.ApplyCoupon(c.UserContext(), userID, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrCartEmpty) {
+			return middleware.SendError(c, fiber.StatusUnprocessableEntity, "CART_EMPTY", "Cart is empty")
+		}
+		return sendCouponError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    cart,
+		"message": "Coupon applied successfully",
+	})
+}
+
+// RemoveCoupon handles removing the discount code from the cart
+func (h *CartHandler) RemoveCoupon(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	cart, err := h.cartService.RemoveCoupon(c.UserContext(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrCartEmpty) {
+			return middleware.SendError(c, fiber.StatusUnprocessableEntity, "CART_EMPTY", "Cart is empty")
+		}
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to remove coupon")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    cart,
+		"message": "Coupon removed successfully",
+	})
+}
+
+// sendCouponError maps the reasons a coupon cannot be used to responses
+func sendCouponError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrCouponNotFound):
+		return middleware.SendError(c, fiber.StatusNotFound, "COUPON_NOT_FOUND", "Coupon code is not valid")
+	case errors.Is(err, service.ErrCouponNotActive):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "COUPON_NOT_ACTIVE", err.Error())
+	case errors.Is(err, service.ErrCouponUsageLimitReached):
+		return middleware.SendError(c, fiber.StatusConflict, "COUPON_USAGE_LIMIT_REACHED", "Coupon usage limit reached")
+	case errors.Is(err, service.ErrCouponNotEligible):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "COUPON_NOT_ELIGIBLE", err.Error())
+	case errors.Is(err, pricing.ErrCouponNotApplicable):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "COUPON_NOT_APPLICABLE", err.Error())
+	}
+
+	return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to apply coupon")
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/pricing"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/Shihasz/gophiway/internal/shipping"
+	"github.com/Shihasz/gophiway/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CheckoutHandler struct {
+	checkoutService *service.CheckoutService
+}
+
+func NewCheckoutHandler(checkoutService *service.CheckoutService) *CheckoutHandler {
+	return &CheckoutHandler{
+		checkoutService: checkoutService,
+	}
+}
+
+// PlaceOrder handles turning the user's cart into an order
+func (h *CheckoutHandler) PlaceOrder(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	var req service.PlaceOrderRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	order, err := h.checkoutService.PlaceOrder(c.UserContext(), userID, &req)
+	if err != nil {
+		return sendCheckoutError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    order,
+		"message": "Order placed successfully",
+	})
+}
+
+// ShippingRates handles quoting the shipping options for the user's cart
+func (h *CheckoutHandler) ShippingRates(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	var req service.ShippingRatesRequest
+
+	// Parse query parameters
+	if err := c.QueryParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid query parameters")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	quotes, err := h.checkoutService.ShippingRates(c.UserContext(), userID, &req)
+	if err != nil {
+		return sendCheckoutError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    quotes,
+	})
+}
+
+// sendCheckoutError maps checkout errors to responses
+func sendCheckoutError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrCartEmpty):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "CART_EMPTY", "Cart is empty")
+	case errors.Is(err, service.ErrAddressNotFound):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "ADDRESS_NOT_FOUND", "Address not found")
+	case errors.Is(err, service.ErrProductUnavailable):
+		return middleware.SendError(c, fiber.StatusConflict, "PRODUCT_UNAVAILABLE", err.Error())
+	case errors.Is(err, service.ErrInsufficientStock):
+		return middleware.SendError(c, fiber.StatusConflict, "INSUFFICIENT_STOCK", err.Error())
+	case errors.Is(err, shipping.ErrDestinationNotServed):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "DESTINATION_NOT_SERVED", "We do not ship to this address")
+	case errors.Is(err, service.ErrShippingMethodRequired):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "SHIPPING_METHOD_REQUIRED", "A shipping method must be selected")
+	case errors.Is(err, service.ErrCouponNotFound),
+		errors.Is(err, service.ErrCouponNotActive),
+		errors.Is(err, service.ErrCouponUsageLimitReached),
+		errors.Is(err, service.ErrCouponNotEligible),
+		errors.Is(err, pricing.ErrCouponNotApplicable):
+		return sendCouponError(c, err)
+	case errors.Is(err, service.ErrGiftCardNotFound),
+		errors.Is(err, service.ErrGiftCardExpired),
+		errors.Is(err, service.ErrGiftCardEmpty),
+		errors.Is(err, service.ErrGiftCardCurrency):
+		return sendGiftCardError(c, err, "Failed to complete checkout")
+	case errors.Is(err, service.ErrShippingMethodUnavailable):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "SHIPPING_METHOD_UNAVAILABLE", "The shipping method is not available for this cart and address")
+	case errors.Is(err, service.ErrShippingQuoteChanged):
+		return middleware.SendError(c, fiber.StatusConflict, "SHIPPING_QUOTE_CHANGED", "The shipping price changed, please review the shipping options")
+	}
+
+	return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to complete checkout")
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type DocumentHandler struct {
+	documentService *service.DocumentService
+}
+
+func NewDocumentHandler(documentService *service.DocumentService) *DocumentHandler {
+	return &DocumentHandler{
+		documentService: documentService,
+	}
+}
+
+// ListDocuments handles listing the documents issued for one of the user's
+// orders
+func (h *DocumentHandler) ListDocuments(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	documents, err := h.documentService.ListUserDocuments(c.UserContext(), userID, c.Params("orderNumber"))
+	if err != nil {
+		return sendDocumentError(c, err, "Failed to list documents")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    documents,
+	})
+}
+
+// DownloadInvoice handles downloading the invoice of one of the user's orders
+func (h *DocumentHandler) DownloadInvoice(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	file, err := h.documentService.GetUserInvoice(c.UserContext(), userID, c.Params("orderNumber"))
+	if err != nil {
+		return sendDocumentError(c, err, "Failed to get invoice")
+	}
+	return sendDocumentFile(c, file)
+}
+
+// DownloadCreditNote handles downloading the credit note of a refund on one
+// of the user's orders
+func (h *DocumentHandler) DownloadCreditNote(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	refundID, err := uuid.Parse(c.Params("refundId"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid refund ID")
+	}
+
+	file, err := h.documentService.GetUserCreditNote(c.UserContext(), userID, c.Params("orderNumber"), refundID)
+	if err != nil {
+		return sendDocumentError(c, err, "Failed to get credit note")
+	}
+	return sendDocumentFile(c, file)
+}
+
+// DownloadPackingSlip handles downloading the packing slip of a shipment of
+// one of the user's orders
+func (h *DocumentHandler) DownloadPackingSlip(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	fulfillmentID, err := uuid.Parse(c.Params("fulfillmentId"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid fulfillment ID")
+	}
+
+	file, err := h.documentService.GetUserPackingSlip(c.UserContext(), userID, c.Params("orderNumber"), fulfillmentID)
+	if err != nil {
+		return sendDocumentError(c, err, "Failed to get packing slip")
+	}
+	return sendDocumentFile(c, file)
+}
+
+// AdminListDocuments handles listing the documents issued for an order
+func (h *DocumentHandler) AdminListDocuments(c *fiber.Ctx) error {
+	orderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid order ID")
+	}
+
+	documents, err := h.documentService.ListOrderDocuments(c.UserContext(), orderID)
+	if err != nil {
+		return sendDocumentError(c, err, "Failed to list documents")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    documents,
+	})
+}
+
+// AdminDownloadInvoice handles downloading the invoice of an order
+func (h *DocumentHandler) AdminDownloadInvoice(c *fiber.Ctx) error {
+	orderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid order ID")
+	}
+
+	file, err := h.documentService.GetInvoice(c.UserContext(), orderID)
+	if err != nil {
+		return sendDocumentError(c, err, "Failed to get invoice")
+	}
+	return sendDocumentFile(c, file)
+}
+
+// AdminDownloadCreditNote handles downloading the credit note of a refund
+func (h *DocumentHandler) AdminDownloadCreditNote(c *fiber.Ctx) error {
+	refundID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid refund ID")
+	}
+
+	file, err := h.documentService.GetCreditNote(c.UserContext(), refundID)
+	if err != nil {
+		return sendDocumentError(c, err, "Failed to get credit note")
+	}
+	return sendDocumentFile(c, file)
+}
+
+// AdminDownloadPackingSlip handles downloading the packing slip of a
+// fulfillment
+func (h *DocumentHandler) AdminDownloadPackingSlip(c *fiber.Ctx) error {
+	fulfillmentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid fulfillment ID")
+	}
+
+	file, err := h.documentService.GetPackingSlip(c.UserContext(), fulfillmentID)
+	if err != nil {
+		return sendDocumentError(c, err, "Failed to get packing slip")
+	}
+	return sendDocumentFile(c, file)
+}
+
+// sendDocumentFile sends a document as a PDF download
+func sendDocumentFile(c *fiber.Ctx, file *service.DocumentFile) error {
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", file.Filename))
+	return c.Send(file.Data)
+}
+
+// sendDocumentError maps document service errors to responses
+func sendDocumentError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound):
+		return middleware.SendError(c, fiber.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+	case errors.Is(err, service.ErrRefundNotFound):
+		return middleware.SendError(c, fiber.StatusNotFound, "REFUND_NOT_FOUND", "Refund not found")
+	case errors.Is(err, service.ErrFulfillmentNotFound):
+		return middleware.SendError(c, fiber.StatusNotFound, "FULFILLMENT_NOT_FOUND", "Fulfillment not found")
+	case errors.Is(err, service.ErrDocumentNotAvailable):
+		return middleware.SendError(c, fiber.StatusConflict, "DOCUMENT_NOT_AVAILABLE", err.Error())
+	case errors.Is(err, service.ErrDocumentMissing):
+		return middleware.SendError(c, fiber.StatusInternalServerError, "DOCUMENT_MISSING", "The stored document could not be found")
+	}
+
+	return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", fallback)
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/Shihasz/gophiway/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type GiftCardHandler struct {
+	giftCardService *service.GiftCardService
+}
+
+func NewGiftCardHandler(giftCardService *service.GiftCardService) *GiftCardHandler {
+	return &GiftCardHandler{
+		giftCardService: giftCardService,
+	}
+}
+
+// CheckBalance handles looking up the balance of a gift card code
+func (h *GiftCardHandler) CheckBalance(c *fiber.Ctx) error {
+	var req service.GiftCardBalanceRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	balance, err := h.giftCardService.CheckBalance(c.UserContext(), &req)
+	if err != nil {
+		return sendGiftCardError(c, err, "Failed to check gift card")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    balance,
+	})
+}
+
+// ListPurchasedGiftCards handles listing the gift cards the user bought
+func (h *GiftCardHandler) ListPurchasedGiftCards(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	cards, err := h.giftCardService.ListPurchasedGiftCards(c.UserContext(), userID)
+	if err != nil {
+		return sendGiftCardError(c, err, "Failed to list gift cards")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    cards,
+	})
+}
+
+// ListGiftCards handles listing all gift cards
+func (h *GiftCardHandler) ListGiftCards(c *fiber.Ctx) error {
+	var req service.ListGiftCardsRequest
+
+	// Parse query parameters
+	if err := c.QueryParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid query parameters")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	cards, err := h.giftCardService.ListGiftCards(c.UserContext(), &req)
+	if err != nil {
+		return sendGiftCardError(c, err, "Failed to list gift cards")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    cards,
+	})
+}
+
+// GetGiftCard handles getting a gift card with its ledger
+func (h *GiftCardHandler) GetGiftCard(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid gift card ID")
+	}
+
+	card, err := h.giftCardService.GetGiftCard(c.UserContext(), id)
+	if err != nil {
+		return sendGiftCardError(c, err, "Failed to get gift card")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    card,
+	})
+}
+
+// IssueGiftCard handles issuing a gift card. The code is only ever returned
+// in this response.
+func (h *GiftCardHandler) IssueGiftCard(c *fiber.Ctx) error {
+	var req service.IssueGiftCardRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	card, err := h.giftCardService.IssueGiftCard(c.UserContext(), &req)
+	if err != nil {
+		return sendGiftCardError(c, err, "Failed to issue gift card")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    card,
+		"message": "Gift card issued successfully",
+	})
+}
+
+// ExpireGiftCards handles writing off the balance of expired gift cards
+func (h *GiftCardHandler) ExpireGiftCards(c *fiber.Ctx) error {
+	expired, err := h.giftCardService.ExpireGiftCards(c.UserContext())
+	if err != nil {
+		return sendGiftCardError(c, err, "Failed to expire gift cards")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    fiber.Map{"expired": expired},
+	})
+}
+
+// sendGiftCardError maps gift card errors to responses
+func sendGiftCardError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrGiftCardNotFound):
+		return middleware.SendError(c, fiber.StatusNotFound, "GIFT_CARD_NOT_FOUND", "Gift card code is not valid")
+	case errors.Is(err, service.ErrGiftCardExpired):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "GIFT_CARD_EXPIRED", err.Error())
+	case errors.Is(err, service.ErrGiftCardEmpty):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "GIFT_CARD_EMPTY", err.Error())
+	case errors.Is(err, service.ErrGiftCardCurrency):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "GIFT_CARD_CURRENCY_MISMATCH", err.Error())
+	case errors.Is(err, service.ErrInvalidGiftCard):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "INVALID_GIFT_CARD", err.Error())
+	}
+
+	return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", fallback)
+}
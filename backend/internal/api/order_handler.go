@@ -0,0 +1,152 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/Shihasz/gophiway/internal/validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+type OrderHandler struct {
+	orderService *service.OrderService
+}
+
+func NewOrderHandler(orderService *service.OrderService) *OrderHandler {
+	return &OrderHandler{
+		orderService: orderService,
+	}
+}
+
+// ListOrders handles listing the user's orders
+func (h *OrderHandler) ListOrders(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	var req service.ListOrdersRequest
+
+	// Parse query parameters
+	if err := c.QueryParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid query parameters")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	resp, err := h.orderService.ListUserOrders(c.UserContext(), userID, &req)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list orders")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    resp,
+	})
+}
+
+// GetOrder handles getting one of the user's orders
+func (h *OrderHandler) GetOrder(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	order, err := h.orderService.GetUserOrder(c.UserContext(), userID, c.Params("orderNumber"))
+	if err != nil {
+		if errors.Is(err, service.ErrOrderNotFound) {
+			return middleware.SendError(c, fiber.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+		}
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get order")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    order,
+	})
+}
+
+// CancelOrder handles a customer cancelling one of their pending orders
+func (h *OrderHandler) CancelOrder(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	var req service.CancelOrderRequest
+
+	// Parse request body (optional)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		}
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	// Customers always act as themselves here, even if they are also admins
+	actor := service.Actor{ID: &userID, Role: service.ActorRoleCustomer}
+
+	order, err := h.orderService.CancelUserOrder(c.UserContext(), userID, c.Params("orderNumber"), actor, req.Reason)
+	if err != nil {
+		if errors.Is(err, service.ErrOrderNotFound) {
+			return middleware.SendError(c, fiber.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+		}
+		if errors.Is(err, service.ErrOrderNotCancellable) {
+			return middleware.SendError(c, fiber.StatusConflict, "ORDER_NOT_CANCELLABLE", "Only pending orders can be cancelled")
+		}
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to cancel order")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    order,
+		"message": "Order cancelled successfully",
+	})
+}
+
+// GetStatusHistory handles getting the status history of one of the user's orders
+func (h *OrderHandler) GetStatusHistory(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	history, err := h.orderService.GetUserOrderStatusHistory(c.UserContext(), userID, c.Params("orderNumber"))
+	if err != nil {
+		if errors.Is(err, service.ErrOrderNotFound) {
+			return middleware.SendError(c, fiber.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+		}
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get order history")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    history,
+	})
+}
+
+// actorFromContext builds the status history actor for the authenticated user
+func actorFromContext(c *fiber.Ctx) service.Actor {
+	actor := service.Actor{Role: service.ActorRoleCustomer}
+
+	if userID, err := middleware.GetUserID(c); err == nil {
+		actor.ID = &userID
+	}
+	if role, err := middleware.GetUserRole(c); err == nil && role == "admin" {
+		actor.Role = service.ActorRoleAdmin
+	}
+
+	return actor
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/Shihasz/gophiway/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PaymentHandler struct {
+	paymentService *service.PaymentService
+}
+
+func NewPaymentHandler(paymentService *service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+	}
+}
+
+// CreatePaymentIntent handles starting a payment for one of the user's orders
+func (h *PaymentHandler) CreatePaymentIntent(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	resp, err := h.paymentService.CreatePaymentIntent(c.UserContext(), userID, c.Params("orderNumber"))
+	if err != nil {
+		return sendPaymentError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    resp,
+	})
+}
+
+// ConfirmPayment handles paying one of the user's orders
+func (h *PaymentHandler) ConfirmPayment(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	var req service.ConfirmPaymentRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	payment, err := h.paymentService.ConfirmPayment(c.UserContext(), userID, c.Params("orderNumber"), &req)
+	if err != nil {
+		return sendPaymentError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    payment,
+	})
+}
+
+// CapturePayment handles capturing the authorized payment of an order
+func (h *PaymentHandler) CapturePayment(c *fiber.Ctx) error {
+	orderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid order ID")
+	}
+
+	payment, err := h.paymentService.CapturePayment(c.UserContext(), orderID)
+	if err != nil {
+		return sendPaymentError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    payment,
+		"message": "Payment captured successfully",
+	})
+}
+
+// VoidPayment handles cancelling the uncaptured payment of an order
+func (h *PaymentHandler) VoidPayment(c *fiber.Ctx) error {
+	orderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid order ID")
+	}
+
+	payment, err := h.paymentService.VoidPayment(c.UserContext(), orderID)
+	if err != nil {
+		return sendPaymentError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    payment,
+		"message": "Payment voided successfully",
+	})
+}
+
+// sendPaymentError maps payment errors to responses
+func sendPaymentError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound):
+		return middleware.SendError(c, fiber.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+	case errors.Is(err, service.ErrPaymentNotFound):
+		return middleware.SendError(c, fiber.StatusNotFound, "PAYMENT_NOT_FOUND", "Payment not found")
+	case errors.Is(err, service.ErrOrderNotPayable):
+		return middleware.SendError(c, fiber.StatusConflict, "ORDER_NOT_PAYABLE", "Order cannot be paid in its current status")
+	case errors.Is(err, service.ErrPaymentInvalidState):
+		return middleware.SendError(c, fiber.StatusConflict, "INVALID_PAYMENT_STATE", "Payment is not in a valid state for this operation")
+	case errors.Is(err, service.ErrPaymentDeclined):
+		return middleware.SendError(c, fiber.StatusPaymentRequired, "PAYMENT_DECLINED", "Payment was declined")
+	}
+
+	return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to process payment")
+}
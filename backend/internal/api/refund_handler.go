@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/Shihasz/gophiway/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type RefundHandler struct {
+	refundService *service.RefundService
+}
+
+func NewRefundHandler(refundService *service.RefundService) *RefundHandler {
+	return &RefundHandler{
+		refundService: refundService,
+	}
+}
+
+// CreateRefund handles refunding an order in full, by item or by amount
+func (h *RefundHandler) CreateRefund(c *fiber.Ctx) error {
+	orderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid order ID")
+	}
+
+	var req service.CreateRefundRequest
+
+	// Parse request body (optional, an empty body refunds in full)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		}
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	refunds, err := h.refundService.RefundOrder(c.UserContext(), orderID, &req, actorFromContext(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			return middleware.SendError(c, fiber.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+		case errors.Is(err, service.ErrOrderNotRefundable):
+			return middleware.SendError(c, fiber.StatusConflict, "ORDER_NOT_REFUNDABLE", "Order has no captured payment to refund")
+		case errors.Is(err, service.ErrInvalidRefund):
+			return middleware.SendError(c, fiber.StatusUnprocessableEntity, "INVALID_REFUND", err.Error())
+		case errors.Is(err, service.ErrRefundFailed):
+			return middleware.SendError(c, fiber.StatusBadGateway, "REFUND_FAILED", "The payment provider rejected the refund")
+		case errors.Is(err, service.ErrRefundPending):
+			return middleware.SendError(c, fiber.StatusBadGateway, "REFUND_PENDING", "The payment provider could not be reached, retry the pending refunds")
+		}
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to refund order")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    refunds,
+		"message": "Refund created successfully",
+	})
+}
+
+// ListRefunds handles listing the refunds of an order
+func (h *RefundHandler) ListRefunds(c *fiber.Ctx) error {
+	orderID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid order ID")
+	}
+
+	refunds, err := h.refundService.ListRefunds(c.UserContext(), orderID)
+	if err != nil {
+		if errors.Is(err, service.ErrOrderNotFound) {
+			return middleware.SendError(c, fiber.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+		}
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list refunds")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    refunds,
+	})
+}
+
+// RetryRefund handles attempting a pending refund again
+func (h *RefundHandler) RetryRefund(c *fiber.Ctx) error {
+	refundID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid refund ID")
+	}
+
+	refund, err := h.refundService.RetryRefund(c.UserContext(), refundID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRefundNotFound):
+			return middleware.SendError(c, fiber.StatusNotFound, "REFUND_NOT_FOUND", "Refund not found")
+		case errors.Is(err, service.ErrInvalidRefund):
+			return middleware.SendError(c, fiber.StatusConflict, "INVALID_REFUND", err.Error())
+		case errors.Is(err, service.ErrRefundFailed):
+			return middleware.SendError(c, fiber.StatusBadGateway, "REFUND_FAILED", "The payment provider rejected the refund")
+		case errors.Is(err, service.ErrRefundPending):
+			return middleware.SendError(c, fiber.StatusBadGateway, "REFUND_PENDING", "The payment provider could not be reached, the refund is still pending")
+		}
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retry refund")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    refund,
+		"message": "Refund retried successfully",
+	})
+}
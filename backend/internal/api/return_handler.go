@@ -0,0 +1,272 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/Shihasz/gophiway/internal/validation"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ReturnHandler struct {
+	returnService *service.ReturnService
+}
+
+func NewReturnHandler(returnService *service.ReturnService) *ReturnHandler {
+	return &ReturnHandler{
+		returnService: returnService,
+	}
+}
+
+// CreateReturn handles a customer requesting a return for a delivered order
+func (h *ReturnHandler) CreateReturn(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	var req service.CreateReturnRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	ret, err := h.returnService.CreateReturn(c.UserContext(), userID, c.Params("orderNumber"), &req)
+	if err != nil {
+		return sendReturnError(c, err, "Failed to create return request")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    ret,
+		"message": "Return requested successfully",
+	})
+}
+
+// ListReturns handles listing the user's return requests
+func (h *ReturnHandler) ListReturns(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	var req service.ListReturnsRequest
+
+	// Parse query parameters
+	if err := c.QueryParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid query parameters")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	resp, err := h.returnService.ListUserReturns(c.UserContext(), userID, &req)
+	if err != nil {
+		return sendReturnError(c, err, "Failed to list return requests")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    resp,
+	})
+}
+
+// GetReturn handles getting one of the user's return requests
+func (h *ReturnHandler) GetReturn(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid return ID")
+	}
+
+	ret, err := h.returnService.GetUserReturn(c.UserContext(), userID, id)
+	if err != nil {
+		return sendReturnError(c, err, "Failed to get return request")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    ret,
+	})
+}
+
+// AdminListReturns handles listing all return requests
+func (h *ReturnHandler) AdminListReturns(c *fiber.Ctx) error {
+	var req service.ListReturnsRequest
+
+	// Parse query parameters
+	if err := c.QueryParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid query parameters")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	resp, err := h.returnService.ListReturns(c.UserContext(), &req)
+	if err != nil {
+		return sendReturnError(c, err, "Failed to list return requests")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    resp,
+	})
+}
+
+// AdminGetReturn handles getting any return request
+func (h *ReturnHandler) AdminGetReturn(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid return ID")
+	}
+
+	ret, err := h.returnService.GetReturn(c.UserContext(), id)
+	if err != nil {
+		return sendReturnError(c, err, "Failed to get return request")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    ret,
+	})
+}
+
+// ApproveReturn handles an admin approving a return and issuing its RMA number
+func (h *ReturnHandler) ApproveReturn(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid return ID")
+	}
+
+	var req service.ApproveReturnRequest
+
+	// Parse request body (optional)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		}
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	ret, err := h.returnService.ApproveReturn(c.UserContext(), id, &req)
+	if err != nil {
+		return sendReturnError(c, err, "Failed to approve return request")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    ret,
+		"message": "Return approved successfully",
+	})
+}
+
+// RejectReturn handles an admin rejecting a return
+func (h *ReturnHandler) RejectReturn(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid return ID")
+	}
+
+	var req service.RejectReturnRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	ret, err := h.returnService.RejectReturn(c.UserContext(), id, &req)
+	if err != nil {
+		return sendReturnError(c, err, "Failed to reject return request")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    ret,
+		"message": "Return rejected successfully",
+	})
+}
+
+// ReceiveReturn handles the warehouse receiving and grading returned items
+func (h *ReturnHandler) ReceiveReturn(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid return ID")
+	}
+
+	var req service.ReceiveReturnRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+	}
+
+	// Validate request
+	if err := validation.ValidateStruct(&req); err != nil {
+		return validation.SendValidationError(c, err)
+	}
+
+	ret, err := h.returnService.ReceiveReturn(c.UserContext(), id, &req, actorFromContext(c))
+	if err != nil {
+		return sendReturnError(c, err, "Failed to receive return")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    ret,
+		"message": "Return received successfully",
+	})
+}
+
+// sendReturnError maps return service errors to HTTP responses
+func sendReturnError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound):
+		return middleware.SendError(c, fiber.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+	case errors.Is(err, service.ErrReturnNotFound):
+		return middleware.SendError(c, fiber.StatusNotFound, "RETURN_NOT_FOUND", "Return request not found")
+	case errors.Is(err, service.ErrOrderNotReturnable):
+		return middleware.SendError(c, fiber.StatusConflict, "ORDER_NOT_RETURNABLE", "Only delivered orders can be returned")
+	case errors.Is(err, service.ErrReturnWindowExpired):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "RETURN_WINDOW_EXPIRED", err.Error())
+	case errors.Is(err, service.ErrInvalidReturnItems):
+		return middleware.SendError(c, fiber.StatusUnprocessableEntity, "INVALID_RETURN_ITEMS", err.Error())
+	case errors.Is(err, service.ErrInvalidReturnState):
+		return middleware.SendError(c, fiber.StatusConflict, "INVALID_RETURN_STATE", "Return request is not in a valid state for this operation")
+	case errors.Is(err, service.ErrOrderNotRefundable), errors.Is(err, service.ErrInvalidRefund):
+		return middleware.SendError(c, fiber.StatusConflict, "REFUND_NOT_POSSIBLE", err.Error())
+	case errors.Is(err, service.ErrRefundFailed):
+		return middleware.SendError(c, fiber.StatusBadGateway, "REFUND_FAILED", "The payment provider rejected the refund, retry receiving the return")
+	case errors.Is(err, service.ErrRefundPending):
+		return middleware.SendError(c, fiber.StatusBadGateway, "REFUND_PENDING", "The payment provider could not be reached, retry the pending refund of the order")
+	}
+
+	return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", fallback)
+}
@@ -1,15 +1,17 @@
 package api
 
 import (
+	"log/slog"
+
 	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/metrics"
 	"github.com/Shihasz/gophiway/internal/middleware"
-	"github.com/Shihasz/gophiway/internal/repository"
 	"github.com/Shihasz/gophiway/internal/service"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(app *fiber.App, db *gorm.DB, cfg *config.Config) {
+func SetupRoutes(app *fiber.App, db *gorm.DB, cfg *config.Config, logger *slog.Logger, metrics *metrics.Metrics) error {
 	// API version group
 	api := app.Group("/api/" + cfg.APIVersion)
 
@@ -22,14 +24,30 @@ func SetupRoutes(app *fiber.App, db *gorm.DB, cfg *config.Config) {
 		})
 	})
 
-	// Initialize repositories
-	userRepo := repository.NewUserRepository(db)
-
 	// Initialize services
-	authService := service.NewAuthService(userRepo, cfg)
+	services, err := service.NewServices(db, cfg, logger, metrics)
+	if err != nil {
+		return err
+	}
 
 	// Initialize handlers
-	authHandler := NewAuthHandler(authService)
+	authHandler := NewAuthHandler(services.Auth)
+	orderHandler := NewOrderHandler(services.Order)
+	adminOrderHandler := NewAdminOrderHandler(services.Order, services.Fulfillment)
+	addressHandler := NewAddressHandler(services.Address)
+	cartHandler := NewCartHandler(services.Cart)
+	checkoutHandler := NewCheckoutHandler(services.Checkout)
+	paymentHandler := NewPaymentHandler(services.Payment)
+	webhookHandler := NewWebhookHandler(services.Webhook, logger)
+	refundHandler := NewRefundHandler(services.Refund)
+	returnHandler := NewReturnHandler(services.Return)
+	storeCreditHandler := NewStoreCreditHandler(services.StoreCredit)
+	adminTaxHandler := NewAdminTaxHandler(services.Tax)
+	adminShippingHandler := NewAdminShippingHandler(services.Shipping)
+	adminCouponHandler := NewAdminCouponHandler(services.Coupon)
+	adminPromotionHandler := NewAdminPromotionHandler(services.Promotion)
+	documentHandler := NewDocumentHandler(services.Document)
+	giftCardHandler := NewGiftCardHandler(services.GiftCard)
 
 	// Auth routes (public)
 	auth := api.Group("/auth")
@@ -43,8 +61,109 @@ func SetupRoutes(app *fiber.App, db *gorm.DB, cfg *config.Config) {
 	authProtected.Use(middleware.AuthMiddleware(cfg))
 	authProtected.Get("/me", authHandler.GetMe)
 
+	// Webhook routes (verified by signature)
+	webhooks := api.Group("/webhooks")
+	webhooks.Post("/stripe", webhookHandler.Stripe)
+
+	// Address book routes (protected)
+	addresses := api.Group("/addresses", middleware.AuthMiddleware(cfg))
+	addresses.Get("/", addressHandler.ListAddresses)
+	addresses.Post("/", addressHandler.CreateAddress)
+	addresses.Get("/:id", addressHandler.GetAddress)
+	addresses.Put("/:id", addressHandler.UpdateAddress)
+	addresses.Delete("/:id", addressHandler.DeleteAddress)
+	addresses.Post("/:id/default", addressHandler.SetDefaultAddress)
+
+	// Cart routes (protected)
+	cart := api.Group("/cart", middleware.AuthMiddleware(cfg))
+	cart.Get("/", cartHandler.GetCart)
+	cart.Post("/coupon", cartHandler.ApplyCoupon)
+	cart.Delete("/coupon", cartHandler.RemoveCoupon)
+
+	// Checkout routes (protected)
+	checkout := api.Group("/checkout", middleware.AuthMiddleware(cfg))
+	checkout.Post("/", checkoutHandler.PlaceOrder)
+	checkout.Get("/shipping-rates", checkoutHandler.ShippingRates)
+
+	// Order routes (protected)
+	orders := api.Group("/orders", middleware.AuthMiddleware(cfg))
+	orders.Get("/", orderHandler.ListOrders)
+	orders.Get("/:orderNumber", orderHandler.GetOrder)
+	orders.Post("/:orderNumber/cancel", orderHandler.CancelOrder)
+	orders.Get("/:orderNumber/history", orderHandler.GetStatusHistory)
+	orders.Post("/:orderNumber/payment", paymentHandler.CreatePaymentIntent)
+	orders.Post("/:orderNumber/payment/confirm", paymentHandler.ConfirmPayment)
+	orders.Post("/:orderNumber/returns", returnHandler.CreateReturn)
+	orders.Get("/:orderNumber/documents", documentHandler.ListDocuments)
+	orders.Get("/:orderNumber/invoice", documentHandler.DownloadInvoice)
+	orders.Get("/:orderNumber/refunds/:refundId/credit-note", documentHandler.DownloadCreditNote)
+	orders.Get("/:orderNumber/fulfillments/:fulfillmentId/packing-slip", documentHandler.DownloadPackingSlip)
+
+	// Return routes (protected)
+	returns := api.Group("/returns", middleware.AuthMiddleware(cfg))
+	returns.Get("/", returnHandler.ListReturns)
+	returns.Get("/:id", returnHandler.GetReturn)
+
+	// Store credit routes (protected)
+	storeCredit := api.Group("/store-credit", middleware.AuthMiddleware(cfg))
+	storeCredit.Get("/", storeCreditHandler.GetStoreCredit)
+
+	// Gift card routes (protected)
+	giftCards := api.Group("/gift-cards", middleware.AuthMiddleware(cfg))
+	giftCards.Get("/", giftCardHandler.ListPurchasedGiftCards)
+	giftCards.Post("/balance", giftCardHandler.CheckBalance)
+
+	// Admin routes
+	admin := api.Group("/admin", middleware.AuthMiddleware(cfg), middleware.RequireRole("admin"))
+	admin.Get("/orders", adminOrderHandler.SearchOrders)
+	admin.Get("/orders/:id", adminOrderHandler.GetOrder)
+	admin.Put("/orders/:id/status", adminOrderHandler.UpdateStatus)
+	admin.Get("/orders/:id/history", adminOrderHandler.GetStatusHistory)
+	admin.Post("/orders/:id/payment/capture", paymentHandler.CapturePayment)
+	admin.Post("/orders/:id/payment/void", paymentHandler.VoidPayment)
+	admin.Post("/orders/:id/refunds", refundHandler.CreateRefund)
+	admin.Get("/orders/:id/refunds", refundHandler.ListRefunds)
+	admin.Post("/refunds/:id/retry", refundHandler.RetryRefund)
+	admin.Post("/orders/:id/fulfillments", adminOrderHandler.CreateFulfillment)
+	admin.Get("/orders/:id/fulfillments", adminOrderHandler.ListFulfillments)
+	admin.Put("/fulfillments/:id/deliver", adminOrderHandler.MarkFulfillmentDelivered)
+	admin.Get("/orders/:id/documents", documentHandler.AdminListDocuments)
+	admin.Get("/orders/:id/invoice", documentHandler.AdminDownloadInvoice)
+	admin.Get("/refunds/:id/credit-note", documentHandler.AdminDownloadCreditNote)
+	admin.Get("/fulfillments/:id/packing-slip", documentHandler.AdminDownloadPackingSlip)
+	admin.Get("/returns", returnHandler.AdminListReturns)
+	admin.Get("/returns/:id", returnHandler.AdminGetReturn)
+	admin.Post("/returns/:id/approve", returnHandler.ApproveReturn)
+	admin.Post("/returns/:id/reject", returnHandler.RejectReturn)
+	admin.Post("/returns/:id/receive", returnHandler.ReceiveReturn)
+	admin.Get("/tax-rates", adminTaxHandler.ListRates)
+	admin.Post("/tax-rates", adminTaxHandler.CreateRate)
+	admin.Put("/tax-rates/:id", adminTaxHandler.UpdateRate)
+	admin.Delete("/tax-rates/:id", adminTaxHandler.DeleteRate)
+	admin.Get("/shipping/zones", adminShippingHandler.ListZones)
+	admin.Post("/shipping/zones", adminShippingHandler.CreateZone)
+	admin.Put("/shipping/zones/:id", adminShippingHandler.UpdateZone)
+	admin.Delete("/shipping/zones/:id", adminShippingHandler.DeleteZone)
+	admin.Post("/shipping/zones/:id/methods", adminShippingHandler.CreateMethod)
+	admin.Put("/shipping/methods/:id", adminShippingHandler.UpdateMethod)
+	admin.Delete("/shipping/methods/:id", adminShippingHandler.DeleteMethod)
+	admin.Get("/coupons", adminCouponHandler.ListCoupons)
+	admin.Post("/coupons", adminCouponHandler.CreateCoupon)
+	admin.Get("/coupons/:id", adminCouponHandler.GetCoupon)
+	admin.Put("/coupons/:id", adminCouponHandler.UpdateCoupon)
+	admin.Delete("/coupons/:id", adminCouponHandler.DeleteCoupon)
+	admin.Get("/promotions", adminPromotionHandler.ListPromotions)
+	admin.Post("/promotions", adminPromotionHandler.CreatePromotion)
+	admin.Get("/promotions/:id", adminPromotionHandler.GetPromotion)
+	admin.Put("/promotions/:id", adminPromotionHandler.UpdatePromotion)
+	admin.Delete("/promotions/:id", adminPromotionHandler.DeletePromotion)
+	admin.Get("/gift-cards", giftCardHandler.ListGiftCards)
+	admin.Post("/gift-cards", giftCardHandler.IssueGiftCard)
+	admin.Post("/gift-cards/expire", giftCardHandler.ExpireGiftCards)
+	admin.Get("/gift-cards/:id", giftCardHandler.GetGiftCard)
+
 	// TODO: Add more route groups here
 	// products := api.Group("/products")
-	// cart := api.Group("/cart")
-	// orders := api.Group("/orders")
+
+	return nil
 }
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type StoreCreditHandler struct {
+	storeCreditService *service.StoreCreditService
+}
+
+func NewStoreCreditHandler(storeCreditService *service.StoreCreditService) *StoreCreditHandler {
+	return &StoreCreditHandler{
+		storeCreditService: storeCreditService,
+	}
+}
+
+// GetStoreCredit handles getting the user's store credit balance and ledger
+func (h *StoreCreditHandler) GetStoreCredit(c *fiber.Ctx) error {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+	}
+
+	credit, err := h.storeCreditService.GetStoreCredit(c.UserContext(), userID)
+	if err != nil {
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get store credit")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    credit,
+	})
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+	logger         *slog.Logger
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService, logger *slog.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+// Stripe handles Stripe webhook deliveries
+func (h *WebhookHandler) Stripe(c *fiber.Ctx) error {
+	result, err := h.webhookService.HandleStripeEvent(c.UserContext(), c.Body(), c.Get("Stripe-Signature"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWebhook) {
+			return middleware.SendError(c, fiber.StatusBadRequest, "INVALID_WEBHOOK", "Invalid webhook signature or payload")
+		}
+
+		// A non-2xx response makes Stripe retry the delivery later
+		h.logger.ErrorContext(c.UserContext(), "Failed to process Stripe webhook", "error", err)
+		return middleware.SendError(c, fiber.StatusInternalServerError, "INTERNAL_ERROR", "Failed to process webhook")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
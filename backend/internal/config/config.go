@@ -2,7 +2,6 @@ package config
 
 import (
 	"os"
-	"strconv"
 	"time"
 )
 
@@ -13,16 +12,28 @@ type Config struct {
 	Port       string
 	APIVersion string
 
+	// Requests
+	RequestTimeout time.Duration
+
+	// Health checks
+	HealthCheckTimeout time.Duration
+	HealthCacheTTL     time.Duration
+
+	// Shutdown
+	ShutdownTimeout time.Duration
+	ShutdownDelay   time.Duration
+
 	// Database
-	DBHost            string
-	DBPort            string
-	DBUser            string
-	DBPassword        string
-	DBName            string
-	DBSSLMode         string
-	DBMaxConnections  int
-	DBMaxIdle         int
-	DBMaxLifetime     time.Duration
+	DBHost           string
+	DBPort           string
+	DBUser           string
+	DBPassword       string
+	DBName           string
+	DBSSLMode        string
+	DBMaxConnections int
+	DBMaxIdle        int
+	DBMaxLifetime    time.Duration
+	DBAutoMigrate    bool
 
 	// Redis
 	RedisHost     string
@@ -37,9 +48,9 @@ type Config struct {
 	JWTRefreshExpiration time.Duration
 
 	// Security
-	BcryptCost         int
-	RateLimitRequests  int
-	RateLimitDuration  time.Duration
+	BcryptCost        int
+	RateLimitRequests int
+	RateLimitDuration time.Duration
 
 	// CORS
 	CORSAllowedOrigins string
@@ -52,6 +63,19 @@ type Config struct {
 	MinIOSecretKey string
 	MinIOUseSSL    bool
 	MinIOBucket    string
+	MinIORegion    string
+
+	// Documents
+	StorageDriver    string
+	DocumentsBucket  string
+	InvoicePrefix    string
+	CreditNotePrefix string
+
+	// Seller details printed on invoices
+	SellerName    string
+	SellerAddress string // lines separated by "|"
+	SellerTaxID   string
+	SellerEmail   string
 
 	// SMTP
 	SMTPHost     string
@@ -61,9 +85,28 @@ type Config struct {
 	SMTPFrom     string
 
 	// Payment
-	StripeSecretKey      string
-	StripeWebhookSecret  string
-	StripePublishableKey string
+	PaymentProvider        string
+	PaymentCurrency        string
+	PaymentCaptureMethod   string
+	StripeSecretKey        string
+	StripeWebhookSecret    string
+	StripePublishableKey   string
+	StripeWebhookTolerance time.Duration
+
+	// Returns
+	ReturnWindowDays int
+
+	// Gift cards
+	GiftCardSecret       string
+	GiftCardValidityDays int
+
+	// Shipping
+	ShippingCarriers string
+
+	// Tax
+	TaxPricesIncludeTax bool
+	TaxShippingTaxable  bool
+	TaxShippingClass    string
 
 	// Frontend
 	FrontendURL string
@@ -71,106 +114,166 @@ type Config struct {
 	// Logging
 	LogLevel  string
 	LogFormat string
+
+	// Metrics
+	MetricsPort  string
+	MetricsToken string
+
+	// Tracing
+	TracingExporter    string
+	TracingEndpoint    string
+	TracingSampleRatio float64
+
+	// settings records where each value came from, for printing
+	settings []Setting
+	// loadErrs are the values that did not parse, reported by Validate
+	loadErrs []error
+}
+
+// Load reads and validates the configuration
+func Load() (*Config, error) {
+	cfg, err := Read()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
 }
 
-func Load() *Config {
-	return &Config{
+// Read reads the configuration without validating it. Each setting comes
+// from its environment variable, else from the file named by the variable
+// with a _FILE suffix, else from the YAML or TOML file named by
+// CONFIG_FILE, else from its default. Only a config file that cannot be
+// read fails, values that do not parse are left to Validate.
+func Read() (*Config, error) {
+	l, err := newLoader(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
 		// Application
-		AppEnv:     getEnv("APP_ENV", "development"),
-		AppName:    getEnv("APP_NAME", "Gophiway"),
-		Port:       getEnv("APP_PORT", "8080"),
-		APIVersion: getEnv("API_VERSION", "v1"),
+		AppEnv:     l.string("APP_ENV", "development"),
+		AppName:    l.string("APP_NAME", "Gophiway"),
+		Port:       l.string("APP_PORT", "8080"),
+		APIVersion: l.string("API_VERSION", "v1"),
+
+		// Requests
+		RequestTimeout: l.duration("REQUEST_TIMEOUT", "30s"),
+
+		// Health checks
+		HealthCheckTimeout: l.duration("HEALTH_CHECK_TIMEOUT", "2s"),
+		HealthCacheTTL:     l.duration("HEALTH_CACHE_TTL", "5s"),
+
+		// Shutdown
+		ShutdownTimeout: l.duration("SHUTDOWN_TIMEOUT", "30s"),
+		ShutdownDelay:   l.duration("SHUTDOWN_DELAY", "0s"),
 
 		// Database
-		DBHost:            getEnv("DB_HOST", "localhost"),
-		DBPort:            getEnv("DB_PORT", "5432"),
-		DBUser:            getEnv("DB_USER", "gophiway"),
-		DBPassword:        getEnv("DB_PASSWORD", "gophiway_dev_password"),
-		DBName:            getEnv("DB_NAME", "gophiway_dev"),
-		DBSSLMode:         getEnv("DB_SSL_MODE", "disable"),
-		DBMaxConnections:  getEnvAsInt("DB_MAX_CONNECTIONS", 100),
-		DBMaxIdle:         getEnvAsInt("DB_MAX_IDLE_CONNECTIONS", 10),
-		DBMaxLifetime:     time.Duration(getEnvAsInt("DB_MAX_LIFETIME", 3600)) * time.Second,
+		DBHost:           l.string("DB_HOST", "localhost"),
+		DBPort:           l.string("DB_PORT", "5432"),
+		DBUser:           l.string("DB_USER", "gophiway"),
+		DBPassword:       l.string("DB_PASSWORD", "gophiway_dev_password"),
+		DBName:           l.string("DB_NAME", "gophiway_dev"),
+		DBSSLMode:        l.string("DB_SSL_MODE", "disable"),
+		DBMaxConnections: l.int("DB_MAX_CONNECTIONS", 100),
+		DBMaxIdle:        l.int("DB_MAX_IDLE_CONNECTIONS", 10),
+		DBMaxLifetime:    time.Duration(l.int("DB_MAX_LIFETIME", 3600)) * time.Second,
+		DBAutoMigrate:    l.bool("DB_AUTO_MIGRATE", false),
 
 		// Redis
-		RedisHost:     getEnv("REDIS_HOST", "localhost"),
-		RedisPort:     getEnv("REDIS_PORT", "6379"),
-		RedisPassword: getEnv("REDIS_PASSWORD", ""),
-		RedisDB:       getEnvAsInt("REDIS_DB", 0),
+		RedisHost:     l.string("REDIS_HOST", "localhost"),
+		RedisPort:     l.string("REDIS_PORT", "6379"),
+		RedisPassword: l.string("REDIS_PASSWORD", ""),
+		RedisDB:       l.int("REDIS_DB", 0),
 
 		// JWT
-		JWTSecret:            getEnv("JWT_SECRET", "your-super-secret-jwt-key"),
-		JWTExpiration:        parseDuration(getEnv("JWT_EXPIRATION", "15m")),
-		JWTRefreshSecret:     getEnv("JWT_REFRESH_SECRET", "your-super-secret-refresh-key"),
-		JWTRefreshExpiration: parseDuration(getEnv("JWT_REFRESH_EXPIRATION", "7d")),
+		JWTSecret:            l.string("JWT_SECRET", "your-super-secret-jwt-key"),
+		JWTExpiration:        l.duration("JWT_EXPIRATION", "15m"),
+		JWTRefreshSecret:     l.string("JWT_REFRESH_SECRET", "your-super-secret-refresh-key"),
+		JWTRefreshExpiration: l.duration("JWT_REFRESH_EXPIRATION", "7d"),
 
 		// Security
-		BcryptCost:        getEnvAsInt("BCRYPT_COST", 12),
-		RateLimitRequests: getEnvAsInt("RATE_LIMIT_REQUESTS", 100),
-		RateLimitDuration: parseDuration(getEnv("RATE_LIMIT_DURATION", "1m")),
+		BcryptCost:        l.int("BCRYPT_COST", 12),
+		RateLimitRequests: l.int("RATE_LIMIT_REQUESTS", 100),
+		RateLimitDuration: l.duration("RATE_LIMIT_DURATION", "1m"),
 
 		// CORS
-		CORSAllowedOrigins: getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:5173"),
-		CORSAllowedMethods: getEnv("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS"),
-		CORSAllowedHeaders: getEnv("CORS_ALLOWED_HEADERS", "Content-Type,Authorization"),
+		CORSAllowedOrigins: l.string("CORS_ALLOWED_ORIGINS", "http://localhost:5173"),
+		CORSAllowedMethods: l.string("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS"),
+		CORSAllowedHeaders: l.string("CORS_ALLOWED_HEADERS", "Content-Type,Authorization,X-Request-ID"),
 
 		// MinIO
-		MinIOEndpoint:  getEnv("MINIO_ENDPOINT", "localhost:9000"),
-		MinIOAccessKey: getEnv("MINIO_ACCESS_KEY", "gophiway"),
-		MinIOSecretKey: getEnv("MINIO_SECRET_KEY", "gophiway_minio_password"),
-		MinIOUseSSL:    getEnvAsBool("MINIO_USE_SSL", false),
-		MinIOBucket:    getEnv("MINIO_BUCKET", "products"),
+		MinIOEndpoint:  l.string("MINIO_ENDPOINT", "localhost:9000"),
+		MinIOAccessKey: l.string("MINIO_ACCESS_KEY", "gophiway"),
+		MinIOSecretKey: l.string("MINIO_SECRET_KEY", "gophiway_minio_password"),
+		MinIOUseSSL:    l.bool("MINIO_USE_SSL", false),
+		MinIOBucket:    l.string("MINIO_BUCKET", "products"),
+		MinIORegion:    l.string("MINIO_REGION", "us-east-1"),
+
+		// Documents
+		StorageDriver:    l.string("STORAGE_DRIVER", "minio"),
+		DocumentsBucket:  l.string("DOCUMENTS_BUCKET", "documents"),
+		InvoicePrefix:    l.string("INVOICE_PREFIX", "INV"),
+		CreditNotePrefix: l.string("CREDIT_NOTE_PREFIX", "CN"),
+
+		// Seller details printed on invoices
+		SellerName:    l.string("SELLER_NAME", "Gophiway"),
+		SellerAddress: l.string("SELLER_ADDRESS", ""),
+		SellerTaxID:   l.string("SELLER_TAX_ID", ""),
+		SellerEmail:   l.string("SELLER_EMAIL", "billing@gophiway.com"),
 
 		// SMTP
-		SMTPHost:     getEnv("SMTP_HOST", "smtp.gmail.com"),
-		SMTPPort:     getEnv("SMTP_PORT", "587"),
-		SMTPUser:     getEnv("SMTP_USER", ""),
-		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
-		SMTPFrom:     getEnv("SMTP_FROM", "noreply@gophiway.com"),
+		SMTPHost:     l.string("SMTP_HOST", "smtp.gmail.com"),
+		SMTPPort:     l.string("SMTP_PORT", "587"),
+		SMTPUser:     l.string("SMTP_USER", ""),
+		SMTPPassword: l.string("SMTP_PASSWORD", ""),
+		SMTPFrom:     l.string("SMTP_FROM", "noreply@gophiway.com"),
 
 		// Payment
-		StripeSecretKey:      getEnv("STRIPE_SECRET_KEY", ""),
-		StripeWebhookSecret:  getEnv("STRIPE_WEBHOOK_SECRET", ""),
-		StripePublishableKey: getEnv("STRIPE_PUBLISHABLE_KEY", ""),
+		PaymentProvider:        l.string("PAYMENT_PROVIDER", "fake"),
+		PaymentCurrency:        l.string("PAYMENT_CURRENCY", "usd"),
+		PaymentCaptureMethod:   l.string("PAYMENT_CAPTURE_METHOD", "automatic"),
+		StripeSecretKey:        l.string("STRIPE_SECRET_KEY", ""),
+		StripeWebhookSecret:    l.string("STRIPE_WEBHOOK_SECRET", ""),
+		StripePublishableKey:   l.string("STRIPE_PUBLISHABLE_KEY", ""),
+		StripeWebhookTolerance: l.duration("STRIPE_WEBHOOK_TOLERANCE", "5m"),
+
+		// Returns
+		ReturnWindowDays: l.int("RETURN_WINDOW_DAYS", 30),
+
+		// Gift cards
+		GiftCardSecret:       l.string("GIFT_CARD_SECRET", "your-super-secret-gift-card-key"),
+		GiftCardValidityDays: l.int("GIFT_CARD_VALIDITY_DAYS", 365),
+
+		// Shipping
+		ShippingCarriers: l.string("SHIPPING_CARRIERS", "stub"),
+
+		// Tax
+		TaxPricesIncludeTax: l.bool("TAX_PRICES_INCLUDE_TAX", false),
+		TaxShippingTaxable:  l.bool("TAX_SHIPPING_TAXABLE", false),
+		TaxShippingClass:    l.string("TAX_SHIPPING_CLASS", "standard"),
 
 		// Frontend
-		FrontendURL: getEnv("FRONTEND_URL", "http://localhost:5173"),
+		FrontendURL: l.string("FRONTEND_URL", "http://localhost:5173"),
 
 		// Logging
-		LogLevel:  getEnv("LOG_LEVEL", "debug"),
-		LogFormat: getEnv("LOG_FORMAT", "json"),
-	}
-}
+		LogLevel:  l.string("LOG_LEVEL", "debug"),
+		LogFormat: l.string("LOG_FORMAT", "json"),
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
+		// Metrics
+		MetricsPort:  l.string("METRICS_PORT", ""),
+		MetricsToken: l.string("METRICS_TOKEN", ""),
 
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
+		// Tracing
+		TracingExporter:    l.string("TRACING_EXPORTER", "none"),
+		TracingEndpoint:    l.string("TRACING_ENDPOINT", ""),
+		TracingSampleRatio: l.float("TRACING_SAMPLE_RATIO", 1),
 	}
-	return defaultValue
-}
+	cfg.settings = l.settings
+	cfg.loadErrs = l.errors()
 
-func getEnvAsBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
-		if boolValue, err := strconv.ParseBool(value); err == nil {
-			return boolValue
-		}
-	}
-	return defaultValue
-}
-
-func parseDuration(s string) time.Duration {
-	duration, err := time.ParseDuration(s)
-	if err != nil {
-		return 15 * time.Minute
-	}
-	return duration
+	return cfg, nil
 }
@@ -0,0 +1,364 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		err  bool
+	}{
+		{in: "90s", want: 90 * time.Second},
+		{in: "15m", want: 15 * time.Minute},
+		{in: "7d", want: 7 * 24 * time.Hour},
+		{in: "1.5d", want: 36 * time.Hour},
+		{in: ".5w", want: 84 * time.Hour},
+		{in: "1w2d12h", want: 228 * time.Hour},
+		{in: "-2d", want: -48 * time.Hour},
+		{in: "", err: true},
+		{in: "d", err: true},
+		{in: "7", err: true},
+		{in: "7x", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDuration(tt.in)
+			if (err != nil) != tt.err {
+				t.Fatalf("ParseDuration(%q) error = %v, want error %v", tt.in, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDuration(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// writeFile writes a file to the test's temporary directory
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// unsetenv clears variables for the test, so the environment it runs in
+// cannot change the result
+func unsetenv(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		t.Setenv(key, "")
+		t.Setenv(key+"_FILE", "")
+	}
+}
+
+func TestReadLayering(t *testing.T) {
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"config.yaml", `
+app:
+  env: staging
+  port: 9000
+db:
+  host: db.internal
+  max_connections: 20
+cors:
+  allowed_origins: [https://a.example, https://b.example]
+jwt:
+  expiration: 1h
+`},
+		{"config.toml", `
+[app]
+env = "staging"
+port = 9000
+
+[db]
+host = "db.internal"
+max_connections = 20
+
+[cors]
+allowed_origins = ["https://a.example", "https://b.example"]
+
+[jwt]
+expiration = "1h"
+`},
+	}
+
+	for _, file := range files {
+		t.Run(file.name, func(t *testing.T) {
+			unsetenv(t, "APP_ENV", "APP_PORT", "DB_HOST", "DB_MAX_CONNECTIONS", "DB_PASSWORD",
+				"CORS_ALLOWED_ORIGINS", "JWT_EXPIRATION", "JWT_SECRET", "LOG_LEVEL")
+			t.Setenv("CONFIG_FILE", writeFile(t, file.name, file.content))
+			t.Setenv("APP_PORT", "7000")
+			t.Setenv("JWT_EXPIRATION", "2d")
+			t.Setenv("DB_PASSWORD_FILE", writeFile(t, "db_password", "s3cret\r\n"))
+			t.Setenv("JWT_SECRET", "from-env")
+
+			cfg, err := Read()
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			if err := cfg.Validate(); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+
+			if cfg.Port != "7000" || cfg.AppEnv != "staging" || cfg.DBHost != "db.internal" ||
+				cfg.DBMaxConnections != 20 || cfg.CORSAllowedOrigins != "https://a.example,https://b.example" ||
+				cfg.JWTExpiration != 48*time.Hour || cfg.DBPassword != "s3cret" || cfg.LogLevel != "debug" {
+				t.Errorf("config = port %s env %s host %s connections %d origins %s expiration %v password %q level %s",
+					cfg.Port, cfg.AppEnv, cfg.DBHost, cfg.DBMaxConnections, cfg.CORSAllowedOrigins,
+					cfg.JWTExpiration, cfg.DBPassword, cfg.LogLevel)
+			}
+
+			settings := make(map[string]Setting)
+			for _, setting := range cfg.Settings() {
+				settings[setting.Key] = setting
+			}
+			want := []Setting{
+				{Key: "APP_PORT", Value: "7000", Source: SourceEnv},
+				{Key: "APP_ENV", Value: "staging", Source: SourceFile},
+				{Key: "DB_MAX_CONNECTIONS", Value: "20", Source: SourceFile},
+				{Key: "JWT_EXPIRATION", Value: "2d", Source: SourceEnv},
+				{Key: "DB_PASSWORD", Value: "[redacted]", Source: SourceEnvFile},
+				{Key: "JWT_SECRET", Value: "[redacted]", Source: SourceEnv},
+				{Key: "LOG_LEVEL", Value: "debug", Source: SourceDefault},
+			}
+			for _, setting := range want {
+				if got := settings[setting.Key]; got != setting {
+					t.Errorf("setting %s = %+v, want %+v", setting.Key, got, setting)
+				}
+			}
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		file  string
+		read  string // error from Read
+		valid string // error from Validate
+	}{
+		{
+			name:  "unknown key in the config file",
+			file:  "db:\n  hots: db.internal\n",
+			valid: "DB_HOTS: unknown setting in config file",
+		},
+		{
+			name:  "value and file both set",
+			env:   map[string]string{"JWT_SECRET": "a", "JWT_SECRET_FILE": "/run/secrets/jwt"},
+			valid: "JWT_SECRET and JWT_SECRET_FILE are both set",
+		},
+		{
+			name:  "secret file missing",
+			env:   map[string]string{"JWT_SECRET_FILE": "/nonexistent/jwt"},
+			valid: "JWT_SECRET_FILE:",
+		},
+		{
+			name:  "integer that does not parse",
+			env:   map[string]string{"DB_MAX_CONNECTIONS": "many"},
+			valid: `DB_MAX_CONNECTIONS: "many" is not a valid integer`,
+		},
+		{
+			name:  "duration that does not parse",
+			file:  "request_timeout: soon\n",
+			valid: `REQUEST_TIMEOUT: "soon" is not a valid duration`,
+		},
+		{
+			name: "config file that does not parse",
+			file: "db: [\n",
+			read: "failed to parse config file",
+		},
+		{
+			name: "config file missing",
+			env:  map[string]string{"CONFIG_FILE": "/nonexistent/config.yaml"},
+			read: "failed to read config file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetenv(t, "CONFIG_FILE", "JWT_SECRET", "DB_MAX_CONNECTIONS", "REQUEST_TIMEOUT", "DB_HOST")
+			if tt.file != "" {
+				t.Setenv("CONFIG_FILE", writeFile(t, "config.yaml", tt.file))
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := Read()
+			if tt.read != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.read) {
+					t.Errorf("Read error = %v, want it to contain %q", err, tt.read)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.valid) {
+				t.Errorf("Validate error = %v, want it to contain %q", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestReadRejectsUnsupportedFile(t *testing.T) {
+	t.Setenv("CONFIG_FILE", writeFile(t, "config.json", "{}"))
+	if _, err := Read(); err == nil || !strings.Contains(err.Error(), "must be .yaml, .yml or .toml") {
+		t.Errorf("Read error = %v, want the supported formats", err)
+	}
+}
+
+// productionConfig is a configuration that passes validation in production
+func productionConfig() *Config {
+	return &Config{
+		AppEnv:               "production",
+		Port:                 "8080",
+		RequestTimeout:       30 * time.Second,
+		HealthCheckTimeout:   2 * time.Second,
+		ShutdownTimeout:      30 * time.Second,
+		JWTSecret:            strings.Repeat("a", 32),
+		JWTExpiration:        15 * time.Minute,
+		JWTRefreshSecret:     strings.Repeat("b", 32),
+		JWTRefreshExpiration: 7 * 24 * time.Hour,
+		GiftCardSecret:       strings.Repeat("c", 32),
+		DBPassword:           "db-password",
+		DBMaxConnections:     100,
+		BcryptCost:           12,
+		LogLevel:             "info",
+		LogFormat:            "json",
+		StorageDriver:        "minio",
+		MinIOSecretKey:       "minio-secret",
+		PaymentProvider:      "stripe",
+		PaymentCaptureMethod: "automatic",
+		StripeSecretKey:      "sk_live_x",
+		StripeWebhookSecret:  "whsec_x",
+		TracingExporter:      "otlp",
+		TracingSampleRatio:   0.1,
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   string // part of the error, none when empty
+	}{
+		{name: "production", change: func(c *Config) {}},
+		{
+			name:   "fake provider in production",
+			change: func(c *Config) { c.PaymentProvider = "fake" },
+			want:   "PAYMENT_PROVIDER fake must not be used in production",
+		},
+		{
+			name: "fake provider and example secrets in development",
+			change: func(c *Config) {
+				c.AppEnv, c.PaymentProvider = "development", "fake"
+				c.JWTSecret, c.DBPassword, c.GiftCardSecret = "your-super-secret-jwt-key", "gophiway_dev_password", ""
+			},
+		},
+		{
+			name:   "missing secret",
+			change: func(c *Config) { c.GiftCardSecret = "" },
+			want:   "GIFT_CARD_SECRET is required in production",
+		},
+		{
+			name:   "example secret",
+			change: func(c *Config) { c.JWTSecret = "your-super-secret-jwt-key-please-change-this" },
+			want:   "JWT_SECRET is still the example value",
+		},
+		{
+			name:   "example password",
+			change: func(c *Config) { c.DBPassword = "gophiway_dev_password" },
+			want:   "DB_PASSWORD is still the example value",
+		},
+		{
+			name:   "short secret",
+			change: func(c *Config) { c.JWTRefreshSecret = strings.Repeat("b", 31) },
+			want:   "JWT_REFRESH_SECRET must be at least 32 characters in production",
+		},
+		{
+			name:   "shared JWT secret",
+			change: func(c *Config) { c.JWTRefreshSecret = c.JWTSecret },
+			want:   "JWT_SECRET and JWT_REFRESH_SECRET must differ",
+		},
+		{
+			name:   "missing MinIO secret",
+			change: func(c *Config) { c.MinIOSecretKey = "" },
+			want:   "MINIO_SECRET_KEY is required in production",
+		},
+		{
+			name:   "MinIO secret unused with memory storage",
+			change: func(c *Config) { c.StorageDriver, c.MinIOSecretKey = "memory", "" },
+		},
+		{
+			name:   "stripe without a webhook secret",
+			change: func(c *Config) { c.StripeWebhookSecret = "" },
+			want:   "STRIPE_WEBHOOK_SECRET is required for the stripe payment provider",
+		},
+		{
+			name:   "unknown log level",
+			change: func(c *Config) { c.LogLevel = "verbose" },
+			want:   "LOG_LEVEL must be debug, info, warn or error",
+		},
+		{
+			name:   "bcrypt cost too low",
+			change: func(c *Config) { c.BcryptCost = 3 },
+			want:   "BCRYPT_COST must be between 4 and 31",
+		},
+		{
+			name:   "sample ratio above one",
+			change: func(c *Config) { c.TracingSampleRatio = 1.5 },
+			want:   "TRACING_SAMPLE_RATIO must be between 0 and 1",
+		},
+		{
+			name:   "non-positive timeout",
+			change: func(c *Config) { c.RequestTimeout = 0 },
+			want:   "REQUEST_TIMEOUT must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := productionConfig()
+			tt.change(cfg)
+
+			err := cfg.Validate()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("Validate = %v, want no error", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("Validate = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := productionConfig()
+	cfg.PaymentProvider = "fake"
+	cfg.JWTSecret = ""
+	cfg.LogFormat = "xml"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate passed")
+	}
+	for _, want := range []string{"PAYMENT_PROVIDER fake", "JWT_SECRET is required", "LOG_FORMAT must be json or text"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate = %v, want it to contain %q", err, want)
+		}
+	}
+}
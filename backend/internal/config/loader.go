@@ -0,0 +1,256 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Sources of a setting
+const (
+	SourceEnv     = "env"
+	SourceEnvFile = "env file"
+	SourceFile    = "config file"
+	SourceDefault = "default"
+)
+
+// Setting is one loaded setting, named by its environment variable
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// loader resolves settings from the environment, _FILE secrets, the
+// config file and defaults, collecting every invalid value
+type loader struct {
+	file     map[string]string
+	settings []Setting
+	errs     []error
+}
+
+// newLoader reads the config file at path, if any
+func newLoader(path string) (*loader, error) {
+	l := &loader{file: map[string]string{}}
+	if path == "" {
+		return l, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var values map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	flatten("", values, l.file)
+	return l, nil
+}
+
+// flatten turns nested sections into keys named like environment
+// variables, so db: {host: x} sets DB_HOST. Lists become comma separated.
+func flatten(prefix string, values map[string]any, out map[string]string) {
+	for key, value := range values {
+		key = strings.ToUpper(key)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		switch value := value.(type) {
+		case map[string]any:
+			flatten(key, value, out)
+		case []any:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = fmt.Sprint(item)
+			}
+			out[key] = strings.Join(items, ",")
+		case nil:
+		default:
+			out[key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// lookup finds the raw value of key and where it came from. Setting both
+// key and key_FILE is an error, since it is unclear which one wins.
+func (l *loader) lookup(key string) (string, string, bool) {
+	value := os.Getenv(key)
+	path := os.Getenv(key + "_FILE")
+
+	switch {
+	case value != "" && path != "":
+		l.errs = append(l.errs, fmt.Errorf("%s and %s_FILE are both set", key, key))
+		return value, SourceEnv, true
+	case value != "":
+		return value, SourceEnv, true
+	case path != "":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("%s_FILE: %w", key, err))
+			return "", SourceEnvFile, false
+		}
+		return strings.TrimRight(string(data), "\r\n"), SourceEnvFile, true
+	}
+
+	if value, ok := l.file[key]; ok {
+		return value, SourceFile, true
+	}
+	return "", SourceDefault, false
+}
+
+// value resolves key, falling back to defaultValue, and records it
+func (l *loader) value(key, defaultValue string) (string, bool) {
+	value, source, ok := l.lookup(key)
+	if !ok {
+		value = defaultValue
+	}
+	l.settings = append(l.settings, Setting{Key: key, Value: value, Source: source})
+	return value, ok
+}
+
+// invalid records a value that does not parse
+func (l *loader) invalid(key, value, want string) {
+	l.errs = append(l.errs, fmt.Errorf("%s: %q is not a valid %s", key, value, want))
+}
+
+func (l *loader) string(key, defaultValue string) string {
+	value, _ := l.value(key, defaultValue)
+	return value
+}
+
+func (l *loader) int(key string, defaultValue int) int {
+	value, ok := l.value(key, strconv.Itoa(defaultValue))
+	if !ok {
+		return defaultValue
+	}
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		l.invalid(key, value, "integer")
+		return defaultValue
+	}
+	return intValue
+}
+
+func (l *loader) float(key string, defaultValue float64) float64 {
+	value, ok := l.value(key, strconv.FormatFloat(defaultValue, 'g', -1, 64))
+	if !ok {
+		return defaultValue
+	}
+	floatValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		l.invalid(key, value, "number")
+		return defaultValue
+	}
+	return floatValue
+}
+
+func (l *loader) bool(key string, defaultValue bool) bool {
+	value, ok := l.value(key, strconv.FormatBool(defaultValue))
+	if !ok {
+		return defaultValue
+	}
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		l.invalid(key, value, "boolean")
+		return defaultValue
+	}
+	return boolValue
+}
+
+func (l *loader) duration(key, defaultValue string) time.Duration {
+	value, _ := l.value(key, defaultValue)
+	duration, err := ParseDuration(value)
+	if err != nil {
+		l.invalid(key, value, "duration")
+		duration, _ = ParseDuration(defaultValue)
+	}
+	return duration
+}
+
+// errors returns the errors met while loading. It runs once every setting
+// has been read, so keys of the config file that none of them used, likely
+// typos, are reported too.
+func (l *loader) errors() []error {
+	known := make(map[string]bool, len(l.settings))
+	for _, setting := range l.settings {
+		known[setting.Key] = true
+	}
+	var unknown []string
+	for key := range l.file {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		l.errs = append(l.errs, fmt.Errorf("%s: unknown setting in config file", key))
+	}
+	return l.errs
+}
+
+// dayWeekPattern matches the day and week units time.ParseDuration lacks
+var dayWeekPattern = regexp.MustCompile(`([0-9]*\.?[0-9]+)([dw])`)
+
+// ParseDuration parses a duration like time.ParseDuration, also accepting
+// d for days and w for weeks, as in "7d" or "1w2d12h"
+func ParseDuration(s string) (time.Duration, error) {
+	var convErr error
+	converted := dayWeekPattern.ReplaceAllStringFunc(s, func(match string) string {
+		parts := dayWeekPattern.FindStringSubmatch(match)
+		number, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			convErr = err
+			return match
+		}
+		hours := number * 24
+		if parts[2] == "w" {
+			hours *= 7
+		}
+		return strconv.FormatFloat(hours, 'f', -1, 64) + "h"
+	})
+	if convErr != nil {
+		return 0, convErr
+	}
+	return time.ParseDuration(converted)
+}
+
+// secretKeyPattern matches the settings whose values are credentials
+var secretKeyPattern = regexp.MustCompile(`(PASSWORD|SECRET|TOKEN|ACCESS_KEY)`)
+
+// IsSecret reports whether the setting named key holds a credential
+func IsSecret(key string) bool {
+	return secretKeyPattern.MatchString(key)
+}
+
+// Settings lists the loaded settings in the order of Config, with the
+// values of secrets replaced, so they can be printed safely
+func (c *Config) Settings() []Setting {
+	settings := make([]Setting, len(c.settings))
+	copy(settings, c.settings)
+	for i, setting := range settings {
+		if IsSecret(setting.Key) && setting.Value != "" {
+			settings[i].Value = "[redacted]"
+		}
+	}
+	return settings
+}
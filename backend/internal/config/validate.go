@@ -0,0 +1,124 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// minSecretLength is the shortest secret accepted in production
+const minSecretLength = 32
+
+// placeholderSecrets are the defaults and example values of the secrets,
+// never acceptable in production
+var placeholderSecrets = []string{
+	"your-super-secret",
+	"change-this",
+	"changeme",
+	"gophiway_dev_password",
+	"gophiway_minio_password",
+}
+
+// IsProduction reports whether the application runs in production
+func (c *Config) IsProduction() bool {
+	return c.AppEnv == "production"
+}
+
+// Validate reports the values that did not parse, the ones that do not
+// make sense together and, in production, the fake payment provider and
+// missing or weak secrets, all together as one error
+func (c *Config) Validate() error {
+	errs := append([]error(nil), c.loadErrs...)
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Port != "", "APP_PORT is required")
+	check(c.RequestTimeout > 0, "REQUEST_TIMEOUT must be positive")
+	check(c.HealthCheckTimeout > 0, "HEALTH_CHECK_TIMEOUT must be positive")
+	check(c.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT must be positive")
+	check(c.ShutdownDelay >= 0, "SHUTDOWN_DELAY must not be negative")
+	check(c.JWTExpiration > 0, "JWT_EXPIRATION must be positive")
+	check(c.JWTRefreshExpiration > 0, "JWT_REFRESH_EXPIRATION must be positive")
+	check(c.BcryptCost >= 4 && c.BcryptCost <= 31, "BCRYPT_COST must be between 4 and 31")
+	check(c.DBMaxConnections > 0, "DB_MAX_CONNECTIONS must be positive")
+	check(oneOf(c.LogLevel, "debug", "info", "warn", "warning", "error"), "LOG_LEVEL must be debug, info, warn or error")
+	check(oneOf(c.LogFormat, "json", "text"), "LOG_FORMAT must be json or text")
+	check(oneOf(c.StorageDriver, "minio", "memory"), "STORAGE_DRIVER must be minio or memory")
+	check(oneOf(c.PaymentProvider, "fake", "stripe"), "PAYMENT_PROVIDER must be fake or stripe")
+	check(oneOf(c.PaymentCaptureMethod, "automatic", "manual"), "PAYMENT_CAPTURE_METHOD must be automatic or manual")
+	check(oneOf(c.TracingExporter, "none", "stdout", "otlp"), "TRACING_EXPORTER must be none, stdout or otlp")
+	check(c.TracingSampleRatio >= 0 && c.TracingSampleRatio <= 1, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+
+	if c.PaymentProvider == "stripe" {
+		check(c.StripeSecretKey != "", "STRIPE_SECRET_KEY is required for the stripe payment provider")
+		check(c.StripeWebhookSecret != "", "STRIPE_WEBHOOK_SECRET is required for the stripe payment provider")
+	}
+
+	if c.IsProduction() {
+		// The fake provider treats every card but its decline token as paid
+		check(c.PaymentProvider != "fake", "PAYMENT_PROVIDER fake must not be used in production")
+		errs = append(errs, c.validateSecrets()...)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+}
+
+// validateSecrets rejects missing, short and placeholder secrets
+func (c *Config) validateSecrets() []error {
+	type namedSecret struct {
+		key   string
+		value string
+	}
+	secrets := []namedSecret{
+		{"JWT_SECRET", c.JWTSecret},
+		{"JWT_REFRESH_SECRET", c.JWTRefreshSecret},
+		{"GIFT_CARD_SECRET", c.GiftCardSecret},
+		{"DB_PASSWORD", c.DBPassword},
+	}
+	if c.StorageDriver == "minio" {
+		secrets = append(secrets, namedSecret{"MINIO_SECRET_KEY", c.MinIOSecretKey})
+	}
+
+	var errs []error
+	for _, secret := range secrets {
+		switch {
+		case secret.value == "":
+			errs = append(errs, fmt.Errorf("%s is required in production", secret.key))
+		case isPlaceholder(secret.value):
+			errs = append(errs, fmt.Errorf("%s is still the example value", secret.key))
+		case strings.HasSuffix(secret.key, "_SECRET") && len(secret.value) < minSecretLength:
+			errs = append(errs, fmt.Errorf("%s must be at least %d characters in production", secret.key, minSecretLength))
+		}
+	}
+	if c.JWTSecret != "" && c.JWTSecret == c.JWTRefreshSecret {
+		errs = append(errs, errors.New("JWT_SECRET and JWT_REFRESH_SECRET must differ"))
+	}
+	return errs
+}
+
+// isPlaceholder reports whether a secret is, or contains, a known example
+func isPlaceholder(value string) bool {
+	lower := strings.ToLower(value)
+	for _, placeholder := range placeholderSecrets {
+		if strings.Contains(lower, placeholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// oneOf reports whether value is one of allowed
+func oneOf(value string, allowed ...string) bool {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return true
+		}
+	}
+	return false
+}
@@ -2,17 +2,18 @@ package database
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/Shihasz/gophiway/internal/config"
-	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/logging"
+	"github.com/Shihasz/gophiway/internal/tracing"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-func Connect(cfg *config.Config) (*gorm.DB, error) {
+// Connect opens the connection pool, logging statements through logger
+func Connect(cfg *config.Config, logger *slog.Logger) (*gorm.DB, error) {
 	dsn := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.DBHost,
@@ -23,15 +24,8 @@ func Connect(cfg *config.Config) (*gorm.DB, error) {
 		cfg.DBSSLMode,
 	)
 
-	var logLevel logger.LogLevel
-	if cfg.AppEnv == "production" {
-		logLevel = logger.Error
-	} else {
-		logLevel = logger.Info
-	}
-
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+		Logger: logging.NewGormLogger(logger),
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
@@ -41,6 +35,10 @@ func Connect(cfg *config.Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if err := db.Use(tracing.NewGormPlugin(cfg.DBName)); err != nil {
+		return nil, fmt.Errorf("failed to register tracing: %w", err)
+	}
+
 	// Set connection pool settings
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -56,31 +54,27 @@ func Connect(cfg *config.Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	log.Println("✅ Database connected successfully")
+	logger.Info("Database connected", "host", cfg.DBHost, "database", cfg.DBName)
 	return db, nil
 }
 
-func Migrate(db *gorm.DB) error {
-	log.Println("Running database migrations...")
+// Migrate applies all pending schema migrations
+func Migrate(db *gorm.DB, logger *slog.Logger) error {
+	logger.Info("Running database migrations")
 
-	err := db.AutoMigrate(
-		&models.User{},
-		&models.Address{},
-		&models.Category{},
-		&models.Product{},
-		&models.ProductImage{},
-		&models.ProductCategory{},
-		&models.Cart{},
-		&models.CartItem{},
-		&models.Order{},
-		&models.OrderItem{},
-		&models.Payment{},
-	)
+	migrator, err := NewMigrator(db, logger)
+	if err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
 
+	applied, err := migrator.Up(0)
 	if err != nil {
 		return fmt.Errorf("migration failed: %w", err)
 	}
 
-	log.Println("✅ Migrations completed successfully")
+	for _, migration := range applied {
+		logger.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+	}
+	logger.Info("Migrations completed", "applied", len(applied))
 	return nil
 }
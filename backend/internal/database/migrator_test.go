@@ -0,0 +1,431 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testMigrations are three migrations whose SQL names what it does, so the
+// statements a run executes read as the migrations it applied or reverted
+var testMigrations = fstest.MapFS{
+	"0001_users.up.sql":      {Data: []byte("up 1")},
+	"0001_users.down.sql":    {Data: []byte("down 1")},
+	"0002_orders.up.sql":     {Data: []byte("up 2")},
+	"0002_orders.down.sql":   {Data: []byte("down 2")},
+	"0010_payments.up.sql":   {Data: []byte("up 10")},
+	"0010_payments.down.sql": {Data: []byte("down 10")},
+	"README.md":              {Data: []byte("not a migration")},
+}
+
+func TestLoadMigrations(t *testing.T) {
+	loaded, err := loadMigrations(testMigrations)
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+
+	var versions []int64
+	for _, migration := range loaded {
+		versions = append(versions, migration.Version)
+	}
+	if !reflect.DeepEqual(versions, []int64{1, 2, 10}) {
+		t.Errorf("versions = %v, want 1, 2 and 10 in order", versions)
+	}
+	if loaded[2].Name != "payments" || loaded[2].up != "up 10" || loaded[2].down != "down 10" {
+		t.Errorf("migration 10 = %+v, want payments with its up and down SQL", loaded[2])
+	}
+
+	// Editing either file changes the checksum
+	for _, name := range []string{"0002_orders.up.sql", "0002_orders.down.sql"} {
+		edited := fstest.MapFS{}
+		for file, data := range testMigrations {
+			edited[file] = data
+		}
+		edited[name] = &fstest.MapFile{Data: []byte("edited")}
+
+		reloaded, err := loadMigrations(edited)
+		if err != nil {
+			t.Fatalf("loadMigrations after editing %s: %v", name, err)
+		}
+		if reloaded[1].Checksum == loaded[1].Checksum {
+			t.Errorf("editing %s kept the checksum", name)
+		}
+		if reloaded[0].Checksum != loaded[0].Checksum {
+			t.Errorf("editing %s changed the checksum of another migration", name)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"missing down", fstest.MapFS{
+			"0001_users.up.sql": {Data: []byte("up 1")},
+		}},
+		{"two names", fstest.MapFS{
+			"0001_users.up.sql":    {Data: []byte("up 1")},
+			"0001_people.down.sql": {Data: []byte("down 1")},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadMigrations(tt.files); err == nil {
+				t.Error("loadMigrations succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestMigratorUpSkipsApplied(t *testing.T) {
+	m, db := newTestMigrator(t)
+	db.apply(t, m, 1)
+
+	applied, err := m.Up(0)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	if got := migrationVersions(applied); !reflect.DeepEqual(got, []int64{2, 10}) {
+		t.Errorf("applied %v, want 2 and 10", got)
+	}
+	if got := db.migrationStatements(); !reflect.DeepEqual(got, []string{"up 2", "up 10"}) {
+		t.Errorf("executed %v, want the up SQL of 2 then 10", got)
+	}
+	if got := db.versions(); !reflect.DeepEqual(got, []int64{1, 2, 10}) {
+		t.Errorf("recorded versions %v, want 1, 2 and 10", got)
+	}
+
+	again, err := m.Up(0)
+	if err != nil {
+		t.Fatalf("Up again: %v", err)
+	}
+	if len(again) != 0 || len(db.migrationStatements()) != 2 {
+		t.Errorf("second Up applied %v, want nothing", migrationVersions(again))
+	}
+}
+
+func TestMigratorUpSteps(t *testing.T) {
+	m, db := newTestMigrator(t)
+
+	applied, err := m.Up(1)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if got := migrationVersions(applied); !reflect.DeepEqual(got, []int64{1}) {
+		t.Errorf("applied %v, want only 1", got)
+	}
+
+	pending, err := m.Pending()
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+	if pending != 2 {
+		t.Errorf("pending = %d, want 2", pending)
+	}
+	if got := db.versions(); !reflect.DeepEqual(got, []int64{1}) {
+		t.Errorf("recorded versions %v, want 1", got)
+	}
+}
+
+func TestMigratorRejectsModifiedMigration(t *testing.T) {
+	m, db := newTestMigrator(t)
+	db.apply(t, m, 1, 2)
+	db.applied[2] = SchemaMigration{Version: 2, Name: "orders", Checksum: "edited since", AppliedAt: time.Now()}
+
+	if _, err := m.Up(0); !errors.Is(err, ErrMigrationChecksum) {
+		t.Errorf("Up = %v, want ErrMigrationChecksum", err)
+	}
+	if _, err := m.Down(1); !errors.Is(err, ErrMigrationChecksum) {
+		t.Errorf("Down = %v, want ErrMigrationChecksum", err)
+	}
+	if got := db.migrationStatements(); len(got) != 0 {
+		t.Errorf("executed %v, want nothing", got)
+	}
+
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if !statuses[1].Modified || statuses[0].Modified {
+		t.Errorf("statuses = %+v, want only 2 modified", statuses)
+	}
+}
+
+func TestMigratorDownRevertsNewestFirst(t *testing.T) {
+	m, db := newTestMigrator(t)
+	db.apply(t, m, 1, 2, 10)
+
+	reverted, err := m.Down(2)
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+
+	if got := migrationVersions(reverted); !reflect.DeepEqual(got, []int64{10, 2}) {
+		t.Errorf("reverted %v, want 10 then 2", got)
+	}
+	if got := db.migrationStatements(); !reflect.DeepEqual(got, []string{"down 10", "down 2"}) {
+		t.Errorf("executed %v, want the down SQL of 10 then 2", got)
+	}
+	if got := db.versions(); !reflect.DeepEqual(got, []int64{1}) {
+		t.Errorf("recorded versions %v, want 1", got)
+	}
+}
+
+func TestMigratorDownRejectsMissingMigration(t *testing.T) {
+	m, db := newTestMigrator(t)
+	db.apply(t, m, 1)
+	db.applied[11] = SchemaMigration{Version: 11, Name: "newer", Checksum: "unknown", AppliedAt: time.Now()}
+
+	if _, err := m.Down(1); !errors.Is(err, ErrMigrationMissing) {
+		t.Errorf("Down = %v, want ErrMigrationMissing", err)
+	}
+
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if last := statuses[len(statuses)-1]; last.Version != 11 || !last.Missing {
+		t.Errorf("last status = %+v, want 11 missing", last)
+	}
+}
+
+func TestMigratorHoldsLockOnOneConnection(t *testing.T) {
+	m, db := newTestMigrator(t)
+	db.fail = "up 2"
+
+	if _, err := m.Up(0); err == nil {
+		t.Fatal("Up succeeded, want the error of migration 2")
+	}
+
+	// The lock is released even though migration 2 failed, and everything
+	// ran on the connection holding it
+	first, last := db.statements[0], db.statements[len(db.statements)-1]
+	if !strings.HasPrefix(first.sql, "SELECT pg_advisory_lock") {
+		t.Errorf("first statement = %q, want the advisory lock", first.sql)
+	}
+	if !strings.HasPrefix(last.sql, "SELECT pg_advisory_unlock") {
+		t.Errorf("last statement = %q, want the advisory unlock", last.sql)
+	}
+	for _, statement := range db.statements {
+		if statement.conn != first.conn {
+			t.Errorf("%q ran on connection %d, want %d", statement.sql, statement.conn, first.conn)
+		}
+	}
+
+	// Migration 1 committed before 2 failed and was rolled back
+	if got := db.versions(); !reflect.DeepEqual(got, []int64{1}) {
+		t.Errorf("recorded versions %v, want 1", got)
+	}
+	if db.rollbacks != 1 {
+		t.Errorf("%d rollbacks, want 1", db.rollbacks)
+	}
+}
+
+// newTestMigrator creates a migrator of the test migrations over a scripted
+// database
+func newTestMigrator(t *testing.T) (*Migrator, *scriptedDB) {
+	t.Helper()
+
+	loaded, err := loadMigrations(testMigrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := &scriptedDB{applied: make(map[int64]SchemaMigration)}
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(db)}), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Migrator{db: gormDB, logger: slog.New(slog.DiscardHandler), migrations: loaded}, db
+}
+
+func migrationVersions(migrations []Migration) []int64 {
+	versions := []int64{}
+	for _, migration := range migrations {
+		versions = append(versions, migration.Version)
+	}
+	return versions
+}
+
+// scriptedDB is a database/sql connector standing in for Postgres. It
+// records every statement with the connection it ran on and keeps the
+// schema_migrations rows in memory; any other statement succeeds unless it
+// is the one to fail. A transaction's rows are only kept once it commits.
+type scriptedDB struct {
+	applied    map[int64]SchemaMigration
+	statements []scriptedStatement
+	fail       string
+	conns      int
+	rollbacks  int
+}
+
+type scriptedStatement struct {
+	conn int
+	sql  string
+}
+
+// apply records migrations as applied with their current checksums
+func (db *scriptedDB) apply(t *testing.T, m *Migrator, versions ...int64) {
+	t.Helper()
+
+	for _, version := range versions {
+		for _, migration := range m.migrations {
+			if migration.Version == version {
+				db.applied[version] = SchemaMigration{
+					Version:   version,
+					Name:      migration.Name,
+					Checksum:  migration.Checksum,
+					AppliedAt: time.Now(),
+				}
+			}
+		}
+	}
+}
+
+// versions lists the recorded migrations
+func (db *scriptedDB) versions() []int64 {
+	versions := []int64{}
+	for version := range db.applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
+
+// migrationStatements lists the migration SQL executed
+func (db *scriptedDB) migrationStatements() []string {
+	var executed []string
+	for _, statement := range db.statements {
+		if strings.HasPrefix(statement.sql, "up ") || strings.HasPrefix(statement.sql, "down ") {
+			executed = append(executed, statement.sql)
+		}
+	}
+	return executed
+}
+
+func (db *scriptedDB) Connect(context.Context) (driver.Conn, error) {
+	db.conns++
+	return &scriptedConn{db: db, id: db.conns}, nil
+}
+
+func (db *scriptedDB) Driver() driver.Driver { return nil }
+
+type scriptedConn struct {
+	db *scriptedDB
+	id int
+	// pending holds the rows a transaction recorded, nil for those it deleted
+	pending map[int64]*SchemaMigration
+}
+
+func (c *scriptedConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *scriptedConn) Close() error                        { return nil }
+
+func (c *scriptedConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *scriptedConn) Begin() (driver.Tx, error) {
+	c.pending = make(map[int64]*SchemaMigration)
+	return c, nil
+}
+
+func (c *scriptedConn) Commit() error {
+	for version, row := range c.pending {
+		if row == nil {
+			delete(c.db.applied, version)
+		} else {
+			c.db.applied[version] = *row
+		}
+	}
+	c.pending = nil
+	return nil
+}
+
+func (c *scriptedConn) Rollback() error {
+	c.db.rollbacks++
+	c.pending = nil
+	return nil
+}
+
+func (c *scriptedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.statements = append(c.db.statements, scriptedStatement{conn: c.id, sql: query})
+	if query == c.db.fail {
+		return nil, errors.New("syntax error")
+	}
+
+	switch {
+	case strings.HasPrefix(query, `INSERT INTO "schema_migrations"`):
+		c.pending[args[0].Value.(int64)] = &SchemaMigration{
+			Version:   args[0].Value.(int64),
+			Name:      args[1].Value.(string),
+			Checksum:  args[2].Value.(string),
+			AppliedAt: args[3].Value.(time.Time),
+		}
+	case strings.HasPrefix(query, `DELETE FROM "schema_migrations"`):
+		c.pending[args[0].Value.(int64)] = nil
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *scriptedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.statements = append(c.db.statements, scriptedStatement{conn: c.id, sql: query})
+
+	switch {
+	case strings.Contains(query, "information_schema.tables"):
+		// The migrations table exists once anything was recorded or created
+		var count int64
+		if len(c.db.applied) > 0 || c.db.created() {
+			count = 1
+		}
+		return &scriptedRows{columns: []string{"count"}, values: [][]driver.Value{{count}}}, nil
+
+	case strings.HasPrefix(query, `SELECT * FROM "schema_migrations"`):
+		rows := &scriptedRows{columns: []string{"version", "name", "checksum", "applied_at"}}
+		for _, version := range c.db.versions() {
+			row := c.db.applied[version]
+			rows.values = append(rows.values, []driver.Value{row.Version, row.Name, row.Checksum, row.AppliedAt})
+		}
+		return rows, nil
+	}
+	return &scriptedRows{}, nil
+}
+
+// created reports whether the migrations table has been created
+func (db *scriptedDB) created() bool {
+	for _, statement := range db.statements {
+		if statement.sql == schemaMigrationsTable {
+			return true
+		}
+	}
+	return false
+}
+
+type scriptedRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *scriptedRows) Columns() []string { return r.columns }
+func (r *scriptedRows) Close() error      { return nil }
+
+func (r *scriptedRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,233 @@
+// Package document lays out invoices, credit notes and packing slips as PDF
+package document
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/pdf"
+)
+
+// Page layout in points
+const (
+	marginLeft   = 40.0
+	marginRight  = pdf.PageWidth - 40
+	contentTop   = 50.0
+	contentEnd   = pdf.PageHeight - 60
+	footerLine   = pdf.PageHeight - 40
+	rowHeight    = 16.0
+	bodySize     = 9.0
+	smallSize    = 8.0
+	headingSize  = 20.0
+	sectionSize  = 10.0
+	dateLayout   = "January 2, 2006"
+	columnGutter = 6.0
+)
+
+// Seller is the business issuing the documents
+type Seller struct {
+	Name    string
+	Address []string
+	TaxID   string
+	Email   string
+}
+
+// Party is a customer and one of their addresses
+type Party struct {
+	Name    string
+	Email   string
+	Address []string
+}
+
+// column is a table column; amounts are right aligned to the column's end
+type column struct {
+	title string
+	end   float64
+	right bool
+}
+
+// layout tracks the write position across pages
+type layout struct {
+	doc    *pdf.Document
+	page   *pdf.Page
+	y      float64
+	header func() // redraws a table header on new pages
+}
+
+func newLayout(title string) *layout {
+	doc := pdf.New(title)
+	return &layout{doc: doc, page: doc.AddPage(), y: contentTop}
+}
+
+// need starts a new page unless height fits on the current one
+func (l *layout) need(height float64) {
+	if l.y+height <= contentEnd {
+		return
+	}
+	l.page = l.doc.AddPage()
+	l.y = contentTop
+	if l.header != nil {
+		l.header()
+	}
+}
+
+// tableHeader draws column titles on a grey band
+func (l *layout) tableHeader(columns []column) {
+	l.page.FillRect(marginLeft, l.y, marginRight-marginLeft, rowHeight+2, 0.9)
+	l.row(columns, titles(columns), pdf.Bold)
+}
+
+// row draws one table row
+func (l *layout) row(columns []column, cells []string, font pdf.Font) {
+	baseline := l.y + rowHeight - 4
+	start := marginLeft + 4
+	for i, col := range columns {
+		text := cells[i]
+		if col.right {
+			l.page.TextRight(col.end-4, baseline, font, bodySize, text)
+		} else {
+			text = l.doc.Truncate(text, font, bodySize, col.end-start-columnGutter)
+			l.page.Text(start, baseline, font, bodySize, text)
+		}
+		start = col.end + columnGutter
+	}
+	l.y += rowHeight
+}
+
+// rule draws a horizontal line across the page
+func (l *layout) rule() {
+	l.page.Line(marginLeft, l.y, marginRight, l.y, 0.5)
+}
+
+// block draws lines of text from x, returning the height used
+func (l *layout) block(x float64, heading string, lines []string) float64 {
+	y := l.y
+	if heading != "" {
+		l.page.Text(x, y, pdf.Bold, sectionSize, heading)
+		y += 14
+	}
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		l.page.Text(x, y, pdf.Regular, bodySize, line)
+		y += 12
+	}
+	return y - l.y
+}
+
+// footer writes the footer text and page numbers on every page and
+// serializes the document
+func (l *layout) footer(text string) ([]byte, error) {
+	pages := l.doc.Pages()
+	for i := 0; i < pages; i++ {
+		page := l.doc.Page(i)
+		page.Line(marginLeft, footerLine-12, marginRight, footerLine-12, 0.5)
+		page.Text(marginLeft, footerLine, pdf.Regular, smallSize, text)
+		page.TextRight(marginRight, footerLine, pdf.Regular, smallSize, fmt.Sprintf("Page %d of %d", i+1, pages))
+	}
+	return l.doc.Bytes()
+}
+
+// heading draws the document title on the left and the seller on the right
+func (l *layout) heading(title string, seller Seller) {
+	l.page.Text(marginLeft, l.y+16, pdf.Bold, headingSize, title)
+
+	y := l.y
+	l.page.TextRight(marginRight, y+10, pdf.Bold, sectionSize+1, seller.Name)
+	y += 24
+	for _, line := range seller.lines() {
+		l.page.TextRight(marginRight, y, pdf.Regular, bodySize, line)
+		y += 12
+	}
+	l.y = math.Max(l.y+40, y+8)
+}
+
+// details draws label and value pairs
+func (l *layout) details(pairs [][2]string) {
+	for _, pair := range pairs {
+		if pair[1] == "" {
+			continue
+		}
+		l.page.Text(marginLeft, l.y, pdf.Bold, bodySize, pair[0])
+		l.page.Text(marginLeft+95, l.y, pdf.Regular, bodySize, pair[1])
+		l.y += 13
+	}
+}
+
+// parties draws two address blocks side by side
+func (l *layout) parties(leftTitle string, left Party, rightTitle string, right *Party) {
+	height := l.block(marginLeft, leftTitle, left.lines())
+	if right != nil {
+		height = math.Max(height, l.block(pdf.PageWidth/2, rightTitle, right.lines()))
+	}
+	l.y += height + 12
+}
+
+func (s Seller) lines() []string {
+	lines := append([]string{}, s.Address...)
+	if s.TaxID != "" {
+		lines = append(lines, "Tax ID: "+s.TaxID)
+	}
+	if s.Email != "" {
+		lines = append(lines, s.Email)
+	}
+	return lines
+}
+
+func (p Party) lines() []string {
+	lines := []string{p.Name}
+	lines = append(lines, p.Address...)
+	if p.Email != "" {
+		lines = append(lines, p.Email)
+	}
+	return lines
+}
+
+func titles(columns []column) []string {
+	cells := make([]string, len(columns))
+	for i, col := range columns {
+		cells[i] = col.title
+	}
+	return cells
+}
+
+// money formats an amount with thousands separators
+func money(amount float64) string {
+	s := fmt.Sprintf("%.2f", math.Abs(amount))
+	whole, cents := s[:len(s)-3], s[len(s)-3:]
+	var b strings.Builder
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	if amount < 0 && s != "0.00" {
+		return "-" + b.String() + cents
+	}
+	return b.String() + cents
+}
+
+// percent formats a rate given as a fraction
+func percent(rate float64) string {
+	s := fmt.Sprintf("%.3f", rate*100)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	return s + "%"
+}
+
+// date formats a date for documents
+func date(t time.Time) string {
+	return t.Format(dateLayout)
+}
+
+// label turns an enum value such as partially_refunded into "Partially refunded"
+func label(value string) string {
+	value = strings.ReplaceAll(value, "_", " ")
+	if value == "" {
+		return value
+	}
+	return strings.ToUpper(value[:1]) + value[1:]
+}
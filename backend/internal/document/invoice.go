@@ -0,0 +1,173 @@
+package document
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/pdf"
+)
+
+// Line is a charged or credited line
+type Line struct {
+	Description string
+	SKU         string
+	Quantity    int
+	UnitPrice   float64
+	Discount    float64
+	Net         float64 // excluding tax
+	TaxRate     float64 // fraction
+	Tax         float64
+	Total       float64 // including tax
+}
+
+// TaxLine is the tax of one jurisdiction at one rate
+type TaxLine struct {
+	Name   string
+	Rate   float64
+	Base   float64
+	Amount float64
+}
+
+// Invoice is an invoice or credit note
+type Invoice struct {
+	Title       string // Invoice, Credit note
+	Number      string
+	IssuedAt    time.Time
+	Reference   string // e.g. the invoice a credit note corrects
+	OrderNumber string
+	OrderDate   time.Time
+	Currency    string
+	StatusLabel string
+	Status      string
+
+	Seller Seller
+	BillTo Party
+	ShipTo *Party
+
+	Lines        []Line
+	Taxes        []TaxLine
+	Subtotal     float64
+	Discount     float64
+	Shipping     float64
+	Tax          float64
+	Total        float64
+	TaxInclusive bool // amounts include tax
+	Notes        []string
+}
+
+var lineColumns = []column{
+	{title: "Description", end: 250},
+	{title: "Qty", end: 280, right: true},
+	{title: "Unit price", end: 335, right: true},
+	{title: "Discount", end: 380, right: true},
+	{title: "Net", end: 428, right: true},
+	{title: "Tax %", end: 470, right: true},
+	{title: "Tax", end: 512, right: true},
+	{title: "Total", end: marginRight, right: true},
+}
+
+var taxColumns = []column{
+	{title: "Tax", end: 250},
+	{title: "Rate", end: 335, right: true},
+	{title: "Taxable amount", end: 437, right: true},
+	{title: "Tax amount", end: marginRight, right: true},
+}
+
+// RenderInvoice lays out an invoice or credit note
+func RenderInvoice(inv *Invoice) ([]byte, error) {
+	l := newLayout(inv.Title + " " + inv.Number)
+	l.heading(strings.ToUpper(inv.Title), inv.Seller)
+
+	l.details([][2]string{
+		{inv.Title + " number", inv.Number},
+		{inv.Title + " date", date(inv.IssuedAt)},
+		{"Reference", inv.Reference},
+		{"Order number", inv.OrderNumber},
+		{"Order date", date(inv.OrderDate)},
+		{inv.StatusLabel, label(inv.Status)},
+		{"Currency", strings.ToUpper(inv.Currency)},
+	})
+	l.y += 12
+	l.parties("Bill to", inv.BillTo, "Ship to", inv.ShipTo)
+
+	// Lines
+	l.header = func() { l.tableHeader(lineColumns) }
+	l.header()
+	for _, line := range inv.Lines {
+		l.need(rowHeight)
+		description := line.Description
+		if line.SKU != "" {
+			description += " (" + line.SKU + ")"
+		}
+		l.row(lineColumns, []string{
+			description,
+			strconv.Itoa(line.Quantity),
+			money(line.UnitPrice),
+			money(line.Discount),
+			money(line.Net),
+			percent(line.TaxRate),
+			money(line.Tax),
+			money(line.Total),
+		}, pdf.Regular)
+	}
+	l.rule()
+	l.header = nil
+	l.y += 16
+
+	// Tax breakdown
+	if len(inv.Taxes) > 0 {
+		l.need(rowHeight * float64(len(inv.Taxes)+2))
+		l.header = func() { l.tableHeader(taxColumns) }
+		l.header()
+		for _, tax := range inv.Taxes {
+			l.need(rowHeight)
+			l.row(taxColumns, []string{tax.Name, percent(tax.Rate), money(tax.Base), money(tax.Amount)}, pdf.Regular)
+		}
+		l.rule()
+		l.header = nil
+		l.y += 16
+	}
+
+	// Totals
+	taxLabel := "Tax"
+	if inv.TaxInclusive {
+		taxLabel = "Tax (included)"
+	}
+	totals := [][2]string{{"Subtotal", money(inv.Subtotal)}}
+	if inv.Discount != 0 {
+		totals = append(totals, [2]string{"Discount", money(-inv.Discount)})
+	}
+	if inv.Shipping != 0 {
+		totals = append(totals, [2]string{"Shipping", money(inv.Shipping)})
+	}
+	totals = append(totals, [2]string{taxLabel, money(inv.Tax)})
+
+	l.need(rowHeight * float64(len(totals)+2))
+	for _, total := range totals {
+		l.page.Text(380, l.y+12, pdf.Regular, bodySize, total[0])
+		l.page.TextRight(marginRight-4, l.y+12, pdf.Regular, bodySize, total[1])
+		l.y += rowHeight
+	}
+	l.page.Line(380, l.y+2, marginRight, l.y+2, 0.8)
+	l.page.Text(380, l.y+16, pdf.Bold, sectionSize, "Total "+strings.ToUpper(inv.Currency))
+	l.page.TextRight(marginRight-4, l.y+16, pdf.Bold, sectionSize, money(inv.Total))
+	l.y += rowHeight * 2
+
+	for _, note := range inv.Notes {
+		l.need(12)
+		l.page.Text(marginLeft, l.y, pdf.Regular, smallSize, note)
+		l.y += 12
+	}
+
+	return l.footer(footerText(inv.Seller))
+}
+
+// footerText identifies the seller on every page
+func footerText(seller Seller) string {
+	text := seller.Name
+	if seller.TaxID != "" {
+		text += " - Tax ID " + seller.TaxID
+	}
+	return text
+}
@@ -0,0 +1,68 @@
+package document
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/pdf"
+)
+
+// PackingItem is a quantity of a product in a shipment
+type PackingItem struct {
+	Description string
+	SKU         string
+	Quantity    int
+}
+
+// PackingSlip lists what one fulfillment contains
+type PackingSlip struct {
+	OrderNumber    string
+	OrderDate      time.Time
+	ShippedAt      time.Time
+	Carrier        string
+	TrackingNumber string
+	ShippingMethod string
+	Seller         Seller
+	ShipTo         Party
+	Items          []PackingItem
+}
+
+var packingColumns = []column{
+	{title: "Item", end: 380},
+	{title: "SKU", end: 500},
+	{title: "Qty", end: marginRight, right: true},
+}
+
+// RenderPackingSlip lays out a packing slip
+func RenderPackingSlip(slip *PackingSlip) ([]byte, error) {
+	l := newLayout("Packing slip " + slip.OrderNumber)
+	l.heading("PACKING SLIP", slip.Seller)
+
+	l.details([][2]string{
+		{"Order number", slip.OrderNumber},
+		{"Order date", date(slip.OrderDate)},
+		{"Shipped", date(slip.ShippedAt)},
+		{"Shipping method", slip.ShippingMethod},
+		{"Carrier", slip.Carrier},
+		{"Tracking number", slip.TrackingNumber},
+	})
+	l.y += 12
+	l.parties("Ship to", slip.ShipTo, "", nil)
+
+	l.header = func() { l.tableHeader(packingColumns) }
+	l.header()
+	units := 0
+	for _, item := range slip.Items {
+		l.need(rowHeight)
+		l.row(packingColumns, []string{item.Description, item.SKU, strconv.Itoa(item.Quantity)}, pdf.Regular)
+		units += item.Quantity
+	}
+	l.rule()
+	l.header = nil
+
+	l.need(rowHeight * 2)
+	l.y += 4
+	l.row(packingColumns, []string{"Total units", "", strconv.Itoa(units)}, pdf.Bold)
+
+	return l.footer(footerText(slip.Seller))
+}
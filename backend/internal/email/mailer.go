@@ -0,0 +1,84 @@
+package email
+
+import (
+	"fmt"
+	"log/slog"
+	"net/smtp"
+	"strings"
+
+	"github.com/Shihasz/gophiway/internal/config"
+)
+
+// Message represents an outgoing email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// NewMailer creates an SMTP mailer when SMTP credentials are configured and
+// falls back to logging emails otherwise
+func NewMailer(cfg *config.Config, logger *slog.Logger) Mailer {
+	if cfg.SMTPUser == "" {
+		return &LogMailer{from: cfg.SMTPFrom, logger: logger}
+	}
+	return &SMTPMailer{
+		host:     cfg.SMTPHost,
+		port:     cfg.SMTPPort,
+		user:     cfg.SMTPUser,
+		password: cfg.SMTPPassword,
+		from:     cfg.SMTPFrom,
+	}
+}
+
+// SMTPMailer sends emails through an SMTP server
+type SMTPMailer struct {
+	host     string
+	port     string
+	user     string
+	password string
+	from     string
+}
+
+// Send sends an email
+func (m *SMTPMailer) Send(msg *Message) error {
+	auth := smtp.PlainAuth("", m.user, m.password, m.host)
+	addr := m.host + ":" + m.port
+
+	if err := smtp.SendMail(addr, auth, m.from, []string{msg.To}, buildMessage(m.from, msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// LogMailer writes emails to the log instead of sending them. It is used in
+// development when no SMTP credentials are configured.
+type LogMailer struct {
+	from   string
+	logger *slog.Logger
+}
+
+// Send logs an email
+func (m *LogMailer) Send(msg *Message) error {
+	m.logger.Info("Email not sent, no SMTP server configured", "from", m.from, "to", msg.To, "subject", msg.Subject)
+	m.logger.Debug("Email body", "to", msg.To, "body", msg.Body)
+	return nil
+}
+
+// buildMessage renders a plain text RFC 5322 message
+func buildMessage(from string, msg *Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"UTF-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+	return []byte(b.String())
+}
@@ -0,0 +1,99 @@
+package email
+
+import (
+	"bytes"
+	"text/template"
+)
+
+var shippingNotificationTemplate = template.Must(template.New("shipping").Parse(`Hi {{.FirstName}},
+
+Good news! Items from your order {{.OrderNumber}} are on their way.
+
+Carrier: {{.Carrier}}
+{{- if .TrackingNumber}}
+Tracking number: {{.TrackingNumber}}
+{{- end}}
+
+Shipped items:
+{{- range .Items}}
+  - {{.Quantity}} × {{.Name}}
+{{- end}}
+
+You can follow your order at {{.OrderURL}}
+
+Thanks for shopping with {{.AppName}}!
+`))
+
+var giftCardDeliveryTemplate = template.Must(template.New("gift_card").Parse(`Hi {{.FirstName}},
+
+Thanks for your order {{.OrderNumber}}! Here are your gift cards:
+{{range .Cards}}
+  {{.Code}}  ({{.Amount}} {{.Currency}}{{if .ExpiresAt}}, valid until {{.ExpiresAt}}{{end}})
+{{- end}}
+
+Keep these codes safe: anyone who has a code can spend its balance, and we
+cannot show them to you again.
+
+Thanks for shopping with {{.AppName}}!
+`))
+
+// ShippingNotificationItem is a line of the shipping notification
+type ShippingNotificationItem struct {
+	Name     string
+	Quantity int
+}
+
+// ShippingNotificationData holds the values of the shipping notification
+type ShippingNotificationData struct {
+	AppName        string
+	FirstName      string
+	OrderNumber    string
+	OrderURL       string
+	Carrier        string
+	TrackingNumber string
+	Items          []ShippingNotificationItem
+}
+
+// ShippingNotification builds the email sent for each fulfillment
+func ShippingNotification(to string, data *ShippingNotificationData) (*Message, error) {
+	var body bytes.Buffer
+	if err := shippingNotificationTemplate.Execute(&body, data); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		To:      to,
+		Subject: "Your order " + data.OrderNumber + " has shipped",
+		Body:    body.String(),
+	}, nil
+}
+
+// GiftCardDeliveryCard is a gift card in the delivery email
+type GiftCardDeliveryCard struct {
+	Code      string
+	Amount    string
+	Currency  string
+	ExpiresAt string
+}
+
+// GiftCardDeliveryData holds the values of the gift card delivery email
+type GiftCardDeliveryData struct {
+	AppName     string
+	FirstName   string
+	OrderNumber string
+	Cards       []GiftCardDeliveryCard
+}
+
+// GiftCardDelivery builds the email carrying the codes of purchased gift cards
+func GiftCardDelivery(to string, data *GiftCardDeliveryData) (*Message, error) {
+	var body bytes.Buffer
+	if err := giftCardDeliveryTemplate.Execute(&body, data); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		To:      to,
+		Subject: "Your " + data.AppName + " gift cards",
+		Body:    body.String(),
+	}, nil
+}
@@ -0,0 +1,104 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/Shihasz/gophiway/internal/database"
+	"gorm.io/gorm"
+)
+
+// Database checks that PostgreSQL answers a ping
+func Database(db *gorm.DB) Checker {
+	return CheckerFunc("database", func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+}
+
+// Migrations checks that no schema migration is waiting to be applied,
+// since the code may depend on tables or columns they add
+func Migrations(db *gorm.DB, logger *slog.Logger) Checker {
+	return CheckerFunc("migrations", func(ctx context.Context) error {
+		migrator, err := database.NewMigrator(db.WithContext(ctx), logger)
+		if err != nil {
+			return err
+		}
+		pending, err := migrator.Pending()
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return fmt.Errorf("%d pending migrations", pending)
+		}
+		return nil
+	})
+}
+
+// Pinger is a dependency able to check its own connection, such as the S3
+// document store
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping checks a Pinger under the given name
+func Ping(name string, pinger Pinger) Checker {
+	return CheckerFunc(name, pinger.Ping)
+}
+
+// Redis checks that the Redis server at addr answers PING, authenticating
+// with password when set
+func Redis(addr, password string) Checker {
+	return CheckerFunc("redis", func(ctx context.Context) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			if err := conn.SetDeadline(deadline); err != nil {
+				return err
+			}
+		}
+
+		reader := bufio.NewReader(conn)
+		if password != "" {
+			if err := redisCommand(conn, reader, "AUTH", password); err != nil {
+				return err
+			}
+		}
+		return redisCommand(conn, reader, "PING")
+	})
+}
+
+// redisCommand sends a command in the RESP protocol and fails unless the
+// reply is a simple string
+func redisCommand(conn net.Conn, reader *bufio.Reader, args ...string) error {
+	var command strings.Builder
+	command.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		command.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+	if _, err := conn.Write([]byte(command.String())); err != nil {
+		return err
+	}
+
+	reply, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	reply = strings.TrimRight(reply, "\r\n")
+	if !strings.HasPrefix(reply, "+") {
+		return fmt.Errorf("redis %s failed: %s", args[0], strings.TrimPrefix(reply, "-"))
+	}
+	return nil
+}
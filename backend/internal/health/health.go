@@ -0,0 +1,204 @@
+// Package health reports whether the API is alive and whether it is ready
+// to serve traffic, the latter by checking the services it depends on
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Statuses of a report and of each check
+const (
+	StatusOK           = "ok"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Checker checks one dependency, returning an error when it is unusable.
+// Check should give up when ctx is done.
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc turns a function into a Checker
+func CheckerFunc(name string, check func(ctx context.Context) error) Checker {
+	return &funcChecker{name: name, check: check}
+}
+
+type funcChecker struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (c *funcChecker) Name() string {
+	return c.name
+}
+
+func (c *funcChecker) Check(ctx context.Context) error {
+	return c.check(ctx)
+}
+
+// CheckResult is the outcome of one check
+type CheckResult struct {
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Report is the response of the health endpoints
+type Report struct {
+	Status  string                 `json:"status"`
+	Service string                 `json:"service"`
+	Version string                 `json:"version"`
+	Uptime  string                 `json:"uptime"`
+	Checks  map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Health runs the registered checkers. Readiness results are cached for a
+// while, so frequent probes do not put load on the dependencies.
+type Health struct {
+	service  string
+	version  string
+	started  time.Time
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	shuttingDown atomic.Bool
+
+	mu       sync.Mutex
+	checkers []Checker
+	cached   map[string]CheckResult
+	cachedAt time.Time
+}
+
+// New creates a Health for the service. Each check gets timeout to answer
+// and readiness is recomputed at most once per cacheTTL.
+func New(service, version string, timeout, cacheTTL time.Duration) *Health {
+	return &Health{
+		service:  service,
+		version:  version,
+		started:  time.Now(),
+		timeout:  timeout,
+		cacheTTL: cacheTTL,
+	}
+}
+
+// Register adds a checker to readiness
+func (h *Health) Register(checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkers = append(h.checkers, checker)
+	h.cached = nil
+}
+
+// ShuttingDown makes readiness fail from now on, so load balancers stop
+// sending requests while the server drains
+func (h *Health) ShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Live reports that the process is up. It checks no dependency, a failing
+// database must not get the process restarted.
+func (h *Health) Live() *Report {
+	return h.report(StatusOK, nil)
+}
+
+// Ready runs the checkers, or returns their cached results, and reports
+// whether all of them passed
+func (h *Health) Ready(ctx context.Context) *Report {
+	if h.shuttingDown.Load() {
+		return h.report(StatusShuttingDown, nil)
+	}
+
+	results := h.results(ctx)
+	status := StatusOK
+	for _, result := range results {
+		if result.Status != StatusOK {
+			status = StatusFail
+		}
+	}
+	return h.report(status, results)
+}
+
+// report builds a report with the given status and checks
+func (h *Health) report(status string, checks map[string]CheckResult) *Report {
+	return &Report{
+		Status:  status,
+		Service: h.service,
+		Version: h.version,
+		Uptime:  time.Since(h.started).Round(time.Second).String(),
+		Checks:  checks,
+	}
+}
+
+// results returns the cached check results while fresh, otherwise runs all
+// checkers concurrently. Probes arriving meanwhile wait for that run
+// instead of starting their own.
+func (h *Health) results(ctx context.Context) map[string]CheckResult {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cached != nil && time.Since(h.cachedAt) < h.cacheTTL {
+		return h.cached
+	}
+
+	// The results are shared with later probes, so the checks must not be
+	// cut short by this probe's client going away
+	ctx = context.WithoutCancel(ctx)
+
+	results := make(map[string]CheckResult, len(h.checkers))
+	var resultsMu sync.Mutex
+	var wg sync.WaitGroup
+	for _, checker := range h.checkers {
+		wg.Add(1)
+		go func(checker Checker) {
+			defer wg.Done()
+			result := h.run(ctx, checker)
+
+			resultsMu.Lock()
+			results[checker.Name()] = result
+			resultsMu.Unlock()
+		}(checker)
+	}
+	wg.Wait()
+
+	h.cached = results
+	h.cachedAt = time.Now()
+	return results
+}
+
+// run runs one checker under the timeout. A checker ignoring its context
+// is abandoned once the timeout passes.
+func (h *Health) run(ctx context.Context, checker Checker) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", h.timeout)
+	}
+
+	result := CheckResult{
+		Status:     StatusOK,
+		DurationMs: time.Since(start).Milliseconds(),
+		CheckedAt:  start.UTC(),
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,122 @@
+// Package lifecycle stops the components of the application in order when
+// it shuts down: servers first, then connections
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Readiness is told when shutdown starts, so load balancers stop routing
+// requests here. *health.Health implements it.
+type Readiness interface {
+	ShuttingDown()
+}
+
+// component is something registered to be stopped
+type component struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// Manager stops the registered components in the reverse order of their
+// registration. Register connections first and the servers using them
+// last, so servers drain before the connections close.
+type Manager struct {
+	logger    *slog.Logger
+	readiness Readiness
+	timeout   time.Duration
+	delay     time.Duration
+
+	mu         sync.Mutex
+	components []component
+	shutdown   sync.Once
+	err        error
+}
+
+// New creates a Manager. On shutdown readiness fails at once, the
+// components start stopping after delay, and all of them together get
+// timeout to do so.
+func New(logger *slog.Logger, readiness Readiness, timeout, delay time.Duration) *Manager {
+	return &Manager{
+		logger:    logger,
+		readiness: readiness,
+		timeout:   timeout,
+		delay:     delay,
+	}
+}
+
+// Register adds a component stopped by calling stop. stop should give up
+// when ctx is done, which happens once the shutdown timeout has passed.
+func (m *Manager) Register(name string, stop func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, component{name: name, stop: stop})
+}
+
+// Run calls serve, which blocks while serving, until it returns or the
+// process receives SIGINT or SIGTERM, then shuts down. A second signal
+// kills the process without waiting.
+func (m *Manager) Run(serve func() error) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- serve()
+	}()
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		m.logger.Info("Gracefully shutting down")
+	case serveErr = <-served:
+	}
+	stop()
+
+	return errors.Join(serveErr, m.Shutdown())
+}
+
+// Shutdown fails readiness, waits for the delay, then stops the components
+// newest first under the shutdown timeout. A component failing to stop
+// does not keep the others from stopping. Later calls return the result of
+// the first.
+func (m *Manager) Shutdown() error {
+	m.shutdown.Do(func() {
+		if m.readiness != nil {
+			m.readiness.ShuttingDown()
+		}
+		if m.delay > 0 {
+			m.logger.Info("Waiting for load balancers to stop routing requests", "delay", m.delay)
+			time.Sleep(m.delay)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		defer cancel()
+
+		m.mu.Lock()
+		components := append([]component(nil), m.components...)
+		m.mu.Unlock()
+
+		var errs []error
+		for i := len(components) - 1; i >= 0; i-- {
+			component := components[i]
+			start := time.Now()
+			if err := component.stop(ctx); err != nil {
+				m.logger.Error("Failed to stop", "component", component.name, "error", err)
+				errs = append(errs, fmt.Errorf("stop %s: %w", component.name, err))
+				continue
+			}
+			m.logger.Info("Stopped", "component", component.name, "duration", time.Since(start))
+		}
+		m.err = errors.Join(errs...)
+	})
+	return m.err
+}
@@ -0,0 +1,202 @@
+// Package metrics collects the Prometheus metrics of the API: HTTP traffic,
+// the database connection pool and business events
+package metrics
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gophiway"
+
+// Metrics holds the collectors of the application. Its methods do nothing
+// on a nil *Metrics, so code that runs outside the server, such as the
+// command line tools, can leave it out.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests        *prometheus.CounterVec
+	httpRequestDuration *prometheus.HistogramVec
+	httpInFlight        prometheus.Gauge
+
+	registrations prometheus.Counter
+	logins        *prometheus.CounterVec
+	cartsCreated  prometheus.Counter
+	ordersPlaced  prometheus.Counter
+	payments      *prometheus.CounterVec
+	revenue       *prometheus.CounterVec
+}
+
+// New creates the collectors and registers them together with the Go
+// runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled, by method, route template and status.",
+		}, []string{"method", "route", "status"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency, by method, route template and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		httpInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "HTTP requests being handled.",
+		}),
+
+		registrations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "user_registrations_total",
+			Help:      "Customer accounts registered.",
+		}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts, by result: succeeded or failed.",
+		}, []string{"result"}),
+		cartsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "carts_created_total",
+			Help:      "Shopping carts created.",
+		}),
+		ordersPlaced: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_placed_total",
+			Help:      "Orders placed at checkout.",
+		}),
+		payments: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "payments_total",
+			Help:      "Payment state changes reported by the payment provider, by outcome.",
+		}, []string{"outcome"}),
+		revenue: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "revenue_total",
+			Help:      "Amount of completed payments, by currency.",
+		}, []string{"currency"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.httpInFlight,
+		m.registrations,
+		m.logins,
+		m.cartsCreated,
+		m.ordersPlaced,
+		m.payments,
+		m.revenue,
+	)
+	return m
+}
+
+// RegisterDB exports the connection pool statistics of a database
+func (m *Metrics) RegisterDB(db *sql.DB, name string) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the metrics in the Prometheus text format. With a token,
+// scrapes must send it as a bearer token.
+func (m *Metrics) Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+	if token == "" {
+		return handler
+	}
+
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// RequestStarted counts a request as in flight
+func (m *Metrics) RequestStarted() {
+	if m == nil {
+		return
+	}
+	m.httpInFlight.Inc()
+}
+
+// RequestFinished records a handled request
+func (m *Metrics) RequestFinished(method, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.httpInFlight.Dec()
+
+	labels := prometheus.Labels{"method": method, "route": route, "status": strconv.Itoa(status)}
+	m.httpRequests.With(labels).Inc()
+	m.httpRequestDuration.With(labels).Observe(duration.Seconds())
+}
+
+// UserRegistered counts a registration
+func (m *Metrics) UserRegistered() {
+	if m == nil {
+		return
+	}
+	m.registrations.Inc()
+}
+
+// LoginAttempted counts a login by whether it succeeded
+func (m *Metrics) LoginAttempted(succeeded bool) {
+	if m == nil {
+		return
+	}
+	result := "failed"
+	if succeeded {
+		result = "succeeded"
+	}
+	m.logins.WithLabelValues(result).Inc()
+}
+
+// CartCreated counts a new shopping cart
+func (m *Metrics) CartCreated() {
+	if m == nil {
+		return
+	}
+	m.cartsCreated.Inc()
+}
+
+// OrderPlaced counts an order placed at checkout
+func (m *Metrics) OrderPlaced() {
+	if m == nil {
+		return
+	}
+	m.ordersPlaced.Inc()
+}
+
+// PaymentChanged counts a payment reaching a new state
+func (m *Metrics) PaymentChanged(outcome string) {
+	if m == nil {
+		return
+	}
+	m.payments.WithLabelValues(outcome).Inc()
+}
+
+// RevenueReceived adds a completed payment to the revenue
+func (m *Metrics) RevenueReceived(currency string, amount float64) {
+	if m == nil {
+		return
+	}
+	m.revenue.WithLabelValues(currency).Add(amount)
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/logging"
 	"github.com/Shihasz/gophiway/pkg/crypto"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -15,25 +16,13 @@ func AuthMiddleware(cfg *config.Config) fiber.Handler {
 		// Get token from Authorization header
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"error": fiber.Map{
-					"code":    "UNAUTHORIZED",
-					"message": "Missing authorization header",
-				},
-			})
+			return SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "Missing authorization header")
 		}
 
 		// Check if it's a Bearer token
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"error": fiber.Map{
-					"code":    "UNAUTHORIZED",
-					"message": "Invalid authorization header format",
-				},
-			})
+			return SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "Invalid authorization header format")
 		}
 
 		token := parts[1]
@@ -41,19 +30,14 @@ func AuthMiddleware(cfg *config.Config) fiber.Handler {
 		// Validate token
 		claims, err := crypto.ValidateToken(token, cfg.JWTSecret)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"error": fiber.Map{
-					"code":    "UNAUTHORIZED",
-					"message": "Invalid or expired token",
-				},
-			})
+			return SendError(c, fiber.StatusUnauthorized, "UNAUTHORIZED", "Invalid or expired token")
 		}
 
 		// Store user info in context
 		c.Locals("userID", claims.UserID)
 		c.Locals("userEmail", claims.Email)
 		c.Locals("userRole", claims.Role)
+		c.SetUserContext(logging.WithUserID(c.UserContext(), claims.UserID.String()))
 
 		return c.Next()
 	}
@@ -64,13 +48,7 @@ func RequireRole(roles ...string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userRole, ok := c.Locals("userRole").(string)
 		if !ok {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"success": false,
-				"error": fiber.Map{
-					"code":    "FORBIDDEN",
-					"message": "Access denied",
-				},
-			})
+			return SendError(c, fiber.StatusForbidden, "FORBIDDEN", "Access denied")
 		}
 
 		// Check if user has required role
@@ -80,13 +58,7 @@ func RequireRole(roles ...string) fiber.Handler {
 			}
 		}
 
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "FORBIDDEN",
-				"message": "Insufficient permissions",
-			},
-		})
+		return SendError(c, fiber.StatusForbidden, "FORBIDDEN", "Insufficient permissions")
 	}
 }
 
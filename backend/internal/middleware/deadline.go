@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Deadline bounds every request by timeout. The deadline is set on the user
+// context, so the database queries and outbound calls made for the request
+// are cancelled once it passes and the request fails with 504.
+func Deadline(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if timeout <= 0 {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		// A request that failed after the deadline failed because of it
+		failed := err != nil || c.Response().StatusCode() >= fiber.StatusInternalServerError
+		if failed && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fiber.NewError(fiber.StatusGatewayTimeout, "Request timed out")
+		}
+		return err
+	}
+}
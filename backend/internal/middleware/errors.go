@@ -0,0 +1,22 @@
+package middleware
+
+import "github.com/gofiber/fiber/v2"
+
+// SendError responds with the error envelope of the API, carrying the
+// request ID so a failure a client reports can be found in the logs
+func SendError(c *fiber.Ctx, status int, code, message string) error {
+	return SendErrorBody(c, status, fiber.Map{
+		"code":    code,
+		"message": message,
+	})
+}
+
+// SendErrorBody responds with the error envelope around body, for errors
+// that carry more than a code and a message
+func SendErrorBody(c *fiber.Ctx, status int, body fiber.Map) error {
+	return c.Status(status).JSON(fiber.Map{
+		"success":    false,
+		"request_id": GetRequestID(c),
+		"error":      body,
+	})
+}
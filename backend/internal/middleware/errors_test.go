@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestErrorResponsesCarryRequestID(t *testing.T) {
+	app := fiber.New()
+	app.Use(RequestID())
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		return SendError(c, fiber.StatusNotFound, "ORDER_NOT_FOUND", "Order not found")
+	})
+	app.Get("/private", AuthMiddleware(&config.Config{JWTSecret: "secret"}), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	tests := []struct {
+		name      string
+		path      string
+		requestID string
+		status    int
+		code      string
+	}{
+		{"handler error", "/missing", "req-123", fiber.StatusNotFound, "ORDER_NOT_FOUND"},
+		{"middleware error", "/private", "req-456", fiber.StatusUnauthorized, "UNAUTHORIZED"},
+		{"generated request ID", "/missing", "", fiber.StatusNotFound, "ORDER_NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			var body struct {
+				Success   bool   `json:"success"`
+				RequestID string `json:"request_id"`
+				Error     struct {
+					Code    string `json:"code"`
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode the response: %v", err)
+			}
+
+			if resp.StatusCode != tt.status || body.Success || body.Error.Code != tt.code || body.Error.Message == "" {
+				t.Errorf("response = %d %+v, want %d with code %s", resp.StatusCode, body, tt.status, tt.code)
+			}
+			if want := resp.Header.Get(RequestIDHeader); body.RequestID == "" || body.RequestID != want {
+				t.Errorf("request_id = %q, want the %s header %q", body.RequestID, RequestIDHeader, want)
+			}
+			if tt.requestID != "" && body.RequestID != tt.requestID {
+				t.Errorf("request_id = %q, want %q", body.RequestID, tt.requestID)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+)
+
+// RequestLogger logs every request once it has been handled. It runs after
+// RequestID, so the record carries the request ID from the user context.
+func RequestLogger(log *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		chainErr := c.Next()
+		if chainErr != nil {
+			// Render the error now so its status is the one logged
+			if err := c.App().ErrorHandler(c, chainErr); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.String("route", c.Route().Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("ip", c.IP()),
+			slog.Int("bytes", len(c.Response().Body())),
+		}
+		if chainErr != nil {
+			attrs = append(attrs, slog.String("error", chainErr.Error()))
+		}
+		log.LogAttrs(c.UserContext(), level, "Request handled", attrs...)
+
+		return nil
+	}
+}
+
+// Recover turns panics into 500 responses and logs them with their stack
+func Recover(log *slog.Logger) fiber.Handler {
+	return recover.New(recover.Config{
+		EnableStackTrace: true,
+		StackTraceHandler: func(c *fiber.Ctx, e interface{}) {
+			log.ErrorContext(c.UserContext(), "Panic recovered",
+				"method", c.Method(),
+				"path", c.Path(),
+				"panic", e,
+				"stack", string(debug.Stack()),
+			)
+		},
+	})
+}
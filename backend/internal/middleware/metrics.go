@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/metrics"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Metrics records the count, latency and status of requests by route
+// template. It runs before RequestLogger, which has rendered any error by
+// the time the status is read.
+func Metrics(m *metrics.Metrics) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		m.RequestStarted()
+
+		err := c.Next()
+
+		// A request matching no route is left with the global middleware's
+		// route, which would lump it in with the root path
+		status := c.Response().StatusCode()
+		route := c.Route().Path
+		if route == "/" && status == fiber.StatusNotFound {
+			route = "unmatched"
+		}
+
+		m.RequestFinished(c.Method(), route, status, time.Since(start))
+		return err
+	}
+}
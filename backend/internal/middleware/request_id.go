@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/Shihasz/gophiway/internal/logging"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern limits accepted request IDs to what is safe to log and
+// echo back
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID accepts the X-Request-ID of the incoming request or generates
+// one. The ID is echoed in the response, stored in the locals and put in the
+// user context, so logs and database calls made for the request carry it.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		c.Set(RequestIDHeader, requestID)
+		c.Locals("requestID", requestID)
+		c.SetUserContext(logging.WithRequestID(c.UserContext(), requestID))
+
+		return c.Next()
+	}
+}
+
+// GetRequestID gets the request ID from context
+func GetRequestID(c *fiber.Ctx) string {
+	requestID, _ := c.Locals("requestID").(string)
+	return requestID
+}
@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/tracing"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTracingExporter installs a tracer provider recording every span in
+// memory and restores the global one when the test ends
+func newTracingExporter(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProvider(&config.Config{AppName: "gophiway-test", TracingSampleRatio: 1}, exporter)
+
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return exporter
+}
+
+// flushSpans exports the spans ended so far
+func flushSpans(t *testing.T, exporter *tracetest.InMemoryExporter) tracetest.SpanStubs {
+	t.Helper()
+
+	if err := otel.GetTracerProvider().(interface {
+		ForceFlush(context.Context) error
+	}).ForceFlush(context.Background()); err != nil {
+		t.Fatalf("failed to flush spans: %v", err)
+	}
+	return exporter.GetSpans()
+}
+
+// findSpan returns the span of the given kind and name
+func findSpan(t *testing.T, spans tracetest.SpanStubs, kind trace.SpanKind, name string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, span := range spans {
+		if span.SpanKind == kind && span.Name == name {
+			return span
+		}
+	}
+	var names []string
+	for _, span := range spans {
+		names = append(names, span.SpanKind.String()+" "+span.Name)
+	}
+	t.Fatalf("no %s span named %q among %v", kind, name, names)
+	return tracetest.SpanStub{}
+}
+
+// hasAttribute reports whether a span carries the attribute
+func hasAttribute(span tracetest.SpanStub, want attribute.KeyValue) bool {
+	for _, kv := range span.Attributes {
+		if kv == want {
+			return true
+		}
+	}
+	return false
+}
+
+// newTracedApp creates an app with the server's tracing and logging
+// middleware, which renders errors before the span reads the status
+func newTracedApp() *fiber.App {
+	app := fiber.New()
+	app.Use(Tracing())
+	app.Use(RequestLogger(slog.New(slog.DiscardHandler)))
+	return app
+}
+
+func TestTracingNamesServerSpanAfterRoute(t *testing.T) {
+	exporter := newTracingExporter(t)
+
+	app := newTracedApp()
+	app.Get("/products/:id", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	for _, path := range []string{"/products/42", "/missing"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+	}
+
+	spans := flushSpans(t, exporter)
+
+	matched := findSpan(t, spans, trace.SpanKindServer, "GET /products/:id")
+	for _, want := range []attribute.KeyValue{
+		semconv.HTTPRoute("/products/:id"),
+		semconv.URLPath("/products/42"),
+		semconv.HTTPResponseStatusCode(fiber.StatusNoContent),
+	} {
+		if !hasAttribute(matched, want) {
+			t.Errorf("server span lacks %s=%s", want.Key, want.Value.Emit())
+		}
+	}
+
+	// Unmatched paths would make a span name per URL, so they keep the method
+	unmatched := findSpan(t, spans, trace.SpanKindServer, "GET")
+	if !hasAttribute(unmatched, semconv.HTTPResponseStatusCode(fiber.StatusNotFound)) {
+		t.Errorf("unmatched span lacks the 404 status: %v", unmatched.Attributes)
+	}
+	for _, kv := range unmatched.Attributes {
+		if kv.Key == semconv.HTTPRouteKey {
+			t.Errorf("unmatched span has route %s", kv.Value.Emit())
+		}
+	}
+}
+
+func TestTracingParentsQueriesAndOutboundCalls(t *testing.T) {
+	exporter := newTracingExporter(t)
+
+	var traceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+	client := &http.Client{Transport: tracing.Transport(nil)}
+
+	// A dry run builds the SQL and runs the callbacks without a server
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(tracing.NewGormPlugin("gophiway")); err != nil {
+		t.Fatal(err)
+	}
+
+	app := newTracedApp()
+	app.Get("/products/:id", func(c *fiber.Ctx) error {
+		var product models.Product
+		if err := db.WithContext(c.UserContext()).Where("slug = ?", c.Params("id")).Find(&product).Error; err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(c.UserContext(), http.MethodGet, upstream.URL+"/rates?key=secret", nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/products/lamp", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	spans := flushSpans(t, exporter)
+	server := findSpan(t, spans, trace.SpanKindServer, "GET /products/:id")
+	query := findSpan(t, spans, trace.SpanKindClient, "SELECT products")
+	outbound := findSpan(t, spans, trace.SpanKindClient, http.MethodGet)
+
+	for _, child := range []tracetest.SpanStub{query, outbound} {
+		if child.Parent.SpanID() != server.SpanContext.SpanID() {
+			t.Errorf("%s span has parent %s, want the server span %s", child.Name, child.Parent.SpanID(), server.SpanContext.SpanID())
+		}
+		if child.SpanContext.TraceID() != server.SpanContext.TraceID() {
+			t.Errorf("%s span is in trace %s, want %s", child.Name, child.SpanContext.TraceID(), server.SpanContext.TraceID())
+		}
+	}
+
+	if !hasAttribute(query, semconv.DBCollectionName("products")) {
+		t.Errorf("query span lacks the table: %v", query.Attributes)
+	}
+	if !hasAttribute(outbound, semconv.URLFull(upstream.URL+"/rates")) {
+		t.Errorf("outbound span lacks the redacted URL: %v", outbound.Attributes)
+	}
+
+	want := "00-" + server.SpanContext.TraceID().String() + "-" + outbound.SpanContext.SpanID().String() + "-01"
+	if traceparent != want {
+		t.Errorf("upstream got traceparent %q, want %q", traceparent, want)
+	}
+}
@@ -29,17 +29,40 @@ type User struct {
 	Orders        []Order   `gorm:"foreignKey:UserID" json:"orders,omitempty"`
 }
 
-// Address represents a user's address
+// User roles
+const (
+	RoleCustomer = "customer"
+	RoleAdmin    = "admin"
+)
+
+// Address types
+const (
+	AddressTypeShipping = "shipping"
+	AddressTypeBilling  = "billing"
+)
+
+// Address represents a user's address. A user has at most one default
+// address per type.
 type Address struct {
 	BaseModel
-	UserID        uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
-	Type          string    `json:"type"` // shipping, billing
+	UserID        uuid.UUID `gorm:"type:uuid;not null;index;uniqueIndex:idx_addresses_user_type_default,where:is_default AND deleted_at IS NULL" json:"user_id"`
+	Type          string    `gorm:"uniqueIndex:idx_addresses_user_type_default" json:"type"` // shipping, billing
 	StreetAddress string    `json:"street_address"`
 	City          string    `json:"city"`
 	State         string    `json:"state"`
 	PostalCode    string    `json:"postal_code"`
-	Country       string    `json:"country"`
-	IsDefault     bool      `gorm:"default:false" json:"is_default"`
+	Country       string    `json:"country"` // ISO 3166-1 alpha-2
+	IsDefault     bool      `gorm:"default:false;uniqueIndex:idx_addresses_user_type_default" json:"is_default"`
+}
+
+// OrderAddress is a copy of an address taken when an order is placed, so
+// later changes to the address book leave the order as it was
+type OrderAddress struct {
+	StreetAddress string `json:"street_address"`
+	City          string `json:"city"`
+	State         string `json:"state"`
+	PostalCode    string `json:"postal_code"`
+	Country       string `json:"country"`
 }
 
 // Category represents a product category
@@ -50,6 +73,9 @@ type Category struct {
 	Description string     `json:"description"`
 	ParentID    *uuid.UUID `gorm:"type:uuid" json:"parent_id,omitempty"`
 	Parent      *Category  `gorm:"foreignKey:ParentID" json:"parent,omitempty"`
+	// ReturnWindowDays overrides the default return window for products in
+	// this category; zero makes them non-returnable
+	ReturnWindowDays *int `json:"return_window_days,omitempty"`
 }
 
 // Product represents a product
@@ -64,6 +90,12 @@ type Product struct {
 	SKU            string          `gorm:"uniqueIndex" json:"sku"`
 	StockQuantity  int             `gorm:"default:0" json:"stock_quantity"`
 	IsActive       bool            `gorm:"default:true" json:"is_active"`
+	TaxClass       string          `gorm:"not null;default:'standard'" json:"tax_class"` // standard, reduced, zero, exempt...
+	Weight         float64         `json:"weight"`                                       // kg
+	Length         float64         `json:"length"`                                       // cm
+	Width          float64         `json:"width"`                                        // cm
+	Height         float64         `json:"height"`                                       // cm
+	IsGiftCard     bool            `gorm:"default:false" json:"is_gift_card"`            // sold units issue gift cards worth the price
 	Images         []ProductImage  `gorm:"foreignKey:ProductID" json:"images,omitempty"`
 	Categories     []Category      `gorm:"many2many:product_categories;" json:"categories,omitempty"`
 }
@@ -87,9 +119,10 @@ type ProductCategory struct {
 // Cart represents a shopping cart
 type Cart struct {
 	BaseModel
-	UserID    *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"`
-	SessionID string     `gorm:"index" json:"session_id"` // For guest users
-	Items     []CartItem `gorm:"foreignKey:CartID" json:"items,omitempty"`
+	UserID     *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	SessionID  string     `gorm:"index" json:"session_id"` // For guest users
+	CouponCode string     `json:"coupon_code,omitempty"`
+	Items      []CartItem `gorm:"foreignKey:CartID" json:"items,omitempty"`
 }
 
 // CartItem represents an item in a cart
@@ -102,23 +135,69 @@ type CartItem struct {
 	PriceAtAdd  float64   `json:"price_at_add"`
 }
 
+// OrderStatus is the fulfillment state of an order
+type OrderStatus string
+
+const (
+	OrderStatusPending          OrderStatus = "pending"
+	OrderStatusProcessing       OrderStatus = "processing"
+	OrderStatusPartiallyShipped OrderStatus = "partially_shipped"
+	OrderStatusShipped          OrderStatus = "shipped"
+	OrderStatusDelivered        OrderStatus = "delivered"
+	OrderStatusCancelled        OrderStatus = "cancelled"
+)
+
+// PaymentStatus is the payment state of an order
+type PaymentStatus string
+
+const (
+	PaymentStatusPending           PaymentStatus = "pending"
+	PaymentStatusAuthorized        PaymentStatus = "authorized"
+	PaymentStatusPaid              PaymentStatus = "paid"
+	PaymentStatusFailed            PaymentStatus = "failed"
+	PaymentStatusPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentStatusRefunded          PaymentStatus = "refunded"
+)
+
 // Order represents an order
 type Order struct {
 	BaseModel
-	UserID              uuid.UUID   `gorm:"type:uuid;not null;index" json:"user_id"`
-	OrderNumber         string      `gorm:"uniqueIndex;not null" json:"order_number"`
-	Status              string      `gorm:"default:'pending'" json:"status"` // pending, processing, shipped, delivered, cancelled
-	Subtotal            float64     `json:"subtotal"`
-	Tax                 float64     `json:"tax"`
-	Shipping            float64     `json:"shipping"`
-	Total               float64     `json:"total"`
-	PaymentStatus       string      `gorm:"default:'pending'" json:"payment_status"` // pending, paid, failed, refunded
-	ShippingAddressID   uuid.UUID   `gorm:"type:uuid" json:"shipping_address_id"`
-	BillingAddressID    uuid.UUID   `gorm:"type:uuid" json:"billing_address_id"`
-	ShippingAddress     Address     `gorm:"foreignKey:ShippingAddressID" json:"shipping_address"`
-	BillingAddress      Address     `gorm:"foreignKey:BillingAddressID" json:"billing_address"`
-	Items               []OrderItem `gorm:"foreignKey:OrderID" json:"items,omitempty"`
-	Payment             *Payment    `gorm:"foreignKey:OrderID" json:"payment,omitempty"`
+	UserID             uuid.UUID       `gorm:"type:uuid;not null;index" json:"user_id"`
+	OrderNumber        string          `gorm:"uniqueIndex;not null" json:"order_number"`
+	Status             OrderStatus     `gorm:"default:'pending'" json:"status"`
+	Subtotal           float64         `json:"subtotal"`
+	Discount           float64         `json:"discount"`
+	CouponCode         string          `json:"coupon_code,omitempty"`
+	Discounts          []OrderDiscount `gorm:"type:jsonb;serializer:json" json:"discounts"`
+	Tax                float64         `json:"tax"`
+	Shipping           float64         `json:"shipping"`
+	Total              float64         `json:"total"`
+	ShippingTax        float64         `json:"shipping_tax"`
+	ShippingDiscount   float64         `json:"shipping_discount"`
+	ShippingMethodID   *uuid.UUID      `gorm:"type:uuid" json:"shipping_method_id,omitempty"`
+	ShippingMethodName string          `json:"shipping_method_name"`
+	TaxInclusive       bool            `gorm:"default:false" json:"tax_inclusive"` // item prices and shipping already include tax
+	PaymentStatus      PaymentStatus   `gorm:"default:'pending'" json:"payment_status"`
+	// Address book entries the snapshots were taken from
+	ShippingAddressID uuid.UUID     `gorm:"type:uuid" json:"shipping_address_id"`
+	BillingAddressID  uuid.UUID     `gorm:"type:uuid" json:"billing_address_id"`
+	ShippingAddress   OrderAddress  `gorm:"embedded;embeddedPrefix:shipping_" json:"shipping_address"`
+	BillingAddress    OrderAddress  `gorm:"embedded;embeddedPrefix:billing_" json:"billing_address"`
+	Items             []OrderItem   `gorm:"foreignKey:OrderID" json:"items,omitempty"`
+	Payments          []Payment     `gorm:"foreignKey:OrderID" json:"payments,omitempty"`
+	Refunds           []Refund      `gorm:"foreignKey:OrderID" json:"refunds,omitempty"`
+	Fulfillments      []Fulfillment `gorm:"foreignKey:OrderID" json:"fulfillments,omitempty"`
+}
+
+// OrderStatusHistory records a single order status transition
+type OrderStatusHistory struct {
+	BaseModel
+	OrderID    uuid.UUID   `gorm:"type:uuid;not null;index" json:"order_id"`
+	FromStatus OrderStatus `json:"from_status"`
+	ToStatus   OrderStatus `gorm:"not null" json:"to_status"`
+	ActorID    *uuid.UUID  `gorm:"type:uuid" json:"actor_id,omitempty"`
+	ActorRole  string      `json:"actor_role"` // customer, admin, system
+	Reason     string      `json:"reason"`
 }
 
 // OrderItem represents an item in an order
@@ -130,17 +209,439 @@ type OrderItem struct {
 	Quantity  int       `gorm:"not null" json:"quantity"`
 	Price     float64   `json:"price"`
 	Total     float64   `json:"total"`
+	Discount  float64   `json:"discount"` // share of order discounts; Total - Discount is taxed
+	// Tax charged on the line, with the rate of each jurisdiction applied
+	TaxClass     string         `json:"tax_class"`
+	TaxRate      float64        `json:"tax_rate"`
+	TaxAmount    float64        `json:"tax_amount"`
+	TaxBreakdown []TaxComponent `gorm:"type:jsonb;serializer:json" json:"tax_breakdown"`
 }
 
-// Payment represents a payment
+// ShippingZone groups the destinations that share shipping methods
+type ShippingZone struct {
+	BaseModel
+	Name    string               `gorm:"not null" json:"name"`
+	Regions []ShippingZoneRegion `gorm:"foreignKey:ZoneID" json:"regions,omitempty"`
+	Methods []ShippingMethod     `gorm:"foreignKey:ZoneID" json:"methods,omitempty"`
+}
+
+// ShippingZoneRegion is a destination covered by a zone. The most specific
+// matching region decides the zone of an address.
+type ShippingZoneRegion struct {
+	BaseModel
+	ZoneID           uuid.UUID `gorm:"type:uuid;not null;index" json:"zone_id"`
+	Country          string    `gorm:"not null;index" json:"country"` // ISO 3166-1 alpha-2, or * for anywhere
+	State            string    `json:"state"`                         // empty matches any state
+	PostalCodePrefix string    `json:"postal_code_prefix"`            // empty matches any postal code
+}
+
+// ShippingRateType is how a shipping method prices a shipment
+type ShippingRateType string
+
+const (
+	ShippingRateFlat      ShippingRateType = "flat"       // Rate per shipment
+	ShippingRateWeight    ShippingRateType = "weight"     // tier by total weight
+	ShippingRatePriceTier ShippingRateType = "price_tier" // tier by subtotal
+	ShippingRateFreeOver  ShippingRateType = "free_over"  // Rate, free from FreeThreshold
+	ShippingRateCarrier   ShippingRateType = "carrier"    // quoted live by a rate provider
+)
+
+// ShippingMethod is a way of shipping to a zone
+type ShippingMethod struct {
+	BaseModel
+	ZoneID        uuid.UUID          `gorm:"type:uuid;not null;index" json:"zone_id"`
+	Name          string             `gorm:"not null" json:"name"`
+	RateType      ShippingRateType   `gorm:"not null" json:"rate_type"`
+	Rate          float64            `json:"rate"`
+	FreeThreshold float64            `json:"free_threshold"`
+	Carrier       string             `json:"carrier,omitempty"`      // rate provider name for carrier rates
+	ServiceCode   string             `json:"service_code,omitempty"` // carrier service level
+	MaxWeight     float64            `json:"max_weight"`             // kg, zero for no limit
+	MinDays       int                `json:"min_days"`
+	MaxDays       int                `json:"max_days"`
+	IsActive      bool               `gorm:"default:true" json:"is_active"`
+	Tiers         []ShippingRateTier `gorm:"foreignKey:MethodID" json:"tiers,omitempty"`
+}
+
+// ShippingRateTier is the rate of a tiered method from a minimum weight or
+// subtotal upwards
+type ShippingRateTier struct {
+	BaseModel
+	MethodID uuid.UUID `gorm:"type:uuid;not null;index" json:"method_id"`
+	MinValue float64   `json:"min_value"` // kg for weight rates, subtotal for price tiers
+	Rate     float64   `json:"rate"`
+}
+
+// CouponType is the kind of discount a coupon gives
+type CouponType string
+
+const (
+	CouponTypePercentage   CouponType = "percentage"
+	CouponTypeFixedAmount  CouponType = "fixed_amount"
+	CouponTypeFreeShipping CouponType = "free_shipping"
+	CouponTypeBuyXGetY     CouponType = "buy_x_get_y"
+)
+
+// Coupon is a discount code
+type Coupon struct {
+	BaseModel
+	Code        string     `gorm:"uniqueIndex;not null" json:"code"` // stored uppercase
+	Description string     `json:"description"`
+	Type        CouponType `gorm:"not null" json:"type"`
+	// Value is the percentage off for percentage and buy_x_get_y coupons
+	// (100 makes the "get" units free) and the amount off for fixed_amount
+	Value       float64 `json:"value"`
+	BuyQuantity int     `json:"buy_quantity,omitempty"`
+	GetQuantity int     `json:"get_quantity,omitempty"`
+	// Conditions
+	MinSubtotal      float64     `json:"min_subtotal"`
+	ProductIDs       []uuid.UUID `gorm:"type:jsonb;serializer:json" json:"product_ids"`
+	CategoryIDs      []uuid.UUID `gorm:"type:jsonb;serializer:json" json:"category_ids"`
+	FirstOrderOnly   bool        `gorm:"default:false" json:"first_order_only"`
+	UsageLimit       *int        `json:"usage_limit,omitempty"`
+	PerCustomerLimit *int        `json:"per_customer_limit,omitempty"`
+	UsageCount       int         `gorm:"not null;default:0" json:"usage_count"`
+	StartsAt         *time.Time  `json:"starts_at,omitempty"`
+	EndsAt           *time.Time  `json:"ends_at,omitempty"`
+	IsActive         bool        `gorm:"default:true" json:"is_active"`
+}
+
+// CouponRedemption records a coupon used on an order
+type CouponRedemption struct {
+	BaseModel
+	CouponID uuid.UUID `gorm:"type:uuid;not null;index;uniqueIndex:idx_coupon_redemptions_coupon_order" json:"coupon_id"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	OrderID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_coupon_redemptions_coupon_order" json:"order_id"`
+	Amount   float64   `json:"amount"`
+}
+
+// OrderDiscount records a promotion or coupon applied when the order was placed
+type OrderDiscount struct {
+	Source       string  `json:"source"` // promotion or coupon
+	Code         string  `json:"code"`
+	Description  string  `json:"description"`
+	Amount       float64 `json:"amount"`
+	FreeShipping bool    `json:"free_shipping,omitempty"`
+}
+
+// PromotionType is the kind of discount a promotion gives
+type PromotionType string
+
+const (
+	PromotionTypePercentage   PromotionType = "percentage"
+	PromotionTypeFixedAmount  PromotionType = "fixed_amount"
+	PromotionTypeBundlePrice  PromotionType = "bundle_price"
+	PromotionTypeFreeShipping PromotionType = "free_shipping"
+)
+
+// Promotion is a discount applied automatically to carts meeting its rules.
+// Promotions are evaluated by descending priority; one that is not
+// stackable only applies on its own and blocks later promotions and coupons.
+type Promotion struct {
+	BaseModel
+	Name        string        `gorm:"not null" json:"name"`
+	Description string        `json:"description"`
+	Type        PromotionType `gorm:"not null" json:"type"`
+	// Value is the percentage off for percentage promotions, the amount off
+	// for fixed_amount and the price of one bundle for bundle_price
+	Value float64 `json:"value"`
+	// Conditions; a bundle is one unit of each entry in ProductIDs
+	ProductIDs  []uuid.UUID `gorm:"type:jsonb;serializer:json" json:"product_ids"`
+	CategoryIDs []uuid.UUID `gorm:"type:jsonb;serializer:json" json:"category_ids"`
+	MinSubtotal float64     `json:"min_subtotal"`
+	MinQuantity int         `json:"min_quantity"` // eligible units required
+	Priority    int         `gorm:"not null;default:0;index" json:"priority"`
+	Stackable   bool        `gorm:"default:true" json:"stackable"`
+	StartsAt    *time.Time  `json:"starts_at,omitempty"`
+	EndsAt      *time.Time  `json:"ends_at,omitempty"`
+	IsActive    bool        `gorm:"default:true" json:"is_active"`
+}
+
+// TaxComponent is the tax one jurisdiction levies on an amount
+type TaxComponent struct {
+	Name   string  `json:"name"`
+	Rate   float64 `json:"rate"`
+	Amount float64 `json:"amount"`
+}
+
+// TaxRate is a jurisdiction's rate for a product tax class. Every rate
+// matching a destination applies, so country and state taxes stack.
+type TaxRate struct {
+	BaseModel
+	Name             string  `gorm:"not null" json:"name"`
+	Country          string  `gorm:"size:2;not null;index" json:"country"` // ISO 3166-1 alpha-2
+	State            string  `json:"state"`                                // empty matches any state
+	PostalCodePrefix string  `json:"postal_code_prefix"`                   // empty matches any postal code
+	TaxClass         string  `gorm:"not null;default:'standard'" json:"tax_class"`
+	Rate             float64 `gorm:"not null" json:"rate"` // fraction, e.g. 0.2 for 20%
+}
+
+// FulfillmentStatus is the delivery state of a fulfillment
+type FulfillmentStatus string
+
+const (
+	FulfillmentStatusShipped   FulfillmentStatus = "shipped"
+	FulfillmentStatusDelivered FulfillmentStatus = "delivered"
+)
+
+// Fulfillment represents a shipment of some or all of an order's items
+type Fulfillment struct {
+	BaseModel
+	OrderID        uuid.UUID         `gorm:"type:uuid;not null;index" json:"order_id"`
+	Status         FulfillmentStatus `gorm:"default:'shipped'" json:"status"`
+	Carrier        string            `gorm:"not null" json:"carrier"`
+	TrackingNumber string            `json:"tracking_number"`
+	ShippedAt      time.Time         `json:"shipped_at"`
+	DeliveredAt    *time.Time        `json:"delivered_at,omitempty"`
+	Items          []FulfillmentItem `gorm:"foreignKey:FulfillmentID" json:"items,omitempty"`
+}
+
+// FulfillmentItem is the quantity of an order item shipped in a fulfillment
+type FulfillmentItem struct {
+	BaseModel
+	FulfillmentID uuid.UUID `gorm:"type:uuid;not null;index" json:"fulfillment_id"`
+	OrderItemID   uuid.UUID `gorm:"type:uuid;not null;index" json:"order_item_id"`
+	Quantity      int       `gorm:"not null" json:"quantity"`
+}
+
+// PaymentState is the state of a single payment with the provider
+type PaymentState string
+
+const (
+	PaymentStatePending           PaymentState = "pending"
+	PaymentStateAuthorized        PaymentState = "authorized"
+	PaymentStateCompleted         PaymentState = "completed"
+	PaymentStateFailed            PaymentState = "failed"
+	PaymentStateCancelled         PaymentState = "cancelled"
+	PaymentStatePartiallyRefunded PaymentState = "partially_refunded"
+	PaymentStateRefunded          PaymentState = "refunded"
+)
+
+// Tender providers are balances held by the store itself. Their payments
+// settle immediately at checkout and are refunded to the same balance.
+const (
+	PaymentProviderGiftCard    = "gift_card"
+	PaymentProviderStoreCredit = "store_credit"
+)
+
+// TenderProviders lists the providers that are not a payment provider
+var TenderProviders = []string{PaymentProviderGiftCard, PaymentProviderStoreCredit}
+
+// Payment represents a payment. An order has one payment per tender: gift
+// cards and store credit cover part of the total and the payment provider
+// the rest.
 type Payment struct {
 	BaseModel
-	OrderID          uuid.UUID `gorm:"type:uuid;not null;index" json:"order_id"`
-	PaymentMethod    string    `json:"payment_method"` // card, paypal, etc.
-	TransactionID    string    `gorm:"uniqueIndex" json:"transaction_id"`
-	Amount           float64   `json:"amount"`
-	Status           string    `gorm:"default:'pending'" json:"status"` // pending, completed, failed, refunded
-	ProviderResponse string    `gorm:"type:jsonb" json:"provider_response,omitempty"`
+	OrderID          uuid.UUID    `gorm:"type:uuid;not null;index" json:"order_id"`
+	Provider         string       `json:"provider"`       // stripe, fake, gift_card, store_credit
+	PaymentMethod    string       `json:"payment_method"` // card, paypal, etc.
+	GiftCardID       *uuid.UUID   `gorm:"type:uuid;index" json:"gift_card_id,omitempty"`
+	TransactionID    string       `gorm:"uniqueIndex" json:"transaction_id"`
+	Amount           float64      `json:"amount"`
+	Currency         string       `json:"currency"`
+	Status           PaymentState `gorm:"default:'pending'" json:"status"`
+	ProviderResponse string       `gorm:"type:jsonb" json:"provider_response,omitempty"`
+}
+
+// RefundStatus is the state of a refund with the provider
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "pending"
+	RefundStatusSucceeded RefundStatus = "succeeded"
+	RefundStatusFailed    RefundStatus = "failed"
+)
+
+// Refund represents money returned to the customer for a payment
+type Refund struct {
+	BaseModel
+	OrderID          uuid.UUID    `gorm:"type:uuid;not null;index" json:"order_id"`
+	PaymentID        uuid.UUID    `gorm:"type:uuid;not null;index" json:"payment_id"`
+	ProviderRefundID *string      `gorm:"uniqueIndex" json:"provider_refund_id,omitempty"`
+	Amount           float64      `gorm:"not null" json:"amount"`
+	Currency         string       `json:"currency"`
+	Status           RefundStatus `gorm:"default:'pending'" json:"status"`
+	Reason           string       `json:"reason"`
+	Restock          bool         `gorm:"default:false" json:"restock"`
+	ActorID          *uuid.UUID   `gorm:"type:uuid" json:"actor_id,omitempty"`
+	FailureMessage   string       `json:"failure_message,omitempty"`
+	ProviderResponse string       `gorm:"type:jsonb" json:"-"`
+	Items            []RefundItem `gorm:"foreignKey:RefundID" json:"items,omitempty"`
+}
+
+// RefundItem is the quantity of an order item covered by a refund
+type RefundItem struct {
+	BaseModel
+	RefundID    uuid.UUID `gorm:"type:uuid;not null;index" json:"refund_id"`
+	OrderItemID uuid.UUID `gorm:"type:uuid;not null;index" json:"order_item_id"`
+	Quantity    int       `gorm:"not null" json:"quantity"`
+	Amount      float64   `json:"amount"`
+}
+
+// DocumentType is the kind of a generated document
+type DocumentType string
+
+const (
+	DocumentTypeInvoice     DocumentType = "invoice"
+	DocumentTypeCreditNote  DocumentType = "credit_note"
+	DocumentTypePackingSlip DocumentType = "packing_slip"
+)
+
+// Document is a generated PDF kept in object storage. Invoices and credit
+// notes are numbered from gap-free sequences; an order has one invoice, a
+// refund one credit note and a fulfillment one packing slip.
+type Document struct {
+	BaseModel
+	Type          DocumentType `gorm:"not null;index" json:"type"`
+	Number        string       `gorm:"uniqueIndex:idx_documents_number,where:number <> ''" json:"number,omitempty"`
+	OrderID       uuid.UUID    `gorm:"type:uuid;not null;index;uniqueIndex:idx_documents_order_invoice,where:type = 'invoice'" json:"order_id"`
+	RefundID      *uuid.UUID   `gorm:"type:uuid;uniqueIndex" json:"refund_id,omitempty"`
+	FulfillmentID *uuid.UUID   `gorm:"type:uuid;uniqueIndex" json:"fulfillment_id,omitempty"`
+	ObjectKey     string       `gorm:"not null" json:"-"`
+	Total         float64      `json:"total"`
+	Currency      string       `json:"currency"`
+	IssuedAt      time.Time    `json:"issued_at"`
+}
+
+// DocumentSequence is the last number used for a kind of document. Its row
+// stays locked while a document is issued, so numbers of documents that fail
+// to issue are reused and the sequence has no gaps.
+type DocumentSequence struct {
+	Name      string `gorm:"primaryKey"`
+	LastValue int64  `gorm:"not null;default:0"`
+}
+
+// ReturnStatus is the state of a return request
+type ReturnStatus string
+
+const (
+	ReturnStatusRequested ReturnStatus = "requested"
+	ReturnStatusApproved  ReturnStatus = "approved"
+	ReturnStatusRejected  ReturnStatus = "rejected"
+	ReturnStatusReceived  ReturnStatus = "received"
+	ReturnStatusCompleted ReturnStatus = "completed"
+)
+
+// ReturnResolution is how the customer is compensated for a return
+type ReturnResolution string
+
+const (
+	ReturnResolutionRefund      ReturnResolution = "refund"
+	ReturnResolutionStoreCredit ReturnResolution = "store_credit"
+)
+
+// ItemCondition grades a returned item on receipt
+type ItemCondition string
+
+const (
+	ItemConditionNew       ItemCondition = "new"
+	ItemConditionOpened    ItemCondition = "opened"
+	ItemConditionDamaged   ItemCondition = "damaged"
+	ItemConditionDefective ItemCondition = "defective"
+)
+
+// Restockable reports whether an item in this condition can be sold again
+func (c ItemCondition) Restockable() bool {
+	return c == ItemConditionNew || c == ItemConditionOpened
+}
+
+// ReturnRequest represents a customer's request to return delivered items
+type ReturnRequest struct {
+	BaseModel
+	OrderID         uuid.UUID        `gorm:"type:uuid;not null;index" json:"order_id"`
+	UserID          uuid.UUID        `gorm:"type:uuid;not null;index" json:"user_id"`
+	RMANumber       *string          `gorm:"uniqueIndex" json:"rma_number,omitempty"`
+	Status          ReturnStatus     `gorm:"default:'requested';index" json:"status"`
+	Resolution      ReturnResolution `gorm:"not null" json:"resolution"`
+	Reason          string           `json:"reason"`
+	AdminNote       string           `json:"admin_note,omitempty"`
+	RejectionReason string           `json:"rejection_reason,omitempty"`
+	ApprovedAt      *time.Time       `json:"approved_at,omitempty"`
+	ReceivedAt      *time.Time       `json:"received_at,omitempty"`
+	CompletedAt     *time.Time       `json:"completed_at,omitempty"`
+	CreditAmount    float64          `json:"credit_amount"`
+	RefundID        *uuid.UUID       `gorm:"type:uuid" json:"refund_id,omitempty"`
+	Items           []ReturnItem     `gorm:"foreignKey:ReturnRequestID" json:"items,omitempty"`
+}
+
+// ReturnItem is the quantity of an order item being returned
+type ReturnItem struct {
+	BaseModel
+	ReturnRequestID  uuid.UUID     `gorm:"type:uuid;not null;index" json:"return_request_id"`
+	OrderItemID      uuid.UUID     `gorm:"type:uuid;not null;index" json:"order_item_id"`
+	Quantity         int           `gorm:"not null" json:"quantity"`
+	Reason           string        `json:"reason"`
+	ReceivedQuantity int           `gorm:"default:0" json:"received_quantity"`
+	Condition        ItemCondition `json:"condition,omitempty"`
+}
+
+// StoreCreditType is the kind of a store credit ledger entry
+type StoreCreditType string
+
+const (
+	StoreCreditIssue  StoreCreditType = "issue"
+	StoreCreditRedeem StoreCreditType = "redeem"
+	StoreCreditRefund StoreCreditType = "refund"
+)
+
+// StoreCreditTransaction is an entry in a user's store credit ledger.
+// Credits are positive and debits negative; the balance is their sum.
+type StoreCreditTransaction struct {
+	BaseModel
+	UserID     uuid.UUID       `gorm:"type:uuid;not null;index" json:"user_id"`
+	Type       StoreCreditType `gorm:"not null" json:"type"`
+	Amount     float64         `gorm:"not null" json:"amount"`
+	SourceType string          `json:"source_type"` // return, order, refund, gift_card
+	SourceID   *uuid.UUID      `gorm:"type:uuid" json:"source_id,omitempty"`
+	Note       string          `json:"note"`
+}
+
+// GiftCard is a prepaid balance redeemable with its code. Only a keyed hash
+// of the code is stored; the code itself is shown once, when it is issued.
+type GiftCard struct {
+	BaseModel
+	CodeHash       string     `gorm:"uniqueIndex;not null" json:"-"`
+	Last4          string     `gorm:"size:4;not null" json:"last4"` // last characters of the code, for display
+	InitialAmount  float64    `gorm:"not null" json:"initial_amount"`
+	Currency       string     `gorm:"not null" json:"currency"`
+	PurchaserID    *uuid.UUID `gorm:"type:uuid;index" json:"purchaser_id,omitempty"`
+	OrderID        *uuid.UUID `gorm:"type:uuid;index" json:"order_id,omitempty"` // order the card was sold on
+	RecipientEmail string     `json:"recipient_email,omitempty"`
+	Note           string     `json:"note,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	// Balance is the sum of the card's ledger, filled in when it is loaded
+	Balance float64 `gorm:"-" json:"balance"`
+}
+
+// GiftCardEntryType is the kind of a gift card ledger entry
+type GiftCardEntryType string
+
+const (
+	GiftCardIssue  GiftCardEntryType = "issue"
+	GiftCardRedeem GiftCardEntryType = "redeem"
+	GiftCardRefund GiftCardEntryType = "refund"
+	GiftCardExpire GiftCardEntryType = "expire"
+)
+
+// GiftCardTransaction is an entry in a gift card's ledger. Credits are
+// positive and debits negative; the balance is their sum.
+type GiftCardTransaction struct {
+	BaseModel
+	GiftCardID uuid.UUID         `gorm:"type:uuid;not null;index" json:"gift_card_id"`
+	Type       GiftCardEntryType `gorm:"not null" json:"type"`
+	Amount     float64           `gorm:"not null" json:"amount"`
+	OrderID    *uuid.UUID        `gorm:"type:uuid;index" json:"order_id,omitempty"`
+	PaymentID  *uuid.UUID        `gorm:"type:uuid" json:"payment_id,omitempty"`
+	Note       string            `json:"note"`
+}
+
+// WebhookEvent records a received provider webhook so redeliveries are ignored
+type WebhookEvent struct {
+	BaseModel
+	Provider string `gorm:"not null;uniqueIndex:idx_webhook_events_provider_event" json:"provider"`
+	EventID  string `gorm:"not null;uniqueIndex:idx_webhook_events_provider_event" json:"event_id"`
+	Type     string `gorm:"index" json:"type"`
+	Payload  string `gorm:"type:jsonb" json:"payload,omitempty"`
 }
 
 // BeforeCreate hook to generate UUID
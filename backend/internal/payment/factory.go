@@ -0,0 +1,22 @@
+package payment
+
+import (
+	"fmt"
+
+	"github.com/Shihasz/gophiway/internal/config"
+)
+
+// NewProvider creates the payment provider selected in the configuration
+func NewProvider(cfg *config.Config) (Provider, error) {
+	switch cfg.PaymentProvider {
+	case "stripe":
+		if cfg.StripeSecretKey == "" {
+			return nil, fmt.Errorf("STRIPE_SECRET_KEY is required for the stripe payment provider")
+		}
+		return NewStripeProvider(cfg.StripeSecretKey), nil
+	case "fake":
+		return NewFakeProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.PaymentProvider)
+	}
+}
@@ -0,0 +1,216 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Test payment methods understood by the fake provider. They mirror the
+// Stripe test tokens so the same client code works against both.
+const (
+	FakeMethodSuccess  = "pm_card_visa"
+	FakeMethodDeclined = "pm_card_chargeDeclined"
+)
+
+// FakeProvider is a deterministic in-process Provider for development and
+// offline testing. IDs are sequential and outcomes depend only on the
+// payment method used. Like Stripe, a request repeating an idempotency key
+// gets the object the first one created.
+type FakeProvider struct {
+	mu      sync.Mutex
+	seq     int
+	intents map[string]*fakeIntent
+	refunds map[string]*Refund
+	keys    map[string]string
+}
+
+type fakeIntent struct {
+	Intent
+	captureMethod string
+	refunded      int64
+}
+
+// NewFakeProvider creates an empty fake provider
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		intents: make(map[string]*fakeIntent),
+		refunds: make(map[string]*Refund),
+		keys:    make(map[string]string),
+	}
+}
+
+// Name identifies the provider
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+// CreateIntent creates an intent awaiting a payment method
+func (p *FakeProvider) CreateIntent(ctx context.Context, req *CreateIntentRequest) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if id, ok := p.keys[req.IdempotencyKey]; ok && req.IdempotencyKey != "" {
+		return p.intents[id].snapshot(), nil
+	}
+
+	id := p.nextID("pi_fake")
+	intent := &fakeIntent{
+		Intent: Intent{
+			ID:           id,
+			Status:       IntentRequiresPaymentMethod,
+			Amount:       req.Amount,
+			Currency:     NormalizeCurrency(req.Currency),
+			ClientSecret: id + "_secret_fake",
+		},
+		captureMethod: req.CaptureMethod,
+	}
+	p.intents[id] = intent
+	if req.IdempotencyKey != "" {
+		p.keys[req.IdempotencyKey] = id
+	}
+
+	return intent.snapshot(), nil
+}
+
+// Confirm succeeds for any payment method except FakeMethodDeclined
+func (p *FakeProvider) Confirm(ctx context.Context, intentID, paymentMethod string) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+	if intent.Status != IntentRequiresPaymentMethod && intent.Status != IntentRequiresConfirmation {
+		return nil, fmt.Errorf("%w: intent is %s", ErrInvalidState, intent.Status)
+	}
+
+	if paymentMethod == FakeMethodDeclined {
+		intent.Status = IntentRequiresPaymentMethod
+		intent.LastPaymentError = "Your card was declined."
+		return nil, &ProviderError{
+			StatusCode:  http.StatusPaymentRequired,
+			Type:        "card_error",
+			Code:        "card_declined",
+			DeclineCode: "generic_decline",
+			Message:     intent.LastPaymentError,
+		}
+	}
+
+	intent.LastPaymentError = ""
+	if intent.captureMethod == CaptureManual {
+		intent.Status = IntentRequiresCapture
+	} else {
+		intent.Status = IntentSucceeded
+		intent.AmountCaptured = intent.Amount
+	}
+
+	return intent.snapshot(), nil
+}
+
+// Capture captures an authorized intent
+func (p *FakeProvider) Capture(ctx context.Context, intentID string, amount int64) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+	if intent.Status != IntentRequiresCapture {
+		return nil, fmt.Errorf("%w: intent is %s", ErrInvalidState, intent.Status)
+	}
+	if amount <= 0 || amount > intent.Amount {
+		amount = intent.Amount
+	}
+
+	intent.Status = IntentSucceeded
+	intent.AmountCaptured = amount
+
+	return intent.snapshot(), nil
+}
+
+// Void cancels an intent that has not been captured
+func (p *FakeProvider) Void(ctx context.Context, intentID string) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+	if intent.Status == IntentSucceeded || intent.Status == IntentCanceled {
+		return nil, fmt.Errorf("%w: intent is %s", ErrInvalidState, intent.Status)
+	}
+
+	intent.Status = IntentCanceled
+
+	return intent.snapshot(), nil
+}
+
+// Refund refunds some or all of a captured intent
+func (p *FakeProvider) Refund(ctx context.Context, req *RefundRequest) (*Refund, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if id, ok := p.keys[req.IdempotencyKey]; ok && req.IdempotencyKey != "" {
+		copied := *p.refunds[id]
+		return &copied, nil
+	}
+
+	intent, ok := p.intents[req.IntentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+	if intent.Status != IntentSucceeded {
+		return nil, fmt.Errorf("%w: intent is %s", ErrInvalidState, intent.Status)
+	}
+
+	remaining := intent.AmountCaptured - intent.refunded
+	amount := req.Amount
+	if amount <= 0 {
+		amount = remaining
+	}
+	if amount > remaining {
+		return nil, &ProviderError{
+			StatusCode: http.StatusBadRequest,
+			Type:       "invalid_request_error",
+			Code:       "amount_too_large",
+			Message:    fmt.Sprintf("Refund amount %d is greater than the unrefunded amount %d", amount, remaining),
+		}
+	}
+
+	intent.refunded += amount
+	refund := &Refund{
+		ID:       p.nextID("re_fake"),
+		IntentID: intent.ID,
+		Amount:   amount,
+		Currency: intent.Currency,
+		Status:   "succeeded",
+	}
+	refund.Raw, _ = json.Marshal(refund)
+	p.refunds[refund.ID] = refund
+	if req.IdempotencyKey != "" {
+		p.keys[req.IdempotencyKey] = refund.ID
+	}
+
+	copied := *refund
+	return &copied, nil
+}
+
+// nextID returns the next sequential ID with the given prefix
+func (p *FakeProvider) nextID(prefix string) string {
+	p.seq++
+	return fmt.Sprintf("%s_%06d", prefix, p.seq)
+}
+
+// snapshot returns a copy of the intent with its raw JSON filled in, so
+// callers never share state with the provider
+func (i *fakeIntent) snapshot() *Intent {
+	intent := i.Intent
+	intent.Raw, _ = json.Marshal(&intent)
+	return &intent
+}
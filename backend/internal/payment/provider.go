@@ -0,0 +1,158 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrIntentNotFound = errors.New("payment intent not found")
+	ErrInvalidState   = errors.New("payment intent is not in a valid state for this operation")
+	ErrCardDeclined   = errors.New("card declined")
+)
+
+// IntentStatus mirrors the lifecycle of a payment intent
+type IntentStatus string
+
+const (
+	IntentRequiresPaymentMethod IntentStatus = "requires_payment_method"
+	IntentRequiresConfirmation  IntentStatus = "requires_confirmation"
+	IntentRequiresAction        IntentStatus = "requires_action"
+	IntentProcessing            IntentStatus = "processing"
+	IntentRequiresCapture       IntentStatus = "requires_capture"
+	IntentSucceeded             IntentStatus = "succeeded"
+	IntentCanceled              IntentStatus = "canceled"
+)
+
+// Capture methods
+const (
+	CaptureAutomatic = "automatic"
+	CaptureManual    = "manual"
+)
+
+// Intent is a provider-side payment intent. Amounts are in minor units.
+type Intent struct {
+	ID               string          `json:"id"`
+	Status           IntentStatus    `json:"status"`
+	Amount           int64           `json:"amount"`
+	AmountCaptured   int64           `json:"amount_received"`
+	Currency         string          `json:"currency"`
+	ClientSecret     string          `json:"client_secret"`
+	LastPaymentError string          `json:"-"`
+	Raw              json.RawMessage `json:"-"`
+}
+
+// Refund is a provider-side refund. Amounts are in minor units.
+type Refund struct {
+	ID       string          `json:"id"`
+	IntentID string          `json:"payment_intent"`
+	Amount   int64           `json:"amount"`
+	Currency string          `json:"currency"`
+	Status   string          `json:"status"` // pending, succeeded, failed, canceled
+	Raw      json.RawMessage `json:"-"`
+}
+
+// CreateIntentRequest describes a payment to collect
+type CreateIntentRequest struct {
+	Amount         int64
+	Currency       string
+	CaptureMethod  string
+	Description    string
+	Metadata       map[string]string
+	IdempotencyKey string
+}
+
+// RefundRequest describes a refund of a captured intent. A zero amount
+// refunds whatever remains.
+type RefundRequest struct {
+	IntentID       string
+	Amount         int64
+	Reason         string
+	IdempotencyKey string
+}
+
+// Provider is implemented by every payment gateway
+type Provider interface {
+	// Name identifies the provider, e.g. "stripe"
+	Name() string
+	// CreateIntent creates a payment intent for the given amount
+	CreateIntent(ctx context.Context, req *CreateIntentRequest) (*Intent, error)
+	// Confirm attaches a payment method to an intent and attempts the payment
+	Confirm(ctx context.Context, intentID, paymentMethod string) (*Intent, error)
+	// Capture captures an authorized intent. A zero amount captures in full.
+	Capture(ctx context.Context, intentID string, amount int64) (*Intent, error)
+	// Void cancels an intent that has not been captured
+	Void(ctx context.Context, intentID string) (*Intent, error)
+	// Refund refunds some or all of a captured intent
+	Refund(ctx context.Context, req *RefundRequest) (*Refund, error)
+}
+
+// ProviderError is returned when the provider rejects a request
+type ProviderError struct {
+	StatusCode  int
+	Type        string
+	Code        string
+	DeclineCode string
+	Message     string
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("payment provider error (%d %s/%s): %s", e.StatusCode, e.Type, e.Code, e.Message)
+}
+
+// Unwrap lets callers match card declines with errors.Is
+func (e *ProviderError) Unwrap() error {
+	if e.Type == "card_error" {
+		return ErrCardDeclined
+	}
+	return nil
+}
+
+// Retryable reports whether a request that failed with err may have been
+// carried out, or may succeed later: the provider was unreachable, limited
+// the rate or failed itself. Such requests are repeated under the same
+// idempotency key rather than given up.
+func Retryable(err error) bool {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.StatusCode == http.StatusTooManyRequests || providerErr.StatusCode >= http.StatusInternalServerError
+	}
+	return !errors.Is(err, ErrIntentNotFound) && !errors.Is(err, ErrInvalidState)
+}
+
+// currencyExponents lists the ISO 4217 currencies whose smallest unit is not
+// a hundredth, with the number of decimals they have
+var currencyExponents = map[string]int{
+	"bif": 0, "clp": 0, "djf": 0, "gnf": 0, "jpy": 0, "kmf": 0, "krw": 0, "mga": 0,
+	"pyg": 0, "rwf": 0, "ugx": 0, "vnd": 0, "vuv": 0, "xaf": 0, "xof": 0, "xpf": 0,
+	"bhd": 3, "jod": 3, "kwd": 3, "omr": 3, "tnd": 3,
+}
+
+// CurrencyExponent is the number of decimals of a currency, two for most
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[NormalizeCurrency(currency)]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// ToMinorUnits converts a decimal amount to the smallest unit of the currency
+func ToMinorUnits(amount float64, currency string) int64 {
+	return int64(math.Round(amount * math.Pow10(CurrencyExponent(currency))))
+}
+
+// FromMinorUnits converts an amount in the smallest unit of the currency to
+// a decimal amount
+func FromMinorUnits(amount int64, currency string) float64 {
+	return float64(amount) / math.Pow10(CurrencyExponent(currency))
+}
+
+// NormalizeCurrency lower-cases an ISO currency code the way providers expect it
+func NormalizeCurrency(currency string) string {
+	return strings.ToLower(currency)
+}
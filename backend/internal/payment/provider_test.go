@@ -0,0 +1,40 @@
+package payment
+
+import "testing"
+
+func TestMinorUnits(t *testing.T) {
+	tests := []struct {
+		currency string
+		amount   float64
+		minor    int64
+	}{
+		{"usd", 42.50, 4250},
+		{"EUR", 0.1 + 0.2, 30},
+		{"usd", 19.999, 2000},
+		{"jpy", 1500, 1500},
+		{"KRW", 12000, 12000},
+		{"jpy", 1499.6, 1500},
+		{"kwd", 12.345, 12345},
+		{"bhd", 0.5, 500},
+	}
+
+	for _, tt := range tests {
+		if got := ToMinorUnits(tt.amount, tt.currency); got != tt.minor {
+			t.Errorf("ToMinorUnits(%v, %s) = %d, want %d", tt.amount, tt.currency, got, tt.minor)
+		}
+	}
+
+	for _, tt := range []struct {
+		currency string
+		minor    int64
+		amount   float64
+	}{
+		{"usd", 4250, 42.50},
+		{"jpy", 1500, 1500},
+		{"kwd", 12345, 12.345},
+	} {
+		if got := FromMinorUnits(tt.minor, tt.currency); got != tt.amount {
+			t.Errorf("FromMinorUnits(%d, %s) = %v, want %v", tt.minor, tt.currency, got, tt.amount)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/tracing"
+)
+
+const stripeAPIBase = "https://api.stripe.com"
+
+// StripeProvider implements Provider on top of the Stripe PaymentIntents HTTP API
+type StripeProvider struct {
+	secretKey  string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewStripeProvider creates a Stripe provider authenticated with the secret key
+func NewStripeProvider(secretKey string) *StripeProvider {
+	return &StripeProvider{
+		secretKey:  secretKey,
+		baseURL:    stripeAPIBase,
+		httpClient: &http.Client{Timeout: 30 * time.Second, Transport: tracing.Transport(nil)},
+	}
+}
+
+// WithBaseURL points the provider at a different API host, e.g. stripe-mock
+func (p *StripeProvider) WithBaseURL(baseURL string) *StripeProvider {
+	p.baseURL = strings.TrimRight(baseURL, "/")
+	return p
+}
+
+// Name identifies the provider
+func (p *StripeProvider) Name() string {
+	return "stripe"
+}
+
+// CreateIntent creates a PaymentIntent
+func (p *StripeProvider) CreateIntent(ctx context.Context, req *CreateIntentRequest) (*Intent, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(req.Amount, 10))
+	form.Set("currency", NormalizeCurrency(req.Currency))
+	if req.CaptureMethod != "" {
+		form.Set("capture_method", req.CaptureMethod)
+	}
+	if req.Description != "" {
+		form.Set("description", req.Description)
+	}
+	for key, value := range req.Metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+
+	var intent Intent
+	if err := p.do(ctx, http.MethodPost, "/v1/payment_intents", form, req.IdempotencyKey, &intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+// Confirm confirms a PaymentIntent with the given payment method
+func (p *StripeProvider) Confirm(ctx context.Context, intentID, paymentMethod string) (*Intent, error) {
+	form := url.Values{}
+	form.Set("payment_method", paymentMethod)
+
+	var intent Intent
+	if err := p.do(ctx, http.MethodPost, "/v1/payment_intents/"+url.PathEscape(intentID)+"/confirm", form, "", &intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+// Capture captures an authorized PaymentIntent
+func (p *StripeProvider) Capture(ctx context.Context, intentID string, amount int64) (*Intent, error) {
+	form := url.Values{}
+	if amount > 0 {
+		form.Set("amount_to_capture", strconv.FormatInt(amount, 10))
+	}
+
+	var intent Intent
+	if err := p.do(ctx, http.MethodPost, "/v1/payment_intents/"+url.PathEscape(intentID)+"/capture", form, "", &intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+// Void cancels an uncaptured PaymentIntent
+func (p *StripeProvider) Void(ctx context.Context, intentID string) (*Intent, error) {
+	var intent Intent
+	if err := p.do(ctx, http.MethodPost, "/v1/payment_intents/"+url.PathEscape(intentID)+"/cancel", url.Values{}, "", &intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+// Refund refunds a captured PaymentIntent
+func (p *StripeProvider) Refund(ctx context.Context, req *RefundRequest) (*Refund, error) {
+	form := url.Values{}
+	form.Set("payment_intent", req.IntentID)
+	if req.Amount > 0 {
+		form.Set("amount", strconv.FormatInt(req.Amount, 10))
+	}
+	if req.Reason != "" {
+		// Stripe only accepts its own reason codes; keep ours in metadata
+		form.Set("metadata[reason]", req.Reason)
+	}
+
+	var refund Refund
+	if err := p.do(ctx, http.MethodPost, "/v1/refunds", form, req.IdempotencyKey, &refund); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// stripeErrorResponse is the error envelope returned by the Stripe API
+type stripeErrorResponse struct {
+	Error struct {
+		Type        string `json:"type"`
+		Code        string `json:"code"`
+		DeclineCode string `json:"decline_code"`
+		Message     string `json:"message"`
+	} `json:"error"`
+}
+
+// do sends a form-encoded request and decodes the JSON response into out.
+// The raw body is kept on intents and refunds for auditing.
+func (p *StripeProvider) do(ctx context.Context, method, path string, form url.Values, idempotencyKey string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.secretKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("stripe request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read stripe response: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		var errResp stripeErrorResponse
+		_ = json.Unmarshal(body, &errResp)
+		if resp.StatusCode == http.StatusNotFound && errResp.Error.Code == "resource_missing" {
+			return ErrIntentNotFound
+		}
+		if errResp.Error.Code == "payment_intent_unexpected_state" {
+			return fmt.Errorf("%w: %s", ErrInvalidState, errResp.Error.Message)
+		}
+		return &ProviderError{
+			StatusCode:  resp.StatusCode,
+			Type:        errResp.Error.Type,
+			Code:        errResp.Error.Code,
+			DeclineCode: errResp.Error.DeclineCode,
+			Message:     errResp.Error.Message,
+		}
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode stripe response: %w", err)
+	}
+
+	switch v := out.(type) {
+	case *Intent:
+		v.Raw = body
+		v.LastPaymentError = lastPaymentError(body)
+	case *Refund:
+		v.Raw = body
+	}
+	return nil
+}
+
+// lastPaymentError extracts the decline message of a failed intent
+func lastPaymentError(body []byte) string {
+	var intent struct {
+		LastPaymentError *struct {
+			Message string `json:"message"`
+		} `json:"last_payment_error"`
+	}
+	if err := json.Unmarshal(body, &intent); err != nil || intent.LastPaymentError == nil {
+		return ""
+	}
+	return intent.LastPaymentError.Message
+}
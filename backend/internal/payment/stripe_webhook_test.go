@@ -0,0 +1,49 @@
+package payment
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyStripeSignature(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"id":"evt_1","type":"payment_intent.succeeded"}`)
+	now := time.Unix(1700000000, 0)
+
+	signed := SignStripePayload(payload, secret, now)
+	_, current, _ := strings.Cut(signed, ",v1=")
+	_, rolled, _ := strings.Cut(SignStripePayload(payload, "whsec_old", now), ",v1=")
+
+	tests := []struct {
+		name    string
+		payload []byte
+		header  string
+		want    error
+	}{
+		{"valid", payload, signed, nil},
+		{"missing header", payload, "", ErrMissingSignature},
+		{"tampered payload", []byte(`{"id":"evt_1","type":"charge.refunded"}`), signed, ErrInvalidSignature},
+		{"tampered signature", payload, signed[:len(signed)-1] + "0", ErrInvalidSignature},
+		{"wrong secret", payload, SignStripePayload(payload, "whsec_other", now), ErrInvalidSignature},
+		{"expired timestamp", payload, SignStripePayload(payload, secret, now.Add(-DefaultWebhookTolerance-time.Second)), ErrSignatureExpired},
+		{"future timestamp", payload, SignStripePayload(payload, secret, now.Add(DefaultWebhookTolerance+time.Second)), ErrSignatureExpired},
+		{"timestamp within tolerance", payload, SignStripePayload(payload, secret, now.Add(-DefaultWebhookTolerance)), nil},
+		{"multiple v1 with the match last", payload, "t=1700000000,v1=" + rolled + ",v1=" + current, nil},
+		{"multiple v1 with the match first", payload, "t=1700000000,v1=" + current + ",v1=" + rolled, nil},
+		{"multiple v1 without a match", payload, "t=1700000000,v1=" + rolled + ",v1=" + strings.Repeat("0", len(current)), ErrInvalidSignature},
+		{"v0 only", payload, "t=1700000000,v0=" + current, ErrInvalidSignature},
+		{"missing timestamp", payload, "v1=" + current, ErrInvalidSignature},
+		{"malformed timestamp", payload, "t=yesterday,v1=" + current, ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyStripeSignature(tt.payload, tt.header, secret, DefaultWebhookTolerance, now)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("VerifyStripeSignature() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package pdf
+
+import (
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// fontFamily is the name the embedded fonts are registered under
+const fontFamily = "go"
+
+// addFonts embeds the Go fonts, which cover Latin, Greek and Cyrillic
+// scripts. Only the glyphs a document uses are written to it.
+func addFonts(f *fpdf.Fpdf) {
+	f.AddUTF8FontFromBytes(fontFamily, "", goregular.TTF)
+	f.AddUTF8FontFromBytes(fontFamily, "B", gobold.TTF)
+}
+
+// fontStyle is the fpdf style of a font
+func fontStyle(font Font) string {
+	if font == Bold {
+		return "B"
+	}
+	return ""
+}
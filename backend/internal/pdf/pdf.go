@@ -0,0 +1,142 @@
+// Package pdf draws simple text-and-line PDF documents with fpdf, embedding
+// the fonts so names and addresses in any script they cover print as
+// written
+package pdf
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// A4 page size in points
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Font is one of the embedded fonts
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+// Document is a PDF being assembled page by page. Pages are drawn when the
+// document is serialized, so earlier pages can still be added to once the
+// page count is known.
+type Document struct {
+	f     *fpdf.Fpdf
+	pages []*Page
+}
+
+// Page is a page of a document. Coordinates are in points from the top-left
+// corner.
+type Page struct {
+	doc *Document
+	ops []func(f *fpdf.Fpdf)
+}
+
+// New creates an empty document
+func New(title string) *Document {
+	f := fpdf.New("P", "pt", "A4", "")
+	f.SetMargins(0, 0, 0)
+	f.SetAutoPageBreak(false, 0)
+	f.SetTitle(title, true)
+	f.SetProducer("gophiway", false)
+	f.SetCreationDate(time.Now().UTC())
+	addFonts(f)
+	return &Document{f: f}
+}
+
+// AddPage appends a blank page
+func (d *Document) AddPage() *Page {
+	page := &Page{doc: d}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Pages is the number of pages added so far
+func (d *Document) Pages() int {
+	return len(d.pages)
+}
+
+// Page returns a page by its zero-based index
+func (d *Document) Page(i int) *Page {
+	return d.pages[i]
+}
+
+// Width is the width of text in points
+func (d *Document) Width(text string, font Font, size float64) float64 {
+	d.f.SetFont(fontFamily, fontStyle(font), size)
+	return d.f.GetStringWidth(text)
+}
+
+// Truncate shortens text to fit a width, ending it with an ellipsis
+func (d *Document) Truncate(text string, font Font, size, width float64) string {
+	if d.Width(text, font, size) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := string(runes) + "…"
+		if d.Width(candidate, font, size) <= width {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// Text draws text with its baseline starting at x, y
+func (p *Page) Text(x, y float64, font Font, size float64, text string) {
+	p.ops = append(p.ops, func(f *fpdf.Fpdf) {
+		f.SetFont(fontFamily, fontStyle(font), size)
+		f.Text(x, y, text)
+	})
+}
+
+// TextRight draws text ending at x
+func (p *Page) TextRight(x, y float64, font Font, size float64, text string) {
+	p.Text(x-p.doc.Width(text, font, size), y, font, size, text)
+}
+
+// Line draws a straight line
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	p.ops = append(p.ops, func(f *fpdf.Fpdf) {
+		f.SetLineWidth(width)
+		f.Line(x1, y1, x2, y2)
+	})
+}
+
+// FillRect fills a rectangle with a shade of grey, 0 being black and 1 white
+func (p *Page) FillRect(x, y, width, height, grey float64) {
+	level := int(grey*255 + 0.5)
+	p.ops = append(p.ops, func(f *fpdf.Fpdf) {
+		f.SetFillColor(level, level, level)
+		f.Rect(x, y, width, height, "F")
+	})
+}
+
+// Bytes draws the pages and serializes the document. The document cannot
+// be changed afterwards.
+func (d *Document) Bytes() ([]byte, error) {
+	pages := d.pages
+	if len(pages) == 0 {
+		pages = []*Page{{}}
+	}
+	for _, page := range pages {
+		d.f.AddPage()
+		for _, op := range page.ops {
+			op(d.f)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := d.f.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
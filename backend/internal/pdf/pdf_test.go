@@ -0,0 +1,47 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDocumentBytes(t *testing.T) {
+	doc := New("Invoice INV-000001")
+	for _, text := range []string{"Zoë Ångström", "Ελένη Παπαδοπούλου", "Дмитрий Иванов", "€ 1,234.50 – “net”"} {
+		page := doc.AddPage()
+		page.FillRect(40, 40, 200, 18, 0.9)
+		page.Text(44, 54, Bold, 9, text)
+		page.TextRight(555, 54, Regular, 9, text)
+		page.Line(40, 60, 555, 60, 0.5)
+	}
+
+	data, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) || !bytes.Contains(data, []byte("/Count 4")) {
+		t.Errorf("output is not a four page PDF: %.40q", data)
+	}
+	if !bytes.Contains(data, []byte("/FontFile2")) {
+		t.Error("fonts are not embedded")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	doc := New("Truncate")
+
+	short := "Дмитрий"
+	if got := doc.Truncate(short, Regular, 9, 200); got != short {
+		t.Errorf("Truncate(%q) = %q, want it unchanged", short, got)
+	}
+
+	long := "Ελένη Παπαδοπούλου, Θεσσαλονίκη"
+	width := doc.Width(long, Regular, 9) / 2
+	got := doc.Truncate(long, Regular, 9, width)
+	if got == long || doc.Width(got, Regular, 9) > width {
+		t.Errorf("Truncate(%q) = %q, want it within %.1f points", long, got, width)
+	}
+	if []rune(got)[len([]rune(got))-1] != '…' {
+		t.Errorf("Truncate(%q) = %q, want it to end with an ellipsis", long, got)
+	}
+}
@@ -0,0 +1,240 @@
+package pricing
+
+import (
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Discount sources
+const (
+	SourceCoupon    = "coupon"
+	SourcePromotion = "promotion"
+)
+
+// Line is a priced cart line
+type Line struct {
+	Reference   string       `json:"reference"` // cart item ID
+	ProductID   uuid.UUID    `json:"product_id"`
+	CategoryIDs []uuid.UUID  `json:"-"`
+	Name        string       `json:"name"`
+	UnitPrice   float64      `json:"unit_price"`
+	Quantity    int          `json:"quantity"`
+	Subtotal    float64      `json:"subtotal"` // before discounts
+	Discount    float64      `json:"discount"`
+	Total       float64      `json:"total"` // after discounts
+	Allocations []Allocation `json:"allocations,omitempty"`
+}
+
+// Allocation is the share of a discount assigned to a line
+type Allocation struct {
+	Source string  `json:"source"`
+	Code   string  `json:"code"`
+	Amount float64 `json:"amount"`
+}
+
+// Discount explains a discount applied to the cart
+type Discount struct {
+	Source       string  `json:"source"`
+	Code         string  `json:"code"`
+	Description  string  `json:"description"`
+	Amount       float64 `json:"amount"`
+	FreeShipping bool    `json:"free_shipping,omitempty"`
+}
+
+// Cart is a cart priced line by line
+type Cart struct {
+	Lines        []Line            `json:"lines"`
+	Subtotal     float64           `json:"subtotal"` // before discounts
+	Discount     float64           `json:"discount"`
+	Total        float64           `json:"total"` // after discounts, before shipping and tax
+	FreeShipping bool              `json:"free_shipping"`
+	Discounts    []Discount        `json:"discounts"`
+	Promotions   []PromotionResult `json:"promotions"`
+
+	// exclusive names the applied promotion that cannot be combined with
+	// further discounts
+	exclusive string
+}
+
+// NewCart prices lines at their unit prices before any discount
+func NewCart(lines []Line) *Cart {
+	cart := &Cart{Lines: lines, Discounts: []Discount{}, Promotions: []PromotionResult{}}
+	for i := range cart.Lines {
+		line := &cart.Lines[i]
+		line.Subtotal = Round(line.UnitPrice * float64(line.Quantity))
+		line.Discount = 0
+		line.Total = line.Subtotal
+		line.Allocations = nil
+	}
+	cart.total()
+	return cart
+}
+
+// Apply records a discount and its allocation to lines, keyed by line index
+func (c *Cart) Apply(discount Discount, allocations map[int]float64) {
+	var amount float64
+	for i, share := range allocations {
+		share = Round(share)
+		if share <= 0 {
+			continue
+		}
+		line := &c.Lines[i]
+		line.Discount = Round(line.Discount + share)
+		line.Total = Round(line.Subtotal - line.Discount)
+		line.Allocations = append(line.Allocations, Allocation{
+			Source: discount.Source,
+			Code:   discount.Code,
+			Amount: share,
+		})
+		amount += share
+	}
+
+	discount.Amount = Round(amount)
+	if discount.FreeShipping {
+		c.FreeShipping = true
+	}
+	c.Discounts = append(c.Discounts, discount)
+	c.total()
+}
+
+// total recomputes the cart totals from its lines
+func (c *Cart) total() {
+	c.Subtotal, c.Discount = 0, 0
+	for _, line := range c.Lines {
+		c.Subtotal += line.Subtotal
+		c.Discount += line.Discount
+	}
+	c.Subtotal = Round(c.Subtotal)
+	c.Discount = Round(c.Discount)
+	c.Total = Round(c.Subtotal - c.Discount)
+}
+
+// Eligible returns the indexes of lines matching any of the products or
+// categories; with neither, every line is eligible
+func (c *Cart) Eligible(productIDs, categoryIDs []uuid.UUID) []int {
+	var indexes []int
+	for i, line := range c.Lines {
+		if len(productIDs) == 0 && len(categoryIDs) == 0 {
+			indexes = append(indexes, i)
+			continue
+		}
+		if contains(productIDs, line.ProductID) {
+			indexes = append(indexes, i)
+			continue
+		}
+		for _, id := range line.CategoryIDs {
+			if contains(categoryIDs, id) {
+				indexes = append(indexes, i)
+				break
+			}
+		}
+	}
+	return indexes
+}
+
+// NetTotal is the discounted total of some lines
+func (c *Cart) NetTotal(indexes []int) float64 {
+	var total float64
+	for _, i := range indexes {
+		total += c.Lines[i].Total
+	}
+	return Round(total)
+}
+
+// Quantity is the number of units in some lines
+func (c *Cart) Quantity(indexes []int) int {
+	var quantity int
+	for _, i := range indexes {
+		quantity += c.Lines[i].Quantity
+	}
+	return quantity
+}
+
+// PercentOff allocates a percentage of each line's remaining total
+func (c *Cart) PercentOff(indexes []int, percent float64) map[int]float64 {
+	allocations := make(map[int]float64, len(indexes))
+	for _, i := range indexes {
+		allocations[i] = Round(c.Lines[i].Total * percent / 100)
+	}
+	return allocations
+}
+
+// AmountOff spreads a fixed amount across lines in proportion to their
+// remaining totals, capped at those totals. Rounding differences go to the
+// largest line so the shares add up exactly.
+func (c *Cart) AmountOff(indexes []int, amount float64) map[int]float64 {
+	base := c.NetTotal(indexes)
+	if base <= 0 || amount <= 0 {
+		return map[int]float64{}
+	}
+	amount = Round(math.Min(amount, base))
+
+	allocations := make(map[int]float64, len(indexes))
+	largest, allocated := -1, 0.0
+	for _, i := range indexes {
+		share := Round(amount * c.Lines[i].Total / base)
+		allocations[i] = share
+		allocated += share
+		if largest < 0 || c.Lines[i].Total > c.Lines[largest].Total {
+			largest = i
+		}
+	}
+	if largest >= 0 {
+		allocations[largest] = Round(allocations[largest] + amount - allocated)
+	}
+	return allocations
+}
+
+// BuyXGetY discounts the cheapest units of every group of buy+get units by
+// percent, grouping the most expensive units first
+func (c *Cart) BuyXGetY(indexes []int, buy, get int, percent float64) map[int]float64 {
+	if buy < 1 || get < 1 {
+		return map[int]float64{}
+	}
+
+	type unit struct {
+		line  int
+		price float64
+	}
+	var units []unit
+	for _, i := range indexes {
+		line := c.Lines[i]
+		if line.Quantity == 0 {
+			continue
+		}
+		// Earlier discounts lower the unit price proportionally
+		price := line.Total / float64(line.Quantity)
+		for n := 0; n < line.Quantity; n++ {
+			units = append(units, unit{line: i, price: price})
+		}
+	}
+	sort.SliceStable(units, func(a, b int) bool { return units[a].price > units[b].price })
+
+	allocations := make(map[int]float64)
+	group := buy + get
+	for start := 0; start+group <= len(units); start += group {
+		for _, u := range units[start+buy : start+group] {
+			allocations[u.line] += u.price * percent / 100
+		}
+	}
+	for i, share := range allocations {
+		allocations[i] = Round(share)
+	}
+	return allocations
+}
+
+// Round rounds an amount to whole cents
+func Round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func contains(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
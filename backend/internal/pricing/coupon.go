@@ -0,0 +1,53 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Shihasz/gophiway/internal/models"
+)
+
+var ErrCouponNotApplicable = errors.New("coupon does not apply to this cart")
+
+// ApplyCoupon checks a coupon's cart conditions and allocates its discount to
+// the eligible lines. Customer conditions such as usage limits are checked
+// by the caller.
+func ApplyCoupon(cart *Cart, coupon *models.Coupon) error {
+	if cart.exclusive != "" {
+		return fmt.Errorf("%w: cannot be combined with %s", ErrCouponNotApplicable, cart.exclusive)
+	}
+	if coupon.MinSubtotal > 0 && cart.Total < coupon.MinSubtotal {
+		return fmt.Errorf("%w: requires a subtotal of at least %.2f", ErrCouponNotApplicable, coupon.MinSubtotal)
+	}
+
+	eligible := cart.Eligible(coupon.ProductIDs, coupon.CategoryIDs)
+	if len(eligible) == 0 {
+		return fmt.Errorf("%w: no eligible products in the cart", ErrCouponNotApplicable)
+	}
+
+	discount := Discount{
+		Source:      SourceCoupon,
+		Code:        coupon.Code,
+		Description: coupon.Description,
+	}
+
+	var allocations map[int]float64
+	switch coupon.Type {
+	case models.CouponTypePercentage:
+		allocations = cart.PercentOff(eligible, coupon.Value)
+	case models.CouponTypeFixedAmount:
+		allocations = cart.AmountOff(eligible, coupon.Value)
+	case models.CouponTypeFreeShipping:
+		discount.FreeShipping = true
+	case models.CouponTypeBuyXGetY:
+		if cart.Quantity(eligible) < coupon.BuyQuantity+coupon.GetQuantity {
+			return fmt.Errorf("%w: add %d eligible items to get %d discounted", ErrCouponNotApplicable, coupon.BuyQuantity, coupon.GetQuantity)
+		}
+		allocations = cart.BuyXGetY(eligible, coupon.BuyQuantity, coupon.GetQuantity, coupon.Value)
+	default:
+		return fmt.Errorf("%w: unknown coupon type %q", ErrCouponNotApplicable, coupon.Type)
+	}
+
+	cart.Apply(discount, allocations)
+	return nil
+}
@@ -0,0 +1,170 @@
+package pricing
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	shirt   = uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	hat     = uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+	socks   = uuid.MustParse("00000000-0000-0000-0000-00000000000c")
+	apparel = uuid.MustParse("00000000-0000-0000-0000-0000000000f1")
+	extras  = uuid.MustParse("00000000-0000-0000-0000-0000000000f2")
+)
+
+// testLines is a cart of 104.97
+func testLines() []Line {
+	return []Line{
+		{Reference: "shirt", ProductID: shirt, CategoryIDs: []uuid.UUID{apparel}, UnitPrice: 30, Quantity: 2},
+		{Reference: "hat", ProductID: hat, UnitPrice: 15, Quantity: 1},
+		{Reference: "socks", ProductID: socks, CategoryIDs: []uuid.UUID{extras}, UnitPrice: 9.99, Quantity: 3},
+	}
+}
+
+// lineDiscounts maps each discounted line's reference to its discount
+func lineDiscounts(cart *Cart) map[string]float64 {
+	discounts := map[string]float64{}
+	for _, line := range cart.Lines {
+		if line.Discount != 0 {
+			discounts[line.Reference] = line.Discount
+		}
+	}
+	return discounts
+}
+
+func TestApplyCoupon(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []Line
+		exclusive    string
+		coupon       models.Coupon
+		discounts    map[string]float64
+		freeShipping bool
+		err          bool
+	}{
+		{
+			name:      "percentage rounds each line",
+			coupon:    models.Coupon{Type: models.CouponTypePercentage, Value: 10},
+			discounts: map[string]float64{"shirt": 6, "hat": 1.5, "socks": 3},
+		},
+		{
+			name:      "percentage on a category",
+			coupon:    models.Coupon{Type: models.CouponTypePercentage, Value: 15, CategoryIDs: []uuid.UUID{apparel}},
+			discounts: map[string]float64{"shirt": 9},
+		},
+		{
+			name:      "fixed amount in proportion",
+			coupon:    models.Coupon{Type: models.CouponTypeFixedAmount, Value: 20},
+			discounts: map[string]float64{"shirt": 11.43, "hat": 2.86, "socks": 5.71},
+		},
+		{
+			name:      "fixed amount capped at the eligible total",
+			coupon:    models.Coupon{Type: models.CouponTypeFixedAmount, Value: 25, ProductIDs: []uuid.UUID{hat}},
+			discounts: map[string]float64{"hat": 15},
+		},
+		{
+			name: "fixed amount remainder goes to the largest line",
+			lines: []Line{
+				{Reference: "a", UnitPrice: 10, Quantity: 1},
+				{Reference: "b", UnitPrice: 10, Quantity: 1},
+				{Reference: "c", UnitPrice: 10, Quantity: 1},
+			},
+			coupon:    models.Coupon{Type: models.CouponTypeFixedAmount, Value: 0.1},
+			discounts: map[string]float64{"a": 0.04, "b": 0.03, "c": 0.03},
+		},
+		{
+			name:         "free shipping at the minimum subtotal",
+			coupon:       models.Coupon{Type: models.CouponTypeFreeShipping, MinSubtotal: 104.97},
+			discounts:    map[string]float64{},
+			freeShipping: true,
+		},
+		{
+			name:   "below the minimum subtotal",
+			coupon: models.Coupon{Type: models.CouponTypePercentage, Value: 10, MinSubtotal: 104.98},
+			err:    true,
+		},
+		{
+			name:   "no eligible products",
+			coupon: models.Coupon{Type: models.CouponTypePercentage, Value: 10, ProductIDs: []uuid.UUID{uuid.New()}},
+			err:    true,
+		},
+		{
+			name:      "buy two get one free across lines",
+			coupon:    models.Coupon{Type: models.CouponTypeBuyXGetY, Value: 100, BuyQuantity: 2, GetQuantity: 1},
+			discounts: map[string]float64{"hat": 15, "socks": 9.99},
+		},
+		{
+			name:      "buy one get one half off",
+			coupon:    models.Coupon{Type: models.CouponTypeBuyXGetY, Value: 50, BuyQuantity: 1, GetQuantity: 1, ProductIDs: []uuid.UUID{shirt}},
+			discounts: map[string]float64{"shirt": 15},
+		},
+		{
+			name:   "too few units for buy x get y",
+			coupon: models.Coupon{Type: models.CouponTypeBuyXGetY, Value: 100, BuyQuantity: 3, GetQuantity: 1, CategoryIDs: []uuid.UUID{extras}},
+			err:    true,
+		},
+		{
+			name:      "after an exclusive promotion",
+			exclusive: "Clearance",
+			coupon:    models.Coupon{Type: models.CouponTypePercentage, Value: 10},
+			err:       true,
+		},
+		{
+			name:   "unknown type",
+			coupon: models.Coupon{Type: "mystery", Value: 10},
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := tt.lines
+			if lines == nil {
+				lines = testLines()
+			}
+			cart := NewCart(lines)
+			cart.exclusive = tt.exclusive
+			subtotal := cart.Subtotal
+			tt.coupon.Code = "SAVE"
+
+			err := ApplyCoupon(cart, &tt.coupon)
+			if tt.err {
+				if !errors.Is(err, ErrCouponNotApplicable) {
+					t.Fatalf("ApplyCoupon error = %v, want ErrCouponNotApplicable", err)
+				}
+				if cart.Discount != 0 || len(cart.Discounts) != 0 {
+					t.Errorf("cart discounted by %.2f after a rejected coupon", cart.Discount)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyCoupon: %v", err)
+			}
+
+			if got := lineDiscounts(cart); !reflect.DeepEqual(got, tt.discounts) {
+				t.Errorf("line discounts = %v, want %v", got, tt.discounts)
+			}
+			if cart.FreeShipping != tt.freeShipping {
+				t.Errorf("FreeShipping = %v, want %v", cart.FreeShipping, tt.freeShipping)
+			}
+
+			var amount float64
+			for _, discount := range tt.discounts {
+				amount += discount
+			}
+			amount = Round(amount)
+			if cart.Discount != amount || cart.Total != Round(subtotal-amount) {
+				t.Errorf("cart discount %.2f total %.2f, want discount %.2f total %.2f",
+					cart.Discount, cart.Total, amount, Round(subtotal-amount))
+			}
+			if len(cart.Discounts) != 1 || cart.Discounts[0].Code != "SAVE" || cart.Discounts[0].Amount != amount {
+				t.Errorf("discounts = %+v, want one SAVE discount of %.2f", cart.Discounts, amount)
+			}
+		})
+	}
+}
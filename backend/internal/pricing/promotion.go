@@ -0,0 +1,193 @@
+package pricing
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+)
+
+// PromotionResult explains whether a promotion applied to the cart and why
+type PromotionResult struct {
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Applied bool      `json:"applied"`
+	Amount  float64   `json:"amount"`
+	Reason  string    `json:"reason"`
+}
+
+// ApplyPromotions evaluates promotions by descending priority and applies
+// each one whose rules the cart meets. A promotion that is not stackable
+// only applies to a cart without other promotions, and once applied no
+// further promotion or coupon does. Every outcome is recorded on the cart.
+func ApplyPromotions(cart *Cart, promotions []models.Promotion) {
+	ordered := make([]models.Promotion, len(promotions))
+	copy(ordered, promotions)
+	sort.SliceStable(ordered, func(a, b int) bool { return ordered[a].Priority > ordered[b].Priority })
+
+	applied := 0
+	for i := range ordered {
+		promotion := &ordered[i]
+		result := PromotionResult{ID: promotion.ID, Name: promotion.Name}
+
+		switch {
+		case cart.exclusive != "":
+			result.Reason = fmt.Sprintf("cannot be combined with %s", cart.exclusive)
+		case !promotion.Stackable && applied > 0:
+			result.Reason = "cannot be combined with other promotions"
+		default:
+			result.Reason, result.Applied = applyPromotion(cart, promotion)
+		}
+
+		if result.Applied {
+			result.Amount = cart.Discounts[len(cart.Discounts)-1].Amount
+			applied++
+			if !promotion.Stackable {
+				cart.exclusive = promotion.Name
+			}
+		}
+		cart.Promotions = append(cart.Promotions, result)
+	}
+}
+
+// applyPromotion applies a promotion if the cart meets its rules, returning
+// the reason it did or did not apply
+func applyPromotion(cart *Cart, promotion *models.Promotion) (string, bool) {
+	if promotion.MinSubtotal > 0 && cart.Total < promotion.MinSubtotal {
+		return fmt.Sprintf("requires a subtotal of at least %.2f", promotion.MinSubtotal), false
+	}
+
+	if promotion.Type == models.PromotionTypeBundlePrice {
+		return applyBundle(cart, promotion)
+	}
+
+	eligible := cart.Eligible(promotion.ProductIDs, promotion.CategoryIDs)
+	if len(eligible) == 0 {
+		return "no eligible products in the cart", false
+	}
+	units := cart.Quantity(eligible)
+	if units < promotion.MinQuantity {
+		return fmt.Sprintf("requires %d eligible items, cart has %d", promotion.MinQuantity, units), false
+	}
+
+	discount := Discount{
+		Source:      SourcePromotion,
+		Code:        promotion.ID.String(),
+		Description: promotion.Name,
+	}
+
+	var allocations map[int]float64
+	var reason string
+	switch promotion.Type {
+	case models.PromotionTypePercentage:
+		allocations = cart.PercentOff(eligible, promotion.Value)
+		reason = fmt.Sprintf("%s%% off %d eligible items", formatValue(promotion.Value), units)
+	case models.PromotionTypeFixedAmount:
+		allocations = cart.AmountOff(eligible, promotion.Value)
+		reason = fmt.Sprintf("%.2f off %d eligible items", promotion.Value, units)
+	case models.PromotionTypeFreeShipping:
+		discount.FreeShipping = true
+		reason = "free shipping"
+	default:
+		return fmt.Sprintf("unknown promotion type %q", promotion.Type), false
+	}
+
+	if !discount.FreeShipping && total(allocations) <= 0 {
+		return "no discount left on the eligible items", false
+	}
+	cart.Apply(discount, allocations)
+	return reason, true
+}
+
+// applyBundle prices every complete set of the bundle's products at the
+// bundle price, spreading each set's saving across its products
+func applyBundle(cart *Cart, promotion *models.Promotion) (string, bool) {
+	required := make(map[uuid.UUID]int)
+	for _, id := range promotion.ProductIDs {
+		required[id]++
+	}
+	if len(required) == 0 {
+		return "bundle has no products", false
+	}
+
+	lines := make(map[uuid.UUID]int, len(required))
+	for i, line := range cart.Lines {
+		if _, ok := required[line.ProductID]; ok {
+			if _, seen := lines[line.ProductID]; !seen {
+				lines[line.ProductID] = i
+			}
+		}
+	}
+	if len(lines) < len(required) {
+		return fmt.Sprintf("bundle is incomplete: %d of %d products in the cart", len(lines), len(required)), false
+	}
+
+	// Complete sets and the current price of one set
+	sets := math.MaxInt
+	var setPrice float64
+	for id, quantity := range required {
+		line := cart.Lines[lines[id]]
+		if n := line.Quantity / quantity; n < sets {
+			sets = n
+		}
+		if line.Quantity > 0 {
+			setPrice += line.Total / float64(line.Quantity) * float64(quantity)
+		}
+	}
+	if sets == 0 {
+		return "bundle is incomplete: add more of the bundled products", false
+	}
+	saving := setPrice - promotion.Value
+	if saving <= 0 {
+		return "bundled products already cost less than the bundle price", false
+	}
+
+	indexes := make([]int, 0, len(lines))
+	for _, i := range lines {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	amount := Round(saving * float64(sets))
+	allocations := make(map[int]float64, len(indexes))
+	largest, allocated := -1, 0.0
+	for _, i := range indexes {
+		line := cart.Lines[i]
+		share := Round(line.Total / float64(line.Quantity) * float64(required[line.ProductID]*sets) / setPrice * saving)
+		allocations[i] = share
+		allocated += share
+		if largest < 0 || line.Total > cart.Lines[largest].Total {
+			largest = i
+		}
+	}
+	allocations[largest] = Round(allocations[largest] + amount - allocated)
+
+	cart.Apply(Discount{
+		Source:      SourcePromotion,
+		Code:        promotion.ID.String(),
+		Description: promotion.Name,
+	}, allocations)
+
+	unit := "bundle"
+	if sets > 1 {
+		unit = "bundles"
+	}
+	return fmt.Sprintf("%d %s at %.2f each", sets, unit, promotion.Value), true
+}
+
+// total sums allocations after rounding
+func total(allocations map[int]float64) float64 {
+	var sum float64
+	for _, share := range allocations {
+		sum += Round(share)
+	}
+	return Round(sum)
+}
+
+// formatValue prints a percentage without trailing zeros
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
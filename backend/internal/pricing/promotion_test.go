@@ -0,0 +1,176 @@
+package pricing
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+)
+
+func promotion(name string, promotionType models.PromotionType, value float64, priority int, stackable bool) models.Promotion {
+	return models.Promotion{Name: name, Type: promotionType, Value: value, Priority: priority, Stackable: stackable, IsActive: true}
+}
+
+func TestApplyPromotions(t *testing.T) {
+	minQuantity := func(p models.Promotion, n int) models.Promotion {
+		p.MinQuantity = n
+		return p
+	}
+	minSubtotal := func(p models.Promotion, amount float64) models.Promotion {
+		p.MinSubtotal = amount
+		return p
+	}
+	products := func(p models.Promotion, ids ...uuid.UUID) models.Promotion {
+		p.ProductIDs = ids
+		return p
+	}
+	categories := func(p models.Promotion, ids ...uuid.UUID) models.Promotion {
+		p.CategoryIDs = ids
+		return p
+	}
+
+	tests := []struct {
+		name         string
+		promotions   []models.Promotion
+		results      []PromotionResult
+		discounts    map[string]float64
+		freeShipping bool
+		exclusive    bool
+	}{
+		{
+			name: "stackable promotions apply by priority",
+			promotions: []models.Promotion{
+				categories(promotion("Apparel", models.PromotionTypePercentage, 10, 1, true), apparel),
+				promotion("Five off", models.PromotionTypeFixedAmount, 5, 5, true),
+			},
+			results: []PromotionResult{
+				{Name: "Five off", Applied: true, Amount: 5, Reason: "5.00 off 6 eligible items"},
+				{Name: "Apparel", Applied: true, Amount: 5.71, Reason: "10% off 2 eligible items"},
+			},
+			discounts: map[string]float64{"shirt": 8.57, "hat": 0.71, "socks": 1.43},
+		},
+		{
+			name: "equal priorities keep their order",
+			promotions: []models.Promotion{
+				promotion("First", models.PromotionTypePercentage, 50, 3, true),
+				promotion("Second", models.PromotionTypePercentage, 50, 3, true),
+			},
+			results: []PromotionResult{
+				{Name: "First", Applied: true, Amount: 52.49, Reason: "50% off 6 eligible items"},
+				{Name: "Second", Applied: true, Amount: 26.24, Reason: "50% off 6 eligible items"},
+			},
+			discounts: map[string]float64{"shirt": 45, "hat": 11.25, "socks": 22.48},
+		},
+		{
+			name: "non-stackable skipped after another applied",
+			promotions: []models.Promotion{
+				promotion("Twenty off", models.PromotionTypeFixedAmount, 20, 1, false),
+				promotion("Ten percent", models.PromotionTypePercentage, 10, 2, true),
+			},
+			results: []PromotionResult{
+				{Name: "Ten percent", Applied: true, Amount: 10.5, Reason: "10% off 6 eligible items"},
+				{Name: "Twenty off", Reason: "cannot be combined with other promotions"},
+			},
+			discounts: map[string]float64{"shirt": 6, "hat": 1.5, "socks": 3},
+		},
+		{
+			name: "non-stackable first blocks later promotions and coupons",
+			promotions: []models.Promotion{
+				promotion("Free delivery", models.PromotionTypeFreeShipping, 0, 1, true),
+				promotion("Clearance", models.PromotionTypePercentage, 20, 9, false),
+			},
+			results: []PromotionResult{
+				{Name: "Clearance", Applied: true, Amount: 20.99, Reason: "20% off 6 eligible items"},
+				{Name: "Free delivery", Reason: "cannot be combined with Clearance"},
+			},
+			discounts: map[string]float64{"shirt": 12, "hat": 3, "socks": 5.99},
+			exclusive: true,
+		},
+		{
+			name: "minimum quantity of eligible items",
+			promotions: []models.Promotion{
+				minQuantity(categories(promotion("Four socks", models.PromotionTypePercentage, 10, 2, true), extras), 4),
+				minQuantity(categories(promotion("Three socks", models.PromotionTypePercentage, 10, 1, true), extras), 3),
+			},
+			results: []PromotionResult{
+				{Name: "Four socks", Reason: "requires 4 eligible items, cart has 3"},
+				{Name: "Three socks", Applied: true, Amount: 3, Reason: "10% off 3 eligible items"},
+			},
+			discounts: map[string]float64{"socks": 3},
+		},
+		{
+			name: "minimum subtotal",
+			promotions: []models.Promotion{
+				minSubtotal(promotion("Big spender", models.PromotionTypeFreeShipping, 0, 1, false), 200),
+				minSubtotal(promotion("Free delivery", models.PromotionTypeFreeShipping, 0, 0, false), 104.97),
+			},
+			results: []PromotionResult{
+				{Name: "Big spender", Reason: "requires a subtotal of at least 200.00"},
+				{Name: "Free delivery", Applied: true, Reason: "free shipping"},
+			},
+			discounts:    map[string]float64{},
+			freeShipping: true,
+			exclusive:    true,
+		},
+		{
+			name:       "bundle price",
+			promotions: []models.Promotion{products(promotion("Shirt and hat", models.PromotionTypeBundlePrice, 40, 1, true), shirt, hat)},
+			results:    []PromotionResult{{Name: "Shirt and hat", Applied: true, Amount: 5, Reason: "1 bundle at 40.00 each"}},
+			discounts:  map[string]float64{"shirt": 3.33, "hat": 1.67},
+		},
+		{
+			name:       "bundle of two sets",
+			promotions: []models.Promotion{products(promotion("Two socks", models.PromotionTypeBundlePrice, 15, 1, true), socks, socks)},
+			results:    []PromotionResult{{Name: "Two socks", Applied: true, Amount: 4.98, Reason: "1 bundle at 15.00 each"}},
+			discounts:  map[string]float64{"socks": 4.98},
+		},
+		{
+			name:       "incomplete bundle",
+			promotions: []models.Promotion{products(promotion("Shirt and tie", models.PromotionTypeBundlePrice, 40, 1, true), shirt, uuid.New())},
+			results:    []PromotionResult{{Name: "Shirt and tie", Reason: "bundle is incomplete: 1 of 2 products in the cart"}},
+			discounts:  map[string]float64{},
+		},
+		{
+			name:       "bundle dearer than its products",
+			promotions: []models.Promotion{products(promotion("Shirt and hat", models.PromotionTypeBundlePrice, 50, 1, true), shirt, hat)},
+			results:    []PromotionResult{{Name: "Shirt and hat", Reason: "bundled products already cost less than the bundle price"}},
+			discounts:  map[string]float64{},
+		},
+		{
+			name: "no discount left",
+			promotions: []models.Promotion{
+				products(promotion("Free hat", models.PromotionTypeFixedAmount, 200, 2, true), hat),
+				products(promotion("Hat sale", models.PromotionTypePercentage, 10, 1, true), hat),
+			},
+			results: []PromotionResult{
+				{Name: "Free hat", Applied: true, Amount: 15, Reason: "200.00 off 1 eligible items"},
+				{Name: "Hat sale", Reason: "no discount left on the eligible items"},
+			},
+			discounts: map[string]float64{"hat": 15},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cart := NewCart(testLines())
+			ApplyPromotions(cart, tt.promotions)
+
+			if !reflect.DeepEqual(cart.Promotions, tt.results) {
+				t.Errorf("results = %+v, want %+v", cart.Promotions, tt.results)
+			}
+			if got := lineDiscounts(cart); !reflect.DeepEqual(got, tt.discounts) {
+				t.Errorf("line discounts = %v, want %v", got, tt.discounts)
+			}
+			if cart.FreeShipping != tt.freeShipping {
+				t.Errorf("FreeShipping = %v, want %v", cart.FreeShipping, tt.freeShipping)
+			}
+
+			err := ApplyCoupon(cart, &models.Coupon{Code: "SHIP", Type: models.CouponTypeFreeShipping})
+			if blocked := errors.Is(err, ErrCouponNotApplicable); blocked != tt.exclusive {
+				t.Errorf("coupon after promotions: error = %v, want blocked %v", err, tt.exclusive)
+			}
+		})
+	}
+}
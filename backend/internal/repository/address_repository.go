@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddressRepository stores user addresses
+type AddressRepository interface {
+	WithTx(tx *gorm.DB) AddressRepository
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
+	LockUser(ctx context.Context, userID uuid.UUID) error
+	Create(ctx context.Context, address *models.Address) error
+	Update(ctx context.Context, address *models.Address) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetUserAddress(ctx context.Context, userID, id uuid.UUID) (*models.Address, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]models.Address, error)
+	CountByType(ctx context.Context, userID uuid.UUID, addressType string) (int64, error)
+	ClearDefault(ctx context.Context, userID uuid.UUID, addressType string) error
+	GetLatestByType(ctx context.Context, userID uuid.UUID, addressType string) (*models.Address, error)
+}
+
+type addressRepository struct {
+	db *gorm.DB
+}
+
+func NewAddressRepository(db *gorm.DB) AddressRepository {
+	return &addressRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *addressRepository) WithTx(tx *gorm.DB) AddressRepository {
+	return &addressRepository{db: tx}
+}
+
+// Transaction runs fn in a database transaction
+func (r *addressRepository) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return r.db.WithContext(ctx).Transaction(fn)
+}
+
+// LockUser locks the user's row until the surrounding transaction ends,
+// serializing changes to their address book
+func (r *addressRepository) LockUser(ctx context.Context, userID uuid.UUID) error {
+	var user models.User
+	return r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		First(&user, "id = ?", userID).Error
+}
+
+// Create creates an address
+func (r *addressRepository) Create(ctx context.Context, address *models.Address) error {
+	return r.db.WithContext(ctx).Create(address).Error
+}
+
+// Update saves an address
+func (r *addressRepository) Update(ctx context.Context, address *models.Address) error {
+	return r.db.WithContext(ctx).Save(address).Error
+}
+
+// Delete soft deletes an address
+func (r *addressRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.Address{}, "id = ?", id).Error
+}
+
+// GetUserAddress gets one of the user's addresses
+func (r *addressRepository) GetUserAddress(ctx context.Context, userID, id uuid.UUID) (*models.Address, error) {
+	var address models.Address
+	err := r.db.WithContext(ctx).First(&address, "id = ? AND user_id = ?", id, userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// ListByUser lists the user's addresses, defaults first
+func (r *addressRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]models.Address, error) {
+	var addresses []models.Address
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("type ASC, is_default DESC, created_at DESC").
+		Find(&addresses).Error
+	return addresses, err
+}
+
+// CountByType counts the user's addresses of a type
+func (r *addressRepository) CountByType(ctx context.Context, userID uuid.UUID, addressType string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Address{}).
+		Where("user_id = ? AND type = ?", userID, addressType).
+		Count(&count).Error
+	return count, err
+}
+
+// ClearDefault unsets the user's default address of a type
+func (r *addressRepository) ClearDefault(ctx context.Context, userID uuid.UUID, addressType string) error {
+	return r.db.WithContext(ctx).Model(&models.Address{}).
+		Where("user_id = ? AND type = ? AND is_default = ?", userID, addressType, true).
+		Update("is_default", false).Error
+}
+
+// GetLatestByType gets the user's most recently added address of a type
+func (r *addressRepository) GetLatestByType(ctx context.Context, userID uuid.UUID, addressType string) (*models.Address, error) {
+	var address models.Address
+	err := r.db.WithContext(ctx).Where("user_id = ? AND type = ?", userID, addressType).
+		Order("created_at DESC").
+		First(&address).Error
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
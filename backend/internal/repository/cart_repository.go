@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CartRepository stores shopping carts and their items
+type CartRepository interface {
+	WithTx(tx *gorm.DB) CartRepository
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Cart, error)
+	ClearItems(ctx context.Context, cartID uuid.UUID) error
+	SetCouponCode(ctx context.Context, cartID uuid.UUID, code string) error
+}
+
+type cartRepository struct {
+	db *gorm.DB
+}
+
+func NewCartRepository(db *gorm.DB) CartRepository {
+	return &cartRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *cartRepository) WithTx(tx *gorm.DB) CartRepository {
+	return &cartRepository{db: tx}
+}
+
+// GetByUserID gets the user's cart with its items and their products
+func (r *cartRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Cart, error) {
+	var cart models.Cart
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Items.Product").
+		First(&cart, "user_id = ?", userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// ClearItems removes every item from a cart
+func (r *cartRepository) ClearItems(ctx context.Context, cartID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("cart_id = ?", cartID).Delete(&models.CartItem{}).Error
+}
+
+// SetCouponCode sets or, with an empty code, clears the cart's coupon
+func (r *cartRepository) SetCouponCode(ctx context.Context, cartID uuid.UUID, code string) error {
+	return r.db.WithContext(ctx).Model(&models.Cart{}).Where("id = ?", cartID).Update("coupon_code", code).Error
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CouponRepository stores coupons and their redemptions
+type CouponRepository interface {
+	WithTx(tx *gorm.DB) CouponRepository
+	Create(ctx context.Context, coupon *models.Coupon) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Coupon, error)
+	GetByCode(ctx context.Context, code string) (*models.Coupon, error)
+	GetByCodeForUpdate(ctx context.Context, code string) (*models.Coupon, error)
+	List(ctx context.Context) ([]models.Coupon, error)
+	Update(ctx context.Context, coupon *models.Coupon) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	IncrementUsage(ctx context.Context, id uuid.UUID) (bool, error)
+	DecrementUsage(ctx context.Context, id uuid.UUID) error
+	CountUserRedemptions(ctx context.Context, couponID, userID uuid.UUID) (int64, error)
+	CreateRedemption(ctx context.Context, redemption *models.CouponRedemption) error
+	GetRedemptionByOrder(ctx context.Context, orderID uuid.UUID) (*models.CouponRedemption, error)
+	DeleteRedemption(ctx context.Context, id uuid.UUID) error
+}
+
+type couponRepository struct {
+	db *gorm.DB
+}
+
+func NewCouponRepository(db *gorm.DB) CouponRepository {
+	return &couponRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *couponRepository) WithTx(tx *gorm.DB) CouponRepository {
+	return &couponRepository{db: tx}
+}
+
+// Create creates a coupon
+func (r *couponRepository) Create(ctx context.Context, coupon *models.Coupon) error {
+	return r.db.WithContext(ctx).Create(coupon).Error
+}
+
+// GetByID gets a coupon by ID
+func (r *couponRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := r.db.WithContext(ctx).First(&coupon, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// GetByCode gets a coupon by its uppercase code
+func (r *couponRepository) GetByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := r.db.WithContext(ctx).First(&coupon, "code = ?", code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// GetByCodeForUpdate gets a coupon by code and locks it until the surrounding
+// transaction ends, serializing concurrent redemptions
+func (r *couponRepository) GetByCodeForUpdate(ctx context.Context, code string) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&coupon, "code = ?", code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// List lists all coupons, newest first
+func (r *couponRepository) List(ctx context.Context) ([]models.Coupon, error) {
+	var coupons []models.Coupon
+	err := r.db.WithContext(ctx).Order("created_at DESC").Find(&coupons).Error
+	return coupons, err
+}
+
+// Update saves a coupon, leaving its usage count alone
+func (r *couponRepository) Update(ctx context.Context, coupon *models.Coupon) error {
+	return r.db.WithContext(ctx).Omit("usage_count").Save(coupon).Error
+}
+
+// Delete deletes a coupon
+func (r *couponRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.Coupon{}, "id = ?", id).Error
+}
+
+// IncrementUsage counts a use of the coupon unless its usage limit has been
+// reached, reporting whether it was counted
+func (r *couponRepository) IncrementUsage(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Coupon{}).
+		Where("id = ? AND (usage_limit IS NULL OR usage_count < usage_limit)", id).
+		UpdateColumn("usage_count", gorm.Expr("usage_count + 1"))
+	return result.RowsAffected > 0, result.Error
+}
+
+// DecrementUsage gives back a use of the coupon
+func (r *couponRepository) DecrementUsage(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.Coupon{}).
+		Where("id = ? AND usage_count > 0", id).
+		UpdateColumn("usage_count", gorm.Expr("usage_count - 1")).Error
+}
+
+// CountUserRedemptions counts how many times a user has redeemed a coupon
+func (r *couponRepository) CountUserRedemptions(ctx context.Context, couponID, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.CouponRedemption{}).
+		Where("coupon_id = ? AND user_id = ?", couponID, userID).
+		Count(&count).Error
+	return count, err
+}
+
+// CreateRedemption records a coupon used on an order
+func (r *couponRepository) CreateRedemption(ctx context.Context, redemption *models.CouponRedemption) error {
+	return r.db.WithContext(ctx).Create(redemption).Error
+}
+
+// GetRedemptionByOrder gets the coupon redemption of an order
+func (r *couponRepository) GetRedemptionByOrder(ctx context.Context, orderID uuid.UUID) (*models.CouponRedemption, error) {
+	var redemption models.CouponRedemption
+	err := r.db.WithContext(ctx).First(&redemption, "order_id = ?", orderID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+// DeleteRedemption removes a redemption so it no longer counts against limits
+func (r *couponRepository) DeleteRedemption(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.CouponRedemption{}, "id = ?", id).Error
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DocumentRepository stores issued documents and their number sequences
+type DocumentRepository interface {
+	WithTx(tx *gorm.DB) DocumentRepository
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
+	Create(ctx context.Context, document *models.Document) error
+	LockSequence(ctx context.Context, name string) (int64, error)
+	SetSequence(ctx context.Context, name string, value int64) error
+	GetInvoice(ctx context.Context, orderID uuid.UUID) (*models.Document, error)
+	GetByRefund(ctx context.Context, refundID uuid.UUID) (*models.Document, error)
+	GetByFulfillment(ctx context.Context, fulfillmentID uuid.UUID) (*models.Document, error)
+	ListByOrder(ctx context.Context, orderID uuid.UUID) ([]models.Document, error)
+}
+
+type documentRepository struct {
+	db *gorm.DB
+}
+
+func NewDocumentRepository(db *gorm.DB) DocumentRepository {
+	return &documentRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *documentRepository) WithTx(tx *gorm.DB) DocumentRepository {
+	return &documentRepository{db: tx}
+}
+
+// Transaction runs fn in a database transaction
+func (r *documentRepository) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return r.db.WithContext(ctx).Transaction(fn)
+}
+
+// Create creates a document
+func (r *documentRepository) Create(ctx context.Context, document *models.Document) error {
+	return r.db.WithContext(ctx).Create(document).Error
+}
+
+// LockSequence locks a numbering sequence until the surrounding transaction
+// ends, returning the last number used
+func (r *documentRepository) LockSequence(ctx context.Context, name string) (int64, error) {
+	if err := r.db.WithContext(ctx).Exec(
+		"INSERT INTO document_sequences (name, last_value) VALUES (?, 0) ON CONFLICT (name) DO NOTHING", name,
+	).Error; err != nil {
+		return 0, err
+	}
+
+	var sequence models.DocumentSequence
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&sequence, "name = ?", name).Error
+	return sequence.LastValue, err
+}
+
+// SetSequence records the last number used by a sequence
+func (r *documentRepository) SetSequence(ctx context.Context, name string, value int64) error {
+	return r.db.WithContext(ctx).Model(&models.DocumentSequence{}).Where("name = ?", name).Update("last_value", value).Error
+}
+
+// GetInvoice gets the invoice of an order
+func (r *documentRepository) GetInvoice(ctx context.Context, orderID uuid.UUID) (*models.Document, error) {
+	var document models.Document
+	err := r.db.WithContext(ctx).First(&document, "order_id = ? AND type = ?", orderID, models.DocumentTypeInvoice).Error
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// GetByRefund gets the credit note of a refund
+func (r *documentRepository) GetByRefund(ctx context.Context, refundID uuid.UUID) (*models.Document, error) {
+	var document models.Document
+	err := r.db.WithContext(ctx).First(&document, "refund_id = ?", refundID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// GetByFulfillment gets the packing slip of a fulfillment
+func (r *documentRepository) GetByFulfillment(ctx context.Context, fulfillmentID uuid.UUID) (*models.Document, error) {
+	var document models.Document
+	err := r.db.WithContext(ctx).First(&document, "fulfillment_id = ?", fulfillmentID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// ListByOrder lists an order's documents in the order they were issued
+func (r *documentRepository) ListByOrder(ctx context.Context, orderID uuid.UUID) ([]models.Document, error) {
+	var documents []models.Document
+	err := r.db.WithContext(ctx).Where("order_id = ?", orderID).Order("issued_at ASC").Find(&documents).Error
+	return documents, err
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FulfillmentRepository stores order fulfillments
+type FulfillmentRepository interface {
+	WithTx(tx *gorm.DB) FulfillmentRepository
+	Create(ctx context.Context, fulfillment *models.Fulfillment) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Fulfillment, error)
+	ListByOrder(ctx context.Context, orderID uuid.UUID) ([]models.Fulfillment, error)
+	Update(ctx context.Context, fulfillment *models.Fulfillment) error
+	FulfilledQuantities(ctx context.Context, orderID uuid.UUID) (map[uuid.UUID]int, error)
+}
+
+type fulfillmentRepository struct {
+	db *gorm.DB
+}
+
+func NewFulfillmentRepository(db *gorm.DB) FulfillmentRepository {
+	return &fulfillmentRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *fulfillmentRepository) WithTx(tx *gorm.DB) FulfillmentRepository {
+	return &fulfillmentRepository{db: tx}
+}
+
+// Create creates a fulfillment together with its items
+func (r *fulfillmentRepository) Create(ctx context.Context, fulfillment *models.Fulfillment) error {
+	return r.db.WithContext(ctx).Create(fulfillment).Error
+}
+
+// GetByID gets a fulfillment with its items
+func (r *fulfillmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Fulfillment, error) {
+	var fulfillment models.Fulfillment
+	err := r.db.WithContext(ctx).Preload("Items").First(&fulfillment, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &fulfillment, nil
+}
+
+// ListByOrder lists the fulfillments of an order with their items
+func (r *fulfillmentRepository) ListByOrder(ctx context.Context, orderID uuid.UUID) ([]models.Fulfillment, error) {
+	var fulfillments []models.Fulfillment
+	err := r.db.WithContext(ctx).Preload("Items").Where("order_id = ?", orderID).Order("created_at ASC").Find(&fulfillments).Error
+	return fulfillments, err
+}
+
+// Update updates a fulfillment
+func (r *fulfillmentRepository) Update(ctx context.Context, fulfillment *models.Fulfillment) error {
+	return r.db.WithContext(ctx).Omit("Items").Save(fulfillment).Error
+}
+
+// FulfilledQuantities returns the quantity shipped so far per order item
+func (r *fulfillmentRepository) FulfilledQuantities(ctx context.Context, orderID uuid.UUID) (map[uuid.UUID]int, error) {
+	var rows []struct {
+		OrderItemID uuid.UUID
+		Quantity    int
+	}
+
+	err := r.db.WithContext(ctx).Model(&models.FulfillmentItem{}).
+		Select("fulfillment_items.order_item_id, SUM(fulfillment_items.quantity) AS quantity").
+		Joins("JOIN fulfillments ON fulfillments.id = fulfillment_items.fulfillment_id AND fulfillments.deleted_at IS NULL").
+		Where("fulfillments.order_id = ?", orderID).
+		Group("fulfillment_items.order_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		quantities[row.OrderItemID] = row.Quantity
+	}
+	return quantities, nil
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GiftCardRepository stores gift cards and their transactions
+type GiftCardRepository interface {
+	WithTx(tx *gorm.DB) GiftCardRepository
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
+	Create(ctx context.Context, card *models.GiftCard) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.GiftCard, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.GiftCard, error)
+	GetByCodeHashForUpdate(ctx context.Context, codeHash string) (*models.GiftCard, error)
+	List(ctx context.Context, page, pageSize int) ([]models.GiftCard, int64, error)
+	ListByPurchaser(ctx context.Context, userID uuid.UUID) ([]models.GiftCard, error)
+	ListExpiredWithBalance(ctx context.Context, now time.Time) ([]models.GiftCard, error)
+	CreateEntry(ctx context.Context, entry *models.GiftCardTransaction) error
+	Balance(ctx context.Context, id uuid.UUID) (float64, error)
+	Balances(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]float64, error)
+	ListEntries(ctx context.Context, id uuid.UUID) ([]models.GiftCardTransaction, error)
+}
+
+type giftCardRepository struct {
+	db *gorm.DB
+}
+
+func NewGiftCardRepository(db *gorm.DB) GiftCardRepository {
+	return &giftCardRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *giftCardRepository) WithTx(tx *gorm.DB) GiftCardRepository {
+	return &giftCardRepository{db: tx}
+}
+
+// Transaction runs fn inside a database transaction
+func (r *giftCardRepository) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return r.db.WithContext(ctx).Transaction(fn)
+}
+
+// Create creates a gift card
+func (r *giftCardRepository) Create(ctx context.Context, card *models.GiftCard) error {
+	return r.db.WithContext(ctx).Create(card).Error
+}
+
+// GetByID gets a gift card by ID
+func (r *giftCardRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.GiftCard, error) {
+	var card models.GiftCard
+	err := r.db.WithContext(ctx).First(&card, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// GetByIDForUpdate gets a gift card by ID and locks the row until the
+// surrounding transaction ends
+func (r *giftCardRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.GiftCard, error) {
+	var card models.GiftCard
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// GetByCodeHashForUpdate gets a gift card by the hash of its code and locks
+// the row until the surrounding transaction ends
+func (r *giftCardRepository) GetByCodeHashForUpdate(ctx context.Context, codeHash string) (*models.GiftCard, error) {
+	var card models.GiftCard
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, "code_hash = ?", codeHash).Error
+	if err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// List lists gift cards, newest first, together with the total number of
+// gift cards
+func (r *giftCardRepository) List(ctx context.Context, page, pageSize int) ([]models.GiftCard, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.GiftCard{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var cards []models.GiftCard
+	err := r.db.WithContext(ctx).Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&cards).Error
+	return cards, total, err
+}
+
+// ListByPurchaser lists the gift cards a user bought, newest first
+func (r *giftCardRepository) ListByPurchaser(ctx context.Context, userID uuid.UUID) ([]models.GiftCard, error) {
+	var cards []models.GiftCard
+	err := r.db.WithContext(ctx).Where("purchaser_id = ?", userID).Order("created_at DESC").Find(&cards).Error
+	return cards, err
+}
+
+// ListExpiredWithBalance lists the gift cards past their expiry that still
+// have a balance
+func (r *giftCardRepository) ListExpiredWithBalance(ctx context.Context, now time.Time) ([]models.GiftCard, error) {
+	var cards []models.GiftCard
+	err := r.db.WithContext(ctx).
+		Where("expires_at <= ?", now).
+		Where("id IN (?)", r.db.Model(&models.GiftCardTransaction{}).
+			Select("gift_card_id").
+			Group("gift_card_id").
+			Having("SUM(amount) > 0")).
+		Find(&cards).Error
+	return cards, err
+}
+
+// CreateEntry adds an entry to a gift card's ledger
+func (r *giftCardRepository) CreateEntry(ctx context.Context, entry *models.GiftCardTransaction) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+// Balance sums a gift card's ledger
+func (r *giftCardRepository) Balance(ctx context.Context, id uuid.UUID) (float64, error) {
+	var balance float64
+	err := r.db.WithContext(ctx).Model(&models.GiftCardTransaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("gift_card_id = ?", id).
+		Scan(&balance).Error
+	return balance, err
+}
+
+// Balances sums the ledgers of several gift cards
+func (r *giftCardRepository) Balances(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]float64, error) {
+	var rows []struct {
+		GiftCardID uuid.UUID
+		Balance    float64
+	}
+	err := r.db.WithContext(ctx).Model(&models.GiftCardTransaction{}).
+		Select("gift_card_id, SUM(amount) AS balance").
+		Where("gift_card_id IN ?", ids).
+		Group("gift_card_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[uuid.UUID]float64, len(rows))
+	for _, row := range rows {
+		balances[row.GiftCardID] = row.Balance
+	}
+	return balances, nil
+}
+
+// ListEntries lists a gift card's ledger, newest first
+func (r *giftCardRepository) ListEntries(ctx context.Context, id uuid.UUID) ([]models.GiftCardTransaction, error) {
+	var entries []models.GiftCardTransaction
+	err := r.db.WithContext(ctx).Where("gift_card_id = ?", id).Order("created_at DESC").Find(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrderRepository stores orders, their items and status history
+type OrderRepository interface {
+	WithTx(tx *gorm.DB) OrderRepository
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Order, error)
+	GetByOrderNumber(ctx context.Context, orderNumber string) (*models.Order, error)
+	GetItems(ctx context.Context, orderID uuid.UUID) ([]models.OrderItem, error)
+	GetItemsWithProducts(ctx context.Context, orderID uuid.UUID) ([]models.OrderItem, error)
+	CountUserOrders(ctx context.Context, userID uuid.UUID) (int64, error)
+	Create(ctx context.Context, order *models.Order) error
+	UpdatePaymentStatus(ctx context.Context, order *models.Order) error
+	UpdateStatus(ctx context.Context, order *models.Order) error
+	CreateStatusHistory(ctx context.Context, entry *models.OrderStatusHistory) error
+	GetStatusChangedAt(ctx context.Context, orderID uuid.UUID, status models.OrderStatus) (*time.Time, error)
+	ListStatusHistory(ctx context.Context, orderID uuid.UUID) ([]models.OrderStatusHistory, error)
+	GetUserOrderByNumber(ctx context.Context, userID uuid.UUID, orderNumber string) (*models.Order, error)
+	List(ctx context.Context, filter OrderFilter) ([]models.Order, int64, error)
+	GetUserOrderDetail(ctx context.Context, userID uuid.UUID, orderNumber string) (*models.Order, error)
+	GetDetail(ctx context.Context, id uuid.UUID) (*models.Order, error)
+}
+
+type orderRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderRepository(db *gorm.DB) OrderRepository {
+	return &orderRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *orderRepository) WithTx(tx *gorm.DB) OrderRepository {
+	return &orderRepository{db: tx}
+}
+
+// Transaction runs fn inside a database transaction
+func (r *orderRepository) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return r.db.WithContext(ctx).Transaction(fn)
+}
+
+// GetByID gets an order by ID
+func (r *orderRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	var order models.Order
+	err := r.db.WithContext(ctx).First(&order, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetByIDForUpdate gets an order by ID and locks the row until the
+// surrounding transaction ends
+func (r *orderRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	var order models.Order
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetByOrderNumber gets an order by its order number
+func (r *orderRepository) GetByOrderNumber(ctx context.Context, orderNumber string) (*models.Order, error) {
+	var order models.Order
+	err := r.db.WithContext(ctx).First(&order, "order_number = ?", orderNumber).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetItems gets the items of an order
+func (r *orderRepository) GetItems(ctx context.Context, orderID uuid.UUID) ([]models.OrderItem, error) {
+	var items []models.OrderItem
+	err := r.db.WithContext(ctx).Where("order_id = ?", orderID).Find(&items).Error
+	return items, err
+}
+
+// GetItemsWithProducts gets the items of an order with their products
+func (r *orderRepository) GetItemsWithProducts(ctx context.Context, orderID uuid.UUID) ([]models.OrderItem, error) {
+	var items []models.OrderItem
+	err := r.db.WithContext(ctx).Preload("Product").Where("order_id = ?", orderID).Find(&items).Error
+	return items, err
+}
+
+// CountUserOrders counts the orders a user has placed, excluding cancelled ones
+func (r *orderRepository) CountUserOrders(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Order{}).
+		Where("user_id = ? AND status <> ?", userID, models.OrderStatusCancelled).
+		Count(&count).Error
+	return count, err
+}
+
+// Create creates an order together with its items
+func (r *orderRepository) Create(ctx context.Context, order *models.Order) error {
+	return r.db.WithContext(ctx).Create(order).Error
+}
+
+// UpdatePaymentStatus persists the order's current payment status
+func (r *orderRepository) UpdatePaymentStatus(ctx context.Context, order *models.Order) error {
+	return r.db.WithContext(ctx).Model(order).Update("payment_status", order.PaymentStatus).Error
+}
+
+// UpdateStatus persists the order's current status
+func (r *orderRepository) UpdateStatus(ctx context.Context, order *models.Order) error {
+	return r.db.WithContext(ctx).Model(order).Update("status", order.Status).Error
+}
+
+// CreateStatusHistory records a status transition
+func (r *orderRepository) CreateStatusHistory(ctx context.Context, entry *models.OrderStatusHistory) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+// GetStatusChangedAt returns when the order last entered the given status
+func (r *orderRepository) GetStatusChangedAt(ctx context.Context, orderID uuid.UUID, status models.OrderStatus) (*time.Time, error) {
+	var entry models.OrderStatusHistory
+	err := r.db.WithContext(ctx).Where("order_id = ? AND to_status = ?", orderID, status).Order("created_at DESC").First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry.CreatedAt, nil
+}
+
+// ListStatusHistory lists the status transitions of an order, oldest first
+func (r *orderRepository) ListStatusHistory(ctx context.Context, orderID uuid.UUID) ([]models.OrderStatusHistory, error) {
+	var history []models.OrderStatusHistory
+	err := r.db.WithContext(ctx).Where("order_id = ?", orderID).Order("created_at ASC").Find(&history).Error
+	return history, err
+}
+
+// GetUserOrderByNumber gets an order by its order number, scoped to the user
+// who placed it
+func (r *orderRepository) GetUserOrderByNumber(ctx context.Context, userID uuid.UUID, orderNumber string) (*models.Order, error) {
+	var order models.Order
+	err := r.db.WithContext(ctx).First(&order, "order_number = ? AND user_id = ?", orderNumber, userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// OrderFilter narrows down order listings. Zero values are ignored.
+type OrderFilter struct {
+	UserID      *uuid.UUID
+	OrderNumber string
+	Email       string
+	Status      models.OrderStatus
+	From        *time.Time
+	To          *time.Time
+	Page        int
+	PageSize    int
+}
+
+// List lists orders matching the filter, newest first, together with the
+// total number of matching orders
+func (r *orderRepository) List(ctx context.Context, filter OrderFilter) ([]models.Order, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Order{})
+
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.OrderNumber != "" {
+		query = query.Where("order_number ILIKE ?", "%"+filter.OrderNumber+"%")
+	}
+	if filter.Email != "" {
+		query = query.Where("user_id IN (?)",
+			r.db.Model(&models.User{}).Select("id").Where("email ILIKE ?", "%"+filter.Email+"%"))
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var orders []models.Order
+	err := query.
+		Order("created_at DESC").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&orders).Error
+	return orders, total, err
+}
+
+// GetUserOrderDetail gets one of the user's orders with its items, addresses,
+// payments, fulfillments and refunds
+func (r *orderRepository) GetUserOrderDetail(ctx context.Context, userID uuid.UUID, orderNumber string) (*models.Order, error) {
+	var order models.Order
+	err := r.db.WithContext(ctx).
+		Preload("Items").
+		Preload("Items.Product").
+		Preload("Payments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("Fulfillments").
+		Preload("Fulfillments.Items").
+		Preload("Refunds").
+		Preload("Refunds.Items").
+		First(&order, "order_number = ? AND user_id = ?", orderNumber, userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetDetail gets an order with its items, addresses, payments, fulfillments
+// and refunds
+func (r *orderRepository) GetDetail(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	var order models.Order
+	err := r.db.WithContext(ctx).
+		Preload("Items").
+		Preload("Items.Product").
+		Preload("Payments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("Fulfillments").
+		Preload("Fulfillments.Items").
+		Preload("Refunds").
+		Preload("Refunds.Items").
+		First(&order, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
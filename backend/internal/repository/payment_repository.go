@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PaymentRepository stores payments and their events
+type PaymentRepository interface {
+	WithTx(tx *gorm.DB) PaymentRepository
+	Create(ctx context.Context, payment *models.Payment) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Payment, error)
+	GetByTransactionID(ctx context.Context, transactionID string) (*models.Payment, error)
+	GetByTransactionIDForUpdate(ctx context.Context, transactionID string) (*models.Payment, error)
+	GetLatestByOrder(ctx context.Context, orderID uuid.UUID) (*models.Payment, error)
+	ListByOrder(ctx context.Context, orderID uuid.UUID) ([]models.Payment, error)
+	ListCapturedByOrderForUpdate(ctx context.Context, orderID uuid.UUID) ([]models.Payment, error)
+	SumTendered(ctx context.Context, orderID uuid.UUID) (float64, error)
+	Update(ctx context.Context, payment *models.Payment) error
+}
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *paymentRepository) WithTx(tx *gorm.DB) PaymentRepository {
+	return &paymentRepository{db: tx}
+}
+
+// Create creates a payment
+func (r *paymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	return r.db.WithContext(ctx).Create(payment).Error
+}
+
+// GetByID gets a payment by ID
+func (r *paymentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.WithContext(ctx).First(&payment, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetByTransactionID gets a payment by its provider transaction ID
+func (r *paymentRepository) GetByTransactionID(ctx context.Context, transactionID string) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.WithContext(ctx).First(&payment, "transaction_id = ?", transactionID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetByTransactionIDForUpdate gets a payment by its provider transaction ID
+// and locks the row until the surrounding transaction ends
+func (r *paymentRepository) GetByTransactionIDForUpdate(ctx context.Context, transactionID string) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, "transaction_id = ?", transactionID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetLatestByOrder gets the most recent payment provider payment of an
+// order, ignoring gift card and store credit tenders
+func (r *paymentRepository) GetLatestByOrder(ctx context.Context, orderID uuid.UUID) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.WithContext(ctx).Where("order_id = ? AND provider NOT IN ?", orderID, models.TenderProviders).
+		Order("created_at DESC").
+		First(&payment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// ListByOrder lists the payments of an order, oldest first
+func (r *paymentRepository) ListByOrder(ctx context.Context, orderID uuid.UUID) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := r.db.WithContext(ctx).Where("order_id = ?", orderID).Order("created_at ASC").Find(&payments).Error
+	return payments, err
+}
+
+// ListCapturedByOrderForUpdate lists the payments of an order that have
+// money left to refund, oldest first, and locks them until the surrounding
+// transaction ends
+func (r *paymentRepository) ListCapturedByOrderForUpdate(ctx context.Context, orderID uuid.UUID) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND status IN ?", orderID,
+			[]models.PaymentState{models.PaymentStateCompleted, models.PaymentStatePartiallyRefunded}).
+		Order("created_at ASC").
+		Find(&payments).Error
+	return payments, err
+}
+
+// SumTendered sums the gift card and store credit payments settled on an
+// order
+func (r *paymentRepository) SumTendered(ctx context.Context, orderID uuid.UUID) (float64, error) {
+	var total float64
+	err := r.db.WithContext(ctx).Model(&models.Payment{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("order_id = ? AND provider IN ? AND status = ?", orderID, models.TenderProviders, models.PaymentStateCompleted).
+		Scan(&total).Error
+	return total, err
+}
+
+// Update updates a payment
+func (r *paymentRepository) Update(ctx context.Context, payment *models.Payment) error {
+	return r.db.WithContext(ctx).Save(payment).Error
+}
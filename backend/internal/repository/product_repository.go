@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProductRepository reads products and adjusts their stock
+type ProductRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Product, error)
+	GetByIDsForUpdate(ctx context.Context, ids []uuid.UUID) ([]models.Product, error)
+	AdjustStock(ctx context.Context, id uuid.UUID, delta int) error
+	ReturnWindows(ctx context.Context, productIDs []uuid.UUID) (map[uuid.UUID][]int, error)
+	CategoryIDs(ctx context.Context, productIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error)
+}
+
+type productRepository struct {
+	db *gorm.DB
+}
+
+func NewProductRepository(db *gorm.DB) ProductRepository {
+	return &productRepository{db: db}
+}
+
+// GetByID gets a product by ID
+func (r *productRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+	var product models.Product
+	err := r.db.WithContext(ctx).First(&product, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// GetByIDsForUpdate gets products by ID and locks their rows until the
+// surrounding transaction ends. Rows are locked in ID order to avoid deadlocks.
+func (r *productRepository) GetByIDsForUpdate(ctx context.Context, ids []uuid.UUID) ([]models.Product, error) {
+	var products []models.Product
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&products).Error
+	return products, err
+}
+
+// AdjustStock atomically adds delta (which may be negative) to a product's stock
+func (r *productRepository) AdjustStock(ctx context.Context, id uuid.UUID, delta int) error {
+	return r.db.WithContext(ctx).Model(&models.Product{}).
+		Where("id = ?", id).
+		UpdateColumn("stock_quantity", gorm.Expr("stock_quantity + ?", delta)).Error
+}
+
+// ReturnWindows returns the return window overrides of each product's
+// categories. Products without overrides are absent from the map.
+func (r *productRepository) ReturnWindows(ctx context.Context, productIDs []uuid.UUID) (map[uuid.UUID][]int, error) {
+	var rows []struct {
+		ProductID        uuid.UUID
+		ReturnWindowDays int
+	}
+
+	err := r.db.WithContext(ctx).Table("product_categories").
+		Select("product_categories.product_id, categories.return_window_days").
+		Joins("JOIN categories ON categories.id = product_categories.category_id AND categories.deleted_at IS NULL").
+		Where("product_categories.product_id IN ? AND categories.return_window_days IS NOT NULL", productIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	windows := make(map[uuid.UUID][]int)
+	for _, row := range rows {
+		windows[row.ProductID] = append(windows[row.ProductID], row.ReturnWindowDays)
+	}
+	return windows, nil
+}
+
+// CategoryIDs returns the categories of each product
+func (r *productRepository) CategoryIDs(ctx context.Context, productIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	var rows []models.ProductCategory
+	err := r.db.WithContext(ctx).Where("product_id IN ?", productIDs).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	categories := make(map[uuid.UUID][]uuid.UUID)
+	for _, row := range rows {
+		categories[row.ProductID] = append(categories[row.ProductID], row.CategoryID)
+	}
+	return categories, nil
+}
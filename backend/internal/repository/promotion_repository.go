@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PromotionRepository stores automatic promotions
+type PromotionRepository interface {
+	WithTx(tx *gorm.DB) PromotionRepository
+	Create(ctx context.Context, promotion *models.Promotion) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Promotion, error)
+	List(ctx context.Context) ([]models.Promotion, error)
+	ListActive(ctx context.Context, at time.Time) ([]models.Promotion, error)
+	Update(ctx context.Context, promotion *models.Promotion) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type promotionRepository struct {
+	db *gorm.DB
+}
+
+func NewPromotionRepository(db *gorm.DB) PromotionRepository {
+	return &promotionRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *promotionRepository) WithTx(tx *gorm.DB) PromotionRepository {
+	return &promotionRepository{db: tx}
+}
+
+// Create creates a promotion
+func (r *promotionRepository) Create(ctx context.Context, promotion *models.Promotion) error {
+	return r.db.WithContext(ctx).Create(promotion).Error
+}
+
+// GetByID gets a promotion by ID
+func (r *promotionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Promotion, error) {
+	var promotion models.Promotion
+	err := r.db.WithContext(ctx).First(&promotion, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// List lists all promotions in evaluation order
+func (r *promotionRepository) List(ctx context.Context) ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	err := r.db.WithContext(ctx).Order("priority DESC, created_at ASC").Find(&promotions).Error
+	return promotions, err
+}
+
+// ListActive lists the promotions running at the given time in evaluation
+// order
+func (r *promotionRepository) ListActive(ctx context.Context, at time.Time) ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	err := r.db.WithContext(ctx).
+		Where("is_active = ?", true).
+		Where("starts_at IS NULL OR starts_at <= ?", at).
+		Where("ends_at IS NULL OR ends_at > ?", at).
+		Order("priority DESC, created_at ASC").
+		Find(&promotions).Error
+	return promotions, err
+}
+
+// Update saves a promotion
+func (r *promotionRepository) Update(ctx context.Context, promotion *models.Promotion) error {
+	return r.db.WithContext(ctx).Save(promotion).Error
+}
+
+// Delete deletes a promotion
+func (r *promotionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.Promotion{}, "id = ?", id).Error
+}
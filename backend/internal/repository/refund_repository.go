@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RefundRepository stores refunds
+type RefundRepository interface {
+	WithTx(tx *gorm.DB) RefundRepository
+	Create(ctx context.Context, refund *models.Refund) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Refund, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Refund, error)
+	ListByOrder(ctx context.Context, orderID uuid.UUID) ([]models.Refund, error)
+	Update(ctx context.Context, refund *models.Refund) error
+	SumByPayment(ctx context.Context, paymentID uuid.UUID, statuses ...models.RefundStatus) (float64, error)
+	RefundedQuantities(ctx context.Context, orderID uuid.UUID) (map[uuid.UUID]int, error)
+	RestockedQuantities(ctx context.Context, orderID uuid.UUID) (map[uuid.UUID]int, error)
+}
+
+type refundRepository struct {
+	db *gorm.DB
+}
+
+func NewRefundRepository(db *gorm.DB) RefundRepository {
+	return &refundRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *refundRepository) WithTx(tx *gorm.DB) RefundRepository {
+	return &refundRepository{db: tx}
+}
+
+// Create creates a refund together with its items
+func (r *refundRepository) Create(ctx context.Context, refund *models.Refund) error {
+	return r.db.WithContext(ctx).Create(refund).Error
+}
+
+// GetByID gets a refund with its items
+func (r *refundRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.WithContext(ctx).Preload("Items").First(&refund, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// GetByIDForUpdate gets a refund with its items and locks its row until the
+// surrounding transaction ends
+func (r *refundRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&refund, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// ListByOrder lists the refunds of an order with their items
+func (r *refundRepository) ListByOrder(ctx context.Context, orderID uuid.UUID) ([]models.Refund, error) {
+	var refunds []models.Refund
+	err := r.db.WithContext(ctx).Preload("Items").Where("order_id = ?", orderID).Order("created_at ASC").Find(&refunds).Error
+	return refunds, err
+}
+
+// Update updates a refund
+func (r *refundRepository) Update(ctx context.Context, refund *models.Refund) error {
+	return r.db.WithContext(ctx).Omit("Items").Save(refund).Error
+}
+
+// SumByPayment sums the amounts of a payment's refunds in the given statuses
+func (r *refundRepository) SumByPayment(ctx context.Context, paymentID uuid.UUID, statuses ...models.RefundStatus) (float64, error) {
+	var total float64
+	err := r.db.WithContext(ctx).Model(&models.Refund{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("payment_id = ? AND status IN ?", paymentID, statuses).
+		Scan(&total).Error
+	return total, err
+}
+
+// RefundedQuantities returns the quantity refunded so far per order item,
+// counting refunds that are pending or succeeded
+func (r *refundRepository) RefundedQuantities(ctx context.Context, orderID uuid.UUID) (map[uuid.UUID]int, error) {
+	return r.itemQuantities(ctx, r.db.WithContext(ctx), orderID)
+}
+
+// RestockedQuantities returns the quantity returned to stock so far per
+// order item, counting restocking refunds that are pending or succeeded
+func (r *refundRepository) RestockedQuantities(ctx context.Context, orderID uuid.UUID) (map[uuid.UUID]int, error) {
+	return r.itemQuantities(ctx, r.db.WithContext(ctx).Where("refunds.restock = ?", true), orderID)
+}
+
+// itemQuantities sums the refunded quantities per order item of the
+// pending and succeeded refunds db selects
+func (r *refundRepository) itemQuantities(ctx context.Context, db *gorm.DB, orderID uuid.UUID) (map[uuid.UUID]int, error) {
+	var rows []struct {
+		OrderItemID uuid.UUID
+		Quantity    int
+	}
+
+	err := db.Model(&models.RefundItem{}).
+		Select("refund_items.order_item_id, SUM(refund_items.quantity) AS quantity").
+		Joins("JOIN refunds ON refunds.id = refund_items.refund_id AND refunds.deleted_at IS NULL").
+		Where("refunds.order_id = ? AND refunds.status IN ?", orderID,
+			[]models.RefundStatus{models.RefundStatusPending, models.RefundStatusSucceeded}).
+		Group("refund_items.order_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		quantities[row.OrderItemID] = row.Quantity
+	}
+	return quantities, nil
+}
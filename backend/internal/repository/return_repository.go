@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReturnRepository stores return requests
+type ReturnRepository interface {
+	WithTx(tx *gorm.DB) ReturnRepository
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
+	Create(ctx context.Context, ret *models.ReturnRequest) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.ReturnRequest, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.ReturnRequest, error)
+	GetUserReturn(ctx context.Context, userID, id uuid.UUID) (*models.ReturnRequest, error)
+	List(ctx context.Context, filter ReturnFilter) ([]models.ReturnRequest, int64, error)
+	Update(ctx context.Context, ret *models.ReturnRequest) error
+	UpdateItem(ctx context.Context, item *models.ReturnItem) error
+	RequestedQuantities(ctx context.Context, orderID uuid.UUID) (map[uuid.UUID]int, error)
+}
+
+type returnRepository struct {
+	db *gorm.DB
+}
+
+func NewReturnRepository(db *gorm.DB) ReturnRepository {
+	return &returnRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *returnRepository) WithTx(tx *gorm.DB) ReturnRepository {
+	return &returnRepository{db: tx}
+}
+
+// Transaction runs fn inside a database transaction
+func (r *returnRepository) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return r.db.WithContext(ctx).Transaction(fn)
+}
+
+// Create creates a return request together with its items
+func (r *returnRepository) Create(ctx context.Context, ret *models.ReturnRequest) error {
+	return r.db.WithContext(ctx).Create(ret).Error
+}
+
+// GetByID gets a return request with its items
+func (r *returnRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.ReturnRequest, error) {
+	var ret models.ReturnRequest
+	err := r.db.WithContext(ctx).Preload("Items").First(&ret, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// GetByIDForUpdate gets a return request with its items and locks it until
+// the surrounding transaction ends
+func (r *returnRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.ReturnRequest, error) {
+	var ret models.ReturnRequest
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&ret, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// GetUserReturn gets one of the user's return requests with its items
+func (r *returnRepository) GetUserReturn(ctx context.Context, userID, id uuid.UUID) (*models.ReturnRequest, error) {
+	var ret models.ReturnRequest
+	err := r.db.WithContext(ctx).Preload("Items").First(&ret, "id = ? AND user_id = ?", id, userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// ReturnFilter narrows down return listings. Zero values are ignored.
+type ReturnFilter struct {
+	UserID   *uuid.UUID
+	Status   models.ReturnStatus
+	Page     int
+	PageSize int
+}
+
+// List lists return requests matching the filter, newest first, together
+// with the total number of matching requests
+func (r *returnRepository) List(ctx context.Context, filter ReturnFilter) ([]models.ReturnRequest, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.ReturnRequest{})
+
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var returns []models.ReturnRequest
+	err := query.
+		Preload("Items").
+		Order("created_at DESC").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&returns).Error
+	return returns, total, err
+}
+
+// Update updates a return request
+func (r *returnRepository) Update(ctx context.Context, ret *models.ReturnRequest) error {
+	return r.db.WithContext(ctx).Omit("Items").Save(ret).Error
+}
+
+// UpdateItem updates a return item
+func (r *returnRepository) UpdateItem(ctx context.Context, item *models.ReturnItem) error {
+	return r.db.WithContext(ctx).Save(item).Error
+}
+
+// RequestedQuantities returns the quantity per order item already covered by
+// return requests that have not been rejected
+func (r *returnRepository) RequestedQuantities(ctx context.Context, orderID uuid.UUID) (map[uuid.UUID]int, error) {
+	var rows []struct {
+		OrderItemID uuid.UUID
+		Quantity    int
+	}
+
+	err := r.db.WithContext(ctx).Model(&models.ReturnItem{}).
+		Select("return_items.order_item_id, SUM(return_items.quantity) AS quantity").
+		Joins("JOIN return_requests ON return_requests.id = return_items.return_request_id AND return_requests.deleted_at IS NULL").
+		Where("return_requests.order_id = ? AND return_requests.status <> ?", orderID, models.ReturnStatusRejected).
+		Group("return_items.order_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		quantities[row.OrderItemID] = row.Quantity
+	}
+	return quantities, nil
+}
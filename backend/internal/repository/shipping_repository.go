@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ShippingRepository stores shipping zones, methods and rate tiers
+type ShippingRepository interface {
+	ListZones(ctx context.Context) ([]models.ShippingZone, error)
+	GetZone(ctx context.Context, id uuid.UUID) (*models.ShippingZone, error)
+	CreateZone(ctx context.Context, zone *models.ShippingZone) error
+	UpdateZone(ctx context.Context, zone *models.ShippingZone) error
+	DeleteZone(ctx context.Context, id uuid.UUID) error
+	GetMethod(ctx context.Context, id uuid.UUID) (*models.ShippingMethod, error)
+	CreateMethod(ctx context.Context, method *models.ShippingMethod) error
+	UpdateMethod(ctx context.Context, method *models.ShippingMethod) error
+	DeleteMethod(ctx context.Context, id uuid.UUID) error
+}
+
+type shippingRepository struct {
+	db *gorm.DB
+}
+
+func NewShippingRepository(db *gorm.DB) ShippingRepository {
+	return &shippingRepository{db: db}
+}
+
+// ListZones lists the shipping zones with their regions, methods and tiers
+func (r *shippingRepository) ListZones(ctx context.Context) ([]models.ShippingZone, error) {
+	var zones []models.ShippingZone
+	err := r.db.WithContext(ctx).
+		Preload("Regions").
+		Preload("Methods", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Methods.Tiers", func(db *gorm.DB) *gorm.DB { return db.Order("min_value ASC") }).
+		Order("name ASC").
+		Find(&zones).Error
+	return zones, err
+}
+
+// GetZone gets a shipping zone with its regions, methods and tiers
+func (r *shippingRepository) GetZone(ctx context.Context, id uuid.UUID) (*models.ShippingZone, error) {
+	var zone models.ShippingZone
+	err := r.db.WithContext(ctx).
+		Preload("Regions").
+		Preload("Methods", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Methods.Tiers", func(db *gorm.DB) *gorm.DB { return db.Order("min_value ASC") }).
+		First(&zone, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+// CreateZone creates a shipping zone with its regions
+func (r *shippingRepository) CreateZone(ctx context.Context, zone *models.ShippingZone) error {
+	return r.db.WithContext(ctx).Create(zone).Error
+}
+
+// UpdateZone saves a zone and replaces its regions
+func (r *shippingRepository) UpdateZone(ctx context.Context, zone *models.ShippingZone) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Regions", "Methods").Save(zone).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("zone_id = ?", zone.ID).Delete(&models.ShippingZoneRegion{}).Error; err != nil {
+			return err
+		}
+		for i := range zone.Regions {
+			zone.Regions[i].ZoneID = zone.ID
+		}
+		if len(zone.Regions) == 0 {
+			return nil
+		}
+		return tx.Create(&zone.Regions).Error
+	})
+}
+
+// DeleteZone deletes a zone with its regions and methods
+func (r *shippingRepository) DeleteZone(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		methodIDs := tx.Model(&models.ShippingMethod{}).Select("id").Where("zone_id = ?", id)
+		if err := tx.Where("method_id IN (?)", methodIDs).Delete(&models.ShippingRateTier{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("zone_id = ?", id).Delete(&models.ShippingMethod{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("zone_id = ?", id).Delete(&models.ShippingZoneRegion{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.ShippingZone{}, "id = ?", id).Error
+	})
+}
+
+// GetMethod gets a shipping method with its tiers
+func (r *shippingRepository) GetMethod(ctx context.Context, id uuid.UUID) (*models.ShippingMethod, error) {
+	var method models.ShippingMethod
+	err := r.db.WithContext(ctx).
+		Preload("Tiers", func(db *gorm.DB) *gorm.DB { return db.Order("min_value ASC") }).
+		First(&method, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &method, nil
+}
+
+// CreateMethod creates a shipping method with its tiers
+func (r *shippingRepository) CreateMethod(ctx context.Context, method *models.ShippingMethod) error {
+	return r.db.WithContext(ctx).Create(method).Error
+}
+
+// UpdateMethod saves a method and replaces its tiers
+func (r *shippingRepository) UpdateMethod(ctx context.Context, method *models.ShippingMethod) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Tiers").Save(method).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("method_id = ?", method.ID).Delete(&models.ShippingRateTier{}).Error; err != nil {
+			return err
+		}
+		for i := range method.Tiers {
+			method.Tiers[i].MethodID = method.ID
+		}
+		if len(method.Tiers) == 0 {
+			return nil
+		}
+		return tx.Create(&method.Tiers).Error
+	})
+}
+
+// DeleteMethod deletes a shipping method with its tiers
+func (r *shippingRepository) DeleteMethod(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("method_id = ?", id).Delete(&models.ShippingRateTier{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.ShippingMethod{}, "id = ?", id).Error
+	})
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StoreCreditRepository stores the store credit ledger
+type StoreCreditRepository interface {
+	WithTx(tx *gorm.DB) StoreCreditRepository
+	LockUser(ctx context.Context, userID uuid.UUID) error
+	Create(ctx context.Context, entry *models.StoreCreditTransaction) error
+	Balance(ctx context.Context, userID uuid.UUID) (float64, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]models.StoreCreditTransaction, error)
+}
+
+type storeCreditRepository struct {
+	db *gorm.DB
+}
+
+func NewStoreCreditRepository(db *gorm.DB) StoreCreditRepository {
+	return &storeCreditRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *storeCreditRepository) WithTx(tx *gorm.DB) StoreCreditRepository {
+	return &storeCreditRepository{db: tx}
+}
+
+// LockUser locks the user's row until the surrounding transaction ends,
+// serializing changes to their store credit
+func (r *storeCreditRepository) LockUser(ctx context.Context, userID uuid.UUID) error {
+	var user models.User
+	return r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		First(&user, "id = ?", userID).Error
+}
+
+// Create adds an entry to a user's store credit ledger
+func (r *storeCreditRepository) Create(ctx context.Context, entry *models.StoreCreditTransaction) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+// Balance sums a user's store credit ledger
+func (r *storeCreditRepository) Balance(ctx context.Context, userID uuid.UUID) (float64, error) {
+	var balance float64
+	err := r.db.WithContext(ctx).Model(&models.StoreCreditTransaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("user_id = ?", userID).
+		Scan(&balance).Error
+	return balance, err
+}
+
+// ListByUser lists a user's store credit ledger, newest first
+func (r *storeCreditRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]models.StoreCreditTransaction, error) {
+	var entries []models.StoreCreditTransaction
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&entries).Error
+	return entries, err
+}
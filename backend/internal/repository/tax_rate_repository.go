@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TaxRateRepository stores tax rates
+type TaxRateRepository interface {
+	Create(ctx context.Context, rate *models.TaxRate) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.TaxRate, error)
+	List(ctx context.Context) ([]models.TaxRate, error)
+	ListByCountry(ctx context.Context, country string) ([]models.TaxRate, error)
+	Update(ctx context.Context, rate *models.TaxRate) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type taxRateRepository struct {
+	db *gorm.DB
+}
+
+func NewTaxRateRepository(db *gorm.DB) TaxRateRepository {
+	return &taxRateRepository{db: db}
+}
+
+// Create adds a tax rate
+func (r *taxRateRepository) Create(ctx context.Context, rate *models.TaxRate) error {
+	return r.db.WithContext(ctx).Create(rate).Error
+}
+
+// GetByID gets a tax rate
+func (r *taxRateRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.TaxRate, error) {
+	var rate models.TaxRate
+	err := r.db.WithContext(ctx).First(&rate, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// List lists all tax rates ordered by jurisdiction
+func (r *taxRateRepository) List(ctx context.Context) ([]models.TaxRate, error) {
+	var rates []models.TaxRate
+	err := r.db.WithContext(ctx).Order("country, state, postal_code_prefix, tax_class").Find(&rates).Error
+	return rates, err
+}
+
+// ListByCountry lists the tax rates of a country
+func (r *taxRateRepository) ListByCountry(ctx context.Context, country string) ([]models.TaxRate, error) {
+	var rates []models.TaxRate
+	err := r.db.WithContext(ctx).Where("country = ?", country).Order("state, postal_code_prefix, tax_class").Find(&rates).Error
+	return rates, err
+}
+
+// Update saves a tax rate
+func (r *taxRateRepository) Update(ctx context.Context, rate *models.TaxRate) error {
+	return r.db.WithContext(ctx).Save(rate).Error
+}
+
+// Delete deletes a tax rate
+func (r *taxRateRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.TaxRate{}, "id = ?", id).Error
+}
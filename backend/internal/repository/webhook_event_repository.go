@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookEventRepository records processed webhook events
+type WebhookEventRepository interface {
+	WithTx(tx *gorm.DB) WebhookEventRepository
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
+	CreateIfNotExists(ctx context.Context, event *models.WebhookEvent) (bool, error)
+}
+
+type webhookEventRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookEventRepository(db *gorm.DB) WebhookEventRepository {
+	return &webhookEventRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *webhookEventRepository) WithTx(tx *gorm.DB) WebhookEventRepository {
+	return &webhookEventRepository{db: tx}
+}
+
+// Transaction runs fn inside a database transaction
+func (r *webhookEventRepository) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return r.db.WithContext(ctx).Transaction(fn)
+}
+
+// CreateIfNotExists records an event and reports whether it was new. An
+// event that was already recorded by the same provider is left untouched.
+func (r *webhookEventRepository) CreateIfNotExists(ctx context.Context, event *models.WebhookEvent) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider"}, {Name: "event_id"}},
+		DoNothing: true,
+	}).Create(event)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,457 @@
+// Package seed fills a database with demo data through the regular
+// services, so seeded orders go through the same checkout, payment and
+// fulfillment steps as real ones
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/payment"
+	"github.com/Shihasz/gophiway/internal/service"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DemoPassword is the password of every seeded customer
+const DemoPassword = "demo-password"
+
+// Scale is how much demo data to create
+type Scale struct {
+	Categories int
+	Products   int
+	Customers  int
+	Orders     int
+}
+
+// Scales are the named seeding scales
+var Scales = map[string]Scale{
+	"small":  {Categories: 5, Products: 25, Customers: 10, Orders: 30},
+	"medium": {Categories: 10, Products: 200, Customers: 100, Orders: 1000},
+	"large":  {Categories: 20, Products: 2000, Customers: 1000, Orders: 20000},
+}
+
+// Result counts what a seeding run created
+type Result struct {
+	Categories int
+	Products   int
+	Customers  int
+	Orders     int
+}
+
+// Seeder creates demo data. Catalog entries and customers are keyed by slug,
+// SKU and email, so seeding again reuses them and only adds orders.
+type Seeder struct {
+	db       *gorm.DB
+	services *service.Services
+	cfg      *config.Config
+	logger   *slog.Logger
+	rand     *rand.Rand
+}
+
+func New(db *gorm.DB, services *service.Services, cfg *config.Config, logger *slog.Logger, seed int64) *Seeder {
+	return &Seeder{
+		db:       db,
+		services: services,
+		cfg:      cfg,
+		logger:   logger,
+		rand:     rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Run seeds shipping, the catalog, customers and orders at the given scale
+func (s *Seeder) Run(ctx context.Context, scale Scale) (*Result, error) {
+	result := &Result{}
+
+	if err := s.seedShipping(ctx); err != nil {
+		return nil, fmt.Errorf("shipping: %w", err)
+	}
+
+	categories, err := s.seedCategories(ctx, scale.Categories, result)
+	if err != nil {
+		return nil, fmt.Errorf("categories: %w", err)
+	}
+
+	products, err := s.seedProducts(ctx, scale.Products, categories, result)
+	if err != nil {
+		return nil, fmt.Errorf("products: %w", err)
+	}
+
+	customers, err := s.seedCustomers(ctx, scale.Customers, result)
+	if err != nil {
+		return nil, fmt.Errorf("customers: %w", err)
+	}
+
+	if err := s.seedOrders(ctx, scale.Orders, products, customers, result); err != nil {
+		return nil, fmt.Errorf("orders: %w", err)
+	}
+
+	return result, nil
+}
+
+// seedShipping creates a domestic zone with standard and express shipping,
+// unless shipping has been configured already
+func (s *Seeder) seedShipping(ctx context.Context) error {
+	zones, err := s.services.Shipping.ListZones(ctx)
+	if err != nil || len(zones) > 0 {
+		return err
+	}
+
+	zone, err := s.services.Shipping.CreateZone(ctx, &service.ShippingZoneRequest{
+		Name:    "United States",
+		Regions: []service.ShippingZoneRegionRequest{{Country: "US"}},
+	})
+	if err != nil {
+		return err
+	}
+
+	methods := []service.ShippingMethodRequest{
+		{Name: "Standard", RateType: "free_over", Rate: 5.99, FreeThreshold: 75, MinDays: 3, MaxDays: 5},
+		{Name: "Express", RateType: "flat", Rate: 14.99, MinDays: 1, MaxDays: 2},
+	}
+	for i := range methods {
+		if _, err := s.services.Shipping.CreateMethod(ctx, zone.ID, &methods[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var categoryNames = []string{
+	"Apparel", "Electronics", "Home & Kitchen", "Books", "Outdoors",
+	"Toys", "Beauty", "Sports", "Garden", "Office",
+}
+
+// seedCategories creates the categories that do not exist yet
+func (s *Seeder) seedCategories(ctx context.Context, count int, result *Result) ([]models.Category, error) {
+	categories := make([]models.Category, 0, count)
+	for i := 0; i < count; i++ {
+		name := categoryNames[i%len(categoryNames)]
+		if i >= len(categoryNames) {
+			name = fmt.Sprintf("%s %d", name, i/len(categoryNames)+1)
+		}
+
+		category := models.Category{
+			Name:        name,
+			Slug:        slugify(name),
+			Description: "Demo " + strings.ToLower(name),
+		}
+		created, err := s.firstOrCreate(ctx, &category, "slug = ?", category.Slug)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			result.Categories++
+		}
+		categories = append(categories, category)
+	}
+	return categories, nil
+}
+
+var (
+	productAdjectives = []string{"Classic", "Compact", "Deluxe", "Eco", "Essential", "Modern", "Rugged", "Smart", "Vintage", "Wireless"}
+	productNouns      = []string{"Backpack", "Blender", "Candle", "Headphones", "Jacket", "Lamp", "Mug", "Notebook", "Sneakers", "Watch"}
+)
+
+// seedProducts creates products with images in one or two categories,
+// stocked deeply enough for every seeded order
+func (s *Seeder) seedProducts(ctx context.Context, count int, categories []models.Category, result *Result) ([]models.Product, error) {
+	products := make([]models.Product, 0, count)
+	for i := 1; i <= count; i++ {
+		name := fmt.Sprintf("%s %s %d",
+			productAdjectives[s.rand.Intn(len(productAdjectives))],
+			productNouns[s.rand.Intn(len(productNouns))], i)
+		price := float64(s.rand.Intn(19500)+500) / 100
+		slug := fmt.Sprintf("demo-product-%05d", i)
+
+		product := models.Product{
+			Name:           name,
+			Slug:           slug,
+			Description:    "A demo product for trying out the store.",
+			Price:          price,
+			CompareAtPrice: roundMoney(price * 1.2),
+			Cost:           roundMoney(price * 0.5),
+			SKU:            fmt.Sprintf("DEMO-%05d", i),
+			StockQuantity:  100000,
+			IsActive:       true,
+			TaxClass:       "standard",
+			Weight:         float64(s.rand.Intn(4900)+100) / 1000,
+			Length:         float64(s.rand.Intn(50) + 10),
+			Width:          float64(s.rand.Intn(40) + 10),
+			Height:         float64(s.rand.Intn(30) + 5),
+		}
+		images := s.rand.Intn(3) + 1
+		for position := 0; position < images; position++ {
+			product.Images = append(product.Images, models.ProductImage{
+				URL:       fmt.Sprintf("https://picsum.photos/seed/%s-%d/800/800", slug, position),
+				AltText:   name,
+				Position:  position,
+				IsPrimary: position == 0,
+			})
+		}
+		if len(categories) > 0 {
+			product.Categories = append(product.Categories, categories[s.rand.Intn(len(categories))])
+			if other := categories[s.rand.Intn(len(categories))]; other.ID != product.Categories[0].ID {
+				product.Categories = append(product.Categories, other)
+			}
+		}
+
+		created, err := s.firstOrCreate(ctx, &product, "sku = ?", product.SKU)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			result.Products++
+		}
+		products = append(products, product)
+	}
+	return products, nil
+}
+
+var demoAddresses = []service.AddressRequest{
+	{StreetAddress: "1 Market St", City: "San Francisco", State: "CA", PostalCode: "94105", Country: "US"},
+	{StreetAddress: "600 Congress Ave", City: "Austin", State: "TX", PostalCode: "78701", Country: "US"},
+	{StreetAddress: "350 5th Ave", City: "New York", State: "NY", PostalCode: "10118", Country: "US"},
+	{StreetAddress: "400 Broad St", City: "Seattle", State: "WA", PostalCode: "98109", Country: "US"},
+	{StreetAddress: "233 S Wacker Dr", City: "Chicago", State: "IL", PostalCode: "60606", Country: "US"},
+	{StreetAddress: "1701 Wynkoop St", City: "Denver", State: "CO", PostalCode: "80202", Country: "US"},
+}
+
+var (
+	firstNames = []string{"Ada", "Grace", "Alan", "Linus", "Margaret", "Dennis", "Barbara", "Ken", "Frances", "Rob"}
+	lastNames  = []string{"Lovelace", "Hopper", "Turing", "Torvalds", "Hamilton", "Ritchie", "Liskov", "Thompson", "Allen", "Pike"}
+)
+
+// seedCustomers creates customers with a default shipping and billing
+// address, all with DemoPassword
+func (s *Seeder) seedCustomers(ctx context.Context, count int, result *Result) ([]models.User, error) {
+	customers := make([]models.User, 0, count)
+	for i := 1; i <= count; i++ {
+		email := fmt.Sprintf("customer%04d@example.com", i)
+
+		var user models.User
+		err := s.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+		if err == nil {
+			customers = append(customers, user)
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		created, err := s.services.Auth.CreateUser(ctx, &service.CreateUserRequest{
+			Email:     email,
+			Password:  DemoPassword,
+			FirstName: firstNames[s.rand.Intn(len(firstNames))],
+			LastName:  lastNames[s.rand.Intn(len(lastNames))],
+			Role:      models.RoleCustomer,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		address := demoAddresses[s.rand.Intn(len(demoAddresses))]
+		for _, addressType := range []string{models.AddressTypeShipping, models.AddressTypeBilling} {
+			req := address
+			req.Type = addressType
+			if _, err := s.services.Address.CreateAddress(ctx, created.ID, &req); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := s.db.WithContext(ctx).First(&user, "id = ?", created.ID).Error; err != nil {
+			return nil, err
+		}
+		result.Customers++
+		customers = append(customers, user)
+	}
+	return customers, nil
+}
+
+// seedOrders checks out random carts. Most orders are then paid, some of
+// those shipped and delivered, a few cancelled and the rest left pending.
+// Orders are backdated over the last 90 days.
+func (s *Seeder) seedOrders(ctx context.Context, count int, products []models.Product, customers []models.User, result *Result) error {
+	if len(products) == 0 || len(customers) == 0 {
+		return nil
+	}
+
+	// Only the fake provider can take payments without a real card
+	canPay := s.cfg.PaymentProvider == "fake"
+	if !canPay {
+		s.logger.Warn("Seeded orders are left unpaid with a real payment provider", "provider", s.cfg.PaymentProvider)
+	}
+
+	admin := service.Actor{Role: service.ActorRoleAdmin}
+	for i := 0; i < count; i++ {
+		customer := customers[s.rand.Intn(len(customers))]
+
+		order, err := s.checkout(ctx, customer, products)
+		if err != nil {
+			return err
+		}
+		result.Orders++
+
+		outcome := s.rand.Float64()
+		switch {
+		case outcome < 0.1:
+			customerActor := service.Actor{ID: &customer.ID, Role: service.ActorRoleCustomer}
+			if _, err := s.services.Order.CancelUserOrder(ctx, customer.ID, order.OrderNumber, customerActor, "Changed my mind"); err != nil {
+				return err
+			}
+		case outcome < 0.25 || !canPay:
+			// Left pending
+		default:
+			if err := s.pay(ctx, customer, order); err != nil {
+				return err
+			}
+			if outcome < 0.6 {
+				break
+			}
+			fulfillment, err := s.ship(ctx, order, admin)
+			if err != nil {
+				return err
+			}
+			if outcome < 0.8 {
+				break
+			}
+			if _, err := s.services.Fulfillment.MarkDelivered(ctx, fulfillment.ID, admin); err != nil {
+				return err
+			}
+		}
+
+		placedAt := time.Now().UTC().Add(-time.Duration(s.rand.Int63n(int64(90 * 24 * time.Hour))))
+		if err := s.db.WithContext(ctx).Model(&models.Order{}).Where("id = ?", order.ID).Update("created_at", placedAt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkout fills the customer's cart with one to four products and places
+// an order for it with the cheapest shipping
+func (s *Seeder) checkout(ctx context.Context, customer models.User, products []models.Product) (*models.Order, error) {
+	cart := models.Cart{UserID: &customer.ID}
+	if _, err := s.firstOrCreate(ctx, &cart, "user_id = ?", customer.ID); err != nil {
+		return nil, err
+	}
+
+	picked := make(map[uuid.UUID]bool)
+	for n := s.rand.Intn(4) + 1; n > 0; n-- {
+		product := products[s.rand.Intn(len(products))]
+		if picked[product.ID] {
+			continue
+		}
+		picked[product.ID] = true
+
+		item := models.CartItem{
+			CartID:     cart.ID,
+			ProductID:  product.ID,
+			Quantity:   s.rand.Intn(3) + 1,
+			PriceAtAdd: product.Price,
+		}
+		if err := s.db.WithContext(ctx).Create(&item).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	addresses, err := s.services.Address.ListAddresses(ctx, customer.ID)
+	if err != nil {
+		return nil, err
+	}
+	req := &service.PlaceOrderRequest{}
+	for _, address := range addresses {
+		if !address.IsDefault {
+			continue
+		}
+		switch address.Type {
+		case models.AddressTypeShipping:
+			req.ShippingAddressID = address.ID
+		case models.AddressTypeBilling:
+			req.BillingAddressID = address.ID
+		}
+	}
+
+	quotes, err := s.services.Checkout.ShippingRates(ctx, customer.ID, &service.ShippingRatesRequest{AddressID: req.ShippingAddressID})
+	if err != nil {
+		return nil, err
+	}
+	if len(quotes) > 0 {
+		cheapest := quotes[0]
+		for _, quote := range quotes[1:] {
+			if quote.Amount < cheapest.Amount {
+				cheapest = quote
+			}
+		}
+		req.ShippingMethodID = &cheapest.MethodID
+	}
+
+	return s.services.Checkout.PlaceOrder(ctx, customer.ID, req)
+}
+
+// pay pays an order with the fake provider's test card, capturing it when
+// payments are only authorized
+func (s *Seeder) pay(ctx context.Context, customer models.User, order *models.Order) error {
+	if _, err := s.services.Payment.CreatePaymentIntent(ctx, customer.ID, order.OrderNumber); err != nil {
+		return err
+	}
+
+	record, err := s.services.Payment.ConfirmPayment(ctx, customer.ID, order.OrderNumber, &service.ConfirmPaymentRequest{
+		PaymentMethod: payment.FakeMethodSuccess,
+	})
+	if err != nil {
+		return err
+	}
+
+	if record.Status == models.PaymentStateAuthorized {
+		_, err = s.services.Payment.CapturePayment(ctx, order.ID)
+	}
+	return err
+}
+
+// ship fulfills every item of an order
+func (s *Seeder) ship(ctx context.Context, order *models.Order, actor service.Actor) (*models.Fulfillment, error) {
+	items := make([]service.FulfillmentItemRequest, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, service.FulfillmentItemRequest{OrderItemID: item.ID, Quantity: item.Quantity})
+	}
+
+	return s.services.Fulfillment.CreateFulfillment(ctx, order.ID, &service.CreateFulfillmentRequest{
+		Carrier:        "UPS",
+		TrackingNumber: fmt.Sprintf("1Z%016d", s.rand.Int63n(1e16)),
+		Items:          items,
+	}, actor)
+}
+
+// firstOrCreate loads the record matching the condition into value, or
+// creates value when there is none, and reports whether it was created
+func (s *Seeder) firstOrCreate(ctx context.Context, value interface{}, query string, args ...interface{}) (bool, error) {
+	err := s.db.WithContext(ctx).Where(query, args...).First(value).Error
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	return true, s.db.WithContext(ctx).Create(value).Error
+}
+
+// slugify lowercases a name and joins its words with dashes
+func slugify(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	})
+	return strings.Join(words, "-")
+}
+
+// roundMoney rounds an amount to cents
+func roundMoney(amount float64) float64 {
+	return float64(int64(amount*100+0.5)) / 100
+}
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/api"
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/database"
+	"github.com/Shihasz/gophiway/internal/health"
+	"github.com/Shihasz/gophiway/internal/lifecycle"
+	"github.com/Shihasz/gophiway/internal/metrics"
+	"github.com/Shihasz/gophiway/internal/middleware"
+	"github.com/Shihasz/gophiway/internal/storage"
+	"github.com/Shihasz/gophiway/internal/tracing"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"gorm.io/gorm"
+)
+
+// New creates the Fiber app with its middleware and routes
+func New(cfg *config.Config, db *gorm.DB, logger *slog.Logger, metrics *metrics.Metrics, checks *health.Health) (*fiber.App, error) {
+	// Create Fiber app
+	app := fiber.New(fiber.Config{
+		AppName:      cfg.AppName,
+		ErrorHandler: customErrorHandler,
+	})
+
+	// Middleware
+	app.Use(middleware.RequestID())
+	app.Use(middleware.Tracing())
+	app.Use(middleware.Metrics(metrics))
+	app.Use(middleware.RequestLogger(logger))
+	app.Use(middleware.Recover(logger))
+	app.Use(middleware.Deadline(cfg.RequestTimeout))
+	app.Use(cors.New(cors.Config{
+		AllowOrigins:     cfg.CORSAllowedOrigins,
+		AllowMethods:     cfg.CORSAllowedMethods,
+		AllowHeaders:     cfg.CORSAllowedHeaders,
+		ExposeHeaders:    middleware.RequestIDHeader,
+		AllowCredentials: true,
+	}))
+
+	// Health checks: liveness only tells the process is up, readiness that
+	// its dependencies are usable. /health is kept for existing probes.
+	ready := func(c *fiber.Ctx) error {
+		report := checks.Ready(c.UserContext())
+		if report.Status != health.StatusOK {
+			c.Status(fiber.StatusServiceUnavailable)
+		}
+		return c.JSON(report)
+	}
+	app.Get("/health/live", func(c *fiber.Ctx) error {
+		return c.JSON(checks.Live())
+	})
+	app.Get("/health/ready", ready)
+	app.Get("/health", ready)
+
+	// Metrics, unless they have a port of their own
+	if cfg.MetricsPort == "" && cfg.MetricsToken != "" {
+		app.Get("/metrics", adaptor.HTTPHandler(metrics.Handler(cfg.MetricsToken)))
+	}
+
+	// Setup API routes
+	if err := api.SetupRoutes(app, db, cfg, logger, metrics); err != nil {
+		return nil, err
+	}
+
+	return app, nil
+}
+
+// Run migrates the database when enabled and serves the API until the
+// process is interrupted. It then shuts down gracefully: readiness fails,
+// in-flight requests drain and the connections close, db included.
+func Run(cfg *config.Config, db *gorm.DB, logger *slog.Logger) error {
+	checks, err := newHealth(cfg, db, logger)
+	if err != nil {
+		return err
+	}
+	lc := lifecycle.New(logger, checks, cfg.ShutdownTimeout, cfg.ShutdownDelay)
+
+	app, err := start(cfg, db, logger, checks, lc)
+	if err != nil {
+		return errors.Join(err, lc.Shutdown())
+	}
+
+	addr := ":" + cfg.Port
+	logger.Info("Gophiway API starting", "addr", addr, "env", cfg.AppEnv)
+	return lc.Run(func() error {
+		return app.Listen(addr)
+	})
+}
+
+// start prepares everything the API needs, registering each part with the
+// lifecycle manager in the order opposite to stopping it: tracing, the
+// database, the metrics server and finally the API itself
+func start(cfg *config.Config, db *gorm.DB, logger *slog.Logger, checks *health.Health, lc *lifecycle.Manager) (*fiber.App, error) {
+	// Initialize tracing, flushed last so the spans of shutdown are kept
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	lc.Register("tracing", shutdownTracing)
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	lc.Register("database", func(context.Context) error {
+		return sqlDB.Close()
+	})
+
+	// Run migrations when enabled, otherwise only warn about pending ones
+	if cfg.DBAutoMigrate {
+		if err := database.Migrate(db, logger); err != nil {
+			return nil, err
+		}
+	} else if migrator, err := database.NewMigrator(db, logger); err != nil {
+		return nil, err
+	} else if pending, err := migrator.Pending(); err != nil {
+		logger.Warn("Failed to check migrations", "error", err)
+	} else if pending > 0 {
+		logger.Warn("Pending migrations, run `gophiway migrate up` or set DB_AUTO_MIGRATE=true", "pending", pending)
+	}
+
+	// Initialize metrics
+	appMetrics := metrics.New()
+	if err := appMetrics.RegisterDB(sqlDB, cfg.DBName); err != nil {
+		return nil, err
+	}
+	if metricsServer := serveMetrics(cfg, appMetrics, logger); metricsServer != nil {
+		lc.Register("metrics server", metricsServer.Shutdown)
+	}
+
+	app, err := New(cfg, db, logger, appMetrics, checks)
+	if err != nil {
+		return nil, err
+	}
+	lc.Register("http server", app.ShutdownWithContext)
+
+	return app, nil
+}
+
+// newHealth creates the health checks with a readiness checker for each
+// service the API depends on
+func newHealth(cfg *config.Config, db *gorm.DB, logger *slog.Logger) (*health.Health, error) {
+	checks := health.New(cfg.AppName, cfg.APIVersion, cfg.HealthCheckTimeout, cfg.HealthCacheTTL)
+	checks.Register(health.Database(db))
+	checks.Register(health.Migrations(db, logger))
+	// Redis is only pinged, the API keeps no client of it to close on shutdown
+	if cfg.RedisHost != "" {
+		checks.Register(health.Redis(net.JoinHostPort(cfg.RedisHost, cfg.RedisPort), cfg.RedisPassword))
+	}
+
+	// The memory store has nothing to check
+	store, err := storage.NewDocumentStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if pinger, ok := store.(health.Pinger); ok {
+		checks.Register(health.Ping("storage", pinger))
+	}
+	return checks, nil
+}
+
+// serveMetrics serves the metrics on their own port when one is configured.
+// Without a port or a token they are not exposed at all.
+func serveMetrics(cfg *config.Config, metrics *metrics.Metrics, logger *slog.Logger) *http.Server {
+	if cfg.MetricsPort == "" {
+		if cfg.MetricsToken == "" {
+			logger.Warn("Metrics are not exposed, set METRICS_PORT or METRICS_TOKEN")
+		}
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler(cfg.MetricsToken))
+	server := &http.Server{
+		Addr:              ":" + cfg.MetricsPort,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		logger.Info("Metrics server starting", "addr", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Metrics server failed", "error", err)
+		}
+	}()
+	return server
+}
+
+func customErrorHandler(c *fiber.Ctx, err error) error {
+	code := fiber.StatusInternalServerError
+
+	if e, ok := err.(*fiber.Error); ok {
+		code = e.Code
+	}
+
+	return middleware.SendErrorBody(c, code, fiber.Map{
+		"code":    code,
+		"message": err.Error(),
+	})
+}
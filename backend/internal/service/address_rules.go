@@ -0,0 +1,73 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// addressRule is how a country formats its addresses
+type addressRule struct {
+	postalCode    *regexp.Regexp // nil when the country has no postal codes
+	postalExample string
+	// postalSpace is the position of the space in the postal code, counted
+	// from the end, for formats customers often type without it
+	postalSpace   int
+	stateRequired bool
+}
+
+// addressRules are the countries with known formats. Other countries accept
+// any postal code and an optional state.
+var addressRules = map[string]addressRule{
+	"US": {postalCode: regexp.MustCompile(`^\d{5}(-\d{4})?$`), postalExample: "12345 or 12345-6789", stateRequired: true},
+	"CA": {postalCode: regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY]\d[ABCEGHJ-NPRSTV-Z] \d[ABCEGHJ-NPRSTV-Z]\d$`), postalExample: "K1A 0B1", postalSpace: 3, stateRequired: true},
+	"AU": {postalCode: regexp.MustCompile(`^\d{4}$`), postalExample: "2000", stateRequired: true},
+	"IN": {postalCode: regexp.MustCompile(`^[1-9]\d{5}$`), postalExample: "110001", stateRequired: true},
+	"BR": {postalCode: regexp.MustCompile(`^\d{5}-\d{3}$`), postalExample: "01310-100", stateRequired: true},
+	"MX": {postalCode: regexp.MustCompile(`^\d{5}$`), postalExample: "06600", stateRequired: true},
+	"JP": {postalCode: regexp.MustCompile(`^\d{3}-\d{4}$`), postalExample: "100-0001", stateRequired: true},
+	"GB": {postalCode: regexp.MustCompile(`^(GIR 0AA|[A-Z]{1,2}\d[A-Z\d]? \d[A-Z]{2})$`), postalExample: "SW1A 1AA", postalSpace: 3},
+	"IE": {postalCode: regexp.MustCompile(`^([AC-FHKNPRTV-Y]\d{2}|D6W) [0-9AC-FHKNPRTV-Y]{4}$`), postalExample: "D02 X285", postalSpace: 4},
+	"NL": {postalCode: regexp.MustCompile(`^\d{4} [A-Z]{2}$`), postalExample: "1012 AB", postalSpace: 2},
+	"SE": {postalCode: regexp.MustCompile(`^\d{3} \d{2}$`), postalExample: "114 55", postalSpace: 2},
+	"DE": {postalCode: regexp.MustCompile(`^\d{5}$`), postalExample: "10115"},
+	"FR": {postalCode: regexp.MustCompile(`^\d{5}$`), postalExample: "75001"},
+	"IT": {postalCode: regexp.MustCompile(`^\d{5}$`), postalExample: "00118"},
+	"ES": {postalCode: regexp.MustCompile(`^\d{5}$`), postalExample: "28001"},
+	"BE": {postalCode: regexp.MustCompile(`^\d{4}$`), postalExample: "1000"},
+	"AT": {postalCode: regexp.MustCompile(`^\d{4}$`), postalExample: "1010"},
+	"CH": {postalCode: regexp.MustCompile(`^\d{4}$`), postalExample: "8001"},
+	"PL": {postalCode: regexp.MustCompile(`^\d{2}-\d{3}$`), postalExample: "00-001"},
+	"SG": {postalCode: regexp.MustCompile(`^\d{6}$`), postalExample: "018956"},
+	"AE": {},
+	"HK": {},
+}
+
+// normalizeAddress tidies the country, state and postal code and checks them
+// against the country's format
+func normalizeAddress(country, state, postalCode string) (string, string, string, error) {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	state = strings.TrimSpace(state)
+	postalCode = strings.Join(strings.Fields(strings.ToUpper(postalCode)), " ")
+
+	rule, known := addressRules[country]
+	if !known {
+		return country, state, postalCode, nil
+	}
+
+	if rule.stateRequired && state == "" {
+		return "", "", "", fmt.Errorf("%w: state is required for %s", ErrInvalidAddress, country)
+	}
+
+	if rule.postalCode == nil {
+		return country, state, "", nil
+	}
+	if rule.postalSpace > 0 && !strings.Contains(postalCode, " ") && len(postalCode) > rule.postalSpace {
+		split := len(postalCode) - rule.postalSpace
+		postalCode = postalCode[:split] + " " + postalCode[split:]
+	}
+	if !rule.postalCode.MatchString(postalCode) {
+		return "", "", "", fmt.Errorf("%w: postal code for %s should look like %s", ErrInvalidAddress, country, rule.postalExample)
+	}
+	return country, state, postalCode, nil
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAddressNotFound = errors.New("address not found")
+	ErrInvalidAddress  = errors.New("invalid address")
+)
+
+type AddressService struct {
+	addressRepo repository.AddressRepository
+}
+
+func NewAddressService(addressRepo repository.AddressRepository) *AddressService {
+	return &AddressService{
+		addressRepo: addressRepo,
+	}
+}
+
+// AddressRequest represents creating or replacing an address. An address
+// stops being the default only when another address of its type takes over.
+type AddressRequest struct {
+	Type          string `json:"type" validate:"required,oneof=shipping billing"`
+	StreetAddress string `json:"street_address" validate:"required,max=255"`
+	City          string `json:"city" validate:"required,max=100"`
+	State         string `json:"state" validate:"max=100"`
+	PostalCode    string `json:"postal_code" validate:"max=20"`
+	Country       string `json:"country" validate:"required,len=2,alpha"`
+	IsDefault     bool   `json:"is_default"`
+}
+
+// ListAddresses lists the user's addresses
+func (s *AddressService) ListAddresses(ctx context.Context, userID uuid.UUID) ([]models.Address, error) {
+	return s.addressRepo.ListByUser(ctx, userID)
+}
+
+// GetAddress gets one of the user's addresses
+func (s *AddressService) GetAddress(ctx context.Context, userID, id uuid.UUID) (*models.Address, error) {
+	return s.getAddress(ctx, s.addressRepo, userID, id)
+}
+
+// CreateAddress adds an address to the user's address book. The first
+// address of a type becomes its default.
+func (s *AddressService) CreateAddress(ctx context.Context, userID uuid.UUID, req *AddressRequest) (*models.Address, error) {
+	address := &models.Address{UserID: userID}
+	if err := req.apply(address); err != nil {
+		return nil, err
+	}
+
+	err := s.addressRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		addressRepo := s.addressRepo.WithTx(tx)
+		if err := addressRepo.LockUser(ctx, userID); err != nil {
+			return err
+		}
+
+		count, err := addressRepo.CountByType(ctx, userID, address.Type)
+		if err != nil {
+			return err
+		}
+		address.IsDefault = req.IsDefault || count == 0
+
+		if address.IsDefault {
+			if err := addressRepo.ClearDefault(ctx, userID, address.Type); err != nil {
+				return err
+			}
+		}
+		return addressRepo.Create(ctx, address)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// UpdateAddress replaces one of the user's addresses. Orders keep the copy
+// taken when they were placed.
+func (s *AddressService) UpdateAddress(ctx context.Context, userID, id uuid.UUID, req *AddressRequest) (*models.Address, error) {
+	var address *models.Address
+	err := s.addressRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		addressRepo := s.addressRepo.WithTx(tx)
+		if err := addressRepo.LockUser(ctx, userID); err != nil {
+			return err
+		}
+
+		var err error
+		address, err = s.getAddress(ctx, addressRepo, userID, id)
+		if err != nil {
+			return err
+		}
+
+		oldType, wasDefault := address.Type, address.IsDefault
+		if err := req.apply(address); err != nil {
+			return err
+		}
+		moved := address.Type != oldType
+
+		// The address keeps its default unless it moves to another type
+		makeDefault := req.IsDefault || (wasDefault && !moved)
+		if moved && !makeDefault {
+			count, err := addressRepo.CountByType(ctx, userID, address.Type)
+			if err != nil {
+				return err
+			}
+			makeDefault = count == 0
+		}
+
+		if makeDefault {
+			if err := addressRepo.ClearDefault(ctx, userID, address.Type); err != nil {
+				return err
+			}
+		}
+		address.IsDefault = makeDefault
+		if err := addressRepo.Update(ctx, address); err != nil {
+			return err
+		}
+
+		if moved && wasDefault {
+			return s.promoteDefault(ctx, addressRepo, userID, oldType)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// SetDefaultAddress makes an address the default of its type
+func (s *AddressService) SetDefaultAddress(ctx context.Context, userID, id uuid.UUID) (*models.Address, error) {
+	var address *models.Address
+	err := s.addressRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		addressRepo := s.addressRepo.WithTx(tx)
+		if err := addressRepo.LockUser(ctx, userID); err != nil {
+			return err
+		}
+
+		var err error
+		address, err = s.getAddress(ctx, addressRepo, userID, id)
+		if err != nil {
+			return err
+		}
+		if address.IsDefault {
+			return nil
+		}
+
+		if err := addressRepo.ClearDefault(ctx, userID, address.Type); err != nil {
+			return err
+		}
+		address.IsDefault = true
+		return addressRepo.Update(ctx, address)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// DeleteAddress removes an address from the user's address book. When it
+// was the default, the most recently added address of its type takes over.
+func (s *AddressService) DeleteAddress(ctx context.Context, userID, id uuid.UUID) error {
+	return s.addressRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		addressRepo := s.addressRepo.WithTx(tx)
+		if err := addressRepo.LockUser(ctx, userID); err != nil {
+			return err
+		}
+
+		address, err := s.getAddress(ctx, addressRepo, userID, id)
+		if err != nil {
+			return err
+		}
+		if err := addressRepo.Delete(ctx, address.ID); err != nil {
+			return err
+		}
+
+		if address.IsDefault {
+			return s.promoteDefault(ctx, addressRepo, userID, address.Type)
+		}
+		return nil
+	})
+}
+
+// getAddress gets one of the user's addresses
+func (s *AddressService) getAddress(ctx context.Context, addressRepo repository.AddressRepository, userID, id uuid.UUID) (*models.Address, error) {
+	address, err := addressRepo.GetUserAddress(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAddressNotFound
+		}
+		return nil, err
+	}
+	return address, nil
+}
+
+// promoteDefault makes the most recently added address of a type its
+// default, if the user has any left
+func (s *AddressService) promoteDefault(ctx context.Context, addressRepo repository.AddressRepository, userID uuid.UUID, addressType string) error {
+	address, err := addressRepo.GetLatestByType(ctx, userID, addressType)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	address.IsDefault = true
+	return addressRepo.Update(ctx, address)
+}
+
+// apply validates the request against the country's format and copies it
+// onto an address
+func (req *AddressRequest) apply(address *models.Address) error {
+	country, state, postalCode, err := normalizeAddress(req.Country, req.State, req.PostalCode)
+	if err != nil {
+		return err
+	}
+
+	address.Type = req.Type
+	address.StreetAddress = strings.TrimSpace(req.StreetAddress)
+	address.City = strings.TrimSpace(req.City)
+	address.State = state
+	address.PostalCode = postalCode
+	address.Country = country
+	return nil
+}
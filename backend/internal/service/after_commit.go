@@ -0,0 +1,51 @@
+package service
+
+import "context"
+
+// afterCommitKey is the context key of the work deferred until commit
+type afterCommitKey struct{}
+
+// afterCommit collects work that must only happen once a transaction has
+// committed, such as recording metrics about its changes
+type afterCommit struct {
+	fns []func()
+}
+
+// withAfterCommit returns a context in which onCommit defers work to the
+// returned collector, to be run once the transaction has committed. Inside
+// a context that already collects, the outermost collector keeps the work
+// and the returned one is empty.
+func withAfterCommit(ctx context.Context) (context.Context, *afterCommit) {
+	if collector, ok := ctx.Value(afterCommitKey{}).(*afterCommit); ok && collector != nil {
+		return ctx, nil
+	}
+	collector := &afterCommit{}
+	return context.WithValue(ctx, afterCommitKey{}, collector), collector
+}
+
+// run runs the collected work
+func (a *afterCommit) run() {
+	if a == nil {
+		return
+	}
+	for _, fn := range a.fns {
+		fn()
+	}
+}
+
+// onCommit defers fn until the transaction of ctx has committed. Outside a
+// context from withAfterCommit fn runs at once.
+func onCommit(ctx context.Context, fn func()) {
+	if collector, ok := ctx.Value(afterCommitKey{}).(*afterCommit); ok && collector != nil {
+		collector.fns = append(collector.fns, fn)
+		return
+	}
+	fn()
+}
+
+// committedContext returns a context for work deferred with onCommit. It
+// outlives the request and no longer collects, so transactions the work
+// runs collect and commit on their own.
+func committedContext(ctx context.Context) context.Context {
+	return context.WithValue(context.WithoutCancel(ctx), afterCommitKey{}, (*afterCommit)(nil))
+}
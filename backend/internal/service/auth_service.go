@@ -1,9 +1,11 @@
 package service
 
 import (
+	"context"
 	"errors"
 
 	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/metrics"
 	"github.com/Shihasz/gophiway/internal/models"
 	"github.com/Shihasz/gophiway/internal/repository"
 	"github.com/Shihasz/gophiway/pkg/crypto"
@@ -18,14 +20,16 @@ var (
 )
 
 type AuthService struct {
-	userRepo *repository.UserRepository
+	userRepo repository.UserRepository
 	cfg      *config.Config
+	metrics  *metrics.Metrics
 }
 
-func NewAuthService(userRepo *repository.UserRepository, cfg *config.Config) *AuthService {
+func NewAuthService(userRepo repository.UserRepository, cfg *config.Config, metrics *metrics.Metrics) *AuthService {
 	return &AuthService{
 		userRepo: userRepo,
 		cfg:      cfg,
+		metrics:  metrics,
 	}
 }
 
@@ -43,6 +47,28 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// CreateUserRequest represents creating a user with any role, which only
+// the command line tools do
+type CreateUserRequest struct {
+	Email     string `json:"email" validate:"required,email"`
+	Password  string `json:"password" validate:"required,min=8"`
+	FirstName string `json:"first_name" validate:"required"`
+	LastName  string `json:"last_name" validate:"required"`
+	Role      string `json:"role" validate:"required,oneof=customer admin"`
+}
+
+// ResetPasswordRequest represents replacing a user's password
+type ResetPasswordRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+// SetRoleRequest represents changing a user's role
+type SetRoleRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=customer admin"`
+}
+
 // AuthResponse represents an authentication response
 type AuthResponse struct {
 	User         *UserResponse `json:"user"`
@@ -61,9 +87,9 @@ type UserResponse struct {
 }
 
 // Register registers a new user
-func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
+func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*AuthResponse, error) {
 	// Check if email already exists
-	exists, err := s.userRepo.EmailExists(req.Email)
+	exists, err := s.userRepo.EmailExists(ctx, req.Email)
 	if err != nil {
 		return nil, err
 	}
@@ -83,12 +109,13 @@ func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
 		PasswordHash: hashedPassword,
 		FirstName:    req.FirstName,
 		LastName:     req.LastName,
-		Role:         "customer",
+		Role:         models.RoleCustomer,
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
+	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, err
 	}
+	s.metrics.UserRegistered()
 
 	// Generate tokens
 	accessToken, err := crypto.GenerateToken(user.ID, user.Email, user.Role, s.cfg.JWTSecret, s.cfg.JWTExpiration)
@@ -116,11 +143,12 @@ func (s *AuthService) Register(req *RegisterRequest) (*AuthResponse, error) {
 }
 
 // Login authenticates a user
-func (s *AuthService) Login(req *LoginRequest) (*AuthResponse, error) {
+func (s *AuthService) Login(ctx context.Context, req *LoginRequest) (*AuthResponse, error) {
 	// Get user by email
-	user, err := s.userRepo.GetByEmail(req.Email)
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.metrics.LoginAttempted(false)
 			return nil, ErrInvalidCredentials
 		}
 		return nil, err
@@ -128,8 +156,10 @@ func (s *AuthService) Login(req *LoginRequest) (*AuthResponse, error) {
 
 	// Check password
 	if !crypto.CheckPassword(req.Password, user.PasswordHash) {
+		s.metrics.LoginAttempted(false)
 		return nil, ErrInvalidCredentials
 	}
+	s.metrics.LoginAttempted(true)
 
 	// Generate tokens
 	accessToken, err := crypto.GenerateToken(user.ID, user.Email, user.Role, s.cfg.JWTSecret, s.cfg.JWTExpiration)
@@ -157,7 +187,7 @@ func (s *AuthService) Login(req *LoginRequest) (*AuthResponse, error) {
 }
 
 // RefreshToken refreshes an access token
-func (s *AuthService) RefreshToken(refreshToken string) (*AuthResponse, error) {
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*AuthResponse, error) {
 	// Validate refresh token
 	claims, err := crypto.ValidateToken(refreshToken, s.cfg.JWTRefreshSecret)
 	if err != nil {
@@ -165,7 +195,7 @@ func (s *AuthService) RefreshToken(refreshToken string) (*AuthResponse, error) {
 	}
 
 	// Get user
-	user, err := s.userRepo.GetByID(claims.UserID)
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrUserNotFound
@@ -199,8 +229,8 @@ func (s *AuthService) RefreshToken(refreshToken string) (*AuthResponse, error) {
 }
 
 // GetCurrentUser gets the current authenticated user
-func (s *AuthService) GetCurrentUser(userID uuid.UUID) (*UserResponse, error) {
-	user, err := s.userRepo.GetByID(userID)
+func (s *AuthService) GetCurrentUser(ctx context.Context, userID uuid.UUID) (*UserResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrUserNotFound
@@ -217,3 +247,92 @@ func (s *AuthService) GetCurrentUser(userID uuid.UUID) (*UserResponse, error) {
 		EmailVerified: user.EmailVerified,
 	}, nil
 }
+
+// CreateUser creates a user with the given role
+func (s *AuthService) CreateUser(ctx context.Context, req *CreateUserRequest) (*UserResponse, error) {
+	exists, err := s.userRepo.EmailExists(ctx, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrEmailAlreadyExists
+	}
+
+	hashedPassword, err := crypto.HashPassword(req.Password, s.cfg.BcryptCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Email:        req.Email,
+		PasswordHash: hashedPassword,
+		FirstName:    req.FirstName,
+		LastName:     req.LastName,
+		Role:         req.Role,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return newUserResponse(user), nil
+}
+
+// ResetPassword replaces a user's password
+func (s *AuthService) ResetPassword(ctx context.Context, req *ResetPasswordRequest) (*UserResponse, error) {
+	user, err := s.getUserByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := crypto.HashPassword(req.Password, s.cfg.BcryptCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user.PasswordHash = hashedPassword
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return newUserResponse(user), nil
+}
+
+// SetRole changes a user's role. Tokens issued before keep the previous
+// role until they expire.
+func (s *AuthService) SetRole(ctx context.Context, req *SetRoleRequest) (*UserResponse, error) {
+	user, err := s.getUserByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Role = req.Role
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return newUserResponse(user), nil
+}
+
+// getUserByEmail gets a user by email
+func (s *AuthService) getUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// newUserResponse builds the response for a user
+func newUserResponse(user *models.User) *UserResponse {
+	return &UserResponse{
+		ID:            user.ID,
+		Email:         user.Email,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerified,
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/pricing"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CartService struct {
+	db               *gorm.DB
+	cartRepo         repository.CartRepository
+	couponService    *CouponService
+	promotionService *PromotionService
+}
+
+func NewCartService(db *gorm.DB, cartRepo repository.CartRepository, couponService *CouponService, promotionService *PromotionService) *CartService {
+	return &CartService{
+		db:               db,
+		cartRepo:         cartRepo,
+		couponService:    couponService,
+		promotionService: promotionService,
+	}
+}
+
+// ApplyCouponRequest represents entering a discount code
+type ApplyCouponRequest struct {
+	Code string `json:"code" validate:"required,max=50"`
+}
+
+// CartResponse is the user's cart priced with its discounts
+type CartResponse struct {
+	ID          uuid.UUID `json:"id"`
+	CouponCode  string    `json:"coupon_code,omitempty"`
+	CouponError string    `json:"coupon_error,omitempty"`
+	*pricing.Cart
+}
+
+// GetCart prices the user's cart. A coupon that no longer applies is
+// reported in the response rather than failing it.
+func (s *CartService) GetCart(ctx context.Context, userID uuid.UUID) (*CartResponse, error) {
+	cart, err := s.cartRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &CartResponse{Cart: pricing.NewCart([]pricing.Line{})}, nil
+		}
+		return nil, err
+	}
+
+	resp := &CartResponse{ID: cart.ID, CouponCode: cart.CouponCode}
+
+	priced, err := s.priceTx(ctx, s.db, userID, cartLines(cart.Items), cart.CouponCode)
+	if err != nil && cart.CouponCode != "" && isCouponError(err) {
+		resp.CouponError = err.Error()
+		priced, err = s.priceTx(ctx, s.db, userID, cartLines(cart.Items), "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Cart = priced
+	return resp, nil
+}
+
+// ApplyCoupon validates a discount code against the cart and keeps it for
+// checkout
+func (s *CartService) ApplyCoupon(ctx context.Context, userID uuid.UUID, req *ApplyCouponRequest) (*CartResponse, error) {
+	cart, err := s.cartRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCartEmpty
+		}
+		return nil, err
+	}
+	if len(cart.Items) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	code := normalizeCouponCode(req.Code)
+	priced, err := s.priceTx(ctx, s.db, userID, cartLines(cart.Items), code)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cartRepo.SetCouponCode(ctx, cart.ID, code); err != nil {
+		return nil, err
+	}
+
+	return &CartResponse{ID: cart.ID, CouponCode: code, Cart: priced}, nil
+}
+
+// RemoveCoupon removes the discount code from the cart
+func (s *CartService) RemoveCoupon(ctx context.Context, userID uuid.UUID) (*CartResponse, error) {
+	cart, err := s.cartRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCartEmpty
+		}
+		return nil, err
+	}
+
+	if err := s.cartRepo.SetCouponCode(ctx, cart.ID, ""); err != nil {
+		return nil, err
+	}
+	cart.CouponCode = ""
+
+	priced, err := s.priceTx(ctx, s.db, userID, cartLines(cart.Items), "")
+	if err != nil {
+		return nil, err
+	}
+	return &CartResponse{ID: cart.ID, Cart: priced}, nil
+}
+
+// priceTx is the cart pricing path shared by the cart and checkout: it
+// prices the lines, applies the running promotions and then the coupon,
+// failing if the coupon does not apply
+func (s *CartService) priceTx(ctx context.Context, tx *gorm.DB, userID uuid.UUID, lines []pricing.Line, couponCode string) (*pricing.Cart, error) {
+	productIDs := make([]uuid.UUID, 0, len(lines))
+	for _, line := range lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+
+	categories, err := repository.NewProductRepository(tx).CategoryIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lines {
+		lines[i].CategoryIDs = categories[lines[i].ProductID]
+	}
+
+	cart := pricing.NewCart(lines)
+
+	promotions, err := s.promotionService.activePromotions(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	pricing.ApplyPromotions(cart, promotions)
+
+	if couponCode != "" {
+		coupon, err := s.couponService.findCoupon(ctx, tx, couponCode)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.couponService.checkCoupon(ctx, tx, coupon, userID); err != nil {
+			return nil, err
+		}
+		if err := pricing.ApplyCoupon(cart, coupon); err != nil {
+			return nil, err
+		}
+	}
+
+	return cart, nil
+}
+
+// cartLines turns cart items into pricing lines at current product prices
+func cartLines(items []models.CartItem) []pricing.Line {
+	lines := make([]pricing.Line, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, productLine(item.ID, &item.Product, item.Quantity))
+	}
+	return lines
+}
+
+// productLine is a pricing line for units of a product
+func productLine(reference uuid.UUID, product *models.Product, quantity int) pricing.Line {
+	return pricing.Line{
+		Reference: reference.String(),
+		ProductID: product.ID,
+		Name:      product.Name,
+		UnitPrice: product.Price,
+		Quantity:  quantity,
+	}
+}
+
+// couponDiscount is the amount taken off lines by the coupon
+func couponDiscount(cart *pricing.Cart) float64 {
+	for _, discount := range cart.Discounts {
+		if discount.Source == pricing.SourceCoupon {
+			return discount.Amount
+		}
+	}
+	return 0
+}
+
+// isCouponError reports whether err is a reason a coupon cannot be used
+func isCouponError(err error) bool {
+	return errors.Is(err, ErrCouponNotFound) ||
+		errors.Is(err, ErrCouponNotActive) ||
+		errors.Is(err, ErrCouponUsageLimitReached) ||
+		errors.Is(err, ErrCouponNotEligible) ||
+		errors.Is(err, pricing.ErrCouponNotApplicable)
+}
@@ -0,0 +1,465 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/metrics"
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/pricing"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/Shihasz/gophiway/internal/shipping"
+	"github.com/Shihasz/gophiway/internal/tax"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCartEmpty          = errors.New("cart is empty")
+	ErrProductUnavailable = errors.New("product is unavailable")
+	ErrInsufficientStock  = errors.New("insufficient stock")
+
+	ErrShippingMethodRequired    = errors.New("a shipping method must be selected")
+	ErrShippingMethodUnavailable = errors.New("shipping method is not available for this cart and address")
+	ErrShippingQuoteChanged      = errors.New("shipping quote changed while placing the order")
+)
+
+type CheckoutService struct {
+	orderRepo          repository.OrderRepository
+	cartRepo           repository.CartRepository
+	addressRepo        repository.AddressRepository
+	cartService        *CartService
+	tenderService      *TenderService
+	taxCalculator      tax.Calculator
+	shippingCalculator *shipping.Calculator
+	metrics            *metrics.Metrics
+}
+
+func NewCheckoutService(
+	orderRepo repository.OrderRepository,
+	cartRepo repository.CartRepository,
+	addressRepo repository.AddressRepository,
+	cartService *CartService,
+	tenderService *TenderService,
+	taxCalculator tax.Calculator,
+	shippingCalculator *shipping.Calculator,
+	metrics *metrics.Metrics,
+) *CheckoutService {
+	return &CheckoutService{
+		orderRepo:          orderRepo,
+		cartRepo:           cartRepo,
+		addressRepo:        addressRepo,
+		cartService:        cartService,
+		tenderService:      tenderService,
+		taxCalculator:      taxCalculator,
+		shippingCalculator: shippingCalculator,
+		metrics:            metrics,
+	}
+}
+
+// PlaceOrderRequest represents a checkout request. The shipping method is
+// required whenever shipping rates are configured for the address. Gift
+// cards, then store credit, pay for as much of the order as they can; the
+// rest is paid through the payment provider.
+type PlaceOrderRequest struct {
+	ShippingAddressID uuid.UUID  `json:"shipping_address_id" validate:"required"`
+	BillingAddressID  uuid.UUID  `json:"billing_address_id" validate:"required"`
+	ShippingMethodID  *uuid.UUID `json:"shipping_method_id"`
+	GiftCardCodes     []string   `json:"gift_card_codes" validate:"omitempty,max=5,dive,required,max=32"`
+	UseStoreCredit    bool       `json:"use_store_credit"`
+}
+
+// ShippingRatesRequest represents a shipping quote request
+type ShippingRatesRequest struct {
+	AddressID uuid.UUID `query:"address_id" validate:"required"`
+}
+
+// ShippingRates quotes the shipping options for the user's cart to one of
+// their addresses
+func (s *CheckoutService) ShippingRates(ctx context.Context, userID uuid.UUID, req *ShippingRatesRequest) ([]shipping.Quote, error) {
+	cart, err := s.cartRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCartEmpty
+		}
+		return nil, err
+	}
+	if len(cart.Items) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	address, err := s.addressRepo.GetUserAddress(ctx, userID, req.AddressID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAddressNotFound
+		}
+		return nil, err
+	}
+
+	priced, err := s.cartService.GetCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes, err := s.shippingCalculator.Quote(ctx, cartShipment(cart, priced, address))
+	if err != nil {
+		return nil, err
+	}
+	if priced.FreeShipping {
+		for i := range quotes {
+			quotes[i].Amount = 0
+		}
+	}
+	return quotes, nil
+}
+
+// PlaceOrder turns the user's cart into a pending order, reserving stock for
+// every item, redeeming the cart's coupon, applying gift cards and store
+// credit and emptying the cart
+func (s *CheckoutService) PlaceOrder(ctx context.Context, userID uuid.UUID, req *PlaceOrderRequest) (*models.Order, error) {
+	cart, err := s.cartRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCartEmpty
+		}
+		return nil, err
+	}
+	if len(cart.Items) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	var shippingAddress, billingAddress *models.Address
+	for _, id := range []uuid.UUID{req.ShippingAddressID, req.BillingAddressID} {
+		address, err := s.addressRepo.GetUserAddress(ctx, userID, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrAddressNotFound
+			}
+			return nil, err
+		}
+		if id == req.ShippingAddressID {
+			shippingAddress = address
+		}
+		if id == req.BillingAddressID {
+			billingAddress = address
+		}
+	}
+
+	// Carriers are asked before any stock is locked; the quote is only
+	// checked again once the products are
+	priced, err := s.cartService.GetCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	quoted := cartShipment(cart, priced, shippingAddress)
+	quote, err := s.quoteShipping(ctx, quoted, req.ShippingMethodID)
+	if err != nil {
+		return nil, err
+	}
+
+	orderNumber, err := generateOrderNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	order := &models.Order{
+		// The ID is known up front so the coupon can be redeemed first
+		BaseModel:         models.BaseModel{ID: uuid.New()},
+		UserID:            userID,
+		OrderNumber:       orderNumber,
+		Status:            models.OrderStatusPending,
+		PaymentStatus:     models.PaymentStatusPending,
+		ShippingAddressID: req.ShippingAddressID,
+		BillingAddressID:  req.BillingAddressID,
+		// Copies, so later address book changes leave the order alone
+		ShippingAddress: orderAddress(shippingAddress),
+		BillingAddress:  orderAddress(billingAddress),
+	}
+
+	err = s.orderRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		productRepo := repository.NewProductRepository(tx)
+
+		ids := make([]uuid.UUID, 0, len(cart.Items))
+		for _, item := range cart.Items {
+			ids = append(ids, item.ProductID)
+		}
+
+		products, err := productRepo.GetByIDsForUpdate(ctx, ids)
+		if err != nil {
+			return err
+		}
+		byID := make(map[uuid.UUID]models.Product, len(products))
+		for _, product := range products {
+			byID[product.ID] = product
+		}
+
+		shipment := &shipping.Shipment{Destination: shippingDestination(shippingAddress)}
+		lines := make([]pricing.Line, 0, len(cart.Items))
+		for _, item := range cart.Items {
+			product, ok := byID[item.ProductID]
+			if !ok || !product.IsActive {
+				return fmt.Errorf("%w: %s", ErrProductUnavailable, item.Product.Name)
+			}
+			if product.StockQuantity < item.Quantity {
+				return fmt.Errorf("%w: only %d of %s left", ErrInsufficientStock, product.StockQuantity, product.Name)
+			}
+
+			lines = append(lines, productLine(item.ID, &product, item.Quantity))
+			shipment.Items = append(shipment.Items, shipmentItem(&product, item.Quantity))
+
+			if err := productRepo.AdjustStock(ctx, product.ID, -item.Quantity); err != nil {
+				return err
+			}
+		}
+
+		// Price the locked products through the same path as the cart
+		priced, err := s.cartService.priceTx(ctx, tx, userID, lines, cart.CouponCode)
+		if err != nil {
+			return err
+		}
+		for _, line := range priced.Lines {
+			product := byID[line.ProductID]
+			order.Items = append(order.Items, models.OrderItem{
+				ProductID: product.ID,
+				Quantity:  line.Quantity,
+				Price:     line.UnitPrice,
+				Total:     line.Subtotal,
+				Discount:  line.Discount,
+				TaxClass:  product.TaxClass,
+			})
+		}
+		order.Subtotal = priced.Subtotal
+		order.Discount = priced.Discount
+		order.CouponCode = cart.CouponCode
+		for _, discount := range priced.Discounts {
+			order.Discounts = append(order.Discounts, models.OrderDiscount{
+				Source:       discount.Source,
+				Code:         discount.Code,
+				Description:  discount.Description,
+				Amount:       discount.Amount,
+				FreeShipping: discount.FreeShipping,
+			})
+		}
+
+		shipment.Subtotal = priced.Total
+		if err := s.applyShipping(ctx, order, quoted, shipment, quote); err != nil {
+			return err
+		}
+		if priced.FreeShipping {
+			order.ShippingDiscount = order.Shipping
+			order.Shipping = 0
+		}
+		if err := s.applyTax(ctx, order, shippingAddress); err != nil {
+			return err
+		}
+
+		if order.CouponCode != "" {
+			amount := couponDiscount(priced) + order.ShippingDiscount
+			if err := s.cartService.couponService.redeemTx(ctx, tx, order.CouponCode, userID, order.ID, amount); err != nil {
+				return err
+			}
+		}
+
+		orderRepo := s.orderRepo.WithTx(tx)
+		if err := orderRepo.Create(ctx, order); err != nil {
+			return err
+		}
+
+		if err := orderRepo.CreateStatusHistory(ctx, &models.OrderStatusHistory{
+			OrderID:   order.ID,
+			ToStatus:  models.OrderStatusPending,
+			ActorID:   &userID,
+			ActorRole: ActorRoleCustomer,
+			Reason:    "Order placed",
+		}); err != nil {
+			return err
+		}
+
+		if err := s.tenderService.applyTx(ctx, tx, order, req.GiftCardCodes, req.UseStoreCredit); err != nil {
+			return err
+		}
+
+		cartRepo := s.cartRepo.WithTx(tx)
+		if err := cartRepo.SetCouponCode(ctx, cart.ID, ""); err != nil {
+			return err
+		}
+		return cartRepo.ClearItems(ctx, cart.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.metrics.OrderPlaced()
+
+	return order, nil
+}
+
+// quoteShipping prices the selected shipping method for the cart. When no
+// shipping rates are configured at all, shipping is free, no method is
+// needed and there is no quote.
+func (s *CheckoutService) quoteShipping(ctx context.Context, shipment *shipping.Shipment, methodID *uuid.UUID) (*shipping.Quote, error) {
+	quotes, err := s.shippingCalculator.Quote(ctx, shipment)
+	if err != nil {
+		return nil, err
+	}
+	if len(quotes) == 0 {
+		return nil, nil
+	}
+	if methodID == nil {
+		return nil, ErrShippingMethodRequired
+	}
+
+	for _, quote := range quotes {
+		if quote.MethodID == *methodID {
+			return &quote, nil
+		}
+	}
+	return nil, ErrShippingMethodUnavailable
+}
+
+// applyShipping charges the order the quote made for the cart, provided the
+// locked products still weigh what was quoted and the method still takes them
+// at the quoted price
+func (s *CheckoutService) applyShipping(ctx context.Context, order *models.Order, quoted, shipment *shipping.Shipment, quote *shipping.Quote) error {
+	if quote == nil {
+		order.Shipping = 0
+		return nil
+	}
+	if shipment.Weight() != quoted.Weight() {
+		return ErrShippingQuoteChanged
+	}
+
+	ok, err := s.shippingCalculator.Recheck(ctx, shipment, quote)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrShippingQuoteChanged
+	}
+
+	order.Shipping = quote.Amount
+	order.ShippingMethodID = &quote.MethodID
+	order.ShippingMethodName = quote.Name
+	return nil
+}
+
+// orderAddress snapshots an address for an order
+func orderAddress(address *models.Address) models.OrderAddress {
+	return models.OrderAddress{
+		StreetAddress: address.StreetAddress,
+		City:          address.City,
+		State:         address.State,
+		PostalCode:    address.PostalCode,
+		Country:       address.Country,
+	}
+}
+
+// shippingDestination is the shipping destination of an address
+func shippingDestination(address *models.Address) shipping.Destination {
+	return shipping.Destination{
+		Country:    address.Country,
+		State:      address.State,
+		PostalCode: address.PostalCode,
+	}
+}
+
+// cartShipment describes the cart's items going to an address
+func cartShipment(cart *models.Cart, priced *CartResponse, address *models.Address) *shipping.Shipment {
+	shipment := &shipping.Shipment{
+		Subtotal:    priced.Total,
+		Destination: shippingDestination(address),
+	}
+	for _, item := range cart.Items {
+		shipment.Items = append(shipment.Items, shipmentItem(&item.Product, item.Quantity))
+	}
+	return shipment
+}
+
+// shipmentItem describes units of a product for shipping
+func shipmentItem(product *models.Product, quantity int) shipping.Item {
+	return shipping.Item{
+		Quantity: quantity,
+		Weight:   product.Weight,
+		Length:   product.Length,
+		Width:    product.Width,
+		Height:   product.Height,
+	}
+}
+
+// applyTax computes the order's tax on the discounted lines, records the
+// per-line breakdown and totals the order. With tax-inclusive prices the tax
+// is already part of the subtotal and shipping.
+func (s *CheckoutService) applyTax(ctx context.Context, order *models.Order, address *models.Address) error {
+	taxReq := &tax.Request{
+		Shipping: order.Shipping,
+		Address: tax.Address{
+			Country:    address.Country,
+			State:      address.State,
+			PostalCode: address.PostalCode,
+		},
+	}
+	for i, item := range order.Items {
+		taxReq.Items = append(taxReq.Items, tax.LineItem{
+			Reference: strconv.Itoa(i),
+			TaxClass:  item.TaxClass,
+			Amount:    roundMoney(item.Total - item.Discount),
+		})
+	}
+
+	result, err := s.taxCalculator.Calculate(ctx, taxReq)
+	if err != nil {
+		return fmt.Errorf("failed to calculate tax: %w", err)
+	}
+
+	for _, line := range result.Lines {
+		i, err := strconv.Atoi(line.Reference)
+		if err != nil || i < 0 || i >= len(order.Items) {
+			return fmt.Errorf("tax calculator returned an unknown line %q", line.Reference)
+		}
+		order.Items[i].TaxClass = line.TaxClass
+		order.Items[i].TaxRate = line.Rate
+		order.Items[i].TaxAmount = line.Tax
+		order.Items[i].TaxBreakdown = line.Components
+	}
+
+	order.Tax = result.Tax
+	order.ShippingTax = result.Shipping.Tax
+	order.TaxInclusive = result.Inclusive
+	order.Total = roundMoney(order.Subtotal - order.Discount + order.Shipping)
+	if !result.Inclusive {
+		order.Total = roundMoney(order.Total + order.Tax)
+	}
+	return nil
+}
+
+// referenceAlphabet avoids characters that are easily confused when read aloud
+const referenceAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// generateOrderNumber returns a human friendly order number such as GW-240131-K7QX2M
+func generateOrderNumber() (string, error) {
+	return generateReference("GW")
+}
+
+// generateReference returns a human friendly reference made of a prefix, the
+// current date and a random suffix
+func generateReference(prefix string) (string, error) {
+	suffix := make([]byte, 6)
+	for i := range suffix {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(referenceAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		suffix[i] = referenceAlphabet[n.Int64()]
+	}
+	return prefix + "-" + time.Now().UTC().Format("060102") + "-" + string(suffix), nil
+}
+
+// roundMoney rounds an amount to whole cents
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCouponNotFound          = errors.New("coupon not found")
+	ErrCouponNotActive         = errors.New("coupon is not active")
+	ErrCouponUsageLimitReached = errors.New("coupon usage limit reached")
+	ErrCouponNotEligible       = errors.New("not eligible for this coupon")
+	ErrCouponCodeTaken         = errors.New("coupon code already exists")
+	ErrInvalidCoupon           = errors.New("invalid coupon")
+)
+
+type CouponService struct {
+	couponRepo repository.CouponRepository
+	orderRepo  repository.OrderRepository
+}
+
+func NewCouponService(couponRepo repository.CouponRepository, orderRepo repository.OrderRepository, orderService *OrderService) *CouponService {
+	s := &CouponService{
+		couponRepo: couponRepo,
+		orderRepo:  orderRepo,
+	}
+
+	// Cancelled orders give their coupon use back
+	orderService.StateMachine().OnTransition(models.OrderStatusPending, models.OrderStatusCancelled, s.releaseRedemption)
+	orderService.StateMachine().OnTransition(models.OrderStatusProcessing, models.OrderStatusCancelled, s.releaseRedemption)
+
+	return s
+}
+
+// CouponRequest represents creating or replacing a coupon
+type CouponRequest struct {
+	Code             string      `json:"code" validate:"required,min=3,max=50"`
+	Description      string      `json:"description" validate:"max=255"`
+	Type             string      `json:"type" validate:"required,oneof=percentage fixed_amount free_shipping buy_x_get_y"`
+	Value            float64     `json:"value" validate:"min=0"`
+	BuyQuantity      int         `json:"buy_quantity" validate:"min=0"`
+	GetQuantity      int         `json:"get_quantity" validate:"min=0"`
+	MinSubtotal      float64     `json:"min_subtotal" validate:"min=0"`
+	ProductIDs       []uuid.UUID `json:"product_ids"`
+	CategoryIDs      []uuid.UUID `json:"category_ids"`
+	FirstOrderOnly   bool        `json:"first_order_only"`
+	UsageLimit       *int        `json:"usage_limit" validate:"omitempty,min=1"`
+	PerCustomerLimit *int        `json:"per_customer_limit" validate:"omitempty,min=1"`
+	StartsAt         *time.Time  `json:"starts_at"`
+	EndsAt           *time.Time  `json:"ends_at"`
+	IsActive         *bool       `json:"is_active"`
+}
+
+// ListCoupons lists all coupons
+func (s *CouponService) ListCoupons(ctx context.Context) ([]models.Coupon, error) {
+	return s.couponRepo.List(ctx)
+}
+
+// GetCoupon gets a coupon
+func (s *CouponService) GetCoupon(ctx context.Context, id uuid.UUID) (*models.Coupon, error) {
+	coupon, err := s.couponRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCouponNotFound
+		}
+		return nil, err
+	}
+	return coupon, nil
+}
+
+// CreateCoupon creates a coupon
+func (s *CouponService) CreateCoupon(ctx context.Context, req *CouponRequest) (*models.Coupon, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	if err := s.ensureCodeAvailable(ctx, req.Code, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	coupon := &models.Coupon{}
+	req.apply(coupon)
+
+	if err := s.couponRepo.Create(ctx, coupon); err != nil {
+		return nil, err
+	}
+
+	// Create skips false booleans in favour of the column default
+	if !coupon.IsActive {
+		if err := s.couponRepo.Update(ctx, coupon); err != nil {
+			return nil, err
+		}
+	}
+	return coupon, nil
+}
+
+// UpdateCoupon replaces a coupon's settings, keeping its usage count
+func (s *CouponService) UpdateCoupon(ctx context.Context, id uuid.UUID, req *CouponRequest) (*models.Coupon, error) {
+	coupon, err := s.GetCoupon(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	if err := s.ensureCodeAvailable(ctx, req.Code, coupon.ID); err != nil {
+		return nil, err
+	}
+
+	req.apply(coupon)
+	if err := s.couponRepo.Update(ctx, coupon); err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+// DeleteCoupon deletes a coupon
+func (s *CouponService) DeleteCoupon(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetCoupon(ctx, id); err != nil {
+		return err
+	}
+	return s.couponRepo.Delete(ctx, id)
+}
+
+// findCoupon looks up a coupon by the code a customer entered
+func (s *CouponService) findCoupon(ctx context.Context, db *gorm.DB, code string) (*models.Coupon, error) {
+	coupon, err := s.couponRepo.WithTx(db).GetByCode(ctx, normalizeCouponCode(code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCouponNotFound
+		}
+		return nil, err
+	}
+	return coupon, nil
+}
+
+// checkCoupon checks the coupon's validity window and the customer
+// conditions; cart conditions are checked by pricing.ApplyCoupon
+func (s *CouponService) checkCoupon(ctx context.Context, db *gorm.DB, coupon *models.Coupon, userID uuid.UUID) error {
+	now := time.Now()
+	if !coupon.IsActive {
+		return ErrCouponNotActive
+	}
+	if coupon.StartsAt != nil && now.Before(*coupon.StartsAt) {
+		return fmt.Errorf("%w: valid from %s", ErrCouponNotActive, coupon.StartsAt.Format(time.RFC3339))
+	}
+	if coupon.EndsAt != nil && !now.Before(*coupon.EndsAt) {
+		return fmt.Errorf("%w: expired on %s", ErrCouponNotActive, coupon.EndsAt.Format(time.RFC3339))
+	}
+	if coupon.UsageLimit != nil && coupon.UsageCount >= *coupon.UsageLimit {
+		return ErrCouponUsageLimitReached
+	}
+
+	if coupon.PerCustomerLimit != nil {
+		used, err := s.couponRepo.WithTx(db).CountUserRedemptions(ctx, coupon.ID, userID)
+		if err != nil {
+			return err
+		}
+		if used >= int64(*coupon.PerCustomerLimit) {
+			return fmt.Errorf("%w: already used %d times", ErrCouponNotEligible, used)
+		}
+	}
+
+	if coupon.FirstOrderOnly {
+		orders, err := s.orderRepo.WithTx(db).CountUserOrders(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if orders > 0 {
+			return fmt.Errorf("%w: only valid on a first order", ErrCouponNotEligible)
+		}
+	}
+
+	return nil
+}
+
+// redeemTx records the coupon's use on an order. The coupon row stays locked
+// until the checkout transaction ends, so concurrent checkouts re-check the
+// limits one at a time and the guarded increment can never overshoot.
+func (s *CouponService) redeemTx(ctx context.Context, tx *gorm.DB, code string, userID, orderID uuid.UUID, amount float64) error {
+	couponRepo := s.couponRepo.WithTx(tx)
+
+	coupon, err := couponRepo.GetByCodeForUpdate(ctx, normalizeCouponCode(code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCouponNotFound
+		}
+		return err
+	}
+	if err := s.checkCoupon(ctx, tx, coupon, userID); err != nil {
+		return err
+	}
+
+	counted, err := couponRepo.IncrementUsage(ctx, coupon.ID)
+	if err != nil {
+		return err
+	}
+	if !counted {
+		return ErrCouponUsageLimitReached
+	}
+
+	return couponRepo.CreateRedemption(ctx, &models.CouponRedemption{
+		CouponID: coupon.ID,
+		UserID:   userID,
+		OrderID:  orderID,
+		Amount:   roundMoney(amount),
+	})
+}
+
+// releaseRedemption is a transition hook giving back the coupon use of a
+// cancelled order
+func (s *CouponService) releaseRedemption(tc *TransitionContext) error {
+	couponRepo := s.couponRepo.WithTx(tc.Tx)
+
+	redemption, err := couponRepo.GetRedemptionByOrder(tc.Ctx, tc.Order.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := couponRepo.DeleteRedemption(tc.Ctx, redemption.ID); err != nil {
+		return err
+	}
+	return couponRepo.DecrementUsage(tc.Ctx, redemption.CouponID)
+}
+
+// ensureCodeAvailable checks no other coupon uses the code
+func (s *CouponService) ensureCodeAvailable(ctx context.Context, code string, exceptID uuid.UUID) error {
+	existing, err := s.couponRepo.GetByCode(ctx, normalizeCouponCode(code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if existing.ID != exceptID {
+		return ErrCouponCodeTaken
+	}
+	return nil
+}
+
+// validate checks the settings each coupon type depends on
+func (req *CouponRequest) validate() error {
+	switch models.CouponType(req.Type) {
+	case models.CouponTypePercentage:
+		if req.Value <= 0 || req.Value > 100 {
+			return fmt.Errorf("%w: percentage must be between 0 and 100", ErrInvalidCoupon)
+		}
+	case models.CouponTypeFixedAmount:
+		if req.Value <= 0 {
+			return fmt.Errorf("%w: amount must be greater than 0", ErrInvalidCoupon)
+		}
+	case models.CouponTypeBuyXGetY:
+		if req.BuyQuantity < 1 || req.GetQuantity < 1 {
+			return fmt.Errorf("%w: buy_quantity and get_quantity must be at least 1", ErrInvalidCoupon)
+		}
+		if req.Value <= 0 || req.Value > 100 {
+			return fmt.Errorf("%w: percentage off the free items must be between 0 and 100", ErrInvalidCoupon)
+		}
+	}
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidCoupon)
+	}
+	return nil
+}
+
+// apply copies the request onto a coupon
+func (req *CouponRequest) apply(coupon *models.Coupon) {
+	coupon.Code = normalizeCouponCode(req.Code)
+	coupon.Description = req.Description
+	coupon.Type = models.CouponType(req.Type)
+	coupon.Value = req.Value
+	coupon.BuyQuantity = req.BuyQuantity
+	coupon.GetQuantity = req.GetQuantity
+	coupon.MinSubtotal = req.MinSubtotal
+	coupon.ProductIDs = req.ProductIDs
+	coupon.CategoryIDs = req.CategoryIDs
+	coupon.FirstOrderOnly = req.FirstOrderOnly
+	coupon.UsageLimit = req.UsageLimit
+	coupon.PerCustomerLimit = req.PerCustomerLimit
+	coupon.StartsAt = req.StartsAt
+	coupon.EndsAt = req.EndsAt
+	coupon.IsActive = req.IsActive == nil || *req.IsActive
+}
+
+// normalizeCouponCode makes codes case insensitive
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestCheckCoupon(t *testing.T) {
+	db := emptyDB(t)
+	customer, returning := uuid.New(), uuid.New()
+	couponID := uuid.New()
+
+	orders := newMemoryOrderRepository(db,
+		&models.Order{BaseModel: models.BaseModel{ID: uuid.New()}, UserID: customer, Status: models.OrderStatusCancelled},
+		&models.Order{BaseModel: models.BaseModel{ID: uuid.New()}, UserID: returning, Status: models.OrderStatusDelivered},
+	)
+	coupons := &memoryCouponRepository{redemptions: []models.CouponRedemption{
+		{CouponID: couponID, UserID: returning, OrderID: uuid.New()},
+		{CouponID: couponID, UserID: returning, OrderID: uuid.New()},
+		{CouponID: uuid.New(), UserID: customer, OrderID: uuid.New()},
+	}}
+	s := &CouponService{couponRepo: coupons, orderRepo: orders}
+
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	limit := func(n int) *int { return &n }
+
+	tests := []struct {
+		name   string
+		coupon models.Coupon
+		user   uuid.UUID
+		err    error
+	}{
+		{"active", models.Coupon{IsActive: true}, customer, nil},
+		{"disabled", models.Coupon{}, customer, ErrCouponNotActive},
+		{"within its window", models.Coupon{IsActive: true, StartsAt: &past, EndsAt: &future}, customer, nil},
+		{"not started", models.Coupon{IsActive: true, StartsAt: &future}, customer, ErrCouponNotActive},
+		{"expired", models.Coupon{IsActive: true, EndsAt: &past}, customer, ErrCouponNotActive},
+		{"under the usage limit", models.Coupon{IsActive: true, UsageLimit: limit(10), UsageCount: 9}, customer, nil},
+		{"usage limit reached", models.Coupon{IsActive: true, UsageLimit: limit(10), UsageCount: 10}, customer, ErrCouponUsageLimitReached},
+		{"under the customer limit", models.Coupon{IsActive: true, PerCustomerLimit: limit(3)}, returning, nil},
+		{"customer limit reached", models.Coupon{IsActive: true, PerCustomerLimit: limit(2)}, returning, ErrCouponNotEligible},
+		{"other coupons' uses do not count", models.Coupon{IsActive: true, PerCustomerLimit: limit(1)}, customer, nil},
+		{"first order", models.Coupon{IsActive: true, FirstOrderOnly: true}, customer, nil},
+		{"not a first order", models.Coupon{IsActive: true, FirstOrderOnly: true}, returning, ErrCouponNotEligible},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.coupon.ID = couponID
+			err := s.checkCoupon(context.Background(), db, &tt.coupon, tt.user)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("checkCoupon = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,752 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/document"
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/Shihasz/gophiway/internal/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDocumentNotAvailable = errors.New("document is not available")
+	ErrDocumentMissing      = errors.New("document is missing from storage")
+	ErrRefundNotFound       = errors.New("refund not found")
+)
+
+// Numbering sequences
+const (
+	sequenceInvoice    = "invoice"
+	sequenceCreditNote = "credit_note"
+)
+
+const pdfContentType = "application/pdf"
+
+type DocumentService struct {
+	documentRepo    repository.DocumentRepository
+	orderRepo       repository.OrderRepository
+	refundRepo      repository.RefundRepository
+	fulfillmentRepo repository.FulfillmentRepository
+	userRepo        repository.UserRepository
+	store           storage.ObjectStore
+	cfg             *config.Config
+	logger          *slog.Logger
+}
+
+func NewDocumentService(
+	orderService *OrderService,
+	documentRepo repository.DocumentRepository,
+	orderRepo repository.OrderRepository,
+	refundRepo repository.RefundRepository,
+	fulfillmentRepo repository.FulfillmentRepository,
+	userRepo repository.UserRepository,
+	store storage.ObjectStore,
+	cfg *config.Config,
+	logger *slog.Logger,
+) *DocumentService {
+	s := &DocumentService{
+		documentRepo:    documentRepo,
+		orderRepo:       orderRepo,
+		refundRepo:      refundRepo,
+		fulfillmentRepo: fulfillmentRepo,
+		userRepo:        userRepo,
+		store:           store,
+		cfg:             cfg,
+		logger:          logger,
+	}
+
+	// Invoices are issued as orders are paid and credit notes as refunds
+	// succeed, so they show the records as they were at that moment
+	orderService.StateMachine().OnPaymentStatus(models.PaymentStatusPaid, s.issueInvoice)
+	orderService.StateMachine().OnRefund(s.issueCreditNote)
+
+	return s
+}
+
+// withTx returns a copy of the service reading and writing its records in
+// the given transaction
+func (s *DocumentService) withTx(tx *gorm.DB) *DocumentService {
+	copied := *s
+	copied.documentRepo = s.documentRepo.WithTx(tx)
+	copied.orderRepo = s.orderRepo.WithTx(tx)
+	copied.refundRepo = s.refundRepo.WithTx(tx)
+	copied.fulfillmentRepo = s.fulfillmentRepo.WithTx(tx)
+	return &copied
+}
+
+// DocumentFile is a document with its PDF
+type DocumentFile struct {
+	Document *models.Document
+	Filename string
+	Data     []byte
+}
+
+// ListUserDocuments lists the documents issued for one of the user's orders
+func (s *DocumentService) ListUserDocuments(ctx context.Context, userID uuid.UUID, orderNumber string) ([]models.Document, error) {
+	order, err := s.userOrder(ctx, userID, orderNumber)
+	if err != nil {
+		return nil, err
+	}
+	return s.documentRepo.ListByOrder(ctx, order.ID)
+}
+
+// ListOrderDocuments lists the documents issued for an order
+func (s *DocumentService) ListOrderDocuments(ctx context.Context, orderID uuid.UUID) ([]models.Document, error) {
+	if _, err := s.order(ctx, orderID); err != nil {
+		return nil, err
+	}
+	return s.documentRepo.ListByOrder(ctx, orderID)
+}
+
+// GetUserInvoice gets the invoice of one of the user's orders
+func (s *DocumentService) GetUserInvoice(ctx context.Context, userID uuid.UUID, orderNumber string) (*DocumentFile, error) {
+	order, err := s.userOrder(ctx, userID, orderNumber)
+	if err != nil {
+		return nil, err
+	}
+	return s.invoice(ctx, order.ID)
+}
+
+// GetInvoice gets the invoice of an order
+func (s *DocumentService) GetInvoice(ctx context.Context, orderID uuid.UUID) (*DocumentFile, error) {
+	if _, err := s.order(ctx, orderID); err != nil {
+		return nil, err
+	}
+	return s.invoice(ctx, orderID)
+}
+
+// GetUserCreditNote gets the credit note of a refund on one of the user's
+// orders
+func (s *DocumentService) GetUserCreditNote(ctx context.Context, userID uuid.UUID, orderNumber string, refundID uuid.UUID) (*DocumentFile, error) {
+	order, err := s.userOrder(ctx, userID, orderNumber)
+	if err != nil {
+		return nil, err
+	}
+	refund, err := s.refund(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+	if refund.OrderID != order.ID {
+		return nil, ErrRefundNotFound
+	}
+	return s.creditNote(ctx, refund)
+}
+
+// GetCreditNote gets the credit note of a refund
+func (s *DocumentService) GetCreditNote(ctx context.Context, refundID uuid.UUID) (*DocumentFile, error) {
+	refund, err := s.refund(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+	return s.creditNote(ctx, refund)
+}
+
+// GetUserPackingSlip gets the packing slip of a fulfillment of one of the
+// user's orders
+func (s *DocumentService) GetUserPackingSlip(ctx context.Context, userID uuid.UUID, orderNumber string, fulfillmentID uuid.UUID) (*DocumentFile, error) {
+	order, err := s.userOrder(ctx, userID, orderNumber)
+	if err != nil {
+		return nil, err
+	}
+	fulfillment, err := s.fulfillment(ctx, fulfillmentID)
+	if err != nil {
+		return nil, err
+	}
+	if fulfillment.OrderID != order.ID {
+		return nil, ErrFulfillmentNotFound
+	}
+	return s.packingSlip(ctx, fulfillment)
+}
+
+// GetPackingSlip gets the packing slip of a fulfillment
+func (s *DocumentService) GetPackingSlip(ctx context.Context, fulfillmentID uuid.UUID) (*DocumentFile, error) {
+	fulfillment, err := s.fulfillment(ctx, fulfillmentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.packingSlip(ctx, fulfillment)
+}
+
+// invoice gets the invoice of an order
+func (s *DocumentService) invoice(ctx context.Context, orderID uuid.UUID) (*DocumentFile, error) {
+	existing, err := s.documentRepo.GetInvoice(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: the invoice is issued once the order is paid", ErrDocumentNotAvailable)
+		}
+		return nil, err
+	}
+	return s.file(ctx, existing)
+}
+
+// creditNote gets the credit note of a refund
+func (s *DocumentService) creditNote(ctx context.Context, refund *models.Refund) (*DocumentFile, error) {
+	existing, err := s.documentRepo.GetByRefund(ctx, refund.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: the credit note is issued once the refund succeeds", ErrDocumentNotAvailable)
+		}
+		return nil, err
+	}
+	return s.file(ctx, existing)
+}
+
+// issueInvoice is a payment status hook issuing the invoice of an order as
+// it is paid. An order is invoiced once, even if paid again after a failed
+// capture.
+func (s *DocumentService) issueInvoice(ctx context.Context, tx *gorm.DB, order *models.Order) error {
+	return s.issueNumbered(ctx, tx, sequenceInvoice, s.cfg.InvoicePrefix, "invoices", &models.Document{
+		Type:    models.DocumentTypeInvoice,
+		OrderID: order.ID,
+	}, func(documentRepo repository.DocumentRepository) (*models.Document, error) {
+		return documentRepo.GetInvoice(ctx, order.ID)
+	})
+}
+
+// issueCreditNote is a refund hook issuing the credit note of a succeeded
+// refund. Money given back on an order that was never invoiced, such as the
+// gift card part of an unpaid order cancelled, corrects no invoice and gets
+// no credit note.
+func (s *DocumentService) issueCreditNote(ctx context.Context, tx *gorm.DB, refund *models.Refund) error {
+	if _, err := s.documentRepo.WithTx(tx).GetInvoice(ctx, refund.OrderID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return s.issueNumbered(ctx, tx, sequenceCreditNote, s.cfg.CreditNotePrefix, "credit-notes", &models.Document{
+		Type:     models.DocumentTypeCreditNote,
+		OrderID:  refund.OrderID,
+		RefundID: &refund.ID,
+	}, func(documentRepo repository.DocumentRepository) (*models.Document, error) {
+		return documentRepo.GetByRefund(ctx, refund.ID)
+	})
+}
+
+// packingSlip gets or creates the packing slip of a fulfillment
+func (s *DocumentService) packingSlip(ctx context.Context, fulfillment *models.Fulfillment) (*DocumentFile, error) {
+	existing, err := s.documentRepo.GetByFulfillment(ctx, fulfillment.ID)
+	if err == nil {
+		return s.file(ctx, existing)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	order, err := s.order(ctx, fulfillment.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &models.Document{
+		Type:          models.DocumentTypePackingSlip,
+		OrderID:       fulfillment.OrderID,
+		FulfillmentID: &fulfillment.ID,
+		ObjectKey:     fmt.Sprintf("packing-slips/%s-%s.pdf", order.OrderNumber, fulfillment.ID),
+		IssuedAt:      time.Now().UTC(),
+	}
+	if err := s.summarize(ctx, doc); err != nil {
+		return nil, err
+	}
+	data, err := s.publish(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.documentRepo.Create(ctx, doc); err != nil {
+		return nil, err
+	}
+	return &DocumentFile{Document: doc, Filename: filename(doc), Data: data}, nil
+}
+
+// issueNumbered numbers a document and records it in tx unless existing
+// finds it issued already, so a failure anywhere gives the number back.
+// Issuing holds the sequence lock until tx ends, which makes the check for
+// an already issued document reliable. The PDF is rendered and stored once
+// tx has committed, keeping the lock clear of the object store; should that
+// fail, it is made again when the document is first requested.
+func (s *DocumentService) issueNumbered(
+	ctx context.Context,
+	tx *gorm.DB,
+	sequence, prefix, folder string,
+	doc *models.Document,
+	existing func(documentRepo repository.DocumentRepository) (*models.Document, error),
+) error {
+	t := s.withTx(tx)
+	last, err := t.documentRepo.LockSequence(ctx, sequence)
+	if err != nil {
+		return err
+	}
+
+	_, err = existing(t.documentRepo)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	number := last + 1
+	doc.Number = fmt.Sprintf("%s-%06d", prefix, number)
+	doc.ObjectKey = folder + "/" + doc.Number + ".pdf"
+	doc.IssuedAt = time.Now().UTC()
+
+	if err := t.summarize(ctx, doc); err != nil {
+		return err
+	}
+	if err := t.documentRepo.Create(ctx, doc); err != nil {
+		return err
+	}
+	if err := t.documentRepo.SetSequence(ctx, sequence, number); err != nil {
+		return err
+	}
+
+	issued := *doc
+	onCommit(ctx, func() {
+		ctx := committedContext(ctx)
+		if _, err := s.publish(ctx, &issued); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to store an issued document", "number", issued.Number, "error", err)
+		}
+	})
+	return nil
+}
+
+// file loads a document's PDF. The stored copy is never rendered again, as
+// the records it was made from may have changed since it was issued; only
+// a document whose PDF could not be stored when it was issued is rendered
+// from the records as they are now.
+func (s *DocumentService) file(ctx context.Context, doc *models.Document) (*DocumentFile, error) {
+	object, err := s.store.Get(ctx, doc.ObjectKey)
+	if err == nil {
+		return &DocumentFile{Document: doc, Filename: filename(doc), Data: object.Data}, nil
+	}
+	if !errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, err
+	}
+
+	data, err := s.publish(ctx, doc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrDocumentMissing, doc.ObjectKey, err)
+	}
+	return &DocumentFile{Document: doc, Filename: filename(doc), Data: data}, nil
+}
+
+// publish renders a document and stores its PDF
+func (s *DocumentService) publish(ctx context.Context, doc *models.Document) ([]byte, error) {
+	data, err := s.render(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(ctx, doc.ObjectKey, pdfContentType, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// summarize fills in the total and currency of a document from the records
+// it covers
+func (s *DocumentService) summarize(ctx context.Context, doc *models.Document) error {
+	order, err := s.order(ctx, doc.OrderID)
+	if err != nil {
+		return err
+	}
+	doc.Currency = s.orderCurrency(order)
+
+	switch doc.Type {
+	case models.DocumentTypeInvoice:
+		doc.Total = order.Total
+	case models.DocumentTypeCreditNote:
+		refund, err := s.refund(ctx, *doc.RefundID)
+		if err != nil {
+			return err
+		}
+		doc.Total = refund.Amount
+	}
+	return nil
+}
+
+// render lays out a document from the records it covers
+func (s *DocumentService) render(ctx context.Context, doc *models.Document) ([]byte, error) {
+	order, err := s.order(ctx, doc.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(ctx, order.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch doc.Type {
+	case models.DocumentTypeInvoice:
+		inv := s.invoiceDocument(order, user)
+		inv.Number, inv.IssuedAt = doc.Number, doc.IssuedAt
+		return document.RenderInvoice(inv)
+
+	case models.DocumentTypeCreditNote:
+		refund, err := s.refund(ctx, *doc.RefundID)
+		if err != nil {
+			return nil, err
+		}
+		invoice, err := s.documentRepo.GetInvoice(ctx, order.ID)
+		if err != nil {
+			return nil, err
+		}
+		inv := s.creditNoteDocument(order, user, refund)
+		inv.Number, inv.IssuedAt = doc.Number, doc.IssuedAt
+		inv.Reference = "Credits invoice " + invoice.Number
+		return document.RenderInvoice(inv)
+
+	case models.DocumentTypePackingSlip:
+		fulfillment, err := s.fulfillment(ctx, *doc.FulfillmentID)
+		if err != nil {
+			return nil, err
+		}
+		return document.RenderPackingSlip(s.packingSlipDocument(order, user, fulfillment))
+	}
+	return nil, fmt.Errorf("unknown document type %q", doc.Type)
+}
+
+// invoiceDocument lays out the charges of an order line by line
+func (s *DocumentService) invoiceDocument(order *models.Order, user *models.User) *document.Invoice {
+	inv := s.baseDocument(order, user, "Invoice")
+	inv.StatusLabel, inv.Status = "Payment status", string(order.PaymentStatus)
+	inv.TaxInclusive = order.TaxInclusive
+	inv.Subtotal = order.Subtotal
+	inv.Discount = order.Discount
+	inv.Shipping = order.Shipping
+	inv.Tax = order.Tax
+	inv.Total = order.Total
+
+	taxes := newTaxSummary()
+	for _, item := range order.Items {
+		line := chargedLine(order, item, item.Quantity)
+		inv.Lines = append(inv.Lines, line)
+		taxes.add(item, line.Net, 1)
+	}
+
+	if order.Shipping > 0 || order.ShippingDiscount > 0 {
+		net := order.Shipping
+		if order.TaxInclusive {
+			net = roundMoney(order.Shipping - order.ShippingTax)
+		}
+		line := document.Line{
+			Description: "Shipping",
+			Quantity:    1,
+			UnitPrice:   roundMoney(order.Shipping + order.ShippingDiscount),
+			Discount:    order.ShippingDiscount,
+			Net:         net,
+			Tax:         order.ShippingTax,
+			Total:       roundMoney(net + order.ShippingTax),
+		}
+		if order.ShippingMethodName != "" {
+			line.Description += ": " + order.ShippingMethodName
+		}
+		if net > 0 {
+			line.TaxRate = order.ShippingTax / net
+		}
+		inv.Lines = append(inv.Lines, line)
+		if order.ShippingTax > 0 {
+			taxes.addComponent("Shipping tax", line.TaxRate, net, order.ShippingTax)
+		}
+	}
+
+	inv.Taxes = taxes.lines
+	if order.TaxInclusive {
+		inv.Notes = append(inv.Notes, "Prices include tax.")
+	}
+	for _, record := range order.Payments {
+		switch record.Provider {
+		case models.PaymentProviderGiftCard:
+			inv.Notes = append(inv.Notes, fmt.Sprintf("Paid by gift card: %.2f %s", record.Amount, strings.ToUpper(inv.Currency)))
+		case models.PaymentProviderStoreCredit:
+			inv.Notes = append(inv.Notes, fmt.Sprintf("Paid with store credit: %.2f %s", record.Amount, strings.ToUpper(inv.Currency)))
+		}
+	}
+	return inv
+}
+
+// creditNoteDocument lays out what a refund gives back. Item refunds credit
+// their share of the charged lines; any remainder, such as shipping or an
+// amount-only refund, is credited as an adjustment with its share of tax.
+func (s *DocumentService) creditNoteDocument(order *models.Order, user *models.User, refund *models.Refund) *document.Invoice {
+	inv := s.baseDocument(order, user, "Credit note")
+	inv.StatusLabel, inv.Status = "Refund status", string(refund.Status)
+	inv.Total = refund.Amount
+
+	items := make(map[uuid.UUID]models.OrderItem, len(order.Items))
+	for _, item := range order.Items {
+		items[item.ID] = item
+	}
+
+	// A refund spread over several payments keeps its items on the first
+	// one; when they are worth more than it, it is credited as a whole
+	var itemsTotal float64
+	for _, refunded := range refund.Items {
+		itemsTotal += refunded.Amount
+	}
+	split := roundMoney(itemsTotal) > refund.Amount
+
+	taxes := newTaxSummary()
+	remaining := refund.Amount
+	for _, refunded := range refund.Items {
+		if split {
+			break
+		}
+		item, ok := items[refunded.OrderItemID]
+		if !ok || item.Quantity == 0 {
+			continue
+		}
+		line := chargedLine(order, item, refunded.Quantity)
+		line.Total = refunded.Amount
+		line.Net = roundMoney(line.Total - line.Tax)
+		inv.Lines = append(inv.Lines, line)
+		taxes.add(item, line.Net, float64(refunded.Quantity)/float64(item.Quantity))
+		remaining = roundMoney(remaining - refunded.Amount)
+	}
+
+	if remaining > 0.005 {
+		var tax float64
+		if order.Total > 0 {
+			tax = roundMoney(remaining * order.Tax / order.Total)
+		}
+		line := document.Line{
+			Description: "Adjustment",
+			Quantity:    1,
+			UnitPrice:   remaining,
+			Net:         roundMoney(remaining - tax),
+			Tax:         tax,
+			Total:       remaining,
+		}
+		if refund.Reason != "" {
+			line.Description += ": " + refund.Reason
+		}
+		if line.Net > 0 {
+			line.TaxRate = tax / line.Net
+		}
+		inv.Lines = append(inv.Lines, line)
+		if tax > 0 {
+			taxes.addComponent("Tax", line.TaxRate, line.Net, tax)
+		}
+	}
+
+	for _, line := range inv.Lines {
+		inv.Subtotal += line.Net
+		inv.Tax += line.Tax
+	}
+	inv.Subtotal = roundMoney(inv.Subtotal)
+	inv.Tax = roundMoney(inv.Tax)
+	inv.Taxes = taxes.lines
+	if refund.Reason != "" {
+		inv.Notes = append(inv.Notes, "Reason: "+refund.Reason)
+	}
+	if split {
+		inv.Notes = append(inv.Notes, "Part of a refund settled across several payment methods.")
+	}
+	return inv
+}
+
+// packingSlipDocument lists the items shipped in a fulfillment
+func (s *DocumentService) packingSlipDocument(order *models.Order, user *models.User, fulfillment *models.Fulfillment) *document.PackingSlip {
+	items := make(map[uuid.UUID]models.OrderItem, len(order.Items))
+	for _, item := range order.Items {
+		items[item.ID] = item
+	}
+
+	slip := &document.PackingSlip{
+		OrderNumber:    order.OrderNumber,
+		OrderDate:      order.CreatedAt,
+		ShippedAt:      fulfillment.ShippedAt,
+		Carrier:        fulfillment.Carrier,
+		TrackingNumber: fulfillment.TrackingNumber,
+		ShippingMethod: order.ShippingMethodName,
+		Seller:         s.seller(),
+		ShipTo:         party(user, order.ShippingAddress, false),
+	}
+	for _, shipped := range fulfillment.Items {
+		item := items[shipped.OrderItemID]
+		slip.Items = append(slip.Items, document.PackingItem{
+			Description: item.Product.Name,
+			SKU:         item.Product.SKU,
+			Quantity:    shipped.Quantity,
+		})
+	}
+	return slip
+}
+
+// baseDocument fills in what invoices and credit notes share
+func (s *DocumentService) baseDocument(order *models.Order, user *models.User, title string) *document.Invoice {
+	shipTo := party(user, order.ShippingAddress, false)
+	return &document.Invoice{
+		Title:       title,
+		OrderNumber: order.OrderNumber,
+		OrderDate:   order.CreatedAt,
+		Currency:    s.orderCurrency(order),
+		Seller:      s.seller(),
+		BillTo:      party(user, order.BillingAddress, true),
+		ShipTo:      &shipTo,
+	}
+}
+
+// seller is the business details from the configuration
+func (s *DocumentService) seller() document.Seller {
+	var address []string
+	for _, line := range strings.Split(s.cfg.SellerAddress, "|") {
+		if line = strings.TrimSpace(line); line != "" {
+			address = append(address, line)
+		}
+	}
+	return document.Seller{
+		Name:    s.cfg.SellerName,
+		Address: address,
+		TaxID:   s.cfg.SellerTaxID,
+		Email:   s.cfg.SellerEmail,
+	}
+}
+
+// orderCurrency is the currency the order was paid in
+func (s *DocumentService) orderCurrency(order *models.Order) string {
+	for _, record := range order.Payments {
+		if record.Currency != "" {
+			return record.Currency
+		}
+	}
+	return s.cfg.PaymentCurrency
+}
+
+func (s *DocumentService) order(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	order, err := s.orderRepo.GetDetail(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+func (s *DocumentService) userOrder(ctx context.Context, userID uuid.UUID, orderNumber string) (*models.Order, error) {
+	order, err := s.orderRepo.GetUserOrderByNumber(ctx, userID, orderNumber)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+func (s *DocumentService) refund(ctx context.Context, id uuid.UUID) (*models.Refund, error) {
+	refund, err := s.refundRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRefundNotFound
+		}
+		return nil, err
+	}
+	return refund, nil
+}
+
+func (s *DocumentService) fulfillment(ctx context.Context, id uuid.UUID) (*models.Fulfillment, error) {
+	fulfillment, err := s.fulfillmentRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFulfillmentNotFound
+		}
+		return nil, err
+	}
+	return fulfillment, nil
+}
+
+// chargedLine is what was charged for some units of an order item
+func chargedLine(order *models.Order, item models.OrderItem, quantity int) document.Line {
+	share := float64(quantity) / float64(item.Quantity)
+	total := itemChargedAmount(order, item, quantity)
+	tax := roundMoney(item.TaxAmount * share)
+	return document.Line{
+		Description: item.Product.Name,
+		SKU:         item.Product.SKU,
+		Quantity:    quantity,
+		UnitPrice:   item.Price,
+		Discount:    roundMoney(item.Discount * share),
+		Net:         roundMoney(total - tax),
+		TaxRate:     item.TaxRate,
+		Tax:         tax,
+		Total:       total,
+	}
+}
+
+// taxSummary totals tax by jurisdiction and rate
+type taxSummary struct {
+	lines []document.TaxLine
+	index map[string]int
+}
+
+func newTaxSummary() *taxSummary {
+	return &taxSummary{index: make(map[string]int)}
+}
+
+// add totals the tax components of a share of an order item
+func (t *taxSummary) add(item models.OrderItem, base, share float64) {
+	if len(item.TaxBreakdown) == 0 {
+		if item.TaxAmount > 0 {
+			t.addComponent("Tax", item.TaxRate, base, roundMoney(item.TaxAmount*share))
+		}
+		return
+	}
+	for _, component := range item.TaxBreakdown {
+		t.addComponent(component.Name, component.Rate, base, roundMoney(component.Amount*share))
+	}
+}
+
+func (t *taxSummary) addComponent(name string, rate, base, amount float64) {
+	key := fmt.Sprintf("%s|%.6f", name, rate)
+	i, ok := t.index[key]
+	if !ok {
+		i = len(t.lines)
+		t.index[key] = i
+		t.lines = append(t.lines, document.TaxLine{Name: name, Rate: rate})
+	}
+	t.lines[i].Base = roundMoney(t.lines[i].Base + base)
+	t.lines[i].Amount = roundMoney(t.lines[i].Amount + amount)
+}
+
+// party is the customer at one of the order's addresses
+func party(user *models.User, address models.OrderAddress, withEmail bool) document.Party {
+	p := document.Party{
+		Name:    strings.TrimSpace(user.FirstName + " " + user.LastName),
+		Address: addressLines(address),
+	}
+	if p.Name == "" {
+		p.Name = user.Email
+	}
+	if withEmail {
+		p.Email = user.Email
+	}
+	return p
+}
+
+// addressLines formats an address for print
+func addressLines(address models.OrderAddress) []string {
+	locality := address.City
+	if address.State != "" {
+		locality += ", " + address.State
+	}
+	if address.PostalCode != "" {
+		locality += " " + address.PostalCode
+	}
+	return []string{address.StreetAddress, strings.TrimSpace(locality), address.Country}
+}
+
+// filename is the download name of a document
+func filename(doc *models.Document) string {
+	return doc.ObjectKey[strings.LastIndex(doc.ObjectKey, "/")+1:]
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// emptyDB opens a database that accepts every statement and holds no rows.
+// Repositories created on it inside hooks find nothing and change nothing,
+// so services can be exercised without a server.
+func emptyDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(emptyConnector{})}), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("failed to open empty database: %v", err)
+	}
+	return db
+}
+
+// emptyConnector connects to the empty database
+type emptyConnector struct{}
+
+func (emptyConnector) Connect(context.Context) (driver.Conn, error) { return emptyConn{}, nil }
+func (emptyConnector) Driver() driver.Driver                        { return emptyDriver{} }
+
+type emptyDriver struct{}
+
+func (emptyDriver) Open(string) (driver.Conn, error) { return emptyConn{}, nil }
+
+// emptyConn runs statements without preparing them, affecting no rows
+type emptyConn struct{}
+
+func (emptyConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (emptyConn) Close() error                        { return nil }
+func (emptyConn) Begin() (driver.Tx, error)           { return emptyTx{}, nil }
+
+func (emptyConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (emptyConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (emptyConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return emptyRows{}, nil
+}
+
+type emptyTx struct{}
+
+func (emptyTx) Commit() error   { return nil }
+func (emptyTx) Rollback() error { return nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return nil }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
+
+// memoryOrderRepository keeps orders in memory. Transactions hand fn the
+// empty database and are never rolled back. Methods the tests do not need
+// panic through the nil embedded interface.
+type memoryOrderRepository struct {
+	repository.OrderRepository
+	db      *gorm.DB
+	orders  map[uuid.UUID]*models.Order
+	history []models.OrderStatusHistory
+}
+
+func newMemoryOrderRepository(db *gorm.DB, orders ...*models.Order) *memoryOrderRepository {
+	r := &memoryOrderRepository{db: db, orders: make(map[uuid.UUID]*models.Order)}
+	for _, order := range orders {
+		stored := *order
+		r.orders[order.ID] = &stored
+	}
+	return r
+}
+
+func (r *memoryOrderRepository) WithTx(tx *gorm.DB) repository.OrderRepository {
+	return r
+}
+
+func (r *memoryOrderRepository) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return fn(r.db)
+}
+
+func (r *memoryOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	order, ok := r.orders[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *order
+	return &copied, nil
+}
+
+func (r *memoryOrderRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r *memoryOrderRepository) GetUserOrderByNumber(ctx context.Context, userID uuid.UUID, orderNumber string) (*models.Order, error) {
+	for _, order := range r.orders {
+		if order.UserID == userID && order.OrderNumber == orderNumber {
+			copied := *order
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryOrderRepository) UpdateStatus(ctx context.Context, order *models.Order) error {
+	r.orders[order.ID].Status = order.Status
+	return nil
+}
+
+func (r *memoryOrderRepository) UpdatePaymentStatus(ctx context.Context, order *models.Order) error {
+	r.orders[order.ID].PaymentStatus = order.PaymentStatus
+	return nil
+}
+
+func (r *memoryOrderRepository) CountUserOrders(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	for _, order := range r.orders {
+		if order.UserID == userID && order.Status != models.OrderStatusCancelled {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryOrderRepository) CreateStatusHistory(ctx context.Context, entry *models.OrderStatusHistory) error {
+	r.history = append(r.history, *entry)
+	return nil
+}
+
+// memoryPaymentRepository keeps payments in memory in the order they were
+// created, counting the updates
+type memoryPaymentRepository struct {
+	repository.PaymentRepository
+	payments []*models.Payment
+	updates  int
+}
+
+func (r *memoryPaymentRepository) WithTx(tx *gorm.DB) repository.PaymentRepository {
+	return r
+}
+
+func (r *memoryPaymentRepository) Create(ctx context.Context, record *models.Payment) error {
+	if record.ID == uuid.Nil {
+		record.ID = uuid.New()
+	}
+	stored := *record
+	r.payments = append(r.payments, &stored)
+	return nil
+}
+
+func (r *memoryPaymentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Payment, error) {
+	return r.find(func(record *models.Payment) bool { return record.ID == id })
+}
+
+func (r *memoryPaymentRepository) GetByTransactionID(ctx context.Context, transactionID string) (*models.Payment, error) {
+	return r.find(func(record *models.Payment) bool { return record.TransactionID == transactionID })
+}
+
+func (r *memoryPaymentRepository) GetByTransactionIDForUpdate(ctx context.Context, transactionID string) (*models.Payment, error) {
+	return r.GetByTransactionID(ctx, transactionID)
+}
+
+func (r *memoryPaymentRepository) GetLatestByOrder(ctx context.Context, orderID uuid.UUID) (*models.Payment, error) {
+	for i := len(r.payments) - 1; i >= 0; i-- {
+		if record := r.payments[i]; record.OrderID == orderID && !isTender(record) {
+			copied := *record
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryPaymentRepository) ListByOrder(ctx context.Context, orderID uuid.UUID) ([]models.Payment, error) {
+	var records []models.Payment
+	for _, record := range r.payments {
+		if record.OrderID == orderID {
+			records = append(records, *record)
+		}
+	}
+	return records, nil
+}
+
+func (r *memoryPaymentRepository) SumTendered(ctx context.Context, orderID uuid.UUID) (float64, error) {
+	var total float64
+	for _, record := range r.payments {
+		if record.OrderID == orderID && isTender(record) && record.Status == models.PaymentStateCompleted {
+			total += record.Amount
+		}
+	}
+	return total, nil
+}
+
+func (r *memoryPaymentRepository) Update(ctx context.Context, record *models.Payment) error {
+	for i, stored := range r.payments {
+		if stored.ID == record.ID {
+			updated := *record
+			r.payments[i] = &updated
+			r.updates++
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// find returns a copy of the first payment matching
+func (r *memoryPaymentRepository) find(match func(record *models.Payment) bool) (*models.Payment, error) {
+	for _, record := range r.payments {
+		if match(record) {
+			copied := *record
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// memoryWebhookEventRepository remembers the events recorded per provider
+type memoryWebhookEventRepository struct {
+	db     *gorm.DB
+	events map[string]models.WebhookEvent
+}
+
+func (r *memoryWebhookEventRepository) WithTx(tx *gorm.DB) repository.WebhookEventRepository {
+	return r
+}
+
+func (r *memoryWebhookEventRepository) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return fn(r.db)
+}
+
+func (r *memoryWebhookEventRepository) CreateIfNotExists(ctx context.Context, event *models.WebhookEvent) (bool, error) {
+	key := event.Provider + "/" + event.EventID
+	if _, ok := r.events[key]; ok {
+		return false, nil
+	}
+	r.events[key] = *event
+	return true, nil
+}
+
+// memoryCouponRepository holds coupon redemptions. Methods the tests do not
+// need panic through the nil embedded interface.
+type memoryCouponRepository struct {
+	repository.CouponRepository
+	redemptions []models.CouponRedemption
+}
+
+func (r *memoryCouponRepository) WithTx(tx *gorm.DB) repository.CouponRepository {
+	return r
+}
+
+func (r *memoryCouponRepository) CountUserRedemptions(ctx context.Context, couponID, userID uuid.UUID) (int64, error) {
+	var count int64
+	for _, redemption := range r.redemptions {
+		if redemption.CouponID == couponID && redemption.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
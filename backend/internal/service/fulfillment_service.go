@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/email"
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrFulfillmentNotFound         = errors.New("fulfillment not found")
+	ErrOrderNotFulfillable         = errors.New("order cannot be fulfilled in its current status")
+	ErrInvalidFulfillmentItems     = errors.New("invalid fulfillment items")
+	ErrFulfillmentAlreadyDelivered = errors.New("fulfillment already delivered")
+)
+
+type FulfillmentService struct {
+	orderService    *OrderService
+	orderRepo       repository.OrderRepository
+	fulfillmentRepo repository.FulfillmentRepository
+	refundRepo      repository.RefundRepository
+	userRepo        repository.UserRepository
+	mailer          email.Mailer
+	cfg             *config.Config
+	logger          *slog.Logger
+}
+
+func NewFulfillmentService(
+	orderService *OrderService,
+	orderRepo repository.OrderRepository,
+	fulfillmentRepo repository.FulfillmentRepository,
+	refundRepo repository.RefundRepository,
+	userRepo repository.UserRepository,
+	mailer email.Mailer,
+	cfg *config.Config,
+	logger *slog.Logger,
+) *FulfillmentService {
+	return &FulfillmentService{
+		orderService:    orderService,
+		orderRepo:       orderRepo,
+		fulfillmentRepo: fulfillmentRepo,
+		refundRepo:      refundRepo,
+		userRepo:        userRepo,
+		mailer:          mailer,
+		cfg:             cfg,
+		logger:          logger,
+	}
+}
+
+// FulfillmentItemRequest is the quantity of an order item to ship
+type FulfillmentItemRequest struct {
+	OrderItemID uuid.UUID `json:"order_item_id" validate:"required"`
+	Quantity    int       `json:"quantity" validate:"required,min=1"`
+}
+
+// CreateFulfillmentRequest represents a request to ship some of an order's items
+type CreateFulfillmentRequest struct {
+	Carrier        string                   `json:"carrier" validate:"required,max=100"`
+	TrackingNumber string                   `json:"tracking_number" validate:"max=100"`
+	Items          []FulfillmentItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+// CreateFulfillment ships a subset of an order's item quantities and moves the
+// order to partially shipped or shipped accordingly
+func (s *FulfillmentService) CreateFulfillment(ctx context.Context, orderID uuid.UUID, req *CreateFulfillmentRequest, actor Actor) (*models.Fulfillment, error) {
+	var fulfillment *models.Fulfillment
+
+	err := s.orderRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		orderRepo := s.orderRepo.WithTx(tx)
+		fulfillmentRepo := s.fulfillmentRepo.WithTx(tx)
+
+		order, err := orderRepo.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+
+		if order.Status != models.OrderStatusProcessing && order.Status != models.OrderStatusPartiallyShipped {
+			return ErrOrderNotFulfillable
+		}
+
+		items, err := orderRepo.GetItems(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		shipped, err := fulfillmentRepo.FulfilledQuantities(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		refunded, err := s.refundRepo.WithTx(tx).RefundedQuantities(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		// Refunded units are no longer owed to the customer
+		ordered := make(map[uuid.UUID]int, len(items))
+		for _, item := range items {
+			ordered[item.ID] = item.Quantity - refunded[item.ID]
+		}
+
+		fulfillment = &models.Fulfillment{
+			OrderID:        order.ID,
+			Status:         models.FulfillmentStatusShipped,
+			Carrier:        req.Carrier,
+			TrackingNumber: req.TrackingNumber,
+			ShippedAt:      time.Now().UTC(),
+		}
+
+		for _, reqItem := range req.Items {
+			quantity, ok := ordered[reqItem.OrderItemID]
+			if !ok {
+				return fmt.Errorf("%w: item %s is not part of the order", ErrInvalidFulfillmentItems, reqItem.OrderItemID)
+			}
+			if shipped[reqItem.OrderItemID]+reqItem.Quantity > quantity {
+				return fmt.Errorf("%w: item %s has only %d unshipped and unrefunded", ErrInvalidFulfillmentItems, reqItem.OrderItemID, max(quantity-shipped[reqItem.OrderItemID], 0))
+			}
+
+			shipped[reqItem.OrderItemID] += reqItem.Quantity
+			fulfillment.Items = append(fulfillment.Items, models.FulfillmentItem{
+				OrderItemID: reqItem.OrderItemID,
+				Quantity:    reqItem.Quantity,
+			})
+		}
+
+		if err := fulfillmentRepo.Create(ctx, fulfillment); err != nil {
+			return err
+		}
+
+		status := models.OrderStatusShipped
+		for id, quantity := range ordered {
+			if shipped[id] < quantity {
+				status = models.OrderStatusPartiallyShipped
+				break
+			}
+		}
+
+		if status != order.Status {
+			reason := fmt.Sprintf("Fulfillment %s shipped via %s", fulfillment.ID, fulfillment.Carrier)
+			if _, err := s.orderService.transitionStatusTx(ctx, tx, order.ID, status, actor, reason); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.sendShippingNotification(ctx, fulfillment)
+
+	return fulfillment, nil
+}
+
+// MarkDelivered marks a fulfillment as delivered. Once everything has shipped
+// and every fulfillment is delivered, the order becomes delivered.
+func (s *FulfillmentService) MarkDelivered(ctx context.Context, fulfillmentID uuid.UUID, actor Actor) (*models.Fulfillment, error) {
+	var fulfillment *models.Fulfillment
+
+	err := s.orderRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		orderRepo := s.orderRepo.WithTx(tx)
+		fulfillmentRepo := s.fulfillmentRepo.WithTx(tx)
+
+		var err error
+		fulfillment, err = fulfillmentRepo.GetByID(ctx, fulfillmentID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrFulfillmentNotFound
+			}
+			return err
+		}
+
+		// Lock the order so concurrent deliveries agree on the final status
+		order, err := orderRepo.GetByIDForUpdate(ctx, fulfillment.OrderID)
+		if err != nil {
+			return err
+		}
+
+		if fulfillment.Status == models.FulfillmentStatusDelivered {
+			return ErrFulfillmentAlreadyDelivered
+		}
+
+		now := time.Now().UTC()
+		fulfillment.Status = models.FulfillmentStatusDelivered
+		fulfillment.DeliveredAt = &now
+		if err := fulfillmentRepo.Update(ctx, fulfillment); err != nil {
+			return err
+		}
+
+		if order.Status != models.OrderStatusShipped {
+			return nil
+		}
+
+		fulfillments, err := fulfillmentRepo.ListByOrder(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		for _, f := range fulfillments {
+			if f.Status != models.FulfillmentStatusDelivered {
+				return nil
+			}
+		}
+
+		_, err = s.orderService.transitionStatusTx(ctx, tx, order.ID, models.OrderStatusDelivered, actor, "All fulfillments delivered")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fulfillment, nil
+}
+
+// ListFulfillments lists the fulfillments of an order
+func (s *FulfillmentService) ListFulfillments(ctx context.Context, orderID uuid.UUID) ([]models.Fulfillment, error) {
+	if _, err := s.orderRepo.GetByID(ctx, orderID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	return s.fulfillmentRepo.ListByOrder(ctx, orderID)
+}
+
+// sendShippingNotification emails the customer about a new fulfillment.
+// Failures are logged rather than returned since the shipment already happened.
+func (s *FulfillmentService) sendShippingNotification(ctx context.Context, fulfillment *models.Fulfillment) {
+	order, err := s.orderRepo.GetDetail(ctx, fulfillment.OrderID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to load order for shipping notification", "order_id", fulfillment.OrderID, "error", err)
+		return
+	}
+
+	user, err := s.userRepo.GetByID(ctx, order.UserID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to load user for shipping notification", "user_id", order.UserID, "error", err)
+		return
+	}
+
+	names := make(map[uuid.UUID]string, len(order.Items))
+	for _, item := range order.Items {
+		names[item.ID] = item.Product.Name
+	}
+
+	data := &email.ShippingNotificationData{
+		AppName:        s.cfg.AppName,
+		FirstName:      user.FirstName,
+		OrderNumber:    order.OrderNumber,
+		OrderURL:       s.cfg.FrontendURL + "/orders/" + order.OrderNumber,
+		Carrier:        fulfillment.Carrier,
+		TrackingNumber: fulfillment.TrackingNumber,
+	}
+	for _, item := range fulfillment.Items {
+		data.Items = append(data.Items, email.ShippingNotificationItem{
+			Name:     names[item.OrderItemID],
+			Quantity: item.Quantity,
+		})
+	}
+
+	msg, err := email.ShippingNotification(user.Email, data)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to render shipping notification", "order_number", order.OrderNumber, "error", err)
+		return
+	}
+
+	if err := s.mailer.Send(msg); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to send shipping notification", "order_number", order.OrderNumber, "error", err)
+	}
+}
@@ -0,0 +1,544 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/email"
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/payment"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrGiftCardNotFound = errors.New("gift card not found")
+	ErrGiftCardExpired  = errors.New("gift card has expired")
+	ErrGiftCardEmpty    = errors.New("gift card has no balance left")
+	ErrGiftCardCurrency = errors.New("gift card is in a different currency")
+	ErrInvalidGiftCard  = errors.New("invalid gift card")
+)
+
+// giftCardCodeLength is the number of characters in a gift card code, printed
+// in groups of four
+const giftCardCodeLength = 16
+
+type GiftCardService struct {
+	giftCardRepo       repository.GiftCardRepository
+	orderRepo          repository.OrderRepository
+	userRepo           repository.UserRepository
+	storeCreditService *StoreCreditService
+	mailer             email.Mailer
+	cfg                *config.Config
+	logger             *slog.Logger
+}
+
+func NewGiftCardService(
+	giftCardRepo repository.GiftCardRepository,
+	orderRepo repository.OrderRepository,
+	userRepo repository.UserRepository,
+	storeCreditService *StoreCreditService,
+	orderService *OrderService,
+	mailer email.Mailer,
+	cfg *config.Config,
+	logger *slog.Logger,
+) *GiftCardService {
+	s := &GiftCardService{
+		giftCardRepo:       giftCardRepo,
+		orderRepo:          orderRepo,
+		userRepo:           userRepo,
+		storeCreditService: storeCreditService,
+		mailer:             mailer,
+		cfg:                cfg,
+		logger:             logger,
+	}
+
+	// Gift cards sold on an order are issued once it is paid
+	orderService.StateMachine().OnTransition(models.OrderStatusPending, models.OrderStatusProcessing, s.issuePurchasedCards)
+
+	return s
+}
+
+// IssueGiftCardRequest represents an admin issuing a gift card
+type IssueGiftCardRequest struct {
+	Amount         float64    `json:"amount" validate:"required,gt=0"`
+	RecipientEmail string     `json:"recipient_email" validate:"omitempty,email"`
+	Note           string     `json:"note" validate:"max=255"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+}
+
+// GiftCardBalanceRequest represents a customer checking a gift card
+type GiftCardBalanceRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+// ListGiftCardsRequest represents the query of a gift card listing
+type ListGiftCardsRequest struct {
+	Page     int `query:"page" validate:"omitempty,min=1"`
+	PageSize int `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+// IssuedGiftCard is a new gift card together with its code, which is not
+// stored and cannot be shown again
+type IssuedGiftCard struct {
+	*models.GiftCard
+	Code string `json:"code"`
+}
+
+// GiftCardBalance is what a customer sees when checking a code
+type GiftCardBalance struct {
+	Last4     string     `json:"last4"`
+	Balance   float64    `json:"balance"`
+	Currency  string     `json:"currency"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// GiftCardDetail is a gift card with its ledger
+type GiftCardDetail struct {
+	*models.GiftCard
+	Transactions []models.GiftCardTransaction `json:"transactions"`
+}
+
+// GiftCardListResponse represents a page of gift cards
+type GiftCardListResponse struct {
+	GiftCards  []models.GiftCard `json:"gift_cards"`
+	Pagination Pagination        `json:"pagination"`
+}
+
+// IssueGiftCard issues a gift card in the store currency
+func (s *GiftCardService) IssueGiftCard(ctx context.Context, req *IssueGiftCardRequest) (*IssuedGiftCard, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidGiftCard)
+	}
+
+	card := &models.GiftCard{
+		RecipientEmail: strings.ToLower(strings.TrimSpace(req.RecipientEmail)),
+		Note:           req.Note,
+		ExpiresAt:      req.ExpiresAt,
+	}
+
+	var code string
+	err := s.giftCardRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		var err error
+		code, err = s.issueTx(ctx, tx, card, req.Amount, "Issued by admin")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &IssuedGiftCard{GiftCard: card, Code: formatGiftCardCode(code)}, nil
+}
+
+// ListGiftCards lists gift cards with their balances
+func (s *GiftCardService) ListGiftCards(ctx context.Context, req *ListGiftCardsRequest) (*GiftCardListResponse, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+
+	cards, total, err := s.giftCardRepo.List(ctx, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fillBalances(ctx, cards); err != nil {
+		return nil, err
+	}
+
+	return &GiftCardListResponse{
+		GiftCards: cards,
+		Pagination: Pagination{
+			Page:       page,
+			PageSize:   pageSize,
+			Total:      total,
+			TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		},
+	}, nil
+}
+
+// GetGiftCard gets a gift card with its ledger
+func (s *GiftCardService) GetGiftCard(ctx context.Context, id uuid.UUID) (*GiftCardDetail, error) {
+	card, err := s.giftCardRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGiftCardNotFound
+		}
+		return nil, err
+	}
+
+	card.Balance, err = s.giftCardRepo.Balance(ctx, card.ID)
+	if err != nil {
+		return nil, err
+	}
+	card.Balance = roundMoney(card.Balance)
+
+	transactions, err := s.giftCardRepo.ListEntries(ctx, card.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GiftCardDetail{GiftCard: card, Transactions: transactions}, nil
+}
+
+// ListPurchasedGiftCards lists the gift cards the user bought with their
+// balances
+func (s *GiftCardService) ListPurchasedGiftCards(ctx context.Context, userID uuid.UUID) ([]models.GiftCard, error) {
+	cards, err := s.giftCardRepo.ListByPurchaser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fillBalances(ctx, cards); err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+// CheckBalance looks up the balance of a gift card code. An expired card
+// has its remaining balance written off first.
+func (s *GiftCardService) CheckBalance(ctx context.Context, req *GiftCardBalanceRequest) (*GiftCardBalance, error) {
+	var result *GiftCardBalance
+
+	err := s.giftCardRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		card, balance, err := s.lockCard(ctx, tx, req.Code)
+		if err != nil {
+			return err
+		}
+		if giftCardExpired(card) && balance > 0 {
+			if err := s.expireTx(ctx, tx, card, balance); err != nil {
+				return err
+			}
+			balance = 0
+		}
+
+		result = &GiftCardBalance{
+			Last4:     card.Last4,
+			Balance:   balance,
+			Currency:  card.Currency,
+			ExpiresAt: card.ExpiresAt,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ExpireGiftCards writes off the remaining balance of every expired gift
+// card and returns how many cards were expired
+func (s *GiftCardService) ExpireGiftCards(ctx context.Context) (int, error) {
+	cards, err := s.giftCardRepo.ListExpiredWithBalance(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, card := range cards {
+		written := false
+		err := s.giftCardRepo.Transaction(ctx, func(tx *gorm.DB) error {
+			giftCardRepo := s.giftCardRepo.WithTx(tx)
+
+			// Redeemed or refunded since it was listed
+			locked, err := giftCardRepo.GetByIDForUpdate(ctx, card.ID)
+			if err != nil {
+				return err
+			}
+			balance, err := giftCardRepo.Balance(ctx, locked.ID)
+			if err != nil {
+				return err
+			}
+			if balance <= 0 {
+				return nil
+			}
+
+			written = true
+			return s.expireTx(ctx, tx, locked, roundMoney(balance))
+		})
+		if err != nil {
+			return expired, err
+		}
+		if written {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+// issueTx creates a gift card worth amount and returns its code
+func (s *GiftCardService) issueTx(ctx context.Context, tx *gorm.DB, card *models.GiftCard, amount float64, note string) (string, error) {
+	code, err := generateGiftCardCode()
+	if err != nil {
+		return "", err
+	}
+
+	card.CodeHash = s.hashCode(code)
+	card.Last4 = code[len(code)-4:]
+	card.InitialAmount = roundMoney(amount)
+	card.Currency = payment.NormalizeCurrency(s.cfg.PaymentCurrency)
+	if card.ExpiresAt == nil && s.cfg.GiftCardValidityDays > 0 {
+		expiresAt := time.Now().UTC().AddDate(0, 0, s.cfg.GiftCardValidityDays)
+		card.ExpiresAt = &expiresAt
+	}
+
+	giftCardRepo := s.giftCardRepo.WithTx(tx)
+	if err := giftCardRepo.Create(ctx, card); err != nil {
+		return "", err
+	}
+	if err := giftCardRepo.CreateEntry(ctx, &models.GiftCardTransaction{
+		GiftCardID: card.ID,
+		Type:       models.GiftCardIssue,
+		Amount:     card.InitialAmount,
+		OrderID:    card.OrderID,
+		Note:       note,
+	}); err != nil {
+		return "", err
+	}
+
+	card.Balance = card.InitialAmount
+	return code, nil
+}
+
+// redeemTx debits up to amount from a gift card for a payment on an order
+// and returns the card and the amount taken. The card stays locked until the
+// transaction ends, so concurrent checkouts cannot overspend it.
+func (s *GiftCardService) redeemTx(ctx context.Context, tx *gorm.DB, code string, amount float64, currency string, orderID, paymentID uuid.UUID) (*models.GiftCard, float64, error) {
+	card, balance, err := s.lockCard(ctx, tx, code)
+	if err != nil {
+		return nil, 0, err
+	}
+	if giftCardExpired(card) {
+		return nil, 0, fmt.Errorf("%w: ending in %s", ErrGiftCardExpired, card.Last4)
+	}
+	if card.Currency != currency {
+		return nil, 0, fmt.Errorf("%w: ending in %s is in %s", ErrGiftCardCurrency, card.Last4, strings.ToUpper(card.Currency))
+	}
+	if balance <= 0 {
+		return nil, 0, fmt.Errorf("%w: ending in %s", ErrGiftCardEmpty, card.Last4)
+	}
+
+	taken := roundMoney(math.Min(balance, amount))
+	if err := s.giftCardRepo.WithTx(tx).CreateEntry(ctx, &models.GiftCardTransaction{
+		GiftCardID: card.ID,
+		Type:       models.GiftCardRedeem,
+		Amount:     -taken,
+		OrderID:    &orderID,
+		PaymentID:  &paymentID,
+	}); err != nil {
+		return nil, 0, err
+	}
+
+	card.Balance = roundMoney(balance - taken)
+	return card, taken, nil
+}
+
+// refundTx credits a refunded gift card payment back to the card. Money
+// refunded after the card has expired goes to the customer's store credit
+// instead, where it cannot lapse.
+func (s *GiftCardService) refundTx(ctx context.Context, tx *gorm.DB, record *models.Payment, refund *models.Refund, userID uuid.UUID) error {
+	card, err := s.giftCardRepo.WithTx(tx).GetByIDForUpdate(ctx, *record.GiftCardID)
+	if err != nil {
+		return err
+	}
+
+	if giftCardExpired(card) {
+		_, err := s.storeCreditService.addEntryTx(ctx, tx, userID, models.StoreCreditRefund, refund.Amount, "gift_card", &card.ID,
+			"Refund to expired gift card ending in "+card.Last4)
+		return err
+	}
+
+	return s.giftCardRepo.WithTx(tx).CreateEntry(ctx, &models.GiftCardTransaction{
+		GiftCardID: card.ID,
+		Type:       models.GiftCardRefund,
+		Amount:     refund.Amount,
+		OrderID:    &refund.OrderID,
+		PaymentID:  &record.ID,
+		Note:       refund.Reason,
+	})
+}
+
+// expireTx writes off a gift card's remaining balance
+func (s *GiftCardService) expireTx(ctx context.Context, tx *gorm.DB, card *models.GiftCard, balance float64) error {
+	return s.giftCardRepo.WithTx(tx).CreateEntry(ctx, &models.GiftCardTransaction{
+		GiftCardID: card.ID,
+		Type:       models.GiftCardExpire,
+		Amount:     -balance,
+		Note:       "Expired on " + card.ExpiresAt.UTC().Format(dateLayout),
+	})
+}
+
+// issuePurchasedCards is a transition hook issuing the gift cards sold on an
+// order once it is paid and mailing their codes to the buyer. The email goes
+// out before the transaction commits; should it roll back, the mailed codes
+// were never stored and are simply rejected.
+func (s *GiftCardService) issuePurchasedCards(tc *TransitionContext) error {
+	items, err := s.orderRepo.WithTx(tc.Tx).GetItemsWithProducts(tc.Ctx, tc.Order.ID)
+	if err != nil {
+		return err
+	}
+
+	var cards []email.GiftCardDeliveryCard
+	for _, item := range items {
+		if !item.Product.IsGiftCard {
+			continue
+		}
+		for i := 0; i < item.Quantity; i++ {
+			card := &models.GiftCard{
+				PurchaserID: &tc.Order.UserID,
+				OrderID:     &tc.Order.ID,
+			}
+			code, err := s.issueTx(tc.Ctx, tc.Tx, card, item.Price, "Sold on order "+tc.Order.OrderNumber)
+			if err != nil {
+				return err
+			}
+
+			delivery := email.GiftCardDeliveryCard{
+				Code:     formatGiftCardCode(code),
+				Amount:   fmt.Sprintf("%.2f", card.InitialAmount),
+				Currency: strings.ToUpper(card.Currency),
+			}
+			if card.ExpiresAt != nil {
+				delivery.ExpiresAt = card.ExpiresAt.Format(dateLayout)
+			}
+			cards = append(cards, delivery)
+		}
+	}
+
+	if len(cards) > 0 {
+		s.sendGiftCards(tc.Ctx, tc.Order, cards)
+	}
+	return nil
+}
+
+// sendGiftCards emails purchased gift card codes to the buyer. Failures are
+// logged rather than returned so they do not undo the payment.
+func (s *GiftCardService) sendGiftCards(ctx context.Context, order *models.Order, cards []email.GiftCardDeliveryCard) {
+	user, err := s.userRepo.GetByID(ctx, order.UserID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to load user for gift card delivery", "user_id", order.UserID, "error", err)
+		return
+	}
+
+	msg, err := email.GiftCardDelivery(user.Email, &email.GiftCardDeliveryData{
+		AppName:     s.cfg.AppName,
+		FirstName:   user.FirstName,
+		OrderNumber: order.OrderNumber,
+		Cards:       cards,
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to render gift card delivery", "order_number", order.OrderNumber, "error", err)
+		return
+	}
+
+	if err := s.mailer.Send(msg); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to send gift card delivery", "order_number", order.OrderNumber, "error", err)
+	}
+}
+
+// lockCard looks up and locks the gift card with the given code and sums
+// its balance
+func (s *GiftCardService) lockCard(ctx context.Context, tx *gorm.DB, code string) (*models.GiftCard, float64, error) {
+	giftCardRepo := s.giftCardRepo.WithTx(tx)
+
+	card, err := giftCardRepo.GetByCodeHashForUpdate(ctx, s.hashCode(normalizeGiftCardCode(code)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ErrGiftCardNotFound
+		}
+		return nil, 0, err
+	}
+
+	balance, err := giftCardRepo.Balance(ctx, card.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	card.Balance = roundMoney(balance)
+	return card, card.Balance, nil
+}
+
+// fillBalances sums the ledgers of the listed gift cards
+func (s *GiftCardService) fillBalances(ctx context.Context, cards []models.GiftCard) error {
+	if len(cards) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(cards))
+	for _, card := range cards {
+		ids = append(ids, card.ID)
+	}
+
+	balances, err := s.giftCardRepo.Balances(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range cards {
+		cards[i].Balance = roundMoney(balances[cards[i].ID])
+	}
+	return nil
+}
+
+// hashCode is the keyed hash a gift card code is stored as. Keying it means
+// a leaked database alone is not enough to recover valid codes.
+func (s *GiftCardService) hashCode(code string) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.GiftCardSecret))
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// giftCardExpired reports whether a gift card is past its expiry
+func giftCardExpired(card *models.GiftCard) bool {
+	return card.ExpiresAt != nil && !time.Now().Before(*card.ExpiresAt)
+}
+
+// generateGiftCardCode returns a random code of giftCardCodeLength
+// characters without separators
+func generateGiftCardCode() (string, error) {
+	code := make([]byte, giftCardCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(referenceAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = referenceAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// formatGiftCardCode prints a code in groups of four, e.g. ABCD-EFGH-JKLM-NPQR
+func formatGiftCardCode(code string) string {
+	var b strings.Builder
+	for i, c := range code {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// normalizeGiftCardCode makes codes case insensitive and ignores the
+// separators customers type in
+func normalizeGiftCardCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
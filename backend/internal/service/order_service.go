@@ -0,0 +1,377 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrOrderNotCancellable = errors.New("order can no longer be cancelled")
+)
+
+const (
+	defaultPageSize = 20
+	dateLayout      = "2006-01-02"
+)
+
+type OrderService struct {
+	orderRepo    repository.OrderRepository
+	stateMachine *OrderStateMachine
+}
+
+func NewOrderService(orderRepo repository.OrderRepository) *OrderService {
+	s := &OrderService{
+		orderRepo:    orderRepo,
+		stateMachine: NewOrderStateMachine(),
+	}
+
+	// Only staff may advance an order; customers can cancel pending orders only
+	s.stateMachine.Guard(models.OrderStatusPending, models.OrderStatusProcessing, requireStaff)
+	s.stateMachine.Guard(models.OrderStatusProcessing, models.OrderStatusPartiallyShipped, requireStaff)
+	s.stateMachine.Guard(models.OrderStatusProcessing, models.OrderStatusShipped, requireStaff)
+	s.stateMachine.Guard(models.OrderStatusPartiallyShipped, models.OrderStatusShipped, requireStaff)
+	s.stateMachine.Guard(models.OrderStatusShipped, models.OrderStatusDelivered, requireStaff)
+	s.stateMachine.Guard(models.OrderStatusProcessing, models.OrderStatusCancelled, requireStaff)
+
+	// Put cancelled items back on the shelf
+	s.stateMachine.OnTransition(models.OrderStatusPending, models.OrderStatusCancelled, restockOrderItems)
+	s.stateMachine.OnTransition(models.OrderStatusProcessing, models.OrderStatusCancelled, restockOrderItems)
+
+	return s
+}
+
+// StateMachine exposes the order state machine so other services can attach
+// guards and hooks
+func (s *OrderService) StateMachine() *OrderStateMachine {
+	return s.stateMachine
+}
+
+// UpdateOrderStatusRequest represents an admin status change request
+type UpdateOrderStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=pending processing partially_shipped shipped delivered cancelled"`
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// ListOrdersRequest represents the query of an order listing
+type ListOrdersRequest struct {
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	PageSize int    `query:"page_size" validate:"omitempty,min=1,max=100"`
+	Status   string `query:"status" validate:"omitempty,oneof=pending processing partially_shipped shipped delivered cancelled"`
+	From     string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To       string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// SearchOrdersRequest represents the query of an admin order search
+type SearchOrdersRequest struct {
+	Page        int    `query:"page" validate:"omitempty,min=1"`
+	PageSize    int    `query:"page_size" validate:"omitempty,min=1,max=100"`
+	OrderNumber string `query:"order_number" validate:"max=50"`
+	Email       string `query:"email" validate:"max=255"`
+	Status      string `query:"status" validate:"omitempty,oneof=pending processing partially_shipped shipped delivered cancelled"`
+	From        string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To          string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// CancelOrderRequest represents a customer cancellation request
+type CancelOrderRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// Pagination describes the page returned by a listing
+type Pagination struct {
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// OrderListResponse represents a page of orders
+type OrderListResponse struct {
+	Orders     []models.Order `json:"orders"`
+	Pagination Pagination     `json:"pagination"`
+}
+
+// ListUserOrders lists the user's orders
+func (s *OrderService) ListUserOrders(ctx context.Context, userID uuid.UUID, req *ListOrdersRequest) (*OrderListResponse, error) {
+	filter, err := req.toFilter()
+	if err != nil {
+		return nil, err
+	}
+	filter.UserID = &userID
+
+	return s.listOrders(ctx, filter)
+}
+
+// SearchOrders searches all orders by number, customer email, status and date
+func (s *OrderService) SearchOrders(ctx context.Context, req *SearchOrdersRequest) (*OrderListResponse, error) {
+	listReq := &ListOrdersRequest{
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Status:   req.Status,
+		From:     req.From,
+		To:       req.To,
+	}
+
+	filter, err := listReq.toFilter()
+	if err != nil {
+		return nil, err
+	}
+	filter.OrderNumber = req.OrderNumber
+	filter.Email = req.Email
+
+	return s.listOrders(ctx, filter)
+}
+
+// GetOrder gets an order with its items, addresses, payment and fulfillments
+func (s *OrderService) GetOrder(ctx context.Context, orderID uuid.UUID) (*models.Order, error) {
+	order, err := s.orderRepo.GetDetail(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+// GetUserOrder gets one of the user's orders with its items, addresses,
+// payment and fulfillments
+func (s *OrderService) GetUserOrder(ctx context.Context, userID uuid.UUID, orderNumber string) (*models.Order, error) {
+	order, err := s.orderRepo.GetUserOrderDetail(ctx, userID, orderNumber)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+// CancelUserOrder cancels one of the user's orders while it is still pending
+func (s *OrderService) CancelUserOrder(ctx context.Context, userID uuid.UUID, orderNumber string, actor Actor, reason string) (*models.Order, error) {
+	order, err := s.getUserOrder(ctx, userID, orderNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != models.OrderStatusPending {
+		return nil, ErrOrderNotCancellable
+	}
+
+	order, err = s.TransitionStatus(ctx, order.ID, models.OrderStatusCancelled, actor, reason)
+	if err != nil {
+		// The order may have moved on between the check and the row lock
+		if errors.Is(err, ErrInvalidTransition) || errors.Is(err, ErrTransitionNotPermitted) {
+			return nil, ErrOrderNotCancellable
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+// listOrders runs a filtered order listing and wraps it with pagination info
+func (s *OrderService) listOrders(ctx context.Context, filter repository.OrderFilter) (*OrderListResponse, error) {
+	orders, total, err := s.orderRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OrderListResponse{
+		Orders: orders,
+		Pagination: Pagination{
+			Page:       filter.Page,
+			PageSize:   filter.PageSize,
+			Total:      total,
+			TotalPages: int((total + int64(filter.PageSize) - 1) / int64(filter.PageSize)),
+		},
+	}, nil
+}
+
+// toFilter converts the listing query into a repository filter. The "to"
+// date is inclusive, so the filter ends at the start of the following day.
+func (req *ListOrdersRequest) toFilter() (repository.OrderFilter, error) {
+	filter := repository.OrderFilter{
+		Status:   models.OrderStatus(req.Status),
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultPageSize
+	}
+
+	if req.From != "" {
+		from, err := time.Parse(dateLayout, req.From)
+		if err != nil {
+			return filter, err
+		}
+		filter.From = &from
+	}
+	if req.To != "" {
+		to, err := time.Parse(dateLayout, req.To)
+		if err != nil {
+			return filter, err
+		}
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	return filter, nil
+}
+
+// TransitionStatus moves an order to a new status, enforcing the lifecycle
+// rules and recording the change in the status history
+func (s *OrderService) TransitionStatus(ctx context.Context, orderID uuid.UUID, to models.OrderStatus, actor Actor, reason string) (*models.Order, error) {
+	var order *models.Order
+
+	ctx, committed := withAfterCommit(ctx)
+	err := s.orderRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		var err error
+		order, err = s.transitionStatusTx(ctx, tx, orderID, to, actor, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	committed.run()
+
+	return order, nil
+}
+
+// transitionStatusTx performs a status transition inside an existing transaction
+func (s *OrderService) transitionStatusTx(ctx context.Context, tx *gorm.DB, orderID uuid.UUID, to models.OrderStatus, actor Actor, reason string) (*models.Order, error) {
+	orderRepo := s.orderRepo.WithTx(tx)
+
+	order, err := orderRepo.GetByIDForUpdate(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	tc := &TransitionContext{
+		Ctx:    ctx,
+		Tx:     tx,
+		Order:  order,
+		From:   order.Status,
+		To:     to,
+		Actor:  actor,
+		Reason: reason,
+	}
+
+	if err := s.stateMachine.validate(tc); err != nil {
+		return nil, err
+	}
+
+	order.Status = to
+	if err := orderRepo.UpdateStatus(ctx, order); err != nil {
+		return nil, err
+	}
+
+	if err := orderRepo.CreateStatusHistory(ctx, &models.OrderStatusHistory{
+		OrderID:    order.ID,
+		FromStatus: tc.From,
+		ToStatus:   tc.To,
+		ActorID:    actor.ID,
+		ActorRole:  actor.Role,
+		Reason:     reason,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.stateMachine.runHooks(tc); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// transitionPaymentStatusTx moves an order to a new payment status inside an
+// existing transaction, rejecting changes the payment lifecycle does not
+// allow, and runs the hooks of the new status
+func (s *OrderService) transitionPaymentStatusTx(ctx context.Context, tx *gorm.DB, order *models.Order, to models.PaymentStatus) error {
+	if order.PaymentStatus == to {
+		return nil
+	}
+	if !canTransitionPaymentStatus(order.PaymentStatus, to) {
+		return fmt.Errorf("%w: %s → %s", ErrInvalidPaymentTransition, order.PaymentStatus, to)
+	}
+
+	order.PaymentStatus = to
+	if err := s.orderRepo.WithTx(tx).UpdatePaymentStatus(ctx, order); err != nil {
+		return err
+	}
+	return s.stateMachine.runPaymentHooks(ctx, tx, order)
+}
+
+// GetStatusHistory gets the status history of an order
+func (s *OrderService) GetStatusHistory(ctx context.Context, orderID uuid.UUID) ([]models.OrderStatusHistory, error) {
+	if _, err := s.orderRepo.GetByID(ctx, orderID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	return s.orderRepo.ListStatusHistory(ctx, orderID)
+}
+
+// GetUserOrderStatusHistory gets the status history of one of the user's orders
+func (s *OrderService) GetUserOrderStatusHistory(ctx context.Context, userID uuid.UUID, orderNumber string) ([]models.OrderStatusHistory, error) {
+	order, err := s.getUserOrder(ctx, userID, orderNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.orderRepo.ListStatusHistory(ctx, order.ID)
+}
+
+// getUserOrder loads one of the user's orders by number. Orders of other
+// users are reported as not found so their existence is not leaked.
+func (s *OrderService) getUserOrder(ctx context.Context, userID uuid.UUID, orderNumber string) (*models.Order, error) {
+	order, err := s.orderRepo.GetUserOrderByNumber(ctx, userID, orderNumber)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+// restockOrderItems returns the quantities of a cancelled order to stock,
+// less those a refund has restocked already
+func restockOrderItems(tc *TransitionContext) error {
+	items, err := repository.NewOrderRepository(tc.Tx).GetItems(tc.Ctx, tc.Order.ID)
+	if err != nil {
+		return err
+	}
+	restocked, err := repository.NewRefundRepository(tc.Tx).RestockedQuantities(tc.Ctx, tc.Order.ID)
+	if err != nil {
+		return err
+	}
+
+	productRepo := repository.NewProductRepository(tc.Tx)
+	for _, item := range items {
+		quantity := item.Quantity - restocked[item.ID]
+		if quantity <= 0 {
+			continue
+		}
+		if err := productRepo.AdjustStock(tc.Ctx, item.ProductID, quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidTransition        = errors.New("invalid order status transition")
+	ErrTransitionNotPermitted   = errors.New("order status transition not permitted")
+	ErrInvalidPaymentTransition = errors.New("invalid order payment status transition")
+)
+
+// Actor roles recorded in the order status history
+const (
+	ActorRoleCustomer = "customer"
+	ActorRoleAdmin    = "admin"
+	ActorRoleSystem   = "system"
+)
+
+// Actor identifies who requested a status transition
+type Actor struct {
+	ID   *uuid.UUID
+	Role string
+}
+
+// SystemActor is used for transitions triggered by the application itself
+var SystemActor = Actor{Role: ActorRoleSystem}
+
+// OrderTransition is a single edge of the order lifecycle
+type OrderTransition struct {
+	From models.OrderStatus
+	To   models.OrderStatus
+}
+
+// TransitionContext is passed to guards and hooks. Tx is the transaction the
+// transition is written in, so hooks can make changes atomically with it.
+type TransitionContext struct {
+	Ctx    context.Context
+	Tx     *gorm.DB
+	Order  *models.Order
+	From   models.OrderStatus
+	To     models.OrderStatus
+	Actor  Actor
+	Reason string
+}
+
+// TransitionGuard may veto a transition by returning an error
+type TransitionGuard func(tc *TransitionContext) error
+
+// TransitionHook runs after a transition has been validated and written
+type TransitionHook func(tc *TransitionContext) error
+
+// PaymentStatusHook runs when an order reaches a payment status, in the
+// transaction making the change
+type PaymentStatusHook func(ctx context.Context, tx *gorm.DB, order *models.Order) error
+
+// RefundHook runs when a refund on an order succeeds, in the transaction
+// recording it
+type RefundHook func(ctx context.Context, tx *gorm.DB, refund *models.Refund) error
+
+// LatePaymentHook runs when a payment is captured on an order cancelled in
+// the meantime, in the transaction recording the capture
+type LatePaymentHook func(ctx context.Context, tx *gorm.DB, order *models.Order, record *models.Payment) error
+
+// OrderStateMachine defines the legal order status transitions together with
+// the guards and side-effect hooks attached to them, and the hooks run as
+// the order is paid and refunded
+type OrderStateMachine struct {
+	transitions  map[models.OrderStatus]map[models.OrderStatus]bool
+	guards       map[OrderTransition][]TransitionGuard
+	hooks        map[OrderTransition][]TransitionHook
+	paymentHooks map[models.PaymentStatus][]PaymentStatusHook
+	refundHooks  []RefundHook
+	lateHooks    []LatePaymentHook
+}
+
+// NewOrderStateMachine creates a state machine with the default order lifecycle:
+// pending → processing → (partially_shipped →) shipped → delivered, with
+// cancellation allowed only before anything has shipped
+func NewOrderStateMachine() *OrderStateMachine {
+	m := &OrderStateMachine{
+		transitions:  make(map[models.OrderStatus]map[models.OrderStatus]bool),
+		guards:       make(map[OrderTransition][]TransitionGuard),
+		hooks:        make(map[OrderTransition][]TransitionHook),
+		paymentHooks: make(map[models.PaymentStatus][]PaymentStatusHook),
+	}
+
+	m.Allow(models.OrderStatusPending, models.OrderStatusProcessing)
+	m.Allow(models.OrderStatusProcessing, models.OrderStatusPartiallyShipped)
+	m.Allow(models.OrderStatusProcessing, models.OrderStatusShipped)
+	m.Allow(models.OrderStatusPartiallyShipped, models.OrderStatusShipped)
+	m.Allow(models.OrderStatusShipped, models.OrderStatusDelivered)
+	m.Allow(models.OrderStatusPending, models.OrderStatusCancelled)
+	m.Allow(models.OrderStatusProcessing, models.OrderStatusCancelled)
+
+	return m
+}
+
+// Allow registers a legal transition
+func (m *OrderStateMachine) Allow(from, to models.OrderStatus) {
+	if m.transitions[from] == nil {
+		m.transitions[from] = make(map[models.OrderStatus]bool)
+	}
+	m.transitions[from][to] = true
+}
+
+// Guard attaches a guard to a transition
+func (m *OrderStateMachine) Guard(from, to models.OrderStatus, guard TransitionGuard) {
+	t := OrderTransition{From: from, To: to}
+	m.guards[t] = append(m.guards[t], guard)
+}
+
+// OnTransition attaches a side-effect hook to a transition
+func (m *OrderStateMachine) OnTransition(from, to models.OrderStatus, hook TransitionHook) {
+	t := OrderTransition{From: from, To: to}
+	m.hooks[t] = append(m.hooks[t], hook)
+}
+
+// OnPaymentStatus attaches a side-effect hook to an order reaching a
+// payment status
+func (m *OrderStateMachine) OnPaymentStatus(status models.PaymentStatus, hook PaymentStatusHook) {
+	m.paymentHooks[status] = append(m.paymentHooks[status], hook)
+}
+
+// OnRefund attaches a side-effect hook to a refund succeeding
+func (m *OrderStateMachine) OnRefund(hook RefundHook) {
+	m.refundHooks = append(m.refundHooks, hook)
+}
+
+// OnLatePayment attaches a side-effect hook to a payment captured on a
+// cancelled order
+func (m *OrderStateMachine) OnLatePayment(hook LatePaymentHook) {
+	m.lateHooks = append(m.lateHooks, hook)
+}
+
+// CanTransition reports whether a transition is part of the lifecycle
+func (m *OrderStateMachine) CanTransition(from, to models.OrderStatus) bool {
+	return m.transitions[from][to]
+}
+
+// AllowedTransitions lists the statuses reachable from the given status
+func (m *OrderStateMachine) AllowedTransitions(from models.OrderStatus) []models.OrderStatus {
+	var statuses []models.OrderStatus
+	for _, to := range []models.OrderStatus{
+		models.OrderStatusPending,
+		models.OrderStatusProcessing,
+		models.OrderStatusPartiallyShipped,
+		models.OrderStatusShipped,
+		models.OrderStatusDelivered,
+		models.OrderStatusCancelled,
+	} {
+		if m.CanTransition(from, to) {
+			statuses = append(statuses, to)
+		}
+	}
+	return statuses
+}
+
+// validate checks that the transition is legal and runs its guards
+func (m *OrderStateMachine) validate(tc *TransitionContext) error {
+	if !m.CanTransition(tc.From, tc.To) {
+		return fmt.Errorf("%w: %s → %s", ErrInvalidTransition, tc.From, tc.To)
+	}
+
+	for _, guard := range m.guards[OrderTransition{From: tc.From, To: tc.To}] {
+		if err := guard(tc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runHooks runs the side-effect hooks of the transition
+func (m *OrderStateMachine) runHooks(tc *TransitionContext) error {
+	for _, hook := range m.hooks[OrderTransition{From: tc.From, To: tc.To}] {
+		if err := hook(tc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runPaymentHooks runs the side-effect hooks of the order's new payment status
+func (m *OrderStateMachine) runPaymentHooks(ctx context.Context, tx *gorm.DB, order *models.Order) error {
+	for _, hook := range m.paymentHooks[order.PaymentStatus] {
+		if err := hook(ctx, tx, order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runRefundHooks runs the side-effect hooks of a succeeded refund
+func (m *OrderStateMachine) runRefundHooks(ctx context.Context, tx *gorm.DB, refund *models.Refund) error {
+	for _, hook := range m.refundHooks {
+		if err := hook(ctx, tx, refund); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runLatePaymentHooks runs the side-effect hooks of a payment captured on a
+// cancelled order
+func (m *OrderStateMachine) runLatePaymentHooks(ctx context.Context, tx *gorm.DB, order *models.Order, record *models.Payment) error {
+	for _, hook := range m.lateHooks {
+		if err := hook(ctx, tx, order, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requireStaff is a guard that only lets admins and the system through
+func requireStaff(tc *TransitionContext) error {
+	if tc.Actor.Role != ActorRoleAdmin && tc.Actor.Role != ActorRoleSystem {
+		return fmt.Errorf("%w: %s → %s requires staff", ErrTransitionNotPermitted, tc.From, tc.To)
+	}
+	return nil
+}
+
+// paymentStatusTransitions are the legal changes of an order's payment
+// status. Money only moves forward: once paid, an order can only be
+// refunded. An unpaid order is refunded when the gift cards and store credit
+// it was partly paid with are returned on cancellation, and a voided
+// authorization leaves it pending again.
+var paymentStatusTransitions = map[models.PaymentStatus][]models.PaymentStatus{
+	models.PaymentStatusPending: {
+		models.PaymentStatusAuthorized,
+		models.PaymentStatusPaid,
+		models.PaymentStatusFailed,
+		models.PaymentStatusPartiallyRefunded,
+		models.PaymentStatusRefunded,
+	},
+	models.PaymentStatusFailed: {
+		models.PaymentStatusAuthorized,
+		models.PaymentStatusPaid,
+		models.PaymentStatusPartiallyRefunded,
+		models.PaymentStatusRefunded,
+	},
+	models.PaymentStatusAuthorized: {
+		models.PaymentStatusPending,
+		models.PaymentStatusPaid,
+		models.PaymentStatusPartiallyRefunded,
+		models.PaymentStatusRefunded,
+	},
+	models.PaymentStatusPaid: {
+		models.PaymentStatusPartiallyRefunded,
+		models.PaymentStatusRefunded,
+	},
+	models.PaymentStatusPartiallyRefunded: {
+		models.PaymentStatusRefunded,
+	},
+}
+
+// canTransitionPaymentStatus reports whether an order's payment status may
+// change from one value to another
+func canTransitionPaymentStatus(from, to models.PaymentStatus) bool {
+	for _, allowed := range paymentStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,572 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/metrics"
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/payment"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOrderNotPayable     = errors.New("order cannot be paid in its current status")
+	ErrPaymentNotFound     = errors.New("payment not found")
+	ErrPaymentDeclined     = errors.New("payment declined")
+	ErrPaymentInvalidState = errors.New("payment is not in a valid state for this operation")
+)
+
+type PaymentService struct {
+	provider     payment.Provider
+	paymentRepo  repository.PaymentRepository
+	orderRepo    repository.OrderRepository
+	orderService *OrderService
+	cfg          *config.Config
+	metrics      *metrics.Metrics
+	logger       *slog.Logger
+}
+
+func NewPaymentService(
+	provider payment.Provider,
+	paymentRepo repository.PaymentRepository,
+	orderRepo repository.OrderRepository,
+	orderService *OrderService,
+	cfg *config.Config,
+	metrics *metrics.Metrics,
+	logger *slog.Logger,
+) *PaymentService {
+	s := &PaymentService{
+		provider:     provider,
+		paymentRepo:  paymentRepo,
+		orderRepo:    orderRepo,
+		orderService: orderService,
+		cfg:          cfg,
+		metrics:      metrics,
+		logger:       logger,
+	}
+
+	// A cancelled order must not be paid through an intent still open, nor
+	// keep an authorization on the customer's card
+	orderService.StateMachine().OnTransition(models.OrderStatusPending, models.OrderStatusCancelled, s.voidOpenPayments)
+	orderService.StateMachine().OnTransition(models.OrderStatusProcessing, models.OrderStatusCancelled, s.voidOpenPayments)
+
+	return s
+}
+
+// ConfirmPaymentRequest represents a request to pay with a payment method
+type ConfirmPaymentRequest struct {
+	PaymentMethod string `json:"payment_method" validate:"required"`
+}
+
+// PaymentIntentResponse is what the client needs to complete a payment
+type PaymentIntentResponse struct {
+	PaymentID      uuid.UUID           `json:"payment_id"`
+	Provider       string              `json:"provider"`
+	ClientSecret   string              `json:"client_secret"`
+	PublishableKey string              `json:"publishable_key,omitempty"`
+	Amount         float64             `json:"amount"`
+	Currency       string              `json:"currency"`
+	Status         models.PaymentState `json:"status"`
+}
+
+// CreatePaymentIntent starts a payment for one of the user's pending orders.
+// The order stays locked meanwhile, so concurrent calls cannot open two
+// intents. A pending intent for the amount due is handed out again, one for
+// another amount is cancelled first. The idempotency key is derived from the
+// order and the attempt, so a retry after a failure to store the payment
+// gets the intent already created rather than a second one.
+func (s *PaymentService) CreatePaymentIntent(ctx context.Context, userID uuid.UUID, orderNumber string) (*PaymentIntentResponse, error) {
+	order, err := s.orderService.getUserOrder(ctx, userID, orderNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var record *models.Payment
+	var clientSecret string
+
+	ctx, committed := withAfterCommit(ctx)
+	err = s.orderRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		paymentRepo := s.paymentRepo.WithTx(tx)
+
+		order, err := s.orderRepo.WithTx(tx).GetByIDForUpdate(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		if order.Status != models.OrderStatusPending ||
+			(order.PaymentStatus != models.PaymentStatusPending && order.PaymentStatus != models.PaymentStatusFailed) {
+			return ErrOrderNotPayable
+		}
+
+		// Gift cards and store credit used at checkout cover part of the total
+		tendered, err := paymentRepo.SumTendered(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		due := roundMoney(order.Total - tendered)
+		if due <= 0 {
+			return ErrOrderNotPayable
+		}
+		currency := payment.NormalizeCurrency(s.cfg.PaymentCurrency)
+
+		records, err := paymentRepo.ListByOrder(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		var attempt int
+		var latest *models.Payment
+		for i := range records {
+			if !isTender(&records[i]) {
+				attempt++
+				latest = &records[i]
+			}
+		}
+
+		if latest != nil && latest.Status == models.PaymentStatePending {
+			if secret := storedClientSecret(latest); secret != "" && latest.Amount == due && latest.Currency == currency {
+				record, clientSecret = latest, secret
+				return nil
+			}
+			if err := s.cancelPendingTx(ctx, tx, latest); err != nil {
+				return err
+			}
+		}
+
+		record = &models.Payment{
+			BaseModel:     models.BaseModel{ID: uuid.New()},
+			OrderID:       order.ID,
+			Provider:      s.provider.Name(),
+			PaymentMethod: "card",
+			Amount:        due,
+			Currency:      currency,
+			Status:        models.PaymentStatePending,
+		}
+
+		intent, err := s.provider.CreateIntent(ctx, &payment.CreateIntentRequest{
+			Amount:         payment.ToMinorUnits(due, currency),
+			Currency:       record.Currency,
+			CaptureMethod:  s.cfg.PaymentCaptureMethod,
+			Description:    "Order " + order.OrderNumber,
+			Metadata:       map[string]string{"order_id": order.ID.String(), "order_number": order.OrderNumber},
+			IdempotencyKey: fmt.Sprintf("payment-%s-%d", order.ID, attempt+1),
+		})
+		if err != nil {
+			return err
+		}
+
+		record.TransactionID = intent.ID
+		record.ProviderResponse = rawJSON(intent.Raw)
+		clientSecret = intent.ClientSecret
+		return paymentRepo.Create(ctx, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	committed.run()
+
+	return &PaymentIntentResponse{
+		PaymentID:      record.ID,
+		Provider:       record.Provider,
+		ClientSecret:   clientSecret,
+		PublishableKey: s.cfg.StripePublishableKey,
+		Amount:         record.Amount,
+		Currency:       record.Currency,
+		Status:         record.Status,
+	}, nil
+}
+
+// cancelPendingTx cancels a pending intent superseded by a new one. Only the
+// payment changes, its order stays payable.
+func (s *PaymentService) cancelPendingTx(ctx context.Context, tx *gorm.DB, record *models.Payment) error {
+	intent, err := s.provider.Void(ctx, record.TransactionID)
+	if err != nil {
+		if errors.Is(err, payment.ErrInvalidState) {
+			return ErrPaymentInvalidState
+		}
+		return err
+	}
+
+	record.Status = models.PaymentStateCancelled
+	record.ProviderResponse = rawJSON(intent.Raw)
+	if err := s.paymentRepo.WithTx(tx).Update(ctx, record); err != nil {
+		return err
+	}
+	onCommit(ctx, func() {
+		s.metrics.PaymentChanged(string(models.PaymentStateCancelled))
+	})
+	return nil
+}
+
+// ConfirmPayment pays the latest payment intent of one of the user's pending
+// orders with the given payment method
+func (s *PaymentService) ConfirmPayment(ctx context.Context, userID uuid.UUID, orderNumber string, req *ConfirmPaymentRequest) (*models.Payment, error) {
+	order, err := s.orderService.getUserOrder(ctx, userID, orderNumber)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderStatusPending {
+		return nil, ErrPaymentInvalidState
+	}
+
+	record, err := s.paymentRepo.GetLatestByOrder(ctx, order.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	if record.Status != models.PaymentStatePending && record.Status != models.PaymentStateFailed {
+		return nil, ErrPaymentInvalidState
+	}
+
+	intent, err := s.provider.Confirm(ctx, record.TransactionID, req.PaymentMethod)
+	if err != nil {
+		if errors.Is(err, payment.ErrCardDeclined) {
+			if _, syncErr := s.markFailed(ctx, record.TransactionID, err.Error()); syncErr != nil {
+				return nil, syncErr
+			}
+			return nil, fmt.Errorf("%w: %v", ErrPaymentDeclined, err)
+		}
+		if errors.Is(err, payment.ErrInvalidState) {
+			return nil, ErrPaymentInvalidState
+		}
+		return nil, err
+	}
+
+	return s.SyncIntent(ctx, intent)
+}
+
+// CapturePayment captures the authorized payment of an order
+func (s *PaymentService) CapturePayment(ctx context.Context, orderID uuid.UUID) (*models.Payment, error) {
+	record, err := s.latestPayment(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status != models.PaymentStateAuthorized {
+		return nil, ErrPaymentInvalidState
+	}
+
+	intent, err := s.provider.Capture(ctx, record.TransactionID, 0)
+	if err != nil {
+		if errors.Is(err, payment.ErrInvalidState) {
+			return nil, ErrPaymentInvalidState
+		}
+		return nil, err
+	}
+
+	return s.SyncIntent(ctx, intent)
+}
+
+// VoidPayment cancels the uncaptured payment of an order
+func (s *PaymentService) VoidPayment(ctx context.Context, orderID uuid.UUID) (*models.Payment, error) {
+	record, err := s.latestPayment(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status != models.PaymentStatePending && record.Status != models.PaymentStateAuthorized {
+		return nil, ErrPaymentInvalidState
+	}
+
+	intent, err := s.provider.Void(ctx, record.TransactionID)
+	if err != nil {
+		if errors.Is(err, payment.ErrInvalidState) {
+			return nil, ErrPaymentInvalidState
+		}
+		return nil, err
+	}
+
+	return s.SyncIntent(ctx, intent)
+}
+
+// SyncIntent applies the provider's view of an intent to the matching payment
+// and its order. Paying or authorizing a pending order moves it to processing.
+func (s *PaymentService) SyncIntent(ctx context.Context, intent *payment.Intent) (*models.Payment, error) {
+	return s.applyPaymentState(ctx, intent.ID, intentPaymentState(intent), rawJSON(intent.Raw))
+}
+
+// syncIntentTx is SyncIntent inside an existing transaction
+func (s *PaymentService) syncIntentTx(ctx context.Context, tx *gorm.DB, intent *payment.Intent) (*models.Payment, error) {
+	return s.applyPaymentStateTx(ctx, tx, intent.ID, intentPaymentState(intent), rawJSON(intent.Raw))
+}
+
+// markFailed records a declined payment attempt together with the decline reason
+func (s *PaymentService) markFailed(ctx context.Context, transactionID, reason string) (*models.Payment, error) {
+	response, err := json.Marshal(map[string]string{"id": transactionID, "error": reason})
+	if err != nil {
+		return nil, err
+	}
+	return s.applyPaymentState(ctx, transactionID, models.PaymentStateFailed, string(response))
+}
+
+// applyPaymentState moves a payment to a new state and updates its order
+func (s *PaymentService) applyPaymentState(ctx context.Context, transactionID string, state models.PaymentState, providerResponse string) (*models.Payment, error) {
+	var record *models.Payment
+
+	ctx, committed := withAfterCommit(ctx)
+	err := s.orderRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		var err error
+		record, err = s.applyPaymentStateTx(ctx, tx, transactionID, state, providerResponse)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	committed.run()
+
+	return record, nil
+}
+
+// applyPaymentStateTx moves a payment to a new state inside an existing
+// transaction and derives the order's payment status and lifecycle from it:
+// a paid or authorized pending order starts processing, and an order whose
+// latest payment is cancelled before capture is cancelled as well. The
+// change reaches the metrics once the transaction commits, see onCommit.
+func (s *PaymentService) applyPaymentStateTx(ctx context.Context, tx *gorm.DB, transactionID string, state models.PaymentState, providerResponse string) (*models.Payment, error) {
+	paymentRepo := s.paymentRepo.WithTx(tx)
+	orderRepo := s.orderRepo.WithTx(tx)
+
+	// Lock the order before the payment, in the order every other path
+	// touching both takes them, so they cannot deadlock
+	unlocked, err := paymentRepo.GetByTransactionID(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+
+	order, err := orderRepo.GetByIDForUpdate(ctx, unlocked.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := paymentRepo.GetByTransactionIDForUpdate(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stale or out-of-order updates must not undo progress, such as a late
+	// payment_failed after the payment succeeded
+	if isPaymentStateRegression(record.Status, state) {
+		return record, nil
+	}
+
+	changed := record.Status != state
+	record.Status = state
+	if providerResponse != "" {
+		record.ProviderResponse = providerResponse
+	}
+	if err := paymentRepo.Update(ctx, record); err != nil {
+		return nil, err
+	}
+	if changed {
+		currency, amount := record.Currency, record.Amount
+		onCommit(ctx, func() {
+			s.metrics.PaymentChanged(string(state))
+			if state == models.PaymentStateCompleted {
+				s.metrics.RevenueReceived(currency, amount)
+			}
+		})
+	}
+
+	// Money arriving after the order was cancelled is not the order's: an
+	// authorization is released and a capture handed to the late payment
+	// hooks, which return it
+	if order.Status == models.OrderStatusCancelled {
+		switch state {
+		case models.PaymentStateAuthorized:
+			s.voidAfterCommit(ctx, record)
+			return record, nil
+		case models.PaymentStateCompleted:
+			if changed {
+				if err := s.orderService.stateMachine.runLatePaymentHooks(ctx, tx, order, record); err != nil {
+					return nil, err
+				}
+			}
+			return record, nil
+		}
+	}
+
+	paymentStatus := order.PaymentStatus
+	switch state {
+	case models.PaymentStateCompleted:
+		paymentStatus = models.PaymentStatusPaid
+	case models.PaymentStateAuthorized:
+		paymentStatus = models.PaymentStatusAuthorized
+	case models.PaymentStateFailed:
+		paymentStatus = models.PaymentStatusFailed
+	case models.PaymentStatePartiallyRefunded, models.PaymentStateRefunded:
+		paymentStatus, err = refundedPaymentStatus(ctx, paymentRepo, order.ID)
+		if err != nil {
+			return nil, err
+		}
+	case models.PaymentStateCancelled:
+		if order.PaymentStatus == models.PaymentStatusAuthorized {
+			paymentStatus = models.PaymentStatusPending
+		}
+	}
+
+	if err := s.orderService.transitionPaymentStatusTx(ctx, tx, order, paymentStatus); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case order.Status == models.OrderStatusPending &&
+		(paymentStatus == models.PaymentStatusPaid || paymentStatus == models.PaymentStatusAuthorized):
+		if _, err := s.orderService.transitionStatusTx(ctx, tx, order.ID, models.OrderStatusProcessing, SystemActor, "Payment received"); err != nil {
+			return nil, err
+		}
+
+	case state == models.PaymentStateCancelled &&
+		s.orderService.stateMachine.CanTransition(order.Status, models.OrderStatusCancelled):
+		latest, err := paymentRepo.GetLatestByOrder(ctx, order.ID)
+		if err != nil {
+			return nil, err
+		}
+		if latest.ID == record.ID {
+			if _, err := s.orderService.transitionStatusTx(ctx, tx, order.ID, models.OrderStatusCancelled, SystemActor, "Payment cancelled"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return record, nil
+}
+
+// voidOpenPayments is a transition hook cancelling the intents a cancelled
+// order could still be paid through, once the cancellation has committed
+func (s *PaymentService) voidOpenPayments(tc *TransitionContext) error {
+	records, err := s.paymentRepo.WithTx(tc.Tx).ListByOrder(tc.Ctx, tc.Order.ID)
+	if err != nil {
+		return err
+	}
+
+	for i := range records {
+		switch {
+		case isTender(&records[i]):
+		case records[i].Status == models.PaymentStatePending,
+			records[i].Status == models.PaymentStateFailed,
+			records[i].Status == models.PaymentStateAuthorized:
+			s.voidAfterCommit(tc.Ctx, &records[i])
+		}
+	}
+	return nil
+}
+
+// voidAfterCommit cancels the intent of a payment once the transaction of
+// ctx has committed, and records the outcome. An intent that cannot be
+// voided is logged; should it be captured after all, the late payment
+// hooks return the money.
+func (s *PaymentService) voidAfterCommit(ctx context.Context, record *models.Payment) {
+	transactionID := record.TransactionID
+	onCommit(ctx, func() {
+		ctx := committedContext(ctx)
+
+		intent, err := s.provider.Void(ctx, transactionID)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to void the payment of a cancelled order", "transaction_id", transactionID, "error", err)
+			return
+		}
+		if _, err := s.SyncIntent(ctx, intent); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to record a voided payment", "transaction_id", transactionID, "error", err)
+		}
+	})
+}
+
+// latestPayment gets the most recent payment of an order
+func (s *PaymentService) latestPayment(ctx context.Context, orderID uuid.UUID) (*models.Payment, error) {
+	if _, err := s.orderRepo.GetByID(ctx, orderID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	record, err := s.paymentRepo.GetLatestByOrder(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	return record, nil
+}
+
+// refundedPaymentStatus is the payment status of an order money has been
+// returned on: refunded once every captured payment is, partially refunded
+// until then
+func refundedPaymentStatus(ctx context.Context, paymentRepo repository.PaymentRepository, orderID uuid.UUID) (models.PaymentStatus, error) {
+	records, err := paymentRepo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return "", err
+	}
+
+	for _, record := range records {
+		if record.Status == models.PaymentStateCompleted || record.Status == models.PaymentStatePartiallyRefunded {
+			return models.PaymentStatusPartiallyRefunded, nil
+		}
+	}
+	return models.PaymentStatusRefunded, nil
+}
+
+// intentPaymentState maps a provider intent status to a payment state
+func intentPaymentState(intent *payment.Intent) models.PaymentState {
+	switch intent.Status {
+	case payment.IntentSucceeded:
+		return models.PaymentStateCompleted
+	case payment.IntentRequiresCapture:
+		return models.PaymentStateAuthorized
+	case payment.IntentCanceled:
+		return models.PaymentStateCancelled
+	case payment.IntentRequiresPaymentMethod:
+		if intent.LastPaymentError != "" {
+			return models.PaymentStateFailed
+		}
+	}
+	return models.PaymentStatePending
+}
+
+// paymentStateRank orders the payment states along the payment lifecycle.
+// Pending and failed share the first rank, since a failed payment can be
+// retried; completed and cancelled both end an attempt.
+var paymentStateRank = map[models.PaymentState]int{
+	models.PaymentStatePending:           0,
+	models.PaymentStateFailed:            0,
+	models.PaymentStateAuthorized:        1,
+	models.PaymentStateCompleted:         2,
+	models.PaymentStateCancelled:         2,
+	models.PaymentStatePartiallyRefunded: 3,
+	models.PaymentStateRefunded:          4,
+}
+
+// isPaymentStateRegression reports whether moving a payment from one state
+// to another goes backwards: to an earlier rank, or sideways between the
+// states ending an attempt
+func isPaymentStateRegression(from, to models.PaymentState) bool {
+	fromRank, toRank := paymentStateRank[from], paymentStateRank[to]
+	return toRank < fromRank || toRank == fromRank && from != to && fromRank > 0
+}
+
+// storedClientSecret is the client secret in the stored provider response
+// of a payment, empty when there is none
+func storedClientSecret(record *models.Payment) string {
+	var intent payment.Intent
+	if err := json.Unmarshal([]byte(record.ProviderResponse), &intent); err != nil {
+		return ""
+	}
+	return intent.ClientSecret
+}
+
+// rawJSON converts a provider response for storage in a jsonb column
+func rawJSON(raw []byte) string {
+	if len(raw) == 0 {
+		return "{}"
+	}
+	return string(raw)
+}
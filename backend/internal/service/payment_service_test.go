@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/payment"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// paymentFixture is a payment service over the fake provider and in-memory
+// repositories, with one pending order to pay
+type paymentFixture struct {
+	service  *PaymentService
+	provider *payment.FakeProvider
+	orders   *memoryOrderRepository
+	payments *memoryPaymentRepository
+	order    *models.Order
+}
+
+func newPaymentFixture(t *testing.T, captureMethod string) *paymentFixture {
+	t.Helper()
+
+	order := &models.Order{
+		BaseModel:     models.BaseModel{ID: uuid.New()},
+		UserID:        uuid.New(),
+		OrderNumber:   "GW-240131-TEST01",
+		Status:        models.OrderStatusPending,
+		PaymentStatus: models.PaymentStatusPending,
+		Total:         42.50,
+	}
+	orders := newMemoryOrderRepository(emptyDB(t), order)
+	payments := &memoryPaymentRepository{}
+	cfg := &config.Config{PaymentCurrency: "USD", PaymentCaptureMethod: captureMethod}
+	provider := payment.NewFakeProvider()
+
+	return &paymentFixture{
+		service:  NewPaymentService(provider, payments, orders, NewOrderService(orders), cfg, nil, slog.New(slog.DiscardHandler)),
+		provider: provider,
+		orders:   orders,
+		payments: payments,
+		order:    order,
+	}
+}
+
+// expect checks the stored states of a payment and of the order after a step
+func (f *paymentFixture) expect(t *testing.T, step string, paymentID uuid.UUID, state models.PaymentState, status models.OrderStatus, paymentStatus models.PaymentStatus) {
+	t.Helper()
+
+	record, err := f.payments.GetByID(context.Background(), paymentID)
+	if err != nil {
+		t.Fatalf("%s: payment %s not stored: %v", step, paymentID, err)
+	}
+	if record.Status != state {
+		t.Errorf("%s: payment is %s, want %s", step, record.Status, state)
+	}
+
+	order := f.orders.orders[f.order.ID]
+	if order.Status != status {
+		t.Errorf("%s: order is %s, want %s", step, order.Status, status)
+	}
+	if order.PaymentStatus != paymentStatus {
+		t.Errorf("%s: order payment status is %s, want %s", step, order.PaymentStatus, paymentStatus)
+	}
+}
+
+func TestPaymentFlowManualCapture(t *testing.T) {
+	ctx := context.Background()
+	f := newPaymentFixture(t, payment.CaptureManual)
+
+	intent, err := f.service.CreatePaymentIntent(ctx, f.order.UserID, f.order.OrderNumber)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if intent.Amount != f.order.Total || intent.Currency != "usd" || intent.ClientSecret == "" {
+		t.Errorf("intent = %+v, want %.2f usd with a client secret", intent, f.order.Total)
+	}
+	f.expect(t, "create", intent.PaymentID, models.PaymentStatePending, models.OrderStatusPending, models.PaymentStatusPending)
+
+	again, err := f.service.CreatePaymentIntent(ctx, f.order.UserID, f.order.OrderNumber)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent again: %v", err)
+	}
+	if again.PaymentID != intent.PaymentID || again.ClientSecret != intent.ClientSecret {
+		t.Errorf("second intent = %+v, want the pending one handed out again", again)
+	}
+
+	if _, err := f.service.ConfirmPayment(ctx, f.order.UserID, f.order.OrderNumber, &ConfirmPaymentRequest{PaymentMethod: payment.FakeMethodSuccess}); err != nil {
+		t.Fatalf("ConfirmPayment: %v", err)
+	}
+	f.expect(t, "confirm", intent.PaymentID, models.PaymentStateAuthorized, models.OrderStatusProcessing, models.PaymentStatusAuthorized)
+
+	if _, err := f.service.CapturePayment(ctx, f.order.ID); err != nil {
+		t.Fatalf("CapturePayment: %v", err)
+	}
+	f.expect(t, "capture", intent.PaymentID, models.PaymentStateCompleted, models.OrderStatusProcessing, models.PaymentStatusPaid)
+
+	if _, err := f.service.VoidPayment(ctx, f.order.ID); !errors.Is(err, ErrPaymentInvalidState) {
+		t.Errorf("VoidPayment after capture = %v, want ErrPaymentInvalidState", err)
+	}
+	f.expect(t, "void after capture", intent.PaymentID, models.PaymentStateCompleted, models.OrderStatusProcessing, models.PaymentStatusPaid)
+}
+
+func TestPaymentFlowManualVoid(t *testing.T) {
+	ctx := context.Background()
+	f := newPaymentFixture(t, payment.CaptureManual)
+
+	intent, err := f.service.CreatePaymentIntent(ctx, f.order.UserID, f.order.OrderNumber)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	f.expect(t, "create", intent.PaymentID, models.PaymentStatePending, models.OrderStatusPending, models.PaymentStatusPending)
+
+	if _, err := f.service.ConfirmPayment(ctx, f.order.UserID, f.order.OrderNumber, &ConfirmPaymentRequest{PaymentMethod: payment.FakeMethodSuccess}); err != nil {
+		t.Fatalf("ConfirmPayment: %v", err)
+	}
+	f.expect(t, "confirm", intent.PaymentID, models.PaymentStateAuthorized, models.OrderStatusProcessing, models.PaymentStatusAuthorized)
+
+	if _, err := f.service.VoidPayment(ctx, f.order.ID); err != nil {
+		t.Fatalf("VoidPayment: %v", err)
+	}
+	f.expect(t, "void", intent.PaymentID, models.PaymentStateCancelled, models.OrderStatusCancelled, models.PaymentStatusPending)
+
+	if _, err := f.service.CapturePayment(ctx, f.order.ID); !errors.Is(err, ErrPaymentInvalidState) {
+		t.Errorf("CapturePayment after void = %v, want ErrPaymentInvalidState", err)
+	}
+}
+
+func TestPaymentFlowAutomaticCapture(t *testing.T) {
+	ctx := context.Background()
+	f := newPaymentFixture(t, payment.CaptureAutomatic)
+
+	intent, err := f.service.CreatePaymentIntent(ctx, f.order.UserID, f.order.OrderNumber)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	f.expect(t, "create", intent.PaymentID, models.PaymentStatePending, models.OrderStatusPending, models.PaymentStatusPending)
+
+	if _, err := f.service.ConfirmPayment(ctx, f.order.UserID, f.order.OrderNumber, &ConfirmPaymentRequest{PaymentMethod: payment.FakeMethodSuccess}); err != nil {
+		t.Fatalf("ConfirmPayment: %v", err)
+	}
+	f.expect(t, "confirm", intent.PaymentID, models.PaymentStateCompleted, models.OrderStatusProcessing, models.PaymentStatusPaid)
+
+	if _, err := f.service.CapturePayment(ctx, f.order.ID); !errors.Is(err, ErrPaymentInvalidState) {
+		t.Errorf("CapturePayment after automatic capture = %v, want ErrPaymentInvalidState", err)
+	}
+}
+
+func TestPaymentFlowDeclineAndRetry(t *testing.T) {
+	ctx := context.Background()
+	f := newPaymentFixture(t, payment.CaptureAutomatic)
+
+	first, err := f.service.CreatePaymentIntent(ctx, f.order.UserID, f.order.OrderNumber)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+
+	_, err = f.service.ConfirmPayment(ctx, f.order.UserID, f.order.OrderNumber, &ConfirmPaymentRequest{PaymentMethod: payment.FakeMethodDeclined})
+	if !errors.Is(err, ErrPaymentDeclined) {
+		t.Fatalf("ConfirmPayment with a declined card = %v, want ErrPaymentDeclined", err)
+	}
+	f.expect(t, "decline", first.PaymentID, models.PaymentStateFailed, models.OrderStatusPending, models.PaymentStatusFailed)
+
+	second, err := f.service.CreatePaymentIntent(ctx, f.order.UserID, f.order.OrderNumber)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent after decline: %v", err)
+	}
+	if second.PaymentID == first.PaymentID {
+		t.Fatalf("retry reused the failed payment %s", first.PaymentID)
+	}
+	f.expect(t, "retry", second.PaymentID, models.PaymentStatePending, models.OrderStatusPending, models.PaymentStatusFailed)
+
+	if _, err := f.service.ConfirmPayment(ctx, f.order.UserID, f.order.OrderNumber, &ConfirmPaymentRequest{PaymentMethod: payment.FakeMethodSuccess}); err != nil {
+		t.Fatalf("ConfirmPayment: %v", err)
+	}
+	f.expect(t, "confirm", second.PaymentID, models.PaymentStateCompleted, models.OrderStatusProcessing, models.PaymentStatusPaid)
+	f.expect(t, "confirm", first.PaymentID, models.PaymentStateFailed, models.OrderStatusProcessing, models.PaymentStatusPaid)
+}
+
+// unvoidableProvider is the fake provider failing to void, as when the
+// provider cannot be reached
+type unvoidableProvider struct {
+	*payment.FakeProvider
+}
+
+func (p unvoidableProvider) Void(ctx context.Context, intentID string) (*payment.Intent, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestPaymentFlowCancelledOrder(t *testing.T) {
+	ctx := context.Background()
+	f := newPaymentFixture(t, payment.CaptureAutomatic)
+	customer := Actor{ID: &f.order.UserID, Role: ActorRoleCustomer}
+
+	intent, err := f.service.CreatePaymentIntent(ctx, f.order.UserID, f.order.OrderNumber)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+
+	if _, err := f.service.orderService.CancelUserOrder(ctx, f.order.UserID, f.order.OrderNumber, customer, "Changed my mind"); err != nil {
+		t.Fatalf("CancelUserOrder: %v", err)
+	}
+	f.expect(t, "cancel", intent.PaymentID, models.PaymentStateCancelled, models.OrderStatusCancelled, models.PaymentStatusPending)
+
+	_, err = f.service.ConfirmPayment(ctx, f.order.UserID, f.order.OrderNumber, &ConfirmPaymentRequest{PaymentMethod: payment.FakeMethodSuccess})
+	if !errors.Is(err, ErrPaymentInvalidState) {
+		t.Errorf("ConfirmPayment after cancel = %v, want ErrPaymentInvalidState", err)
+	}
+	f.expect(t, "confirm after cancel", intent.PaymentID, models.PaymentStateCancelled, models.OrderStatusCancelled, models.PaymentStatusPending)
+
+	if _, err := f.service.CreatePaymentIntent(ctx, f.order.UserID, f.order.OrderNumber); !errors.Is(err, ErrOrderNotPayable) {
+		t.Errorf("CreatePaymentIntent after cancel = %v, want ErrOrderNotPayable", err)
+	}
+}
+
+func TestPaymentFlowAuthorizedOrderCancelled(t *testing.T) {
+	ctx := context.Background()
+	f := newPaymentFixture(t, payment.CaptureManual)
+	admin := Actor{ID: new(uuid.UUID), Role: ActorRoleAdmin}
+
+	intent, err := f.service.CreatePaymentIntent(ctx, f.order.UserID, f.order.OrderNumber)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if _, err := f.service.ConfirmPayment(ctx, f.order.UserID, f.order.OrderNumber, &ConfirmPaymentRequest{PaymentMethod: payment.FakeMethodSuccess}); err != nil {
+		t.Fatalf("ConfirmPayment: %v", err)
+	}
+	f.expect(t, "confirm", intent.PaymentID, models.PaymentStateAuthorized, models.OrderStatusProcessing, models.PaymentStatusAuthorized)
+
+	// Staff cancelling the order release the hold on the customer's card
+	if _, err := f.service.orderService.TransitionStatus(ctx, f.order.ID, models.OrderStatusCancelled, admin, "Out of stock"); err != nil {
+		t.Fatalf("TransitionStatus: %v", err)
+	}
+	f.expect(t, "cancel", intent.PaymentID, models.PaymentStateCancelled, models.OrderStatusCancelled, models.PaymentStatusPending)
+}
+
+func TestPaymentFlowCapturedAfterCancellation(t *testing.T) {
+	ctx := context.Background()
+	f := newPaymentFixture(t, payment.CaptureAutomatic)
+	customer := Actor{ID: &f.order.UserID, Role: ActorRoleCustomer}
+
+	var late []uuid.UUID
+	f.service.orderService.StateMachine().OnLatePayment(func(ctx context.Context, tx *gorm.DB, order *models.Order, record *models.Payment) error {
+		late = append(late, record.ID)
+		return nil
+	})
+
+	intent, err := f.service.CreatePaymentIntent(ctx, f.order.UserID, f.order.OrderNumber)
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+
+	// The intent outlives the cancellation and is paid directly at the
+	// provider, whose webhook arrives afterwards
+	f.service.provider = unvoidableProvider{f.provider}
+	if _, err := f.service.orderService.CancelUserOrder(ctx, f.order.UserID, f.order.OrderNumber, customer, "Changed my mind"); err != nil {
+		t.Fatalf("CancelUserOrder: %v", err)
+	}
+	f.expect(t, "cancel", intent.PaymentID, models.PaymentStatePending, models.OrderStatusCancelled, models.PaymentStatusPending)
+
+	record, err := f.payments.GetByID(ctx, intent.PaymentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paid, err := f.provider.Confirm(ctx, record.TransactionID, payment.FakeMethodSuccess)
+	if err != nil {
+		t.Fatalf("Confirm at the provider: %v", err)
+	}
+	if _, err := f.service.SyncIntent(ctx, paid); err != nil {
+		t.Fatalf("SyncIntent: %v", err)
+	}
+	f.expect(t, "late capture", intent.PaymentID, models.PaymentStateCompleted, models.OrderStatusCancelled, models.PaymentStatusPending)
+
+	if len(late) != 1 || late[0] != intent.PaymentID {
+		t.Errorf("late payment hooks ran for %v, want once for %s", late, intent.PaymentID)
+	}
+
+	// A redelivered webhook does not return the money twice
+	if _, err := f.service.SyncIntent(ctx, paid); err != nil {
+		t.Fatalf("SyncIntent again: %v", err)
+	}
+	if len(late) != 1 {
+		t.Errorf("late payment hooks ran %d times after a redelivery, want once", len(late))
+	}
+}
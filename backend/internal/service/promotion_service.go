@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPromotionNotFound = errors.New("promotion not found")
+	ErrInvalidPromotion  = errors.New("invalid promotion")
+)
+
+type PromotionService struct {
+	promotionRepo repository.PromotionRepository
+}
+
+func NewPromotionService(promotionRepo repository.PromotionRepository) *PromotionService {
+	return &PromotionService{
+		promotionRepo: promotionRepo,
+	}
+}
+
+// PromotionRequest represents creating or replacing a promotion
+type PromotionRequest struct {
+	Name        string      `json:"name" validate:"required,max=255"`
+	Description string      `json:"description" validate:"max=1000"`
+	Type        string      `json:"type" validate:"required,oneof=percentage fixed_amount bundle_price free_shipping"`
+	Value       float64     `json:"value" validate:"min=0"`
+	ProductIDs  []uuid.UUID `json:"product_ids"`
+	CategoryIDs []uuid.UUID `json:"category_ids"`
+	MinSubtotal float64     `json:"min_subtotal" validate:"min=0"`
+	MinQuantity int         `json:"min_quantity" validate:"min=0"`
+	Priority    int         `json:"priority"`
+	Stackable   *bool       `json:"stackable"`
+	StartsAt    *time.Time  `json:"starts_at"`
+	EndsAt      *time.Time  `json:"ends_at"`
+	IsActive    *bool       `json:"is_active"`
+}
+
+// ListPromotions lists all promotions in evaluation order
+func (s *PromotionService) ListPromotions(ctx context.Context) ([]models.Promotion, error) {
+	return s.promotionRepo.List(ctx)
+}
+
+// GetPromotion gets a promotion
+func (s *PromotionService) GetPromotion(ctx context.Context, id uuid.UUID) (*models.Promotion, error) {
+	promotion, err := s.promotionRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromotionNotFound
+		}
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// CreatePromotion creates a promotion
+func (s *PromotionService) CreatePromotion(ctx context.Context, req *PromotionRequest) (*models.Promotion, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	promotion := &models.Promotion{}
+	req.apply(promotion)
+
+	if err := s.promotionRepo.Create(ctx, promotion); err != nil {
+		return nil, err
+	}
+
+	// Create skips false booleans in favour of the column default
+	if !promotion.IsActive || !promotion.Stackable {
+		if err := s.promotionRepo.Update(ctx, promotion); err != nil {
+			return nil, err
+		}
+	}
+	return promotion, nil
+}
+
+// UpdatePromotion replaces a promotion's settings
+func (s *PromotionService) UpdatePromotion(ctx context.Context, id uuid.UUID, req *PromotionRequest) (*models.Promotion, error) {
+	promotion, err := s.GetPromotion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	req.apply(promotion)
+	if err := s.promotionRepo.Update(ctx, promotion); err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// DeletePromotion deletes a promotion
+func (s *PromotionService) DeletePromotion(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetPromotion(ctx, id); err != nil {
+		return err
+	}
+	return s.promotionRepo.Delete(ctx, id)
+}
+
+// activePromotions lists the promotions running now, for pricing a cart
+func (s *PromotionService) activePromotions(ctx context.Context, db *gorm.DB) ([]models.Promotion, error) {
+	return s.promotionRepo.WithTx(db).ListActive(ctx, time.Now())
+}
+
+// validate checks the settings each promotion type depends on
+func (req *PromotionRequest) validate() error {
+	switch models.PromotionType(req.Type) {
+	case models.PromotionTypePercentage:
+		if req.Value <= 0 || req.Value > 100 {
+			return fmt.Errorf("%w: percentage must be between 0 and 100", ErrInvalidPromotion)
+		}
+	case models.PromotionTypeFixedAmount:
+		if req.Value <= 0 {
+			return fmt.Errorf("%w: amount must be greater than 0", ErrInvalidPromotion)
+		}
+	case models.PromotionTypeBundlePrice:
+		if req.Value <= 0 {
+			return fmt.Errorf("%w: bundle price must be greater than 0", ErrInvalidPromotion)
+		}
+		if len(req.ProductIDs) < 2 {
+			return fmt.Errorf("%w: a bundle needs at least 2 products", ErrInvalidPromotion)
+		}
+		if len(req.CategoryIDs) > 0 {
+			return fmt.Errorf("%w: bundles are made of products, not categories", ErrInvalidPromotion)
+		}
+	}
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidPromotion)
+	}
+	return nil
+}
+
+// apply copies the request onto a promotion
+func (req *PromotionRequest) apply(promotion *models.Promotion) {
+	promotion.Name = req.Name
+	promotion.Description = req.Description
+	promotion.Type = models.PromotionType(req.Type)
+	promotion.Value = req.Value
+	promotion.ProductIDs = req.ProductIDs
+	promotion.CategoryIDs = req.CategoryIDs
+	promotion.MinSubtotal = req.MinSubtotal
+	promotion.MinQuantity = req.MinQuantity
+	promotion.Priority = req.Priority
+	promotion.Stackable = req.Stackable == nil || *req.Stackable
+	promotion.StartsAt = req.StartsAt
+	promotion.EndsAt = req.EndsAt
+	promotion.IsActive = req.IsActive == nil || *req.IsActive
+}
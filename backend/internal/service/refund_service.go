@@ -0,0 +1,529 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/payment"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOrderNotRefundable = errors.New("order has no captured payment to refund")
+	ErrInvalidRefund      = errors.New("invalid refund")
+	ErrRefundFailed       = errors.New("refund failed")
+	ErrRefundPending      = errors.New("refund is pending, the payment provider could not be reached")
+)
+
+type RefundService struct {
+	provider       payment.Provider
+	paymentService *PaymentService
+	tenderService  *TenderService
+	orderRepo      repository.OrderRepository
+	paymentRepo    repository.PaymentRepository
+	refundRepo     repository.RefundRepository
+}
+
+func NewRefundService(
+	provider payment.Provider,
+	paymentService *PaymentService,
+	tenderService *TenderService,
+	orderRepo repository.OrderRepository,
+	paymentRepo repository.PaymentRepository,
+	refundRepo repository.RefundRepository,
+) *RefundService {
+	s := &RefundService{
+		provider:       provider,
+		paymentService: paymentService,
+		tenderService:  tenderService,
+		orderRepo:      orderRepo,
+		paymentRepo:    paymentRepo,
+		refundRepo:     refundRepo,
+	}
+
+	// Money captured for a cancelled order goes back in full, whether it was
+	// captured before the cancellation or after it
+	paymentService.orderService.StateMachine().OnTransition(models.OrderStatusProcessing, models.OrderStatusCancelled, s.refundCancelledOrder)
+	paymentService.orderService.StateMachine().OnLatePayment(s.refundLatePayment)
+
+	return s
+}
+
+// RefundItemRequest is the quantity of an order item to refund
+type RefundItemRequest struct {
+	OrderItemID uuid.UUID `json:"order_item_id" validate:"required"`
+	Quantity    int       `json:"quantity" validate:"required,min=1"`
+}
+
+// CreateRefundRequest represents a refund request. With items, the refund
+// covers those quantities; with an amount, exactly that amount is refunded
+// (and overrides the value of any items); with neither, whatever remains of
+// the payment is refunded.
+type CreateRefundRequest struct {
+	Amount  float64             `json:"amount" validate:"omitempty,gt=0"`
+	Items   []RefundItemRequest `json:"items" validate:"omitempty,dive"`
+	Reason  string              `json:"reason" validate:"max=500"`
+	Restock bool                `json:"restock"`
+}
+
+// RefundOrder refunds an order. Money goes back the way it came: payment
+// provider payments first, then gift cards and store credit, so one request
+// may create a refund per payment. Refunds are recorded as pending before
+// anything is returned, so concurrent refunds cannot exceed what was paid,
+// and settled one by one afterwards. Should the provider be unreachable, that
+// refund and the ones after it stay pending, to be retried with RetryRefund.
+func (s *RefundService) RefundOrder(ctx context.Context, orderID uuid.UUID, req *CreateRefundRequest, actor Actor) ([]models.Refund, error) {
+	refunds, records, err := s.reserveRefund(ctx, orderID, req, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range refunds {
+		refund := &refunds[i]
+		record := records[refund.PaymentID]
+
+		if isTender(record) {
+			err = s.refundTender(ctx, refund, record)
+		} else {
+			err = s.refundWithProvider(ctx, refund, record)
+		}
+		if errors.Is(err, ErrRefundPending) {
+			return nil, err
+		}
+		if err != nil {
+			// Later refunds were not attempted and no longer hold their amount
+			for j := i + 1; j < len(refunds); j++ {
+				refunds[j].Status = models.RefundStatusFailed
+				refunds[j].FailureMessage = "Not attempted after an earlier refund failed"
+				if updateErr := s.refundRepo.Update(ctx, &refunds[j]); updateErr != nil {
+					return nil, updateErr
+				}
+			}
+			return nil, err
+		}
+	}
+
+	return refunds, nil
+}
+
+// RetryRefund attempts a pending refund again, after the payment provider
+// could not be reached. The provider sees the same idempotency key as the
+// first time, so a refund that did go through is not made twice.
+func (s *RefundService) RetryRefund(ctx context.Context, refundID uuid.UUID) (*models.Refund, error) {
+	refund, err := s.refundRepo.GetByID(ctx, refundID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRefundNotFound
+		}
+		return nil, err
+	}
+	if refund.Status != models.RefundStatusPending || refund.ProviderRefundID != nil {
+		return nil, fmt.Errorf("%w: refund is %s and not awaiting a retry", ErrInvalidRefund, refund.Status)
+	}
+
+	record, err := s.paymentRepo.GetByID(ctx, refund.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if isTender(record) {
+		err = s.refundTender(ctx, refund, record)
+	} else {
+		err = s.refundWithProvider(ctx, refund, record)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// refundCancelledOrder is a transition hook refunding whatever remains of
+// the payment provider payments of a cancelled order. Gift cards and store
+// credit are released by the tender service.
+func (s *RefundService) refundCancelledOrder(tc *TransitionContext) error {
+	refundRepo := s.refundRepo.WithTx(tc.Tx)
+
+	records, err := s.paymentRepo.WithTx(tc.Tx).ListCapturedByOrderForUpdate(tc.Ctx, tc.Order.ID)
+	if err != nil {
+		return err
+	}
+
+	for i := range records {
+		record := &records[i]
+		if isTender(record) {
+			continue
+		}
+
+		refunded, err := refundRepo.SumByPayment(tc.Ctx, record.ID, models.RefundStatusPending, models.RefundStatusSucceeded)
+		if err != nil {
+			return err
+		}
+		amount := roundMoney(record.Amount - refunded)
+		if amount <= 0 {
+			continue
+		}
+
+		refund := &models.Refund{
+			OrderID:          record.OrderID,
+			PaymentID:        record.ID,
+			Amount:           amount,
+			Currency:         record.Currency,
+			Status:           models.RefundStatusPending,
+			Reason:           "Order cancelled",
+			ActorID:          tc.Actor.ID,
+			ProviderResponse: "{}",
+		}
+		if err := refundRepo.Create(tc.Ctx, refund); err != nil {
+			return err
+		}
+		s.refundAfterCommit(tc.Ctx, refund, record)
+	}
+	return nil
+}
+
+// refundLatePayment is a late payment hook refunding a payment captured after
+// its order was cancelled
+func (s *RefundService) refundLatePayment(ctx context.Context, tx *gorm.DB, order *models.Order, record *models.Payment) error {
+	refund := &models.Refund{
+		OrderID:          order.ID,
+		PaymentID:        record.ID,
+		Amount:           record.Amount,
+		Currency:         record.Currency,
+		Status:           models.RefundStatusPending,
+		Reason:           "Paid after the order was cancelled",
+		ProviderResponse: "{}",
+	}
+	if err := s.refundRepo.WithTx(tx).Create(ctx, refund); err != nil {
+		return err
+	}
+
+	s.refundAfterCommit(ctx, refund, record)
+	return nil
+}
+
+// refundAfterCommit sends a refund recorded as pending to the provider once
+// the transaction of ctx has committed. If the provider fails, the refund
+// stays pending or failed for staff to retry.
+func (s *RefundService) refundAfterCommit(ctx context.Context, refund *models.Refund, record *models.Payment) {
+	paid := *record
+	onCommit(ctx, func() {
+		// The outcome is recorded on the refund itself
+		_ = s.refundWithProvider(committedContext(ctx), refund, &paid)
+	})
+}
+
+// refundWithProvider returns a refund through the payment provider. When
+// the provider cannot be reached the outcome is unknown, so the refund stays
+// pending and keeps holding its amount.
+func (s *RefundService) refundWithProvider(ctx context.Context, refund *models.Refund, record *models.Payment) error {
+	result, err := s.provider.Refund(ctx, &payment.RefundRequest{
+		IntentID:       record.TransactionID,
+		Amount:         payment.ToMinorUnits(refund.Amount, refund.Currency),
+		Reason:         refund.Reason,
+		IdempotencyKey: "refund-" + refund.ID.String(),
+	})
+	if err != nil {
+		if payment.Retryable(err) {
+			return fmt.Errorf("%w: %v", ErrRefundPending, err)
+		}
+		refund.Status = models.RefundStatusFailed
+		refund.FailureMessage = err.Error()
+		if settleErr := s.settle(ctx, refund, record, nil); settleErr != nil {
+			return settleErr
+		}
+		return fmt.Errorf("%w: %v", ErrRefundFailed, err)
+	}
+
+	err = s.settle(ctx, refund, record, func(tx *gorm.DB) error {
+		providerRefundID := result.ID
+		refund.ProviderRefundID = &providerRefundID
+		refund.ProviderResponse = rawJSON(result.Raw)
+		switch result.Status {
+		case "failed", "canceled":
+			refund.Status = models.RefundStatusFailed
+			refund.FailureMessage = "Refund " + result.Status + " by provider"
+		case "succeeded":
+			refund.Status = models.RefundStatusSucceeded
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if refund.Status == models.RefundStatusFailed {
+		return fmt.Errorf("%w: provider reported %s", ErrRefundFailed, result.Status)
+	}
+	return nil
+}
+
+// refundTender returns a refund to the gift card or store credit it was
+// paid with
+func (s *RefundService) refundTender(ctx context.Context, refund *models.Refund, record *models.Payment) error {
+	return s.settle(ctx, refund, record, func(tx *gorm.DB) error {
+		if err := s.tenderService.refundTx(ctx, tx, refund, record); err != nil {
+			return err
+		}
+		refund.Status = models.RefundStatusSucceeded
+		return nil
+	})
+}
+
+// settle applies the outcome of a refund with apply, if any, and records it
+// in one transaction. A refund a concurrent attempt has settled already is
+// left alone and reloaded instead, so a retry racing the first attempt
+// cannot return the money or restock the items twice.
+func (s *RefundService) settle(ctx context.Context, refund *models.Refund, record *models.Payment, apply func(tx *gorm.DB) error) error {
+	ctx, committed := withAfterCommit(ctx)
+	err := s.orderRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		// The order is locked first, as everywhere else
+		if _, err := s.orderRepo.WithTx(tx).GetByIDForUpdate(ctx, refund.OrderID); err != nil {
+			return err
+		}
+		locked, err := s.refundRepo.WithTx(tx).GetByIDForUpdate(ctx, refund.ID)
+		if err != nil {
+			return err
+		}
+		if locked.Status != models.RefundStatusPending || locked.ProviderRefundID != nil {
+			*refund = *locked
+			return nil
+		}
+
+		if apply != nil {
+			if err := apply(tx); err != nil {
+				return err
+			}
+		}
+		return s.settleRefundTx(ctx, tx, refund, record)
+	})
+	if err != nil {
+		return err
+	}
+	committed.run()
+	return nil
+}
+
+// ListRefunds lists the refunds of an order
+func (s *RefundService) ListRefunds(ctx context.Context, orderID uuid.UUID) ([]models.Refund, error) {
+	if _, err := s.orderRepo.GetByID(ctx, orderID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	return s.refundRepo.ListByOrder(ctx, orderID)
+}
+
+// reserveRefund validates the request against what has already been
+// refunded, spreads it over the order's captured payments and records a
+// pending refund for each payment it touches
+func (s *RefundService) reserveRefund(ctx context.Context, orderID uuid.UUID, req *CreateRefundRequest, actor Actor) ([]models.Refund, map[uuid.UUID]*models.Payment, error) {
+	var refunds []models.Refund
+	records := make(map[uuid.UUID]*models.Payment)
+
+	err := s.orderRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		orderRepo := s.orderRepo.WithTx(tx)
+		refundRepo := s.refundRepo.WithTx(tx)
+
+		order, err := orderRepo.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+
+		captured, err := s.paymentRepo.WithTx(tx).ListCapturedByOrderForUpdate(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		if len(captured) == 0 {
+			return ErrOrderNotRefundable
+		}
+
+		// Payment provider payments first, then tenders, most recent first
+		var ordered []*models.Payment
+		for i := range captured {
+			if !isTender(&captured[i]) {
+				ordered = append(ordered, &captured[i])
+			}
+		}
+		for i := len(captured) - 1; i >= 0; i-- {
+			if isTender(&captured[i]) {
+				ordered = append(ordered, &captured[i])
+			}
+		}
+
+		available := make(map[uuid.UUID]float64, len(ordered))
+		var remaining float64
+		for _, record := range ordered {
+			alreadyRefunded, err := refundRepo.SumByPayment(ctx, record.ID, models.RefundStatusPending, models.RefundStatusSucceeded)
+			if err != nil {
+				return err
+			}
+			available[record.ID] = roundMoney(record.Amount - alreadyRefunded)
+			remaining += available[record.ID]
+		}
+		remaining = roundMoney(remaining)
+
+		var amount float64
+		var items []models.RefundItem
+		if len(req.Items) > 0 {
+			orderItems, err := orderRepo.GetItems(ctx, order.ID)
+			if err != nil {
+				return err
+			}
+			refunded, err := refundRepo.RefundedQuantities(ctx, order.ID)
+			if err != nil {
+				return err
+			}
+
+			byID := make(map[uuid.UUID]models.OrderItem, len(orderItems))
+			for _, item := range orderItems {
+				byID[item.ID] = item
+			}
+
+			for _, reqItem := range req.Items {
+				item, ok := byID[reqItem.OrderItemID]
+				if !ok {
+					return fmt.Errorf("%w: item %s is not part of the order", ErrInvalidRefund, reqItem.OrderItemID)
+				}
+				if refunded[item.ID]+reqItem.Quantity > item.Quantity {
+					return fmt.Errorf("%w: item %s has only %d refundable", ErrInvalidRefund, item.ID, item.Quantity-refunded[item.ID])
+				}
+
+				refunded[item.ID] += reqItem.Quantity
+				itemAmount := itemChargedAmount(order, item, reqItem.Quantity)
+				items = append(items, models.RefundItem{
+					OrderItemID: item.ID,
+					Quantity:    reqItem.Quantity,
+					Amount:      itemAmount,
+				})
+				amount += itemAmount
+			}
+		}
+
+		switch {
+		case req.Amount > 0:
+			amount = req.Amount
+		case len(req.Items) == 0:
+			amount = remaining
+		}
+		amount = roundMoney(amount)
+
+		if amount <= 0 {
+			return fmt.Errorf("%w: nothing left to refund", ErrInvalidRefund)
+		}
+		if amount > remaining {
+			return fmt.Errorf("%w: amount %.2f exceeds the refundable %.2f", ErrInvalidRefund, amount, remaining)
+		}
+
+		for _, record := range ordered {
+			if amount <= 0 {
+				break
+			}
+			share := math.Min(amount, available[record.ID])
+			if share <= 0 {
+				continue
+			}
+			amount = roundMoney(amount - share)
+
+			refund := models.Refund{
+				OrderID:          order.ID,
+				PaymentID:        record.ID,
+				Amount:           roundMoney(share),
+				Currency:         record.Currency,
+				Status:           models.RefundStatusPending,
+				Reason:           req.Reason,
+				ActorID:          actor.ID,
+				ProviderResponse: "{}",
+			}
+			// The items and their restock belong to the first refund
+			if len(refunds) == 0 {
+				refund.Items = items
+				refund.Restock = req.Restock && len(items) > 0
+			}
+			if err := refundRepo.Create(ctx, &refund); err != nil {
+				return err
+			}
+
+			refunds = append(refunds, refund)
+			records[record.ID] = record
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return refunds, records, nil
+}
+
+// settleRefundTx records the outcome of a refund, restocks refunded items and
+// derives the payment and order payment status from the refunded total
+func (s *RefundService) settleRefundTx(ctx context.Context, tx *gorm.DB, refund *models.Refund, record *models.Payment) error {
+	refundRepo := s.refundRepo.WithTx(tx)
+
+	if err := refundRepo.Update(ctx, refund); err != nil {
+		return err
+	}
+
+	if refund.Status == models.RefundStatusFailed {
+		return nil
+	}
+	if refund.Status == models.RefundStatusSucceeded {
+		if err := s.paymentService.orderService.stateMachine.runRefundHooks(ctx, tx, refund); err != nil {
+			return err
+		}
+	}
+
+	if refund.Restock {
+		productRepo := repository.NewProductRepository(tx)
+		items, err := s.orderRepo.WithTx(tx).GetItems(ctx, refund.OrderID)
+		if err != nil {
+			return err
+		}
+		products := make(map[uuid.UUID]uuid.UUID, len(items))
+		for _, item := range items {
+			products[item.ID] = item.ProductID
+		}
+		for _, item := range refund.Items {
+			if err := productRepo.AdjustStock(ctx, products[item.OrderItemID], item.Quantity); err != nil {
+				return err
+			}
+		}
+	}
+
+	refunded, err := refundRepo.SumByPayment(ctx, record.ID, models.RefundStatusPending, models.RefundStatusSucceeded)
+	if err != nil {
+		return err
+	}
+
+	state := models.PaymentStatePartiallyRefunded
+	if roundMoney(refunded) >= roundMoney(record.Amount) {
+		state = models.PaymentStateRefunded
+	}
+
+	_, err = s.paymentService.applyPaymentStateTx(ctx, tx, record.TransactionID, state, "")
+	return err
+}
+
+// itemChargedAmount is what the customer paid for some units of an item after
+// discounts, including its tax unless prices were tax inclusive
+func itemChargedAmount(order *models.Order, item models.OrderItem, quantity int) float64 {
+	if item.Quantity == 0 {
+		return 0
+	}
+
+	charged := item.Total - item.Discount
+	if !order.TaxInclusive {
+		charged += item.TaxAmount
+	}
+	return roundMoney(charged * float64(quantity) / float64(item.Quantity))
+}
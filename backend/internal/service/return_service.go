@@ -0,0 +1,494 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReturnNotFound      = errors.New("return request not found")
+	ErrOrderNotReturnable  = errors.New("only delivered orders can be returned")
+	ErrReturnWindowExpired = errors.New("return window has expired")
+	ErrInvalidReturnItems  = errors.New("invalid return items")
+	ErrInvalidReturnState  = errors.New("return request is not in a valid state for this operation")
+)
+
+type ReturnService struct {
+	orderService       *OrderService
+	refundService      *RefundService
+	storeCreditService *StoreCreditService
+	orderRepo          repository.OrderRepository
+	returnRepo         repository.ReturnRepository
+	cfg                *config.Config
+}
+
+func NewReturnService(
+	orderService *OrderService,
+	refundService *RefundService,
+	storeCreditService *StoreCreditService,
+	orderRepo repository.OrderRepository,
+	returnRepo repository.ReturnRepository,
+	cfg *config.Config,
+) *ReturnService {
+	return &ReturnService{
+		orderService:       orderService,
+		refundService:      refundService,
+		storeCreditService: storeCreditService,
+		orderRepo:          orderRepo,
+		returnRepo:         returnRepo,
+		cfg:                cfg,
+	}
+}
+
+// ReturnItemRequest is the quantity of an order item to return
+type ReturnItemRequest struct {
+	OrderItemID uuid.UUID `json:"order_item_id" validate:"required"`
+	Quantity    int       `json:"quantity" validate:"required,min=1"`
+	Reason      string    `json:"reason" validate:"max=500"`
+}
+
+// CreateReturnRequest represents a customer's return request
+type CreateReturnRequest struct {
+	Reason     string              `json:"reason" validate:"required,max=1000"`
+	Resolution string              `json:"resolution" validate:"required,oneof=refund store_credit"`
+	Items      []ReturnItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+// ApproveReturnRequest represents an admin approving a return
+type ApproveReturnRequest struct {
+	Note string `json:"note" validate:"max=1000"`
+}
+
+// RejectReturnRequest represents an admin rejecting a return
+type RejectReturnRequest struct {
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
+
+// ReceiveReturnItemRequest grades a returned item on receipt
+type ReceiveReturnItemRequest struct {
+	ReturnItemID uuid.UUID `json:"return_item_id" validate:"required"`
+	Quantity     int       `json:"quantity" validate:"min=0"`
+	Condition    string    `json:"condition" validate:"required,oneof=new opened damaged defective"`
+}
+
+// ReceiveReturnRequest represents the warehouse receiving a return
+type ReceiveReturnRequest struct {
+	Items []ReceiveReturnItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+// ListReturnsRequest represents the query of a return listing
+type ListReturnsRequest struct {
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	PageSize int    `query:"page_size" validate:"omitempty,min=1,max=100"`
+	Status   string `query:"status" validate:"omitempty,oneof=requested approved rejected received completed"`
+}
+
+// ReturnListResponse represents a page of return requests
+type ReturnListResponse struct {
+	Returns    []models.ReturnRequest `json:"returns"`
+	Pagination Pagination             `json:"pagination"`
+}
+
+// CreateReturn opens a return request for items of one of the user's
+// delivered orders, within each product's return window
+func (s *ReturnService) CreateReturn(ctx context.Context, userID uuid.UUID, orderNumber string, req *CreateReturnRequest) (*models.ReturnRequest, error) {
+	order, err := s.orderService.getUserOrder(ctx, userID, orderNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.ReturnRequest{
+		OrderID:    order.ID,
+		UserID:     userID,
+		Status:     models.ReturnStatusRequested,
+		Resolution: models.ReturnResolution(req.Resolution),
+		Reason:     req.Reason,
+	}
+
+	err = s.orderRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		orderRepo := s.orderRepo.WithTx(tx)
+		returnRepo := s.returnRepo.WithTx(tx)
+
+		// Lock the order so concurrent requests cannot return the same items twice
+		order, err := orderRepo.GetByIDForUpdate(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		if order.Status != models.OrderStatusDelivered {
+			return ErrOrderNotReturnable
+		}
+
+		deliveredAt, err := orderRepo.GetStatusChangedAt(ctx, order.ID, models.OrderStatusDelivered)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			deliveredAt = &order.UpdatedAt
+		}
+
+		items, err := orderRepo.GetItems(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		requested, err := returnRepo.RequestedQuantities(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		byID := make(map[uuid.UUID]models.OrderItem, len(items))
+		productIDs := make([]uuid.UUID, 0, len(items))
+		for _, item := range items {
+			byID[item.ID] = item
+			productIDs = append(productIDs, item.ProductID)
+		}
+
+		windows, err := repository.NewProductRepository(tx).ReturnWindows(ctx, productIDs)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		for _, reqItem := range req.Items {
+			item, ok := byID[reqItem.OrderItemID]
+			if !ok {
+				return fmt.Errorf("%w: item %s is not part of the order", ErrInvalidReturnItems, reqItem.OrderItemID)
+			}
+
+			days := s.returnWindowDays(windows[item.ProductID])
+			if now.After(deliveredAt.AddDate(0, 0, days)) {
+				return fmt.Errorf("%w: item %s could be returned within %d days of delivery", ErrReturnWindowExpired, item.ID, days)
+			}
+
+			if requested[item.ID]+reqItem.Quantity > item.Quantity {
+				return fmt.Errorf("%w: item %s has only %d returnable", ErrInvalidReturnItems, item.ID, item.Quantity-requested[item.ID])
+			}
+			requested[item.ID] += reqItem.Quantity
+
+			ret.Items = append(ret.Items, models.ReturnItem{
+				OrderItemID: item.ID,
+				Quantity:    reqItem.Quantity,
+				Reason:      reqItem.Reason,
+			})
+		}
+
+		return returnRepo.Create(ctx, ret)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// ListUserReturns lists the user's return requests
+func (s *ReturnService) ListUserReturns(ctx context.Context, userID uuid.UUID, req *ListReturnsRequest) (*ReturnListResponse, error) {
+	filter := req.toFilter()
+	filter.UserID = &userID
+	return s.listReturns(ctx, filter)
+}
+
+// GetUserReturn gets one of the user's return requests
+func (s *ReturnService) GetUserReturn(ctx context.Context, userID, id uuid.UUID) (*models.ReturnRequest, error) {
+	ret, err := s.returnRepo.GetUserReturn(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReturnNotFound
+		}
+		return nil, err
+	}
+	return ret, nil
+}
+
+// ListReturns lists all return requests
+func (s *ReturnService) ListReturns(ctx context.Context, req *ListReturnsRequest) (*ReturnListResponse, error) {
+	return s.listReturns(ctx, req.toFilter())
+}
+
+// GetReturn gets a return request
+func (s *ReturnService) GetReturn(ctx context.Context, id uuid.UUID) (*models.ReturnRequest, error) {
+	ret, err := s.returnRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReturnNotFound
+		}
+		return nil, err
+	}
+	return ret, nil
+}
+
+// ApproveReturn approves a requested return and assigns its return
+// authorization (RMA) number
+func (s *ReturnService) ApproveReturn(ctx context.Context, id uuid.UUID, req *ApproveReturnRequest) (*models.ReturnRequest, error) {
+	rmaNumber, err := generateReference("RMA")
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateReturn(ctx, id, func(ret *models.ReturnRequest) error {
+		if ret.Status != models.ReturnStatusRequested {
+			return ErrInvalidReturnState
+		}
+
+		now := time.Now().UTC()
+		ret.Status = models.ReturnStatusApproved
+		ret.RMANumber = &rmaNumber
+		ret.AdminNote = req.Note
+		ret.ApprovedAt = &now
+		return nil
+	})
+}
+
+// RejectReturn rejects a requested return
+func (s *ReturnService) RejectReturn(ctx context.Context, id uuid.UUID, req *RejectReturnRequest) (*models.ReturnRequest, error) {
+	return s.updateReturn(ctx, id, func(ret *models.ReturnRequest) error {
+		if ret.Status != models.ReturnStatusRequested {
+			return ErrInvalidReturnState
+		}
+
+		ret.Status = models.ReturnStatusRejected
+		ret.RejectionReason = req.Reason
+		return nil
+	})
+}
+
+// ReceiveReturn records the items received for an approved return, restocks
+// those in sellable condition and compensates the customer with a refund or
+// store credit. If compensation fails, the return stays received and calling
+// ReceiveReturn again retries only the compensation.
+func (s *ReturnService) ReceiveReturn(ctx context.Context, id uuid.UUID, req *ReceiveReturnRequest, actor Actor) (*models.ReturnRequest, error) {
+	ret, err := s.receiveItems(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.resolveReturn(ctx, ret, actor)
+}
+
+// receiveItems grades and restocks the received items
+func (s *ReturnService) receiveItems(ctx context.Context, id uuid.UUID, req *ReceiveReturnRequest) (*models.ReturnRequest, error) {
+	var ret *models.ReturnRequest
+
+	err := s.returnRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		returnRepo := s.returnRepo.WithTx(tx)
+
+		var err error
+		ret, err = returnRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrReturnNotFound
+			}
+			return err
+		}
+
+		switch ret.Status {
+		case models.ReturnStatusReceived:
+			// Items were graded before; only the compensation is retried
+			return nil
+		case models.ReturnStatusApproved:
+		default:
+			return ErrInvalidReturnState
+		}
+
+		items, err := s.orderRepo.WithTx(tx).GetItems(ctx, ret.OrderID)
+		if err != nil {
+			return err
+		}
+		products := make(map[uuid.UUID]uuid.UUID, len(items))
+		for _, item := range items {
+			products[item.ID] = item.ProductID
+		}
+
+		byID := make(map[uuid.UUID]*models.ReturnItem, len(ret.Items))
+		for i := range ret.Items {
+			byID[ret.Items[i].ID] = &ret.Items[i]
+		}
+
+		productRepo := repository.NewProductRepository(tx)
+		for _, reqItem := range req.Items {
+			item, ok := byID[reqItem.ReturnItemID]
+			if !ok {
+				return fmt.Errorf("%w: item %s is not part of the return", ErrInvalidReturnItems, reqItem.ReturnItemID)
+			}
+			if reqItem.Quantity > item.Quantity {
+				return fmt.Errorf("%w: only %d of item %s were authorized", ErrInvalidReturnItems, item.Quantity, item.ID)
+			}
+
+			item.ReceivedQuantity = reqItem.Quantity
+			item.Condition = models.ItemCondition(reqItem.Condition)
+			if err := returnRepo.UpdateItem(ctx, item); err != nil {
+				return err
+			}
+
+			if item.Condition.Restockable() && item.ReceivedQuantity > 0 {
+				if err := productRepo.AdjustStock(ctx, products[item.OrderItemID], item.ReceivedQuantity); err != nil {
+					return err
+				}
+			}
+		}
+
+		now := time.Now().UTC()
+		ret.Status = models.ReturnStatusReceived
+		ret.ReceivedAt = &now
+		return returnRepo.Update(ctx, ret)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// resolveReturn refunds or credits the value of the received items and
+// completes the return
+func (s *ReturnService) resolveReturn(ctx context.Context, ret *models.ReturnRequest, actor Actor) (*models.ReturnRequest, error) {
+	order, err := s.orderRepo.GetByID(ctx, ret.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.orderRepo.GetItems(ctx, ret.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.OrderItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	var amount float64
+	var refundItems []RefundItemRequest
+	for _, item := range ret.Items {
+		if item.ReceivedQuantity == 0 {
+			continue
+		}
+		amount += itemChargedAmount(order, byID[item.OrderItemID], item.ReceivedQuantity)
+		refundItems = append(refundItems, RefundItemRequest{
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.ReceivedQuantity,
+		})
+	}
+	amount = roundMoney(amount)
+
+	reference := ret.ID.String()
+	if ret.RMANumber != nil {
+		reference = *ret.RMANumber
+	}
+
+	if amount > 0 && ret.Resolution == models.ReturnResolutionRefund {
+		// Stock was already adjusted according to each item's condition
+		refunds, err := s.refundService.RefundOrder(ctx, ret.OrderID, &CreateRefundRequest{
+			Items:  refundItems,
+			Reason: "Return " + reference,
+		}, actor)
+		if err != nil {
+			return nil, err
+		}
+		// The first refund carries the returned items
+		ret.RefundID = &refunds[0].ID
+	}
+
+	err = s.returnRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		if amount > 0 && ret.Resolution == models.ReturnResolutionStoreCredit {
+			if _, err := s.storeCreditService.issueTx(ctx, tx, ret.UserID, amount, "return", &ret.ID, "Return "+reference); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now().UTC()
+		ret.Status = models.ReturnStatusCompleted
+		ret.CreditAmount = amount
+		ret.CompletedAt = &now
+		return s.returnRepo.WithTx(tx).Update(ctx, ret)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// updateReturn loads and locks a return request, applies fn and saves it
+func (s *ReturnService) updateReturn(ctx context.Context, id uuid.UUID, fn func(ret *models.ReturnRequest) error) (*models.ReturnRequest, error) {
+	var ret *models.ReturnRequest
+
+	err := s.returnRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		returnRepo := s.returnRepo.WithTx(tx)
+
+		var err error
+		ret, err = returnRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrReturnNotFound
+			}
+			return err
+		}
+
+		if err := fn(ret); err != nil {
+			return err
+		}
+		return returnRepo.Update(ctx, ret)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// listReturns runs a filtered return listing and wraps it with pagination info
+func (s *ReturnService) listReturns(ctx context.Context, filter repository.ReturnFilter) (*ReturnListResponse, error) {
+	returns, total, err := s.returnRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReturnListResponse{
+		Returns: returns,
+		Pagination: Pagination{
+			Page:       filter.Page,
+			PageSize:   filter.PageSize,
+			Total:      total,
+			TotalPages: int((total + int64(filter.PageSize) - 1) / int64(filter.PageSize)),
+		},
+	}, nil
+}
+
+// returnWindowDays picks the most generous category override, falling back
+// to the configured default when the product's categories set none
+func (s *ReturnService) returnWindowDays(overrides []int) int {
+	if len(overrides) == 0 {
+		return s.cfg.ReturnWindowDays
+	}
+
+	days := overrides[0]
+	for _, d := range overrides[1:] {
+		if d > days {
+			days = d
+		}
+	}
+	return days
+}
+
+// toFilter converts the listing query into a repository filter
+func (req *ListReturnsRequest) toFilter() repository.ReturnFilter {
+	filter := repository.ReturnFilter{
+		Status:   models.ReturnStatus(req.Status),
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultPageSize
+	}
+	return filter
+}
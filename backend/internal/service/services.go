@@ -0,0 +1,100 @@
+package service
+
+import (
+	"log/slog"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/email"
+	"github.com/Shihasz/gophiway/internal/metrics"
+	"github.com/Shihasz/gophiway/internal/payment"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/Shihasz/gophiway/internal/shipping"
+	"github.com/Shihasz/gophiway/internal/storage"
+	"github.com/Shihasz/gophiway/internal/tax"
+	"gorm.io/gorm"
+)
+
+// Services holds every service wired to its repositories and
+// infrastructure, shared by the API and the command line tools
+type Services struct {
+	Auth        *AuthService
+	Address     *AddressService
+	Order       *OrderService
+	Fulfillment *FulfillmentService
+	Coupon      *CouponService
+	Promotion   *PromotionService
+	Cart        *CartService
+	Payment     *PaymentService
+	StoreCredit *StoreCreditService
+	GiftCard    *GiftCardService
+	Tender      *TenderService
+	Checkout    *CheckoutService
+	Webhook     *WebhookService
+	Refund      *RefundService
+	Tax         *TaxService
+	Shipping    *ShippingService
+	Document    *DocumentService
+	Return      *ReturnService
+}
+
+// NewServices builds the services on top of the database and the
+// infrastructure selected by the configuration
+func NewServices(db *gorm.DB, cfg *config.Config, logger *slog.Logger, metrics *metrics.Metrics) (*Services, error) {
+	// Initialize repositories
+	userRepo := repository.NewUserRepository(db)
+	orderRepo := repository.NewOrderRepository(db)
+	fulfillmentRepo := repository.NewFulfillmentRepository(db)
+	cartRepo := repository.NewCartRepository(db)
+	addressRepo := repository.NewAddressRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	webhookEventRepo := repository.NewWebhookEventRepository(db)
+	refundRepo := repository.NewRefundRepository(db)
+	returnRepo := repository.NewReturnRepository(db)
+	storeCreditRepo := repository.NewStoreCreditRepository(db)
+	taxRateRepo := repository.NewTaxRateRepository(db)
+	shippingRepo := repository.NewShippingRepository(db)
+	couponRepo := repository.NewCouponRepository(db)
+	promotionRepo := repository.NewPromotionRepository(db)
+	giftCardRepo := repository.NewGiftCardRepository(db)
+	documentRepo := repository.NewDocumentRepository(db)
+
+	// Initialize infrastructure
+	mailer := email.NewMailer(cfg, logger)
+	paymentProvider, err := payment.NewProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	taxCalculator := tax.NewCalculator(cfg, taxRateRepo)
+	rateProviders, err := shipping.NewRateProviders(cfg)
+	if err != nil {
+		return nil, err
+	}
+	shippingCalculator := shipping.NewCalculator(shippingRepo, logger, rateProviders...)
+	documentStore, err := storage.NewDocumentStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize services
+	s := &Services{}
+	s.Auth = NewAuthService(userRepo, cfg, metrics)
+	s.Address = NewAddressService(addressRepo)
+	s.Order = NewOrderService(orderRepo)
+	s.Fulfillment = NewFulfillmentService(s.Order, orderRepo, fulfillmentRepo, refundRepo, userRepo, mailer, cfg, logger)
+	s.Coupon = NewCouponService(couponRepo, orderRepo, s.Order)
+	s.Promotion = NewPromotionService(promotionRepo)
+	s.Cart = NewCartService(db, cartRepo, s.Coupon, s.Promotion)
+	s.Payment = NewPaymentService(paymentProvider, paymentRepo, orderRepo, s.Order, cfg, metrics, logger)
+	s.StoreCredit = NewStoreCreditService(storeCreditRepo)
+	s.GiftCard = NewGiftCardService(giftCardRepo, orderRepo, userRepo, s.StoreCredit, s.Order, mailer, cfg, logger)
+	s.Tender = NewTenderService(s.GiftCard, s.StoreCredit, s.Payment, s.Order, orderRepo, paymentRepo, refundRepo, cfg)
+	s.Checkout = NewCheckoutService(orderRepo, cartRepo, addressRepo, s.Cart, s.Tender, taxCalculator, shippingCalculator, metrics)
+	s.Webhook = NewWebhookService(s.Payment, webhookEventRepo, cfg, logger)
+	s.Refund = NewRefundService(paymentProvider, s.Payment, s.Tender, orderRepo, paymentRepo, refundRepo)
+	s.Tax = NewTaxService(taxRateRepo)
+	s.Shipping = NewShippingService(shippingRepo)
+	s.Document = NewDocumentService(s.Order, documentRepo, orderRepo, refundRepo, fulfillmentRepo, userRepo, documentStore, cfg, logger)
+	s.Return = NewReturnService(s.Order, s.Refund, s.StoreCredit, orderRepo, returnRepo, cfg)
+
+	return s, nil
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrShippingZoneNotFound   = errors.New("shipping zone not found")
+	ErrShippingMethodNotFound = errors.New("shipping method not found")
+	ErrInvalidShippingMethod  = errors.New("invalid shipping method")
+)
+
+type ShippingService struct {
+	shippingRepo repository.ShippingRepository
+}
+
+func NewShippingService(shippingRepo repository.ShippingRepository) *ShippingService {
+	return &ShippingService{
+		shippingRepo: shippingRepo,
+	}
+}
+
+// ShippingZoneRegionRequest is a destination covered by a zone
+type ShippingZoneRegionRequest struct {
+	Country          string `json:"country" validate:"required,max=2"`
+	State            string `json:"state" validate:"max=100"`
+	PostalCodePrefix string `json:"postal_code_prefix" validate:"max=20"`
+}
+
+// ShippingZoneRequest represents creating or replacing a shipping zone
+type ShippingZoneRequest struct {
+	Name    string                      `json:"name" validate:"required,max=100"`
+	Regions []ShippingZoneRegionRequest `json:"regions" validate:"required,min=1,dive"`
+}
+
+// ShippingRateTierRequest is a tier of a weight or price tiered method
+type ShippingRateTierRequest struct {
+	MinValue float64 `json:"min_value" validate:"min=0"`
+	Rate     float64 `json:"rate" validate:"min=0"`
+}
+
+// ShippingMethodRequest represents creating or replacing a shipping method
+type ShippingMethodRequest struct {
+	Name          string                    `json:"name" validate:"required,max=100"`
+	RateType      string                    `json:"rate_type" validate:"required,oneof=flat weight price_tier free_over carrier"`
+	Rate          float64                   `json:"rate" validate:"min=0"`
+	FreeThreshold float64                   `json:"free_threshold" validate:"min=0"`
+	Carrier       string                    `json:"carrier" validate:"max=50"`
+	ServiceCode   string                    `json:"service_code" validate:"max=50"`
+	MaxWeight     float64                   `json:"max_weight" validate:"min=0"`
+	MinDays       int                       `json:"min_days" validate:"min=0"`
+	MaxDays       int                       `json:"max_days" validate:"min=0"`
+	IsActive      *bool                     `json:"is_active"`
+	Tiers         []ShippingRateTierRequest `json:"tiers" validate:"dive"`
+}
+
+// ListZones lists the shipping zones with their methods
+func (s *ShippingService) ListZones(ctx context.Context) ([]models.ShippingZone, error) {
+	return s.shippingRepo.ListZones(ctx)
+}
+
+// GetZone gets a shipping zone with its methods
+func (s *ShippingService) GetZone(ctx context.Context, id uuid.UUID) (*models.ShippingZone, error) {
+	zone, err := s.shippingRepo.GetZone(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShippingZoneNotFound
+		}
+		return nil, err
+	}
+	return zone, nil
+}
+
+// CreateZone creates a shipping zone
+func (s *ShippingService) CreateZone(ctx context.Context, req *ShippingZoneRequest) (*models.ShippingZone, error) {
+	zone := &models.ShippingZone{}
+	req.apply(zone)
+
+	if err := s.shippingRepo.CreateZone(ctx, zone); err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+// UpdateZone renames a zone and replaces its regions
+func (s *ShippingService) UpdateZone(ctx context.Context, id uuid.UUID, req *ShippingZoneRequest) (*models.ShippingZone, error) {
+	zone, err := s.GetZone(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	req.apply(zone)
+	if err := s.shippingRepo.UpdateZone(ctx, zone); err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+// DeleteZone deletes a zone and its methods
+func (s *ShippingService) DeleteZone(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetZone(ctx, id); err != nil {
+		return err
+	}
+	return s.shippingRepo.DeleteZone(ctx, id)
+}
+
+// CreateMethod adds a shipping method to a zone
+func (s *ShippingService) CreateMethod(ctx context.Context, zoneID uuid.UUID, req *ShippingMethodRequest) (*models.ShippingMethod, error) {
+	if _, err := s.GetZone(ctx, zoneID); err != nil {
+		return nil, err
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	method := &models.ShippingMethod{ZoneID: zoneID}
+	req.apply(method)
+
+	if err := s.shippingRepo.CreateMethod(ctx, method); err != nil {
+		return nil, err
+	}
+
+	// Create skips false booleans in favour of the column default
+	if !method.IsActive {
+		if err := s.shippingRepo.UpdateMethod(ctx, method); err != nil {
+			return nil, err
+		}
+	}
+	return method, nil
+}
+
+// UpdateMethod replaces a shipping method and its tiers
+func (s *ShippingService) UpdateMethod(ctx context.Context, id uuid.UUID, req *ShippingMethodRequest) (*models.ShippingMethod, error) {
+	method, err := s.shippingRepo.GetMethod(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShippingMethodNotFound
+		}
+		return nil, err
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	req.apply(method)
+	if err := s.shippingRepo.UpdateMethod(ctx, method); err != nil {
+		return nil, err
+	}
+	return method, nil
+}
+
+// DeleteMethod deletes a shipping method
+func (s *ShippingService) DeleteMethod(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.shippingRepo.GetMethod(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrShippingMethodNotFound
+		}
+		return err
+	}
+	return s.shippingRepo.DeleteMethod(ctx, id)
+}
+
+// apply copies the request onto a zone, normalizing its regions
+func (req *ShippingZoneRequest) apply(zone *models.ShippingZone) {
+	zone.Name = req.Name
+	zone.Regions = make([]models.ShippingZoneRegion, 0, len(req.Regions))
+	for _, region := range req.Regions {
+		zone.Regions = append(zone.Regions, models.ShippingZoneRegion{
+			Country:          strings.ToUpper(strings.TrimSpace(region.Country)),
+			State:            strings.TrimSpace(region.State),
+			PostalCodePrefix: strings.TrimSpace(region.PostalCodePrefix),
+		})
+	}
+}
+
+// validate checks the settings each rate type depends on
+func (req *ShippingMethodRequest) validate() error {
+	switch models.ShippingRateType(req.RateType) {
+	case models.ShippingRateWeight, models.ShippingRatePriceTier:
+		if len(req.Tiers) == 0 {
+			return fmt.Errorf("%w: %s rates need at least one tier", ErrInvalidShippingMethod, req.RateType)
+		}
+	case models.ShippingRateFreeOver:
+		if req.FreeThreshold <= 0 {
+			return fmt.Errorf("%w: free_over rates need a free_threshold", ErrInvalidShippingMethod)
+		}
+	case models.ShippingRateCarrier:
+		if req.Carrier == "" {
+			return fmt.Errorf("%w: carrier rates need a carrier", ErrInvalidShippingMethod)
+		}
+	}
+	if req.MaxDays < req.MinDays {
+		return fmt.Errorf("%w: max_days is less than min_days", ErrInvalidShippingMethod)
+	}
+	return nil
+}
+
+// apply copies the request onto a method
+func (req *ShippingMethodRequest) apply(method *models.ShippingMethod) {
+	method.Name = req.Name
+	method.RateType = models.ShippingRateType(req.RateType)
+	method.Rate = req.Rate
+	method.FreeThreshold = req.FreeThreshold
+	method.Carrier = req.Carrier
+	method.ServiceCode = req.ServiceCode
+	method.MaxWeight = req.MaxWeight
+	method.MinDays = req.MinDays
+	method.MaxDays = req.MaxDays
+	method.IsActive = req.IsActive == nil || *req.IsActive
+
+	method.Tiers = make([]models.ShippingRateTier, 0, len(req.Tiers))
+	for _, tier := range req.Tiers {
+		method.Tiers = append(method.Tiers, models.ShippingRateTier{
+			MinValue: tier.MinValue,
+			Rate:     tier.Rate,
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"math"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type StoreCreditService struct {
+	storeCreditRepo repository.StoreCreditRepository
+}
+
+func NewStoreCreditService(storeCreditRepo repository.StoreCreditRepository) *StoreCreditService {
+	return &StoreCreditService{
+		storeCreditRepo: storeCreditRepo,
+	}
+}
+
+// StoreCreditResponse represents a user's store credit balance and ledger
+type StoreCreditResponse struct {
+	Balance      float64                         `json:"balance"`
+	Transactions []models.StoreCreditTransaction `json:"transactions"`
+}
+
+// GetStoreCredit gets the user's store credit balance and ledger
+func (s *StoreCreditService) GetStoreCredit(ctx context.Context, userID uuid.UUID) (*StoreCreditResponse, error) {
+	balance, err := s.storeCreditRepo.Balance(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.storeCreditRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StoreCreditResponse{
+		Balance:      roundMoney(balance),
+		Transactions: transactions,
+	}, nil
+}
+
+// issueTx credits a user's store credit inside an existing transaction
+func (s *StoreCreditService) issueTx(ctx context.Context, tx *gorm.DB, userID uuid.UUID, amount float64, sourceType string, sourceID *uuid.UUID, note string) (*models.StoreCreditTransaction, error) {
+	return s.addEntryTx(ctx, tx, userID, models.StoreCreditIssue, amount, sourceType, sourceID, note)
+}
+
+// redeemTx debits up to amount from a user's store credit for an order and
+// returns the amount taken. The user's row stays locked until the
+// transaction ends, so concurrent checkouts cannot overspend the balance.
+func (s *StoreCreditService) redeemTx(ctx context.Context, tx *gorm.DB, userID uuid.UUID, amount float64, orderID uuid.UUID, note string) (float64, error) {
+	storeCreditRepo := s.storeCreditRepo.WithTx(tx)
+
+	if err := storeCreditRepo.LockUser(ctx, userID); err != nil {
+		return 0, err
+	}
+	balance, err := storeCreditRepo.Balance(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	taken := roundMoney(math.Min(balance, amount))
+	if taken <= 0 {
+		return 0, nil
+	}
+
+	if _, err := s.addEntryTx(ctx, tx, userID, models.StoreCreditRedeem, -taken, "order", &orderID, note); err != nil {
+		return 0, err
+	}
+	return taken, nil
+}
+
+// addEntryTx adds an entry to a user's store credit ledger inside an
+// existing transaction
+func (s *StoreCreditService) addEntryTx(ctx context.Context, tx *gorm.DB, userID uuid.UUID, entryType models.StoreCreditType, amount float64, sourceType string, sourceID *uuid.UUID, note string) (*models.StoreCreditTransaction, error) {
+	entry := &models.StoreCreditTransaction{
+		UserID:     userID,
+		Type:       entryType,
+		Amount:     roundMoney(amount),
+		SourceType: sourceType,
+		SourceID:   sourceID,
+		Note:       note,
+	}
+
+	if err := s.storeCreditRepo.WithTx(tx).Create(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/Shihasz/gophiway/internal/tax"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrTaxRateNotFound = errors.New("tax rate not found")
+
+type TaxService struct {
+	taxRateRepo repository.TaxRateRepository
+}
+
+func NewTaxService(taxRateRepo repository.TaxRateRepository) *TaxService {
+	return &TaxService{
+		taxRateRepo: taxRateRepo,
+	}
+}
+
+// TaxRateRequest represents creating or replacing a tax rate
+type TaxRateRequest struct {
+	Name             string  `json:"name" validate:"required,max=100"`
+	Country          string  `json:"country" validate:"required,len=2,alpha"`
+	State            string  `json:"state" validate:"max=100"`
+	PostalCodePrefix string  `json:"postal_code_prefix" validate:"max=20"`
+	TaxClass         string  `json:"tax_class" validate:"max=50"`
+	Rate             float64 `json:"rate" validate:"min=0,max=1"`
+}
+
+// ListRates lists the tax rate table
+func (s *TaxService) ListRates(ctx context.Context) ([]models.TaxRate, error) {
+	return s.taxRateRepo.List(ctx)
+}
+
+// CreateRate adds a tax rate
+func (s *TaxService) CreateRate(ctx context.Context, req *TaxRateRequest) (*models.TaxRate, error) {
+	rate := &models.TaxRate{}
+	req.apply(rate)
+
+	if err := s.taxRateRepo.Create(ctx, rate); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+// UpdateRate replaces a tax rate
+func (s *TaxService) UpdateRate(ctx context.Context, id uuid.UUID, req *TaxRateRequest) (*models.TaxRate, error) {
+	rate, err := s.taxRateRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaxRateNotFound
+		}
+		return nil, err
+	}
+
+	req.apply(rate)
+	if err := s.taxRateRepo.Update(ctx, rate); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+// DeleteRate deletes a tax rate
+func (s *TaxService) DeleteRate(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.taxRateRepo.GetByID(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTaxRateNotFound
+		}
+		return err
+	}
+	return s.taxRateRepo.Delete(ctx, id)
+}
+
+// apply copies the request onto a rate, normalizing the jurisdiction
+func (req *TaxRateRequest) apply(rate *models.TaxRate) {
+	rate.Name = req.Name
+	rate.Country = strings.ToUpper(req.Country)
+	rate.State = strings.TrimSpace(req.State)
+	rate.PostalCodePrefix = strings.TrimSpace(req.PostalCodePrefix)
+	rate.TaxClass = strings.ToLower(strings.TrimSpace(req.TaxClass))
+	if rate.TaxClass == "" {
+		rate.TaxClass = tax.ClassStandard
+	}
+	rate.Rate = req.Rate
+}
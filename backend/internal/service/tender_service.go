@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/payment"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TenderService pays orders with gift cards and store credit. Each tender is
+// a payment of its own that settles immediately; the payment provider is
+// charged whatever they leave over.
+type TenderService struct {
+	giftCardService    *GiftCardService
+	storeCreditService *StoreCreditService
+	paymentService     *PaymentService
+	orderRepo          repository.OrderRepository
+	paymentRepo        repository.PaymentRepository
+	refundRepo         repository.RefundRepository
+	cfg                *config.Config
+}
+
+func NewTenderService(
+	giftCardService *GiftCardService,
+	storeCreditService *StoreCreditService,
+	paymentService *PaymentService,
+	orderService *OrderService,
+	orderRepo repository.OrderRepository,
+	paymentRepo repository.PaymentRepository,
+	refundRepo repository.RefundRepository,
+	cfg *config.Config,
+) *TenderService {
+	s := &TenderService{
+		giftCardService:    giftCardService,
+		storeCreditService: storeCreditService,
+		paymentService:     paymentService,
+		orderRepo:          orderRepo,
+		paymentRepo:        paymentRepo,
+		refundRepo:         refundRepo,
+		cfg:                cfg,
+	}
+
+	// Cancelled orders give their gift card and store credit back
+	orderService.StateMachine().OnTransition(models.OrderStatusPending, models.OrderStatusCancelled, s.releaseTenders)
+	orderService.StateMachine().OnTransition(models.OrderStatusProcessing, models.OrderStatusCancelled, s.releaseTenders)
+
+	return s
+}
+
+// applyTx covers as much of a newly placed order as the given gift cards
+// and, if asked, the user's store credit allow, recording one completed
+// payment per tender. An order they cover in full is paid and starts
+// processing straight away.
+func (s *TenderService) applyTx(ctx context.Context, tx *gorm.DB, order *models.Order, giftCardCodes []string, useStoreCredit bool) error {
+	currency := payment.NormalizeCurrency(s.cfg.PaymentCurrency)
+	due := order.Total
+
+	seen := make(map[string]bool, len(giftCardCodes))
+	for _, code := range giftCardCodes {
+		code = normalizeGiftCardCode(code)
+		if seen[code] || due <= 0 {
+			continue
+		}
+		seen[code] = true
+
+		paymentID := uuid.New()
+		card, taken, err := s.giftCardService.redeemTx(ctx, tx, code, due, currency, order.ID, paymentID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.createTenderTx(ctx, tx, order, paymentID, models.PaymentProviderGiftCard, &card.ID, taken, currency); err != nil {
+			return err
+		}
+		due = roundMoney(due - taken)
+	}
+
+	if useStoreCredit && due > 0 {
+		taken, err := s.storeCreditService.redeemTx(ctx, tx, order.UserID, due, order.ID, "Order "+order.OrderNumber)
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			if err := s.createTenderTx(ctx, tx, order, uuid.New(), models.PaymentProviderStoreCredit, nil, taken, currency); err != nil {
+				return err
+			}
+			due = roundMoney(due - taken)
+		}
+	}
+
+	if len(order.Payments) == 0 || due > 0 {
+		return nil
+	}
+
+	if err := s.paymentService.orderService.transitionPaymentStatusTx(ctx, tx, order, models.PaymentStatusPaid); err != nil {
+		return err
+	}
+	paid, err := s.paymentService.orderService.transitionStatusTx(ctx, tx, order.ID, models.OrderStatusProcessing, SystemActor, "Paid with gift card or store credit")
+	if err != nil {
+		return err
+	}
+	order.Status = paid.Status
+	return nil
+}
+
+// refundTx credits a refund of a tender payment back to the balance it was
+// paid from
+func (s *TenderService) refundTx(ctx context.Context, tx *gorm.DB, refund *models.Refund, record *models.Payment) error {
+	order, err := s.orderRepo.WithTx(tx).GetByID(ctx, refund.OrderID)
+	if err != nil {
+		return err
+	}
+
+	switch record.Provider {
+	case models.PaymentProviderGiftCard:
+		return s.giftCardService.refundTx(ctx, tx, record, refund, order.UserID)
+	case models.PaymentProviderStoreCredit:
+		_, err := s.storeCreditService.addEntryTx(ctx, tx, order.UserID, models.StoreCreditRefund, refund.Amount, "refund", &refund.ID,
+			"Refund on order "+order.OrderNumber)
+		return err
+	}
+	return fmt.Errorf("payment %s is not a gift card or store credit tender", record.ID)
+}
+
+// releaseTenders is a transition hook refunding the gift card and store
+// credit payments of a cancelled order in full. Payment provider payments
+// are left to the refund flow.
+func (s *TenderService) releaseTenders(tc *TransitionContext) error {
+	paymentRepo := s.paymentRepo.WithTx(tc.Tx)
+	refundRepo := s.refundRepo.WithTx(tc.Tx)
+
+	records, err := paymentRepo.ListCapturedByOrderForUpdate(tc.Ctx, tc.Order.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if !isTender(&record) {
+			continue
+		}
+
+		refunded, err := refundRepo.SumByPayment(tc.Ctx, record.ID, models.RefundStatusPending, models.RefundStatusSucceeded)
+		if err != nil {
+			return err
+		}
+		amount := roundMoney(record.Amount - refunded)
+		if amount <= 0 {
+			continue
+		}
+
+		refund := &models.Refund{
+			OrderID:          record.OrderID,
+			PaymentID:        record.ID,
+			Amount:           amount,
+			Currency:         record.Currency,
+			Status:           models.RefundStatusSucceeded,
+			Reason:           "Order cancelled",
+			ActorID:          tc.Actor.ID,
+			ProviderResponse: "{}",
+		}
+		if err := refundRepo.Create(tc.Ctx, refund); err != nil {
+			return err
+		}
+		if err := s.refundTx(tc.Ctx, tc.Tx, refund, &record); err != nil {
+			return err
+		}
+		if err := s.paymentService.orderService.stateMachine.runRefundHooks(tc.Ctx, tc.Tx, refund); err != nil {
+			return err
+		}
+		if _, err := s.paymentService.applyPaymentStateTx(tc.Ctx, tc.Tx, record.TransactionID, models.PaymentStateRefunded, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createTenderTx records a settled tender payment on an order
+func (s *TenderService) createTenderTx(ctx context.Context, tx *gorm.DB, order *models.Order, id uuid.UUID, provider string, giftCardID *uuid.UUID, amount float64, currency string) error {
+	record := models.Payment{
+		BaseModel:        models.BaseModel{ID: id},
+		OrderID:          order.ID,
+		Provider:         provider,
+		PaymentMethod:    provider,
+		GiftCardID:       giftCardID,
+		TransactionID:    provider + "_" + id.String(),
+		Amount:           amount,
+		Currency:         currency,
+		Status:           models.PaymentStateCompleted,
+		ProviderResponse: "{}",
+	}
+	if err := s.paymentRepo.WithTx(tx).Create(ctx, &record); err != nil {
+		return err
+	}
+
+	order.Payments = append(order.Payments, record)
+	return nil
+}
+
+// isTender reports whether a payment was made with a gift card or store
+// credit rather than through the payment provider
+func isTender(record *models.Payment) bool {
+	return record.Provider == models.PaymentProviderGiftCard || record.Provider == models.PaymentProviderStoreCredit
+}
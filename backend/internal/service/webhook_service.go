@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/payment"
+	"github.com/Shihasz/gophiway/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidWebhook = errors.New("invalid webhook")
+)
+
+type WebhookService struct {
+	paymentService   *PaymentService
+	webhookEventRepo repository.WebhookEventRepository
+	cfg              *config.Config
+	logger           *slog.Logger
+}
+
+func NewWebhookService(paymentService *PaymentService, webhookEventRepo repository.WebhookEventRepository, cfg *config.Config, logger *slog.Logger) *WebhookService {
+	return &WebhookService{
+		paymentService:   paymentService,
+		webhookEventRepo: webhookEventRepo,
+		cfg:              cfg,
+		logger:           logger,
+	}
+}
+
+// WebhookResult describes what happened to a webhook delivery
+type WebhookResult struct {
+	EventID   string `json:"event_id"`
+	Type      string `json:"type"`
+	Duplicate bool   `json:"duplicate"`
+	Handled   bool   `json:"handled"`
+}
+
+// HandleStripeEvent verifies and processes a Stripe webhook. The event ID is
+// stored in the same transaction as its effects, so a delivery is either
+// fully applied once or not at all, and redeliveries are ignored.
+func (s *WebhookService) HandleStripeEvent(ctx context.Context, payload []byte, signatureHeader string) (*WebhookResult, error) {
+	if s.cfg.StripeWebhookSecret == "" {
+		return nil, fmt.Errorf("%w: webhook secret is not configured", ErrInvalidWebhook)
+	}
+
+	event, err := payment.ConstructStripeEvent(payload, signatureHeader, s.cfg.StripeWebhookSecret, s.cfg.StripeWebhookTolerance)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+	}
+
+	result := &WebhookResult{EventID: event.ID, Type: event.Type}
+
+	ctx, committed := withAfterCommit(ctx)
+	err = s.webhookEventRepo.Transaction(ctx, func(tx *gorm.DB) error {
+		created, err := s.webhookEventRepo.WithTx(tx).CreateIfNotExists(ctx, &models.WebhookEvent{
+			Provider: "stripe",
+			EventID:  event.ID,
+			Type:     event.Type,
+			Payload:  string(payload),
+		})
+		if err != nil {
+			return err
+		}
+		if !created {
+			result.Duplicate = true
+			return nil
+		}
+
+		result.Handled, err = s.applyStripeEvent(ctx, tx, event)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	committed.run()
+
+	return result, nil
+}
+
+// applyStripeEvent maps a Stripe event onto payments and orders. It reports
+// whether the event was relevant to the application.
+func (s *WebhookService) applyStripeEvent(ctx context.Context, tx *gorm.DB, event *payment.StripeEvent) (bool, error) {
+	switch event.Type {
+	case payment.EventPaymentIntentSucceeded,
+		payment.EventPaymentIntentPaymentFailed,
+		payment.EventPaymentIntentCanceled,
+		payment.EventPaymentIntentAmountCapturable:
+		intent, err := event.Intent()
+		if err != nil {
+			return false, err
+		}
+		return s.ignoreUnknownPayment(ctx, event, func() error {
+			_, err := s.paymentService.syncIntentTx(ctx, tx, intent)
+			return err
+		})
+
+	case payment.EventChargeRefunded:
+		charge, err := event.Charge()
+		if err != nil {
+			return false, err
+		}
+		if charge.AmountRefunded == 0 {
+			return false, nil
+		}
+		state := models.PaymentStatePartiallyRefunded
+		if charge.Refunded || charge.AmountRefunded >= charge.Amount {
+			state = models.PaymentStateRefunded
+		}
+		return s.ignoreUnknownPayment(ctx, event, func() error {
+			_, err := s.paymentService.applyPaymentStateTx(ctx, tx, charge.PaymentIntent, state, "")
+			return err
+		})
+	}
+
+	return false, nil
+}
+
+// ignoreUnknownPayment runs fn and treats events about payments this
+// application did not create as irrelevant rather than as failures
+func (s *WebhookService) ignoreUnknownPayment(ctx context.Context, event *payment.StripeEvent, fn func() error) (bool, error) {
+	if err := fn(); err != nil {
+		if errors.Is(err, ErrPaymentNotFound) {
+			s.logger.InfoContext(ctx, "Ignoring Stripe event for an unknown payment", "event_id", event.ID, "event_type", event.Type)
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/Shihasz/gophiway/internal/payment"
+	"github.com/google/uuid"
+)
+
+func TestHandleStripeEventIgnoresReplays(t *testing.T) {
+	ctx := context.Background()
+	f := newPaymentFixture(t, payment.CaptureAutomatic)
+	f.service.cfg.StripeWebhookSecret = "whsec_test"
+	f.service.cfg.StripeWebhookTolerance = payment.DefaultWebhookTolerance
+
+	record := &models.Payment{
+		BaseModel:     models.BaseModel{ID: uuid.New()},
+		OrderID:       f.order.ID,
+		Provider:      "stripe",
+		TransactionID: "pi_test",
+		Amount:        f.order.Total,
+		Currency:      "usd",
+		Status:        models.PaymentStatePending,
+	}
+	if err := f.payments.Create(ctx, record); err != nil {
+		t.Fatal(err)
+	}
+
+	events := &memoryWebhookEventRepository{db: f.orders.db, events: make(map[string]models.WebhookEvent)}
+	webhooks := NewWebhookService(f.service, events, f.service.cfg, slog.New(slog.DiscardHandler))
+
+	payload := []byte(`{"id":"evt_test","type":"payment_intent.succeeded","data":{"object":` +
+		`{"id":"pi_test","status":"succeeded","amount":4250,"amount_received":4250,"currency":"usd"}}}`)
+	deliver := func() *WebhookResult {
+		t.Helper()
+		signature := payment.SignStripePayload(payload, f.service.cfg.StripeWebhookSecret, time.Now())
+		result, err := webhooks.HandleStripeEvent(ctx, payload, signature)
+		if err != nil {
+			t.Fatalf("HandleStripeEvent: %v", err)
+		}
+		return result
+	}
+
+	first := deliver()
+	if first.Duplicate || !first.Handled {
+		t.Errorf("first delivery = %+v, want handled", first)
+	}
+	f.expect(t, "first delivery", record.ID, models.PaymentStateCompleted, models.OrderStatusProcessing, models.PaymentStatusPaid)
+	updates, history := f.payments.updates, len(f.orders.history)
+
+	replay := deliver()
+	if !replay.Duplicate || replay.Handled {
+		t.Errorf("replay = %+v, want a duplicate left unhandled", replay)
+	}
+	if f.payments.updates != updates || len(f.orders.history) != history {
+		t.Errorf("replay changed the payment or order: %d payment updates and %d history entries, want %d and %d",
+			f.payments.updates, len(f.orders.history), updates, history)
+	}
+	if len(events.events) != 1 {
+		t.Errorf("%d events recorded, want 1", len(events.events))
+	}
+}
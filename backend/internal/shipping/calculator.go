@@ -0,0 +1,250 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+)
+
+var ErrDestinationNotServed = errors.New("no shipping method serves this destination")
+
+// ZoneSource supplies the shipping zones with their regions, methods and
+// rate tiers, typically from the database
+type ZoneSource interface {
+	ListZones(ctx context.Context) ([]models.ShippingZone, error)
+}
+
+// Quote is a priced shipping option
+type Quote struct {
+	MethodID uuid.UUID `json:"method_id"`
+	Name     string    `json:"name"`
+	Carrier  string    `json:"carrier,omitempty"`
+	Amount   float64   `json:"amount"`
+	MinDays  int       `json:"min_days"`
+	MaxDays  int       `json:"max_days"`
+}
+
+// Calculator prices shipments from the zone table, asking rate providers
+// for carrier-rated methods
+type Calculator struct {
+	zones     ZoneSource
+	providers map[string]RateProvider
+	logger    *slog.Logger
+}
+
+// NewCalculator creates a calculator using the given carrier providers
+func NewCalculator(zones ZoneSource, logger *slog.Logger, providers ...RateProvider) *Calculator {
+	byName := make(map[string]RateProvider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+	return &Calculator{zones: zones, providers: byName, logger: logger}
+}
+
+// Quote prices every active method of the zone covering the destination,
+// cheapest first, leaving out methods that cannot carry the shipment. With no
+// zones configured at all there is nothing to charge and no quotes are
+// returned; otherwise a destination without options is ErrDestinationNotServed.
+func (c *Calculator) Quote(ctx context.Context, shipment *Shipment) ([]Quote, error) {
+	zones, err := c.zones.ListZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(zones) == 0 {
+		return []Quote{}, nil
+	}
+
+	zone := matchZone(zones, shipment.Destination)
+	if zone == nil {
+		return nil, ErrDestinationNotServed
+	}
+
+	quotes := make([]Quote, 0, len(zone.Methods))
+	for _, method := range zone.Methods {
+		if !method.IsActive {
+			continue
+		}
+
+		quote, ok := c.price(ctx, &method, shipment)
+		if ok {
+			quotes = append(quotes, quote)
+		}
+	}
+
+	if len(quotes) == 0 {
+		return nil, ErrDestinationNotServed
+	}
+
+	sort.SliceStable(quotes, func(i, j int) bool {
+		return quotes[i].Amount < quotes[j].Amount
+	})
+	return quotes, nil
+}
+
+// Recheck reports whether a quote still holds for the shipment without asking
+// any carrier: the method must still be active in the zone covering the
+// destination and able to carry the shipment, and a table-rated method must
+// still cost the quoted amount. Carrier rates are taken as quoted.
+func (c *Calculator) Recheck(ctx context.Context, shipment *Shipment, quote *Quote) (bool, error) {
+	zones, err := c.zones.ListZones(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	zone := matchZone(zones, shipment.Destination)
+	if zone == nil {
+		return false, nil
+	}
+
+	for _, method := range zone.Methods {
+		if method.ID != quote.MethodID || !method.IsActive {
+			continue
+		}
+
+		if method.RateType == models.ShippingRateCarrier {
+			return method.MaxWeight <= 0 || shipment.Weight() <= method.MaxWeight, nil
+		}
+		repriced, ok := c.price(ctx, &method, shipment)
+		return ok && repriced.Amount == quote.Amount, nil
+	}
+	return false, nil
+}
+
+// price quotes a single method, reporting false when it cannot be used
+func (c *Calculator) price(ctx context.Context, method *models.ShippingMethod, shipment *Shipment) (Quote, bool) {
+	quote := Quote{
+		MethodID: method.ID,
+		Name:     method.Name,
+		MinDays:  method.MinDays,
+		MaxDays:  method.MaxDays,
+	}
+
+	weight := shipment.Weight()
+	if method.MaxWeight > 0 && weight > method.MaxWeight {
+		return quote, false
+	}
+
+	switch method.RateType {
+	case models.ShippingRateFlat:
+		quote.Amount = method.Rate
+	case models.ShippingRateFreeOver:
+		quote.Amount = method.Rate
+		if shipment.Subtotal >= method.FreeThreshold {
+			quote.Amount = 0
+		}
+	case models.ShippingRateWeight:
+		rate, ok := tierRate(method.Tiers, weight)
+		if !ok {
+			return quote, false
+		}
+		quote.Amount = rate
+	case models.ShippingRatePriceTier:
+		rate, ok := tierRate(method.Tiers, shipment.Subtotal)
+		if !ok {
+			return quote, false
+		}
+		quote.Amount = rate
+	case models.ShippingRateCarrier:
+		provider, ok := c.providers[method.Carrier]
+		if !ok {
+			c.logger.WarnContext(ctx, "Shipping method uses a carrier that is not enabled", "shipping_method_id", method.ID, "carrier", method.Carrier)
+			return quote, false
+		}
+
+		rate, err := provider.Rate(ctx, method.ServiceCode, shipment)
+		if err != nil {
+			if !errors.Is(err, ErrServiceUnavailable) {
+				c.logger.ErrorContext(ctx, "Failed to get carrier rate", "shipping_method_id", method.ID, "carrier", method.Carrier, "error", err)
+			}
+			return quote, false
+		}
+		quote.Carrier = method.Carrier
+		quote.Amount = rate.Amount
+		if rate.MaxDays > 0 {
+			quote.MinDays, quote.MaxDays = rate.MinDays, rate.MaxDays
+		}
+	default:
+		return quote, false
+	}
+
+	quote.Amount = math.Round(quote.Amount*100) / 100
+	return quote, true
+}
+
+// tierRate picks the tier with the highest minimum not above value
+func tierRate(tiers []models.ShippingRateTier, value float64) (float64, bool) {
+	var best *models.ShippingRateTier
+	for i := range tiers {
+		if tiers[i].MinValue <= value && (best == nil || tiers[i].MinValue > best.MinValue) {
+			best = &tiers[i]
+		}
+	}
+	if best == nil {
+		return 0, false
+	}
+	return best.Rate, true
+}
+
+// matchZone finds the zone with the most specific region covering the
+// destination. A postal code prefix beats a state, which beats a country,
+// which beats the * wildcard.
+func matchZone(zones []models.ShippingZone, dest Destination) *models.ShippingZone {
+	var best *models.ShippingZone
+	bestScore := -1
+
+	for i := range zones {
+		for _, region := range zones[i].Regions {
+			score, ok := regionScore(region, dest)
+			if ok && score > bestScore {
+				best, bestScore = &zones[i], score
+			}
+		}
+	}
+	return best
+}
+
+// regionScore reports whether a region covers the destination and how
+// specifically
+func regionScore(region models.ShippingZoneRegion, dest Destination) (int, bool) {
+	score := 0
+
+	switch {
+	case region.Country == "*":
+	case strings.EqualFold(region.Country, strings.TrimSpace(dest.Country)):
+		score++
+	default:
+		return 0, false
+	}
+
+	if region.State != "" {
+		if !strings.EqualFold(region.State, strings.TrimSpace(dest.State)) {
+			return 0, false
+		}
+		score += 2
+	}
+
+	if region.PostalCodePrefix != "" {
+		if !strings.HasPrefix(normalizePostalCode(dest.PostalCode), normalizePostalCode(region.PostalCodePrefix)) {
+			return 0, false
+		}
+		score += 4 + len(region.PostalCodePrefix)
+	}
+
+	return score, true
+}
+
+// normalizePostalCode uppercases a postal code and drops spaces and dashes
+func normalizePostalCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(code))
+}
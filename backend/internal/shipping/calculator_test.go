@@ -0,0 +1,232 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/Shihasz/gophiway/internal/models"
+	"github.com/google/uuid"
+)
+
+// zoneTable is a fixed set of shipping zones
+type zoneTable []models.ShippingZone
+
+func (t zoneTable) ListZones(ctx context.Context) ([]models.ShippingZone, error) {
+	return t, nil
+}
+
+func method(name string, rateType models.ShippingRateType, rate float64) models.ShippingMethod {
+	return models.ShippingMethod{
+		BaseModel: models.BaseModel{ID: uuid.New()},
+		Name:      name,
+		RateType:  rateType,
+		Rate:      rate,
+		IsActive:  true,
+	}
+}
+
+func testZones() zoneTable {
+	standard := method("Standard", models.ShippingRateFreeOver, 5.99)
+	standard.FreeThreshold = 50
+
+	byWeight := method("By weight", models.ShippingRateWeight, 0)
+	byWeight.MinDays, byWeight.MaxDays = 2, 5
+	byWeight.Tiers = []models.ShippingRateTier{{MinValue: 0, Rate: 4}, {MinValue: 2, Rate: 7.5}, {MinValue: 10, Rate: 15}}
+
+	bulky := method("Bulky", models.ShippingRateFlat, 25)
+	bulky.MaxWeight = 5
+
+	retired := method("Retired", models.ShippingRateFlat, 0.5)
+	retired.IsActive = false
+
+	ground := method("Ground", models.ShippingRateCarrier, 0)
+	ground.Carrier, ground.ServiceCode = "stub", "ground"
+	express := method("Express", models.ShippingRateCarrier, 0)
+	express.Carrier, express.ServiceCode, express.MaxWeight = "stub", "express", 20
+	overnight := method("Overnight", models.ShippingRateCarrier, 0)
+	overnight.Carrier, overnight.ServiceCode = "stub", "overnight"
+	freight := method("Freight", models.ShippingRateCarrier, 0)
+	freight.Carrier = "freightco"
+
+	byPrice := method("By price", models.ShippingRatePriceTier, 0)
+	byPrice.Tiers = []models.ShippingRateTier{{MinValue: 20, Rate: 9.999}, {MinValue: 100, Rate: 3}}
+
+	return zoneTable{
+		{
+			Name:    "Domestic",
+			Regions: []models.ShippingZoneRegion{{Country: "US"}},
+			Methods: []models.ShippingMethod{standard, byWeight, bulky, retired},
+		},
+		{
+			Name:    "Hawaii",
+			Regions: []models.ShippingZoneRegion{{Country: "US", State: "HI"}},
+			Methods: []models.ShippingMethod{ground, express, overnight, freight},
+		},
+		{
+			Name:    "Honolulu",
+			Regions: []models.ShippingZoneRegion{{Country: "US", State: "HI", PostalCodePrefix: "968"}},
+			Methods: []models.ShippingMethod{method("Courier", models.ShippingRateFlat, 12)},
+		},
+		{
+			Name:    "Rest of world",
+			Regions: []models.ShippingZoneRegion{{Country: "*"}},
+			Methods: []models.ShippingMethod{byPrice},
+		},
+	}
+}
+
+// priced is a quote by method name and amount
+type priced struct {
+	Name   string
+	Amount float64
+}
+
+func TestCalculatorQuote(t *testing.T) {
+	calculator := NewCalculator(testZones(), slog.New(slog.DiscardHandler), NewStubProvider())
+
+	tests := []struct {
+		name     string
+		shipment Shipment
+		want     []priced
+		err      error
+	}{
+		{
+			name:     "below the free shipping threshold",
+			shipment: Shipment{Items: []Item{{Quantity: 2, Weight: 0.5}}, Subtotal: 49.99, Destination: Destination{Country: "us"}},
+			want:     []priced{{"By weight", 4}, {"Standard", 5.99}, {"Bulky", 25}},
+		},
+		{
+			name:     "at the free shipping threshold",
+			shipment: Shipment{Items: []Item{{Quantity: 1, Weight: 2}}, Subtotal: 50, Destination: Destination{Country: "US"}},
+			want:     []priced{{"Standard", 0}, {"By weight", 7.5}, {"Bulky", 25}},
+		},
+		{
+			name:     "heavier than a method carries",
+			shipment: Shipment{Items: []Item{{Quantity: 3, Weight: 4}}, Subtotal: 120, Destination: Destination{Country: "US"}},
+			want:     []priced{{"Standard", 0}, {"By weight", 15}},
+		},
+		{
+			name:     "state zone with carrier rates per started kg",
+			shipment: Shipment{Items: []Item{{Quantity: 1, Weight: 2.3}}, Subtotal: 80, Destination: Destination{Country: "US", State: "hi", PostalCode: "96720"}},
+			want:     []priced{{"Ground", 8.74}, {"Express", 22.49}},
+		},
+		{
+			name:     "postal code zone beats the state",
+			shipment: Shipment{Items: []Item{{Quantity: 1, Weight: 1}}, Subtotal: 10, Destination: Destination{Country: "US", State: "HI", PostalCode: "96813"}},
+			want:     []priced{{"Courier", 12}},
+		},
+		{
+			name:     "wildcard zone priced by subtotal",
+			shipment: Shipment{Items: []Item{{Quantity: 1, Weight: 1}}, Subtotal: 99.99, Destination: Destination{Country: "FR"}},
+			want:     []priced{{"By price", 10}},
+		},
+		{
+			name:     "wildcard zone from the highest tier",
+			shipment: Shipment{Items: []Item{{Quantity: 1, Weight: 1}}, Subtotal: 100, Destination: Destination{Country: "FR"}},
+			want:     []priced{{"By price", 3}},
+		},
+		{
+			name:     "below the lowest tier",
+			shipment: Shipment{Items: []Item{{Quantity: 1, Weight: 1}}, Subtotal: 19.99, Destination: Destination{Country: "FR"}},
+			err:      ErrDestinationNotServed,
+		},
+		{
+			name:     "no carrier service carries it",
+			shipment: Shipment{Items: []Item{{Quantity: 1, Weight: 25}}, Subtotal: 10, Destination: Destination{Country: "US", State: "HI"}},
+			want:     []priced{{"Ground", 36.24}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quotes, err := calculator.Quote(context.Background(), &tt.shipment)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Quote error = %v, want %v", err, tt.err)
+			}
+
+			got := []priced{}
+			for _, quote := range quotes {
+				got = append(got, priced{quote.Name, quote.Amount})
+			}
+			if tt.want == nil {
+				tt.want = []priced{}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("quotes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculatorQuoteWithoutZones(t *testing.T) {
+	calculator := NewCalculator(zoneTable{}, slog.New(slog.DiscardHandler))
+
+	quotes, err := calculator.Quote(context.Background(), &Shipment{Destination: Destination{Country: "US"}})
+	if err != nil || len(quotes) != 0 {
+		t.Errorf("Quote = %v, %v, want no quotes and no error", quotes, err)
+	}
+
+	calculator = NewCalculator(testZones()[:1], slog.New(slog.DiscardHandler))
+	if _, err := calculator.Quote(context.Background(), &Shipment{Destination: Destination{Country: "CA"}}); !errors.Is(err, ErrDestinationNotServed) {
+		t.Errorf("Quote outside every zone = %v, want ErrDestinationNotServed", err)
+	}
+}
+
+func TestCalculatorRecheck(t *testing.T) {
+	zones := testZones()
+	calculator := NewCalculator(zones, slog.New(slog.DiscardHandler), NewStubProvider())
+	domestic := Destination{Country: "US"}
+	hawaii := Destination{Country: "US", State: "HI"}
+
+	quote := func(destination Destination, subtotal float64, name string) *Quote {
+		t.Helper()
+		quotes, err := calculator.Quote(context.Background(), &Shipment{Items: []Item{{Quantity: 1, Weight: 3}}, Subtotal: subtotal, Destination: destination})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range quotes {
+			if quotes[i].Name == name {
+				return &quotes[i]
+			}
+		}
+		t.Fatalf("no %s quote", name)
+		return nil
+	}
+
+	tests := []struct {
+		name     string
+		quote    *Quote
+		shipment Shipment
+		want     bool
+	}{
+		{"unchanged", quote(domestic, 40, "Standard"), Shipment{Items: []Item{{Quantity: 1, Weight: 3}}, Subtotal: 40, Destination: domestic}, true},
+		{"now free", quote(domestic, 40, "Standard"), Shipment{Items: []Item{{Quantity: 1, Weight: 3}}, Subtotal: 60, Destination: domestic}, false},
+		{"heavier tier", quote(domestic, 40, "By weight"), Shipment{Items: []Item{{Quantity: 4, Weight: 3}}, Subtotal: 40, Destination: domestic}, false},
+		{"now too heavy", quote(domestic, 40, "Bulky"), Shipment{Items: []Item{{Quantity: 2, Weight: 3}}, Subtotal: 40, Destination: domestic}, false},
+		{"other zone", quote(domestic, 40, "Standard"), Shipment{Items: []Item{{Quantity: 1, Weight: 3}}, Subtotal: 40, Destination: hawaii}, false},
+		{"carrier rate as quoted", quote(hawaii, 40, "Express"), Shipment{Items: []Item{{Quantity: 2, Weight: 3}}, Subtotal: 40, Destination: hawaii}, true},
+		{"carrier over its weight", quote(hawaii, 40, "Express"), Shipment{Items: []Item{{Quantity: 7, Weight: 3}}, Subtotal: 40, Destination: hawaii}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := calculator.Recheck(context.Background(), &tt.shipment, tt.quote)
+			if err != nil {
+				t.Fatalf("Recheck: %v", err)
+			}
+			if ok != tt.want {
+				t.Errorf("Recheck = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+
+	// A method retired since the quote no longer holds
+	standard := quote(domestic, 40, "Standard")
+	zones[0].Methods[0].IsActive = false
+	if ok, err := calculator.Recheck(context.Background(), &Shipment{Items: []Item{{Quantity: 1, Weight: 3}}, Subtotal: 40, Destination: domestic}, standard); err != nil || ok {
+		t.Errorf("Recheck of a retired method = %v, %v, want false", ok, err)
+	}
+}
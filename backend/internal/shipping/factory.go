@@ -0,0 +1,24 @@
+package shipping
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Shihasz/gophiway/internal/config"
+)
+
+// NewRateProviders creates the carrier rate providers enabled in the
+// configuration
+func NewRateProviders(cfg *config.Config) ([]RateProvider, error) {
+	var providers []RateProvider
+	for _, name := range strings.Split(cfg.ShippingCarriers, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "stub":
+			providers = append(providers, NewStubProvider())
+		default:
+			return nil, fmt.Errorf("unknown shipping carrier %q", name)
+		}
+	}
+	return providers, nil
+}
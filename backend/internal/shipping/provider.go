@@ -0,0 +1,84 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+)
+
+var ErrServiceUnavailable = errors.New("carrier service unavailable for this shipment")
+
+// Destination is where a shipment goes
+type Destination struct {
+	Country    string
+	State      string
+	PostalCode string
+}
+
+// Item is a product in a shipment
+type Item struct {
+	Quantity int
+	Weight   float64 // kg per unit
+	Length   float64 // cm
+	Width    float64 // cm
+	Height   float64 // cm
+}
+
+// Shipment is what is being shipped and where
+type Shipment struct {
+	Items       []Item
+	Subtotal    float64
+	Destination Destination
+}
+
+// Weight is the total weight of the shipment in kg
+func (s *Shipment) Weight() float64 {
+	var weight float64
+	for _, item := range s.Items {
+		weight += item.Weight * float64(item.Quantity)
+	}
+	return weight
+}
+
+// CarrierRate is a live rate quoted by a carrier
+type CarrierRate struct {
+	Amount  float64
+	MinDays int
+	MaxDays int
+}
+
+// RateProvider quotes live rates for a carrier's service levels. Carrier
+// integrations implement it; StubProvider stands in for them locally.
+type RateProvider interface {
+	Name() string
+	Rate(ctx context.Context, serviceCode string, shipment *Shipment) (*CarrierRate, error)
+}
+
+// StubProvider is a deterministic RateProvider for development. Rates are a
+// base price per service level plus a charge per started kg.
+type StubProvider struct{}
+
+// NewStubProvider creates a stub carrier
+func NewStubProvider() *StubProvider {
+	return &StubProvider{}
+}
+
+// Name identifies the provider
+func (p *StubProvider) Name() string {
+	return "stub"
+}
+
+// Rate quotes the "ground" and "express" service levels
+func (p *StubProvider) Rate(ctx context.Context, serviceCode string, shipment *Shipment) (*CarrierRate, error) {
+	kg := math.Ceil(shipment.Weight())
+
+	switch strings.ToLower(serviceCode) {
+	case "", "ground":
+		return &CarrierRate{Amount: 4.99 + 1.25*kg, MinDays: 3, MaxDays: 7}, nil
+	case "express":
+		return &CarrierRate{Amount: 14.99 + 2.5*kg, MinDays: 1, MaxDays: 2}, nil
+	default:
+		return nil, ErrServiceUnavailable
+	}
+}
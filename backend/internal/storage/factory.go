@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/Shihasz/gophiway/internal/config"
+)
+
+// NewDocumentStore creates the store for generated documents selected in
+// the configuration
+func NewDocumentStore(cfg *config.Config) (ObjectStore, error) {
+	switch cfg.StorageDriver {
+	case "minio":
+		return NewS3Store(cfg.MinIOEndpoint, cfg.MinIOUseSSL, cfg.MinIORegion, cfg.MinIOAccessKey, cfg.MinIOSecretKey, cfg.DocumentsBucket)
+	case "memory":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+	}
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore keeps objects in memory; they are lost on restart. It is meant
+// for local development without MinIO.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	objects map[string]Object
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{objects: make(map[string]Object)}
+}
+
+// Put stores a copy of data under key
+func (s *MemoryStore) Put(ctx context.Context, key, contentType string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = Object{Data: append([]byte(nil), data...), ContentType: contentType}
+	return nil
+}
+
+// Get fetches an object
+func (s *MemoryStore) Get(ctx context.Context, key string) (*Object, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	object, ok := s.objects[key]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	return &Object{Data: append([]byte(nil), object.Data...), ContentType: object.ContentType}, nil
+}
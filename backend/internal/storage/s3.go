@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/Shihasz/gophiway/internal/tracing"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Store stores objects in an S3-compatible bucket such as MinIO
+type S3Store struct {
+	client *minio.Client
+	region string
+	bucket string
+
+	bucketMu sync.Mutex
+	bucketOK bool
+}
+
+// NewS3Store creates a store for a bucket on an S3-compatible server
+func NewS3Store(endpoint string, useSSL bool, region, accessKey, secretKey, bucket string) (*S3Store, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:     credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure:    useSSL,
+		Region:    region,
+		Transport: tracing.Transport(nil),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage: invalid endpoint %s: %w", endpoint, err)
+	}
+	return &S3Store{client: client, region: region, bucket: bucket}, nil
+}
+
+// Put stores data under key, creating the bucket on first use if needed
+func (s *S3Store) Put(ctx context.Context, key, contentType string, data []byte) error {
+	if err := s.ensureBucket(ctx); err != nil {
+		return err
+	}
+
+	_, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("storage: put %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get fetches an object
+func (s *S3Store) Get(ctx context.Context, key string) (*Object, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s.getError(key, err)
+	}
+	defer object.Close()
+
+	// The request is only sent once the object is read
+	data, err := io.ReadAll(object)
+	if err != nil {
+		return nil, s.getError(key, err)
+	}
+	info, err := object.Stat()
+	if err != nil {
+		return nil, s.getError(key, err)
+	}
+	return &Object{Data: data, ContentType: info.ContentType}, nil
+}
+
+// getError turns a failed read into ErrObjectNotFound when the object or
+// its bucket does not exist
+func (s *S3Store) getError(key string, err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NoSuchBucket":
+		return ErrObjectNotFound
+	}
+	return fmt.Errorf("storage: get %s: %w", key, err)
+}
+
+// ensureBucket creates the bucket unless it exists
+func (s *S3Store) ensureBucket(ctx context.Context) error {
+	s.bucketMu.Lock()
+	defer s.bucketMu.Unlock()
+	if s.bucketOK {
+		return nil
+	}
+
+	exists, err := s.client.BucketExists(ctx, s.bucket)
+	if err != nil {
+		return fmt.Errorf("storage: bucket %s check failed: %w", s.bucket, err)
+	}
+	if !exists {
+		err := s.client.MakeBucket(ctx, s.bucket, minio.MakeBucketOptions{Region: s.region})
+		if err != nil && minio.ToErrorResponse(err).Code != "BucketAlreadyOwnedByYou" {
+			return fmt.Errorf("storage: create bucket %s failed: %w", s.bucket, err)
+		}
+	}
+
+	s.bucketOK = true
+	return nil
+}
+
+// Ping checks that the server is reachable and accepts the credentials. A
+// missing bucket passes, Put creates it on first use.
+func (s *S3Store) Ping(ctx context.Context) error {
+	if _, err := s.client.BucketExists(ctx, s.bucket); err != nil {
+		return fmt.Errorf("storage: bucket %s check failed: %w", s.bucket, err)
+	}
+	return nil
+}
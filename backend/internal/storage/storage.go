@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+// Object is a stored file
+type Object struct {
+	Data        []byte
+	ContentType string
+}
+
+// ObjectStore keeps files by key
+type ObjectStore interface {
+	// Put stores data under key, replacing any existing object
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// Get fetches an object, returning ErrObjectNotFound when missing
+	Get(ctx context.Context, key string) (*Object, error)
+}
@@ -0,0 +1,72 @@
+package tax
+
+import (
+	"context"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"github.com/Shihasz/gophiway/internal/models"
+)
+
+// ClassStandard is the tax class of products and shipping unless configured
+// otherwise
+const ClassStandard = "standard"
+
+// Address is the destination taxes are calculated for
+type Address struct {
+	Country    string
+	State      string
+	PostalCode string
+}
+
+// LineItem is a taxable line of an order
+type LineItem struct {
+	// Reference is echoed back on the matching Line so callers can map
+	// results to their own items
+	Reference string
+	TaxClass  string
+	// Amount is the line total after discounts, including tax when prices
+	// are tax inclusive
+	Amount float64
+}
+
+// Request is everything a calculator needs to tax an order
+type Request struct {
+	Items    []LineItem
+	Shipping float64
+	Address  Address
+}
+
+// Line is the tax on a single line item or on shipping
+type Line struct {
+	Reference  string
+	TaxClass   string
+	Net        float64 // amount excluding tax
+	Tax        float64
+	Rate       float64 // combined rate of all components
+	Components []models.TaxComponent
+}
+
+// Result is the tax on an order
+type Result struct {
+	Lines    []Line
+	Shipping Line
+	// Tax is the total tax including shipping tax
+	Tax float64
+	// Inclusive reports whether the amounts in the request already included
+	// the tax, in which case it must not be added on top
+	Inclusive bool
+}
+
+// Calculator computes the tax owed on an order
+type Calculator interface {
+	Calculate(ctx context.Context, req *Request) (*Result, error)
+}
+
+// NewCalculator creates the tax calculator for the configuration
+func NewCalculator(cfg *config.Config, rates RateSource) Calculator {
+	return NewTableCalculator(rates, TableOptions{
+		PricesIncludeTax: cfg.TaxPricesIncludeTax,
+		ShippingTaxable:  cfg.TaxShippingTaxable,
+		ShippingTaxClass: cfg.TaxShippingClass,
+	})
+}
@@ -0,0 +1,160 @@
+package tax
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/Shihasz/gophiway/internal/models"
+)
+
+// RateSource supplies the rate table, typically the tax_rates table
+type RateSource interface {
+	ListByCountry(ctx context.Context, country string) ([]models.TaxRate, error)
+}
+
+// TableOptions configures a TableCalculator
+type TableOptions struct {
+	PricesIncludeTax bool
+	ShippingTaxable  bool
+	ShippingTaxClass string
+}
+
+// TableCalculator taxes orders from a table of per-region rates. A rate
+// applies when its country matches and its state and postal code prefix are
+// empty or match the destination; all applicable rates for a line's tax
+// class are summed. Classes without rates, such as exempt, are not taxed.
+type TableCalculator struct {
+	rates RateSource
+	opts  TableOptions
+}
+
+// NewTableCalculator creates a table-driven calculator
+func NewTableCalculator(rates RateSource, opts TableOptions) *TableCalculator {
+	if opts.ShippingTaxClass == "" {
+		opts.ShippingTaxClass = ClassStandard
+	}
+	return &TableCalculator{rates: rates, opts: opts}
+}
+
+// Calculate taxes each line and, when taxable, the shipping
+func (c *TableCalculator) Calculate(ctx context.Context, req *Request) (*Result, error) {
+	country := strings.ToUpper(strings.TrimSpace(req.Address.Country))
+
+	all, err := c.rates.ListByCountry(ctx, country)
+	if err != nil {
+		return nil, err
+	}
+
+	var applicable []models.TaxRate
+	for _, rate := range all {
+		if matchesRegion(rate, req.Address) {
+			applicable = append(applicable, rate)
+		}
+	}
+
+	result := &Result{
+		Lines:     make([]Line, 0, len(req.Items)),
+		Inclusive: c.opts.PricesIncludeTax,
+	}
+
+	for _, item := range req.Items {
+		class := item.TaxClass
+		if class == "" {
+			class = ClassStandard
+		}
+
+		line := c.taxLine(item.Amount, class, applicable)
+		line.Reference = item.Reference
+		result.Lines = append(result.Lines, line)
+		result.Tax += line.Tax
+	}
+
+	if c.opts.ShippingTaxable && req.Shipping > 0 {
+		result.Shipping = c.taxLine(req.Shipping, c.opts.ShippingTaxClass, applicable)
+	} else {
+		result.Shipping = Line{Net: req.Shipping}
+	}
+	result.Tax = round(result.Tax + result.Shipping.Tax)
+
+	return result, nil
+}
+
+// taxLine applies the rates of a class to an amount. Tax is rounded per line
+// and per component; with inclusive prices it is extracted from the amount.
+func (c *TableCalculator) taxLine(amount float64, class string, rates []models.TaxRate) Line {
+	line := Line{TaxClass: class}
+
+	var combined float64
+	var matched []models.TaxRate
+	for _, rate := range rates {
+		if strings.EqualFold(rate.TaxClass, class) {
+			matched = append(matched, rate)
+			combined += rate.Rate
+		}
+	}
+	// Sums of fractional rates pick up float noise (0.05+0.07)
+	line.Rate = math.Round(combined*1e6) / 1e6
+
+	if combined == 0 {
+		line.Net = amount
+		return line
+	}
+
+	if c.opts.PricesIncludeTax {
+		line.Tax = round(amount - amount/(1+combined))
+		line.Net = round(amount - line.Tax)
+
+		// Split the extracted tax by rate, leaving any rounding remainder on
+		// the last component so the parts add up to the line tax
+		remaining := line.Tax
+		for i, rate := range matched {
+			part := round(line.Tax * rate.Rate / combined)
+			if i == len(matched)-1 {
+				part = round(remaining)
+			}
+			remaining -= part
+			line.Components = append(line.Components, models.TaxComponent{Name: rate.Name, Rate: rate.Rate, Amount: part})
+		}
+		return line
+	}
+
+	line.Net = amount
+	for _, rate := range matched {
+		part := round(amount * rate.Rate)
+		line.Tax += part
+		line.Components = append(line.Components, models.TaxComponent{Name: rate.Name, Rate: rate.Rate, Amount: part})
+	}
+	line.Tax = round(line.Tax)
+
+	return line
+}
+
+// matchesRegion reports whether a rate covers the destination
+func matchesRegion(rate models.TaxRate, addr Address) bool {
+	if rate.State != "" && !strings.EqualFold(rate.State, strings.TrimSpace(addr.State)) {
+		return false
+	}
+	if rate.PostalCodePrefix != "" {
+		postal := normalizePostalCode(addr.PostalCode)
+		if !strings.HasPrefix(postal, normalizePostalCode(rate.PostalCodePrefix)) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizePostalCode uppercases a postal code and drops spaces and dashes
+func normalizePostalCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(code))
+}
+
+// round rounds an amount to whole cents
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
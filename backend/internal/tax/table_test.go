@@ -0,0 +1,220 @@
+package tax
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Shihasz/gophiway/internal/models"
+)
+
+// rateTable is a fixed rate table
+type rateTable []models.TaxRate
+
+func (t rateTable) ListByCountry(ctx context.Context, country string) ([]models.TaxRate, error) {
+	var rates []models.TaxRate
+	for _, rate := range t {
+		if rate.Country == country {
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+var testRates = rateTable{
+	{Name: "Texas", Country: "US", State: "TX", TaxClass: ClassStandard, Rate: 0.0625},
+	{Name: "Austin", Country: "US", State: "TX", PostalCodePrefix: "787", TaxClass: ClassStandard, Rate: 0.02},
+	{Name: "GST", Country: "CA", TaxClass: ClassStandard, Rate: 0.05},
+	{Name: "PST", Country: "CA", State: "BC", TaxClass: ClassStandard, Rate: 0.07},
+	{Name: "VAT", Country: "GB", TaxClass: ClassStandard, Rate: 0.2},
+	{Name: "VAT", Country: "GB", TaxClass: "reduced", Rate: 0.05},
+	{Name: "London levy", Country: "GB", PostalCodePrefix: "SW1", TaxClass: ClassStandard, Rate: 0.01},
+}
+
+func TestTableCalculatorLines(t *testing.T) {
+	tests := []struct {
+		name       string
+		inclusive  bool
+		address    Address
+		item       LineItem
+		net, tax   float64
+		rate       float64
+		components []models.TaxComponent
+	}{
+		{
+			name:    "exclusive rounds to cents",
+			address: Address{Country: "us", State: "TX", PostalCode: "75001"},
+			item:    LineItem{Amount: 19.99},
+			net:     19.99, tax: 1.25, rate: 0.0625,
+			components: []models.TaxComponent{{Name: "Texas", Rate: 0.0625, Amount: 1.25}},
+		},
+		{
+			name:    "postal code prefix adds a component",
+			address: Address{Country: "US", State: "tx", PostalCode: "78701-1234"},
+			item:    LineItem{Amount: 100},
+			net:     100, tax: 8.25, rate: 0.0825,
+			components: []models.TaxComponent{
+				{Name: "Texas", Rate: 0.0625, Amount: 6.25},
+				{Name: "Austin", Rate: 0.02, Amount: 2},
+			},
+		},
+		{
+			name:    "components round one by one",
+			address: Address{Country: "CA", State: "BC"},
+			item:    LineItem{Amount: 10.05},
+			net:     10.05, tax: 1.2, rate: 0.12,
+			components: []models.TaxComponent{
+				{Name: "GST", Rate: 0.05, Amount: 0.5},
+				{Name: "PST", Rate: 0.07, Amount: 0.7},
+			},
+		},
+		{
+			name:    "state rate left out elsewhere",
+			address: Address{Country: "CA", State: "ON"},
+			item:    LineItem{Amount: 10.05},
+			net:     10.05, tax: 0.5, rate: 0.05,
+			components: []models.TaxComponent{{Name: "GST", Rate: 0.05, Amount: 0.5}},
+		},
+		{
+			name:      "inclusive extracts the tax",
+			inclusive: true,
+			address:   Address{Country: "GB", PostalCode: "M1 1AA"},
+			item:      LineItem{Amount: 120},
+			net:       100, tax: 20, rate: 0.2,
+			components: []models.TaxComponent{{Name: "VAT", Rate: 0.2, Amount: 20}},
+		},
+		{
+			name:      "inclusive remainder goes to the last component",
+			inclusive: true,
+			address:   Address{Country: "CA", State: "BC"},
+			item:      LineItem{Amount: 10},
+			net:       8.93, tax: 1.07, rate: 0.12,
+			components: []models.TaxComponent{
+				{Name: "GST", Rate: 0.05, Amount: 0.45},
+				{Name: "PST", Rate: 0.07, Amount: 0.62},
+			},
+		},
+		{
+			name:      "inclusive postal code prefix ignores spacing",
+			inclusive: true,
+			address:   Address{Country: "GB", PostalCode: "sw1a 1aa"},
+			item:      LineItem{Amount: 24.99},
+			net:       20.65, tax: 4.34, rate: 0.21,
+			components: []models.TaxComponent{
+				{Name: "VAT", Rate: 0.2, Amount: 4.13},
+				{Name: "London levy", Rate: 0.01, Amount: 0.21},
+			},
+		},
+		{
+			name:    "class rates only",
+			address: Address{Country: "GB", PostalCode: "SW1A 1AA"},
+			item:    LineItem{Amount: 30, TaxClass: "reduced"},
+			net:     30, tax: 1.5, rate: 0.05,
+			components: []models.TaxComponent{{Name: "VAT", Rate: 0.05, Amount: 1.5}},
+		},
+		{
+			name:    "class without rates is exempt",
+			address: Address{Country: "GB"},
+			item:    LineItem{Amount: 30, TaxClass: "exempt"},
+			net:     30,
+		},
+		{
+			name:      "country without rates is untaxed",
+			inclusive: true,
+			address:   Address{Country: "DE"},
+			item:      LineItem{Amount: 30},
+			net:       30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calculator := NewTableCalculator(testRates, TableOptions{PricesIncludeTax: tt.inclusive})
+			tt.item.Reference = "item-1"
+
+			result, err := calculator.Calculate(context.Background(), &Request{Items: []LineItem{tt.item}, Address: tt.address})
+			if err != nil {
+				t.Fatalf("Calculate: %v", err)
+			}
+			if result.Inclusive != tt.inclusive {
+				t.Errorf("Inclusive = %v, want %v", result.Inclusive, tt.inclusive)
+			}
+
+			line := result.Lines[0]
+			if line.Reference != "item-1" {
+				t.Errorf("Reference = %q, want item-1", line.Reference)
+			}
+			if line.Net != tt.net || line.Tax != tt.tax || line.Rate != tt.rate {
+				t.Errorf("line = net %.2f tax %.2f rate %v, want net %.2f tax %.2f rate %v",
+					line.Net, line.Tax, line.Rate, tt.net, tt.tax, tt.rate)
+			}
+			if !reflect.DeepEqual(line.Components, tt.components) {
+				t.Errorf("components = %+v, want %+v", line.Components, tt.components)
+			}
+			if result.Tax != tt.tax {
+				t.Errorf("result tax = %.2f, want %.2f", result.Tax, tt.tax)
+			}
+		})
+	}
+}
+
+func TestTableCalculatorShipping(t *testing.T) {
+	req := &Request{
+		Items: []LineItem{
+			{Reference: "a", Amount: 10.05},
+			{Reference: "b", Amount: 4.99, TaxClass: "reduced"},
+		},
+		Shipping: 5,
+		Address:  Address{Country: "GB", PostalCode: "M1 1AA"},
+	}
+
+	tests := []struct {
+		name     string
+		opts     TableOptions
+		shipping Line
+		tax      float64
+	}{
+		{
+			name:     "shipping untaxed",
+			opts:     TableOptions{},
+			shipping: Line{Net: 5},
+			tax:      2.26,
+		},
+		{
+			name:     "shipping taxed at the standard rate",
+			opts:     TableOptions{ShippingTaxable: true},
+			shipping: Line{TaxClass: ClassStandard, Net: 5, Tax: 1, Rate: 0.2},
+			tax:      3.26,
+		},
+		{
+			name:     "shipping taxed in its own class",
+			opts:     TableOptions{ShippingTaxable: true, ShippingTaxClass: "reduced"},
+			shipping: Line{TaxClass: "reduced", Net: 5, Tax: 0.25, Rate: 0.05},
+			tax:      2.51,
+		},
+		{
+			name:     "inclusive shipping",
+			opts:     TableOptions{PricesIncludeTax: true, ShippingTaxable: true},
+			shipping: Line{TaxClass: ClassStandard, Net: 4.17, Tax: 0.83, Rate: 0.2},
+			tax:      2.74,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewTableCalculator(testRates, tt.opts).Calculate(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Calculate: %v", err)
+			}
+
+			shipping := result.Shipping
+			shipping.Components = nil
+			if !reflect.DeepEqual(shipping, tt.shipping) {
+				t.Errorf("shipping = %+v, want %+v", shipping, tt.shipping)
+			}
+			if result.Tax != tt.tax {
+				t.Errorf("tax = %.2f, want %.2f", result.Tax, tt.tax)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// GormPlugin wraps every GORM statement in a client span named after its
+// operation and table. The span carries the SQL with its placeholders, not
+// the bound values.
+type GormPlugin struct {
+	dbName string
+}
+
+// NewGormPlugin creates the plugin for the database named dbName
+func NewGormPlugin(dbName string) *GormPlugin {
+	return &GormPlugin{dbName: dbName}
+}
+
+// Name identifies the plugin to GORM
+func (p *GormPlugin) Name() string {
+	return "gophiway:tracing"
+}
+
+// Initialize registers the callbacks that start a span before each kind of
+// statement and end it after
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	name := p.Name()
+
+	// Spans are renamed from the SQL once it is built, the operation given
+	// here only names the ones that fail before that
+	return errors.Join(
+		callbacks.Create().Before("*").Register(name+":before_create", p.start("INSERT")),
+		callbacks.Create().After("*").Register(name+":after_create", p.end),
+		callbacks.Query().Before("*").Register(name+":before_query", p.start("SELECT")),
+		callbacks.Query().After("*").Register(name+":after_query", p.end),
+		callbacks.Update().Before("*").Register(name+":before_update", p.start("UPDATE")),
+		callbacks.Update().After("*").Register(name+":after_update", p.end),
+		callbacks.Delete().Before("*").Register(name+":before_delete", p.start("DELETE")),
+		callbacks.Delete().After("*").Register(name+":after_delete", p.end),
+		callbacks.Row().Before("*").Register(name+":before_row", p.start("db")),
+		callbacks.Row().After("*").Register(name+":after_row", p.end),
+		callbacks.Raw().Before("*").Register(name+":before_raw", p.start("db")),
+		callbacks.Raw().After("*").Register(name+":after_raw", p.end),
+	)
+}
+
+// gormSpanKey stores a statement's span between its callbacks
+const gormSpanKey = "gophiway:tracing:span"
+
+// gormSpan is a statement's span and the context it was started in
+type gormSpan struct {
+	span   trace.Span
+	parent context.Context
+}
+
+// start begins the span of a statement in the statement's context, which
+// the query and everything it preloads then run under
+func (p *GormPlugin) start(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		parent := db.Statement.Context
+		if parent == nil {
+			parent = context.Background()
+		}
+
+		ctx, span := Tracer().Start(parent, operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemNamePostgreSQL,
+				semconv.DBNamespace(p.dbName),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, &gormSpan{span: span, parent: parent})
+	}
+}
+
+// end names the span now that the table and SQL are known, records the
+// outcome and ends it
+func (p *GormPlugin) end(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	s := value.(*gormSpan)
+	db.Statement.Context = s.parent
+	defer s.span.End()
+
+	if !s.span.IsRecording() {
+		return
+	}
+
+	query := db.Statement.SQL.String()
+	operation := ""
+	if fields := strings.Fields(query); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+	if operation != "" {
+		s.span.SetAttributes(semconv.DBOperationName(operation))
+	}
+
+	name := strings.TrimSpace(operation + " " + db.Statement.Table)
+	if db.Statement.Table != "" {
+		s.span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+	}
+	if name != "" {
+		s.span.SetName(name)
+	}
+	s.span.SetAttributes(
+		semconv.DBQueryText(query),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+
+	// Not finding a record is an answer, not a failure
+	if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,79 @@
+// Package tracing sets up OpenTelemetry tracing: the tracer provider and
+// its exporter, W3C trace context propagation, and spans around database
+// queries and outbound HTTP calls. The application holds no Redis client,
+// the readiness check only pings the server, so there is no Redis to trace.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Shihasz/gophiway/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer of the application's own spans
+const instrumentationName = "github.com/Shihasz/gophiway"
+
+// Tracer returns the tracer of the application's spans. It follows the
+// global provider, so it can be taken before Setup runs.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup installs the W3C trace context propagator and, unless
+// TRACING_EXPORTER is none, a tracer provider exporting to stdout or to an
+// OTLP/HTTP collector. The returned function flushes and stops it.
+func Setup(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch strings.ToLower(cfg.TracingExporter) {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New()
+	case "otlp":
+		opts := []otlptracehttp.Option{}
+		if cfg.TracingEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.TracingEndpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.TracingExporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing exporter: %w", err)
+	}
+
+	provider := NewProvider(cfg, exporter)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewProvider creates a tracer provider sampling by TRACING_SAMPLE_RATIO
+// and batching spans to exporter. Tests can pass an in-memory exporter
+// from the sdk's tracetest package.
+func NewProvider(cfg *config.Config, exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TracingSampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName(cfg.AppName),
+			semconv.ServiceVersion(cfg.APIVersion),
+			semconv.DeploymentEnvironmentName(cfg.AppEnv),
+		)),
+	)
+}
@@ -1,6 +1,7 @@
 package validation
 
 import (
+	"github.com/Shihasz/gophiway/internal/middleware"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 )
@@ -49,8 +50,18 @@ func getErrorMessage(e validator.FieldError) string {
 		return "Value is too short (minimum " + e.Param() + " characters)"
 	case "max":
 		return "Value is too long (maximum " + e.Param() + " characters)"
+	case "len":
+		return "Value must be exactly " + e.Param() + " characters"
+	case "alpha":
+		return "Value must contain only letters"
 	case "eqfield":
 		return "Value must match " + e.Param()
+	case "gt":
+		return "Value must be greater than " + e.Param()
+	case "datetime":
+		return "Invalid date format (expected " + e.Param() + ")"
+	case "oneof":
+		return "Value must be one of: " + e.Param()
 	default:
 		return "Invalid value"
 	}
@@ -59,12 +70,9 @@ func getErrorMessage(e validator.FieldError) string {
 // SendValidationError sends a validation error response
 func SendValidationError(c *fiber.Ctx, err error) error {
 	errors := FormatValidationErrors(err)
-	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-		"success": false,
-		"error": fiber.Map{
-			"code":    "VALIDATION_ERROR",
-			"message": "Validation failed",
-			"details": errors,
-		},
+	return middleware.SendErrorBody(c, fiber.StatusBadRequest, fiber.Map{
+		"code":    "VALIDATION_ERROR",
+		"message": "Validation failed",
+		"details": errors,
 	})
 }
@@ -0,0 +1,2 @@
+-- The columns belong to the baseline schema since, so reverting this
+-- migration keeps them; reverting 0001 drops their tables.
@@ -0,0 +1,50 @@
+-- Columns added to existing tables since the former AutoMigrate boot step
+-- created them. This runs before the baseline, so a database that boot
+-- step created has them before 0001 indexes them and 0002 backfills them.
+-- Fresh databases have none of these tables yet and skip every statement.
+
+ALTER TABLE IF EXISTS categories
+    ADD COLUMN IF NOT EXISTS return_window_days bigint;
+
+ALTER TABLE IF EXISTS products
+    ADD COLUMN IF NOT EXISTS tax_class text NOT NULL DEFAULT 'standard',
+    ADD COLUMN IF NOT EXISTS weight decimal,
+    ADD COLUMN IF NOT EXISTS length decimal,
+    ADD COLUMN IF NOT EXISTS width decimal,
+    ADD COLUMN IF NOT EXISTS height decimal,
+    ADD COLUMN IF NOT EXISTS is_gift_card boolean DEFAULT false;
+
+ALTER TABLE IF EXISTS carts
+    ADD COLUMN IF NOT EXISTS coupon_code text;
+
+ALTER TABLE IF EXISTS orders
+    ADD COLUMN IF NOT EXISTS discount decimal,
+    ADD COLUMN IF NOT EXISTS coupon_code text,
+    ADD COLUMN IF NOT EXISTS discounts jsonb,
+    ADD COLUMN IF NOT EXISTS shipping_tax decimal,
+    ADD COLUMN IF NOT EXISTS shipping_discount decimal,
+    ADD COLUMN IF NOT EXISTS shipping_method_id uuid,
+    ADD COLUMN IF NOT EXISTS shipping_method_name text,
+    ADD COLUMN IF NOT EXISTS tax_inclusive boolean DEFAULT false,
+    ADD COLUMN IF NOT EXISTS shipping_street_address text,
+    ADD COLUMN IF NOT EXISTS shipping_city text,
+    ADD COLUMN IF NOT EXISTS shipping_state text,
+    ADD COLUMN IF NOT EXISTS shipping_postal_code text,
+    ADD COLUMN IF NOT EXISTS shipping_country text,
+    ADD COLUMN IF NOT EXISTS billing_street_address text,
+    ADD COLUMN IF NOT EXISTS billing_city text,
+    ADD COLUMN IF NOT EXISTS billing_state text,
+    ADD COLUMN IF NOT EXISTS billing_postal_code text,
+    ADD COLUMN IF NOT EXISTS billing_country text;
+
+ALTER TABLE IF EXISTS order_items
+    ADD COLUMN IF NOT EXISTS discount decimal,
+    ADD COLUMN IF NOT EXISTS tax_class text,
+    ADD COLUMN IF NOT EXISTS tax_rate decimal,
+    ADD COLUMN IF NOT EXISTS tax_amount decimal,
+    ADD COLUMN IF NOT EXISTS tax_breakdown jsonb;
+
+ALTER TABLE IF EXISTS payments
+    ADD COLUMN IF NOT EXISTS provider text,
+    ADD COLUMN IF NOT EXISTS gift_card_id uuid,
+    ADD COLUMN IF NOT EXISTS currency text;
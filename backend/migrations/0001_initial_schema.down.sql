@@ -0,0 +1,32 @@
+DROP TABLE IF EXISTS document_sequences CASCADE;
+DROP TABLE IF EXISTS documents CASCADE;
+DROP TABLE IF EXISTS webhook_events CASCADE;
+DROP TABLE IF EXISTS gift_card_transactions CASCADE;
+DROP TABLE IF EXISTS gift_cards CASCADE;
+DROP TABLE IF EXISTS store_credit_transactions CASCADE;
+DROP TABLE IF EXISTS return_items CASCADE;
+DROP TABLE IF EXISTS return_requests CASCADE;
+DROP TABLE IF EXISTS refund_items CASCADE;
+DROP TABLE IF EXISTS refunds CASCADE;
+DROP TABLE IF EXISTS payments CASCADE;
+DROP TABLE IF EXISTS fulfillment_items CASCADE;
+DROP TABLE IF EXISTS fulfillments CASCADE;
+DROP TABLE IF EXISTS order_status_histories CASCADE;
+DROP TABLE IF EXISTS promotions CASCADE;
+DROP TABLE IF EXISTS coupon_redemptions CASCADE;
+DROP TABLE IF EXISTS coupons CASCADE;
+DROP TABLE IF EXISTS shipping_rate_tiers CASCADE;
+DROP TABLE IF EXISTS shipping_methods CASCADE;
+DROP TABLE IF EXISTS shipping_zone_regions CASCADE;
+DROP TABLE IF EXISTS shipping_zones CASCADE;
+DROP TABLE IF EXISTS tax_rates CASCADE;
+DROP TABLE IF EXISTS order_items CASCADE;
+DROP TABLE IF EXISTS orders CASCADE;
+DROP TABLE IF EXISTS cart_items CASCADE;
+DROP TABLE IF EXISTS carts CASCADE;
+DROP TABLE IF EXISTS product_images CASCADE;
+DROP TABLE IF EXISTS product_categories CASCADE;
+DROP TABLE IF EXISTS products CASCADE;
+DROP TABLE IF EXISTS categories CASCADE;
+DROP TABLE IF EXISTS addresses CASCADE;
+DROP TABLE IF EXISTS users CASCADE;
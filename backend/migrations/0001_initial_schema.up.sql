@@ -0,0 +1,593 @@
+-- Baseline schema. Every statement is guarded so databases created by the
+-- former AutoMigrate boot step adopt this version without changes.
+
+CREATE TABLE IF NOT EXISTS users (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    email text NOT NULL,
+    password_hash text NOT NULL,
+    first_name text,
+    last_name text,
+    phone text,
+    role text DEFAULT 'customer',
+    email_verified boolean DEFAULT false,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS addresses (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id uuid NOT NULL,
+    "type" text,
+    street_address text,
+    city text,
+    state text,
+    postal_code text,
+    country text,
+    is_default boolean DEFAULT false,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_users_addresses FOREIGN KEY (user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_addresses_deleted_at ON addresses (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_addresses_user_id ON addresses (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_addresses_user_type_default ON addresses (user_id,"type",is_default) WHERE is_default AND deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS categories (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    "name" text NOT NULL,
+    slug text NOT NULL,
+    description text,
+    parent_id uuid,
+    return_window_days bigint,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_categories_parent FOREIGN KEY (parent_id) REFERENCES categories(id)
+);
+CREATE INDEX IF NOT EXISTS idx_categories_deleted_at ON categories (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_slug ON categories (slug);
+
+CREATE TABLE IF NOT EXISTS products (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    "name" text NOT NULL,
+    slug text NOT NULL,
+    description text,
+    price decimal NOT NULL,
+    compare_at_price decimal,
+    cost decimal,
+    sku text,
+    stock_quantity bigint DEFAULT 0,
+    is_active boolean DEFAULT true,
+    tax_class text NOT NULL DEFAULT 'standard',
+    weight decimal,
+    length decimal,
+    width decimal,
+    height decimal,
+    is_gift_card boolean DEFAULT false,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (sku);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_slug ON products (slug);
+
+CREATE TABLE IF NOT EXISTS product_categories (
+    product_id uuid,
+    category_id uuid,
+    PRIMARY KEY (product_id,category_id)
+);
+
+CREATE TABLE IF NOT EXISTS product_images (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    product_id uuid NOT NULL,
+    url text NOT NULL,
+    alt_text text,
+    "position" bigint DEFAULT 0,
+    is_primary boolean DEFAULT false,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_products_images FOREIGN KEY (product_id) REFERENCES products(id)
+);
+CREATE INDEX IF NOT EXISTS idx_product_images_deleted_at ON product_images (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_product_images_product_id ON product_images (product_id);
+
+CREATE TABLE IF NOT EXISTS carts (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id uuid,
+    session_id text,
+    coupon_code text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_carts_deleted_at ON carts (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_carts_session_id ON carts (session_id);
+CREATE INDEX IF NOT EXISTS idx_carts_user_id ON carts (user_id);
+
+CREATE TABLE IF NOT EXISTS cart_items (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    cart_id uuid NOT NULL,
+    product_id uuid NOT NULL,
+    quantity bigint NOT NULL,
+    price_at_add decimal,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_cart_items_product FOREIGN KEY (product_id) REFERENCES products(id),
+    CONSTRAINT fk_carts_items FOREIGN KEY (cart_id) REFERENCES carts(id)
+);
+CREATE INDEX IF NOT EXISTS idx_cart_items_cart_id ON cart_items (cart_id);
+CREATE INDEX IF NOT EXISTS idx_cart_items_deleted_at ON cart_items (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_cart_items_product_id ON cart_items (product_id);
+
+CREATE TABLE IF NOT EXISTS orders (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id uuid NOT NULL,
+    order_number text NOT NULL,
+    status text DEFAULT 'pending',
+    subtotal decimal,
+    discount decimal,
+    coupon_code text,
+    discounts jsonb,
+    tax decimal,
+    shipping decimal,
+    total decimal,
+    shipping_tax decimal,
+    shipping_discount decimal,
+    shipping_method_id uuid,
+    shipping_method_name text,
+    tax_inclusive boolean DEFAULT false,
+    payment_status text DEFAULT 'pending',
+    shipping_address_id uuid,
+    billing_address_id uuid,
+    shipping_street_address text,
+    shipping_city text,
+    shipping_state text,
+    shipping_postal_code text,
+    shipping_country text,
+    billing_street_address text,
+    billing_city text,
+    billing_state text,
+    billing_postal_code text,
+    billing_country text,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_users_orders FOREIGN KEY (user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_order_number ON orders (order_number);
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    order_id uuid NOT NULL,
+    product_id uuid NOT NULL,
+    quantity bigint NOT NULL,
+    price decimal,
+    total decimal,
+    discount decimal,
+    tax_class text,
+    tax_rate decimal,
+    tax_amount decimal,
+    tax_breakdown jsonb,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_order_items_product FOREIGN KEY (product_id) REFERENCES products(id),
+    CONSTRAINT fk_orders_items FOREIGN KEY (order_id) REFERENCES orders(id)
+);
+CREATE INDEX IF NOT EXISTS idx_order_items_deleted_at ON order_items (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items (order_id);
+CREATE INDEX IF NOT EXISTS idx_order_items_product_id ON order_items (product_id);
+
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    "name" text NOT NULL,
+    country varchar(2) NOT NULL,
+    state text,
+    postal_code_prefix text,
+    tax_class text NOT NULL DEFAULT 'standard',
+    rate decimal NOT NULL,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_tax_rates_country ON tax_rates (country);
+CREATE INDEX IF NOT EXISTS idx_tax_rates_deleted_at ON tax_rates (deleted_at);
+
+CREATE TABLE IF NOT EXISTS shipping_zones (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    "name" text NOT NULL,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_shipping_zones_deleted_at ON shipping_zones (deleted_at);
+
+CREATE TABLE IF NOT EXISTS shipping_zone_regions (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    zone_id uuid NOT NULL,
+    country text NOT NULL,
+    state text,
+    postal_code_prefix text,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_shipping_zones_regions FOREIGN KEY (zone_id) REFERENCES shipping_zones(id)
+);
+CREATE INDEX IF NOT EXISTS idx_shipping_zone_regions_country ON shipping_zone_regions (country);
+CREATE INDEX IF NOT EXISTS idx_shipping_zone_regions_deleted_at ON shipping_zone_regions (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_shipping_zone_regions_zone_id ON shipping_zone_regions (zone_id);
+
+CREATE TABLE IF NOT EXISTS shipping_methods (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    zone_id uuid NOT NULL,
+    "name" text NOT NULL,
+    rate_type text NOT NULL,
+    rate decimal,
+    free_threshold decimal,
+    carrier text,
+    service_code text,
+    max_weight decimal,
+    min_days bigint,
+    max_days bigint,
+    is_active boolean DEFAULT true,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_shipping_zones_methods FOREIGN KEY (zone_id) REFERENCES shipping_zones(id)
+);
+CREATE INDEX IF NOT EXISTS idx_shipping_methods_deleted_at ON shipping_methods (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_shipping_methods_zone_id ON shipping_methods (zone_id);
+
+CREATE TABLE IF NOT EXISTS shipping_rate_tiers (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    method_id uuid NOT NULL,
+    min_value decimal,
+    rate decimal,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_shipping_methods_tiers FOREIGN KEY (method_id) REFERENCES shipping_methods(id)
+);
+CREATE INDEX IF NOT EXISTS idx_shipping_rate_tiers_deleted_at ON shipping_rate_tiers (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_shipping_rate_tiers_method_id ON shipping_rate_tiers (method_id);
+
+CREATE TABLE IF NOT EXISTS coupons (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    code text NOT NULL,
+    description text,
+    "type" text NOT NULL,
+    "value" decimal,
+    buy_quantity bigint,
+    get_quantity bigint,
+    min_subtotal decimal,
+    product_ids jsonb,
+    category_ids jsonb,
+    first_order_only boolean DEFAULT false,
+    usage_limit bigint,
+    per_customer_limit bigint,
+    usage_count bigint NOT NULL DEFAULT 0,
+    starts_at timestamptz,
+    ends_at timestamptz,
+    is_active boolean DEFAULT true,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_coupons_deleted_at ON coupons (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_coupons_code ON coupons (code);
+
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    coupon_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    order_id uuid NOT NULL,
+    amount decimal,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_coupon_id ON coupon_redemptions (coupon_id);
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_deleted_at ON coupon_redemptions (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_user_id ON coupon_redemptions (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_coupon_redemptions_coupon_order ON coupon_redemptions (coupon_id,order_id);
+
+CREATE TABLE IF NOT EXISTS promotions (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    "name" text NOT NULL,
+    description text,
+    "type" text NOT NULL,
+    "value" decimal,
+    product_ids jsonb,
+    category_ids jsonb,
+    min_subtotal decimal,
+    min_quantity bigint,
+    priority bigint NOT NULL DEFAULT 0,
+    stackable boolean DEFAULT true,
+    starts_at timestamptz,
+    ends_at timestamptz,
+    is_active boolean DEFAULT true,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_promotions_deleted_at ON promotions (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_promotions_priority ON promotions (priority);
+
+CREATE TABLE IF NOT EXISTS order_status_histories (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    order_id uuid NOT NULL,
+    from_status text,
+    to_status text NOT NULL,
+    actor_id uuid,
+    actor_role text,
+    reason text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_order_status_histories_deleted_at ON order_status_histories (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_order_status_histories_order_id ON order_status_histories (order_id);
+
+CREATE TABLE IF NOT EXISTS fulfillments (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    order_id uuid NOT NULL,
+    status text DEFAULT 'shipped',
+    carrier text NOT NULL,
+    tracking_number text,
+    shipped_at timestamptz,
+    delivered_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_orders_fulfillments FOREIGN KEY (order_id) REFERENCES orders(id)
+);
+CREATE INDEX IF NOT EXISTS idx_fulfillments_deleted_at ON fulfillments (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_fulfillments_order_id ON fulfillments (order_id);
+
+CREATE TABLE IF NOT EXISTS fulfillment_items (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    fulfillment_id uuid NOT NULL,
+    order_item_id uuid NOT NULL,
+    quantity bigint NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_fulfillments_items FOREIGN KEY (fulfillment_id) REFERENCES fulfillments(id)
+);
+CREATE INDEX IF NOT EXISTS idx_fulfillment_items_deleted_at ON fulfillment_items (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_fulfillment_items_fulfillment_id ON fulfillment_items (fulfillment_id);
+CREATE INDEX IF NOT EXISTS idx_fulfillment_items_order_item_id ON fulfillment_items (order_item_id);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    order_id uuid NOT NULL,
+    provider text,
+    payment_method text,
+    gift_card_id uuid,
+    transaction_id text,
+    amount decimal,
+    currency text,
+    status text DEFAULT 'pending',
+    provider_response jsonb,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_orders_payments FOREIGN KEY (order_id) REFERENCES orders(id)
+);
+CREATE INDEX IF NOT EXISTS idx_payments_deleted_at ON payments (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_payments_gift_card_id ON payments (gift_card_id);
+CREATE INDEX IF NOT EXISTS idx_payments_order_id ON payments (order_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_transaction_id ON payments (transaction_id);
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    order_id uuid NOT NULL,
+    payment_id uuid NOT NULL,
+    provider_refund_id text,
+    amount decimal NOT NULL,
+    currency text,
+    status text DEFAULT 'pending',
+    reason text,
+    restock boolean DEFAULT false,
+    actor_id uuid,
+    failure_message text,
+    provider_response jsonb,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_orders_refunds FOREIGN KEY (order_id) REFERENCES orders(id)
+);
+CREATE INDEX IF NOT EXISTS idx_refunds_deleted_at ON refunds (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_refunds_order_id ON refunds (order_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_payment_id ON refunds (payment_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refunds_provider_refund_id ON refunds (provider_refund_id);
+
+CREATE TABLE IF NOT EXISTS refund_items (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    refund_id uuid NOT NULL,
+    order_item_id uuid NOT NULL,
+    quantity bigint NOT NULL,
+    amount decimal,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_refunds_items FOREIGN KEY (refund_id) REFERENCES refunds(id)
+);
+CREATE INDEX IF NOT EXISTS idx_refund_items_deleted_at ON refund_items (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_refund_items_order_item_id ON refund_items (order_item_id);
+CREATE INDEX IF NOT EXISTS idx_refund_items_refund_id ON refund_items (refund_id);
+
+CREATE TABLE IF NOT EXISTS return_requests (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    order_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    rma_number text,
+    status text DEFAULT 'requested',
+    resolution text NOT NULL,
+    reason text,
+    admin_note text,
+    rejection_reason text,
+    approved_at timestamptz,
+    received_at timestamptz,
+    completed_at timestamptz,
+    credit_amount decimal,
+    refund_id uuid,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_return_requests_deleted_at ON return_requests (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_return_requests_order_id ON return_requests (order_id);
+CREATE INDEX IF NOT EXISTS idx_return_requests_status ON return_requests (status);
+CREATE INDEX IF NOT EXISTS idx_return_requests_user_id ON return_requests (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_return_requests_rma_number ON return_requests (rma_number);
+
+CREATE TABLE IF NOT EXISTS return_items (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    return_request_id uuid NOT NULL,
+    order_item_id uuid NOT NULL,
+    quantity bigint NOT NULL,
+    reason text,
+    received_quantity bigint DEFAULT 0,
+    condition text,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_return_requests_items FOREIGN KEY (return_request_id) REFERENCES return_requests(id)
+);
+CREATE INDEX IF NOT EXISTS idx_return_items_deleted_at ON return_items (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_return_items_order_item_id ON return_items (order_item_id);
+CREATE INDEX IF NOT EXISTS idx_return_items_return_request_id ON return_items (return_request_id);
+
+CREATE TABLE IF NOT EXISTS store_credit_transactions (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id uuid NOT NULL,
+    "type" text NOT NULL,
+    amount decimal NOT NULL,
+    source_type text,
+    source_id uuid,
+    note text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_store_credit_transactions_deleted_at ON store_credit_transactions (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_store_credit_transactions_user_id ON store_credit_transactions (user_id);
+
+CREATE TABLE IF NOT EXISTS gift_cards (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    code_hash text NOT NULL,
+    last4 varchar(4) NOT NULL,
+    initial_amount decimal NOT NULL,
+    currency text NOT NULL,
+    purchaser_id uuid,
+    order_id uuid,
+    recipient_email text,
+    note text,
+    expires_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_gift_cards_deleted_at ON gift_cards (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_gift_cards_order_id ON gift_cards (order_id);
+CREATE INDEX IF NOT EXISTS idx_gift_cards_purchaser_id ON gift_cards (purchaser_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_gift_cards_code_hash ON gift_cards (code_hash);
+
+CREATE TABLE IF NOT EXISTS gift_card_transactions (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    gift_card_id uuid NOT NULL,
+    "type" text NOT NULL,
+    amount decimal NOT NULL,
+    order_id uuid,
+    payment_id uuid,
+    note text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_gift_card_transactions_deleted_at ON gift_card_transactions (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_gift_card_transactions_gift_card_id ON gift_card_transactions (gift_card_id);
+CREATE INDEX IF NOT EXISTS idx_gift_card_transactions_order_id ON gift_card_transactions (order_id);
+
+CREATE TABLE IF NOT EXISTS webhook_events (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    provider text NOT NULL,
+    event_id text NOT NULL,
+    "type" text,
+    payload jsonb,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_events_deleted_at ON webhook_events (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_events_type ON webhook_events ("type");
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_events_provider_event ON webhook_events (provider,event_id);
+
+CREATE TABLE IF NOT EXISTS documents (
+    id uuid DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    "type" text NOT NULL,
+    "number" text,
+    order_id uuid NOT NULL,
+    refund_id uuid,
+    fulfillment_id uuid,
+    object_key text NOT NULL,
+    total decimal,
+    currency text,
+    issued_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_documents_deleted_at ON documents (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_documents_order_id ON documents (order_id);
+CREATE INDEX IF NOT EXISTS idx_documents_type ON documents ("type");
+CREATE UNIQUE INDEX IF NOT EXISTS idx_documents_fulfillment_id ON documents (fulfillment_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_documents_number ON documents ("number") WHERE number <> '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_documents_order_invoice ON documents (order_id) WHERE type = 'invoice';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_documents_refund_id ON documents (refund_id);
+
+CREATE TABLE IF NOT EXISTS document_sequences (
+    "name" text,
+    last_value bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("name")
+);
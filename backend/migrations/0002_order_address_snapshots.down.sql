@@ -0,0 +1,5 @@
+-- The backfilled snapshots are kept and the address book foreign keys are
+-- not restored, since addresses may have been deleted since.
+
+DROP TRIGGER IF EXISTS orders_address_snapshot_immutable ON orders;
+DROP FUNCTION IF EXISTS orders_address_snapshot_immutable();
@@ -0,0 +1,60 @@
+-- Decouple orders from the address book. Orders placed before address
+-- snapshots existed get theirs copied from the addresses they pointed at,
+-- soft deleted ones included; the foreign keys to the address book are
+-- dropped; and a trigger keeps captured snapshots from ever being rewritten.
+
+UPDATE orders AS o SET
+    shipping_street_address = a.street_address,
+    shipping_city = a.city,
+    shipping_state = a.state,
+    shipping_postal_code = a.postal_code,
+    shipping_country = a.country
+FROM addresses AS a
+WHERE a.id = o.shipping_address_id AND o.shipping_country IS NULL;
+
+UPDATE orders AS o SET
+    billing_street_address = a.street_address,
+    billing_city = a.city,
+    billing_state = a.state,
+    billing_postal_code = a.postal_code,
+    billing_country = a.country
+FROM addresses AS a
+WHERE a.id = o.billing_address_id AND o.billing_country IS NULL;
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS fk_orders_shipping_address;
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS fk_orders_billing_address;
+
+CREATE OR REPLACE FUNCTION orders_address_snapshot_immutable() RETURNS trigger AS $$
+BEGIN
+    IF OLD.shipping_country IS NOT NULL AND
+        ROW(NEW.shipping_street_address, NEW.shipping_city, NEW.shipping_state, NEW.shipping_postal_code, NEW.shipping_country)
+        IS DISTINCT FROM
+        ROW(OLD.shipping_street_address, OLD.shipping_city, OLD.shipping_state, OLD.shipping_postal_code, OLD.shipping_country) THEN
+        RAISE EXCEPTION 'order % shipping address snapshot cannot be changed', OLD.order_number;
+    END IF;
+    IF OLD.billing_country IS NOT NULL AND
+        ROW(NEW.billing_street_address, NEW.billing_city, NEW.billing_state, NEW.billing_postal_code, NEW.billing_country)
+        IS DISTINCT FROM
+        ROW(OLD.billing_street_address, OLD.billing_city, OLD.billing_state, OLD.billing_postal_code, OLD.billing_country) THEN
+        RAISE EXCEPTION 'order % billing address snapshot cannot be changed', OLD.order_number;
+    END IF;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS orders_address_snapshot_immutable ON orders;
+CREATE TRIGGER orders_address_snapshot_immutable
+    BEFORE UPDATE ON orders
+    FOR EACH ROW EXECUTE FUNCTION orders_address_snapshot_immutable();
+
+DO $$
+DECLARE
+    missing bigint;
+BEGIN
+    SELECT count(*) INTO missing FROM orders
+    WHERE shipping_country IS NULL OR billing_country IS NULL;
+    IF missing > 0 THEN
+        RAISE WARNING '% orders reference addresses that no longer exist and have no address snapshot', missing;
+    END IF;
+END
+$$;